@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// expandAlias resolves a path written as @name or @name/rest through the
+// -aliases table; other paths are returned unchanged.
+func expandAlias(p string, aliases map[string]string) (string, error) {
+	if !strings.HasPrefix(p, "@") {
+		return p, nil
+	}
+	name, rest, _ := strings.Cut(p[1:], "/")
+	target, ok := aliases[name]
+	if !ok {
+		return "", fmt.Errorf("unknown alias @%s (define it with -aliases or the aliases setting)", name)
+	}
+	if rest == "" {
+		return target, nil
+	}
+	return strings.TrimSuffix(target, "/") + "/" + rest, nil
+}
+
+// expandAliases resolves aliases in every option that takes a path: -path,
+// -paths and the command arguments.
+func expandAliases(opts *options) error {
+	var err error
+	if opts.startPath, err = expandAlias(opts.startPath, opts.aliases); err != nil {
+		return err
+	}
+	for _, list := range [][]string{opts.paths, opts.args} {
+		for i := range list {
+			if list[i], err = expandAlias(list[i], opts.aliases); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// aliasNames returns the aliases as @name, sorted, for completion.
+func aliasNames(aliases map[string]string) []string {
+	out := make([]string, 0, len(aliases))
+	for name := range aliases {
+		out = append(out, "@"+name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// runAliases lists the defined aliases as "@name<TAB>path" lines, e.g. for
+// shell completion (cut -f1).
+func runAliases(_ context.Context, _ *vault.Client, opts options, _ *regexp.Regexp) error {
+	for _, name := range aliasNames(opts.aliases) {
+		fmt.Fprintf(os.Stdout, "%s\t%s\n", name, opts.aliases[name[1:]])
+	}
+	return nil
+}
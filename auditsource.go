@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"fvf/search"
+	"fvf/ui"
+)
+
+// auditEventLimit is how many recent events the audit panel shows.
+const auditEventLimit = 50
+
+// auditFetcher reads recent events for a secret from -audit-source: a Vault
+// file audit device log (JSON lines), or an http(s) URL of an indexed store
+// that answers GET ?path=<api path>... with matching audit lines. An empty
+// source disables the audit panel.
+func auditFetcher(source string) ui.AuditFetcher {
+	if source == "" {
+		return nil
+	}
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return func(apiPaths []string) ([]search.AuditEvent, error) {
+			u, err := url.Parse(source)
+			if err != nil {
+				return nil, err
+			}
+			q := u.Query()
+			for _, p := range apiPaths {
+				q.Add("path", p)
+			}
+			u.RawQuery = q.Encode()
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			defer cancel()
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("%s: %s", source, resp.Status)
+			}
+			return search.ScanAuditLog(resp.Body, apiPaths, auditEventLimit)
+		}
+	}
+	return func(apiPaths []string) ([]search.AuditEvent, error) {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return search.ScanAuditLog(f, apiPaths, auditEventLimit)
+	}
+}
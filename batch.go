@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// batchDocument reads paths with read, at most limit at a time, and renders
+// them as one document in format: "json", an object keyed by path, or
+// "dotenv", KEY='value' lines prefixed per secret (see envPrefixes). The
+// first failed read fails the document.
+func batchDocument(paths []string, read func(p string) (interface{}, error), limit int, format string) (string, error) {
+	vals := make([]interface{}, len(paths))
+	err := forEachLimit(limit, len(paths), func(i int) error {
+		v, err := read(paths[i])
+		if err != nil {
+			return fmt.Errorf("%s: %w", paths[i], err)
+		}
+		vals[i] = v
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if format == "dotenv" {
+		return dotenvDocument(paths, vals)
+	}
+	doc := make(map[string]interface{}, len(paths))
+	for i, p := range paths {
+		doc[p] = vals[i]
+	}
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b) + "\n", nil
+}
+
+// dotenvDocument renders every key of every secret as PREFIX_KEY='value',
+// sorted; nested values are JSON.
+func dotenvDocument(paths []string, vals []interface{}) (string, error) {
+	prefixes := envPrefixes(paths)
+	var lines []string
+	for i, p := range paths {
+		data, ok := vals[i].(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("%s: secret is not a key/value map", p)
+		}
+		for k, v := range data {
+			s, ok := tryScalar(v)
+			if !ok {
+				b, err := json.Marshal(v)
+				if err != nil {
+					return "", fmt.Errorf("%s: %s: %w", p, k, err)
+				}
+				s = string(b)
+			}
+			lines = append(lines, prefixes[i]+"_"+envVarName(k)+"="+shellQuote(s))
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// envPrefixes names each path by its last segment, taking in more segments
+// where that is ambiguous: kv/app/db and kv/web/db become APP_DB and WEB_DB.
+func envPrefixes(paths []string) []string {
+	out := make([]string, len(paths))
+	for i, p := range paths {
+		segs := strings.Split(strings.Trim(p, "/"), "/")
+		for n := 1; n <= len(segs); n++ {
+			suffix := strings.Join(segs[len(segs)-n:], "/")
+			unique := true
+			for j, q := range paths {
+				if j != i && (q == suffix || strings.HasSuffix(q, "/"+suffix)) {
+					unique = false
+					break
+				}
+			}
+			out[i] = envVarName(suffix)
+			if unique {
+				break
+			}
+		}
+	}
+	return out
+}
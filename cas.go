@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// isCASConflict reports whether err is a KV v2 check-and-set failure, i.e.
+// the secret was written by someone else since it was read.
+func isCASConflict(err error) bool {
+	return errors.Is(err, search.ErrCASConflict)
+}
+
+// keyChange describes how one key of side differs from base.
+func keyChange(base, side map[string]interface{}, k string) string {
+	b, inBase := base[k]
+	v, inSide := side[k]
+	switch {
+	case !inBase && !inSide:
+		return "-"
+	case !inBase:
+		return "added"
+	case !inSide:
+		return "removed"
+	case reflect.DeepEqual(b, v):
+		return "unchanged"
+	}
+	return "changed"
+}
+
+func changed(c string) bool { return c != "unchanged" && c != "-" }
+
+// printThreeWay shows, key by key, what the concurrent writer (theirs) and
+// this write (ours) changed relative to the version read (base). Values are
+// never printed; keys both sides changed differently are marked.
+func printThreeWay(w io.Writer, base, theirs, ours map[string]interface{}) {
+	seen := map[string]bool{}
+	var keys []string
+	for _, m := range []map[string]interface{}{base, theirs, ours} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	width := len("key")
+	for _, k := range keys {
+		width = max(width, len(k))
+	}
+	fmt.Fprintf(w, "  %-*s  %-9s  %s\n", width, "key", "theirs", "ours")
+	for _, k := range keys {
+		t, o := keyChange(base, theirs, k), keyChange(base, ours, k)
+		if !changed(t) && !changed(o) {
+			continue
+		}
+		line := fmt.Sprintf("  %-*s  %-9s  %-9s", width, k, t, o)
+		if changed(t) && changed(o) && !reflect.DeepEqual(theirs[k], ours[k]) {
+			line += "  conflict"
+		}
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+}
+
+// writeCAS writes the KV v2 secret p with check-and-set against version, the
+// version base was read at (0 when it did not exist); ours derives the data
+// to write from a version's data (put ignores it, patch merges into it).
+// When the secret changed in between, the three-way key diff is printed to
+// w and, if retry is set and the user agrees (or -retry-cas), ours is
+// applied again on top of the new version. Otherwise the conflict fails the
+// write. It returns the version written.
+func writeCAS(ctx context.Context, client *vault.Client, opts options, w io.Writer, cmd, p string, base map[string]interface{}, version int, ours func(current map[string]interface{}) map[string]interface{}, retry bool) (int, error) {
+	mnt, inner := search.SplitMount(strings.Trim(p, "/"))
+	for {
+		data := ours(base)
+		written, err := search.WriteSecretCAS(ctx, client.Logical(), mnt, inner, data, version)
+		if !isCASConflict(err) {
+			return written, err
+		}
+		theirs, now, rerr := search.ReadSecretCurrent(ctx, client.Logical(), mnt, inner)
+		if rerr != nil {
+			return 0, fmt.Errorf("%s changed while writing (read version %d): %w", p, version, err)
+		}
+		fmt.Fprintf(w, "fvf: %s was changed by someone else: read version %d, now version %d\n", p, version, now)
+		printThreeWay(w, base, theirs, data)
+		if !retry {
+			return 0, fmt.Errorf("%s changed while writing (read version %d, now %d); nothing written", p, version, now)
+		}
+		if !opts.retryCAS {
+			if err := confirmTyped(cmd, fmt.Sprintf("fvf: apply this write on top of version %d? Type \"y\" to retry: ", now), "y"); err != nil {
+				return 0, err
+			}
+		}
+		base, version = theirs, now
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ciMode is the -ci setting: "" (off) or github.
+var ciMode = ""
+
+// ciOut receives the masking commands; the runner reads workflow commands
+// from stderr too, which keeps stdout clean for pipes. Replaced in tests.
+var ciOut io.Writer = os.Stderr
+
+// maskForCI registers every value in v with the CI log redactor before it is
+// printed, so later echoes of it in the job log show as ***.
+func maskForCI(v interface{}) {
+	if ciMode == "" {
+		return
+	}
+	for _, s := range maskableValues(v) {
+		// Multi-line values are masked line by line; the runner matches single lines
+		for _, line := range strings.Split(s, "\n") {
+			if line = strings.TrimRight(line, "\r"); strings.TrimSpace(line) != "" {
+				fmt.Fprintf(ciOut, "::add-mask::%s\n", line)
+			}
+		}
+	}
+}
+
+// maskableValues flattens a secret value into its leaf strings, sorted.
+func maskableValues(v interface{}) []string {
+	var out []string
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch vv := v.(type) {
+		case map[string]interface{}:
+			for _, x := range vv {
+				walk(x)
+			}
+		case []interface{}:
+			for _, x := range vv {
+				walk(x)
+			}
+		default:
+			if s, ok := tryScalar(v); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	walk(v)
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// subcommand is a named entry point (fvf <name> [flags] [args]). Bare fvf, or
+// fvf with flags only, keeps running the interactive/search mode.
+type subcommand struct {
+	name    string
+	usage   string
+	summary string
+	// offline commands run without a Vault connection.
+	offline bool
+	// offlineWhen, when set, reports whether these options need no Vault connection.
+	offlineWhen func(opts options) bool
+	// untimed, when set, reports whether the command runs until interrupted
+	// with these options, so -timeout does not bound the whole run.
+	untimed func(opts options) bool
+	// writes, when set, reports whether the command changes Vault with these
+	// options; -read-only refuses to run it (see checkReadOnly).
+	writes func(opts options) bool
+	// flags are the command's own flags, accepted besides sharedFlags.
+	flags []string
+	run   func(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) error
+}
+
+// sharedFlags are accepted by every command: connection, KV detection,
+// logging, redaction and output format.
+var sharedFlags = []string{
+	"config", "version", "timeout", "fetch-timeout", "fetch-retries", "kv1", "kv2", "force-kv2",
+	"http-max-idle-per-host", "http-keepalive", "http2", "cpuprofile", "memprofile", "log-level", "log-file",
+	"otlp-endpoint", "plugins", "read-only", "aliases", "color", "redact-keys", "redact-values", "no-redact",
+	"ci", "json", "output",
+}
+
+// withWalk returns the flags selecting the secrets of a -path/-paths walk,
+// followed by names.
+func withWalk(names ...string) []string {
+	walk := []string{"path", "paths", "match", "name", "max-depth", "max-list-keys", "concurrency", "strict"}
+	return append(walk, names...)
+}
+
+// flagSet returns the FlagSet cmd parses its command line with: the shared
+// flags and its own, bound to the values of all, which defines every flag.
+func (c *subcommand) flagSet(all *flag.FlagSet) *flag.FlagSet {
+	fs := flag.NewFlagSet("fvf "+c.name, flag.ContinueOnError)
+	fs.SetOutput(all.Output())
+	for _, names := range [][]string{sharedFlags, c.flags} {
+		for _, name := range names {
+			f := all.Lookup(name)
+			if f == nil {
+				panic(fmt.Sprintf("fvf %s: no flag -%s", c.name, name))
+			}
+			fs.Var(f.Value, f.Name, f.Usage)
+		}
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s\n\n%s\n\nFlags:\n", c.usage, c.summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// Write predicates for subcommand.writes.
+var (
+	alwaysWrites       = func(options) bool { return true }
+	writesUnlessDryRun = func(o options) bool { return !o.dryRun }
+)
+
+// subcommands lists the commands in the order shown by fvf help.
+var subcommands []subcommand
+
+func init() {
+	subcommands = []subcommand{
+		{name: "search", usage: "fvf search [flags]", summary: "Walk Vault and print matching paths (and values) without the TUI; -offline answers from the index", offlineWhen: func(o options) bool { return o.offline }, flags: withWalk("values", "sort", "relative", "offline", "index-file"), run: runSearch},
+		{name: "index", usage: "fvf index [flags]", summary: "Save every path below -path/-paths (all KV mounts by default) for -offline searches", flags: withWalk("index-file"), run: runIndex},
+		{name: "get", usage: "fvf get [flags] <path-or-pattern>", summary: "Print one secret; without one at the path, a fuzzy match (picked from a list when several)", flags: []string{"name", "values"}, run: runGet},
+		{name: "share", usage: "fvf share [flags] <path>", summary: "Print a single-use wrapping token for a secret (vault unwrap)", flags: []string{"wrap-ttl"}, run: runShare},
+		{name: "put", usage: "fvf put [flags] <path> key=value...", summary: "Write a secret from key=value pairs (key=@file, key=-), replacing its keys", writes: alwaysWrites, flags: []string{"cas", "retry-cas", "schemas"}, run: runPut},
+		{name: "patch", usage: "fvf patch [flags] <path> key=value...", summary: "Set keys on a secret and keep the others (check-and-set on KV v2)", writes: alwaysWrites, flags: []string{"cas", "retry-cas", "schemas"}, run: runPatch},
+		{name: "cp", usage: "fvf cp [flags] <src> <dst>", summary: "Copy a secret, or with -r every secret below a prefix (-dry-run, -on-conflict)", writes: writesUnlessDryRun, flags: withWalk("r", "dry-run", "on-conflict", "keep-metadata"), run: runCp},
+		{name: "mv", usage: "fvf mv [flags] <src> <dst>", summary: "Move a secret, or with -r every secret below a prefix (-dry-run, -on-conflict)", writes: writesUnlessDryRun, flags: withWalk("r", "dry-run", "on-conflict", "keep-metadata", "yes"), run: runMv},
+		{name: "rename", usage: "fvf rename -path p -from re -to repl [flags]", summary: "Move every secret whose path matches -from to the -to rewrite, after a preview", writes: writesUnlessDryRun, flags: withWalk("from", "to", "dry-run", "on-conflict", "keep-metadata", "yes"), run: runRename},
+		{name: "meta", usage: "fvf meta get|set|rm [flags] <path> [key=value... | key...]", summary: "Show or edit a KV v2 secret's custom_metadata (set and rm keep the other keys)", writes: func(o options) bool { return len(o.args) == 0 || o.args[0] != "get" }, run: runMeta},
+		{name: "delete", usage: "fvf delete -versions list [flags] [path...]", summary: "Soft-delete KV v2 versions of secrets (paths, or the -path walk); undelete stays possible", writes: writesUnlessDryRun, flags: withWalk("versions", "dry-run"), run: runDelete},
+		{name: "destroy", usage: "fvf destroy -versions list [flags] [path...]", summary: "Permanently destroy KV v2 versions of secrets (paths, or the -path walk), after confirmation", writes: writesUnlessDryRun, flags: withWalk("versions", "dry-run", "yes"), run: runDestroy},
+		{name: "settings", usage: "fvf settings [flags] [path...]", summary: "Show or bulk-set KV v2 max_versions, cas_required and delete_version_after (paths, or the -path walk)", writes: func(o options) bool { _, change, _ := settingsChange(o); return change && !o.dryRun }, flags: withWalk("max-versions", "cas-required", "delete-version-after", "dry-run", "yes"), run: runSettings},
+		{name: "sync", usage: "fvf sync [flags] <src> <dst>", summary: "Make a prefix match another (create/update/delete, unchanged values skipped); -watch keeps it in sync", writes: writesUnlessDryRun, flags: withWalk("dry-run", "watch", "refresh", "hook-url", "hook-cmd", "retry-cas", "yes"), untimed: func(o options) bool { return o.watch }, run: runSync},
+		{name: "diff", usage: "fvf diff [flags] <src> <dst>", summary: "Show what sync would change to make a prefix match another (values compared by digest, never shown); -out saves it for apply", flags: withWalk("out", "manifest-key", "unkeyed-manifest"), run: runDiff},
+		{name: "apply", usage: "fvf apply [flags] <diff.json> [dst]", summary: "Apply a diff saved by fvf diff -out, if neither side changed since (check-and-set on KV v2)", writes: writesUnlessDryRun, flags: []string{"concurrency", "dry-run", "yes", "retry-cas", "manifest-key"}, run: runApply},
+		{name: "migrate-kv1", usage: "fvf migrate-kv1 [flags] <kv1-mount> <kv2-mount>", summary: "Copy a KV v1 mount into KV v2 with rate limiting, resume (-state) and digest verification", writes: writesUnlessDryRun, flags: withWalk("dry-run", "rate", "state"), run: runMigrateKV1},
+		{name: "rm", usage: "fvf rm [flags] <path>", summary: "Delete a secret, or with -r every secret below a path, after typed confirmation (-dry-run, -yes)", writes: writesUnlessDryRun, flags: withWalk("r", "dry-run", "yes"), run: runRm},
+		{name: "rollback", usage: "fvf rollback -to-version N [flags] [path...]", summary: "Write an earlier KV v2 version as the new current version, with check-and-set (paths, or the -path walk)", writes: alwaysWrites, flags: withWalk("to-version"), run: runRollback},
+		{name: "ls", usage: "fvf ls [flags] [path]", summary: "List one level below a path (KV mounts when omitted)", run: runLs},
+		{name: "serve", usage: "fvf serve [flags]", summary: "Keep a path index warm and serve it on a localhost HTTP/JSON API", flags: withWalk("listen", "refresh", "serve-token"), run: runServe},
+		{name: "watch", usage: "fvf watch [flags]", summary: "Poll Vault and report created/updated/deleted secrets, with webhook or command hooks", flags: withWalk("refresh", "hook-url", "hook-cmd"), run: runWatch},
+		{name: "scan-certs", usage: "fvf scan-certs [flags] [path...]", summary: "List PEM certificates expiring soon; -notify alerts Slack, a webhook or owners by mail", flags: withWalk("expiry-within", "notify"), run: runScanCerts},
+		{name: "policy-coverage", usage: "fvf policy-coverage -policy name [flags]", summary: "Report which walked secrets an ACL policy grants access to and which of its rules match nothing", flags: withWalk("policy", "values", "sort"), run: runPolicyCoverage},
+		{name: "orphans", usage: "fvf orphans [flags]", summary: "Report prefixes whose secrets no non-root policy grants, or not updated within -stale-after (archival candidates)", flags: withWalk("stale-after", "values", "sort"), run: runOrphans},
+		{name: "manifest", usage: "fvf manifest [-out f | -verify f]", summary: "Write per-secret value digests and versions, or report drift against such a manifest", flags: withWalk("out", "verify", "manifest-key", "unkeyed-manifest"), run: runManifest},
+		{name: "rpc", usage: "fvf rpc [flags]", summary: "Answer JSON-RPC/MCP search requests on stdin/stdout for editors and tools", flags: withWalk("rpc-allow-values", "audit-log"), run: runRPC},
+		{name: "backup", usage: "fvf backup -out <file|url> -recipient <age-key> [path...]", summary: "Stream an age-encrypted archive of secrets (the paths, or the -path walk) to a file or an s3://, gs:// or az:// URL", flags: withWalk("out", "recipient"), run: runBackup},
+		{name: "export", usage: "fvf export -sops <file> [path...]", summary: "Write secrets (the paths, or the -path walk) to a SOPS-encrypted YAML/JSON file", flags: withWalk("sops"), run: runExport},
+		{name: "direnv", usage: "fvf direnv [flags] <path>", summary: "Print an .envrc exporting a secret's keys (-direnv-mode lazy fetches on load)", offlineWhen: func(o options) bool { return o.direnvMode != "export" }, flags: []string{"path", "direnv-mode"}, run: runDirenv},
+		{name: "docker-secret", usage: "fvf docker-secret [flags] <path>", summary: "Create docker secrets from a secret's keys (or print a compose env_file)", flags: []string{"keys", "env-file"}, run: runDockerSecret},
+		{name: "eso", usage: "fvf eso [flags] [path...]", summary: "Print ExternalSecret/SecretStore manifests (external-secrets.io) for the secrets", flags: withWalk("keys", "k8s-namespace"), run: runESO},
+		{name: "aliases", usage: "fvf aliases", summary: "List the path aliases (@name<TAB>path) usable wherever a path is accepted", offline: true, run: runAliases},
+		{name: "help", usage: "fvf help", summary: "Show the available commands", offline: true, run: runHelp},
+	}
+}
+
+// checkReadOnly refuses commands that would change Vault while -read-only is
+// on. It runs at dispatch, so no write path is reached, whatever the command.
+func checkReadOnly(cmd *subcommand, opts options) error {
+	if !opts.readOnly || cmd == nil || cmd.writes == nil || !cmd.writes(opts) {
+		return nil
+	}
+	return fmt.Errorf("%s changes Vault, which read-only mode forbids (allow writes with read-only: false in the config, or -read-only=false)", cmd.name)
+}
+
+// findSubcommand returns the command named by the first argument, or nil when
+// args start with a flag or name no command.
+func findSubcommand(args []string) *subcommand {
+	if len(args) == 0 {
+		return nil
+	}
+	for i := range subcommands {
+		if subcommands[i].name == args[0] {
+			return &subcommands[i]
+		}
+	}
+	return nil
+}
+
+func runHelp(_ context.Context, _ *vault.Client, _ options, _ *regexp.Regexp) error {
+	fmt.Fprintf(os.Stdout, "Usage: fvf [flags]            interactive search (default)\n")
+	fmt.Fprintf(os.Stdout, "       fvf <command> [flags] [args]\n\nCommands:\n")
+	for _, c := range subcommands {
+		fmt.Fprintf(os.Stdout, "  %-34s %s\n", c.usage, c.summary)
+	}
+	fmt.Fprintf(os.Stdout, "\nRun fvf <command> -h for a command's flags, fvf -h for the interactive search's.\n")
+	return nil
+}
+
+// runSearch is the non-interactive walk: matching paths, with values when requested.
+func runSearch(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) error {
+	if len(opts.args) > 0 {
+		return fmt.Errorf("search takes no arguments (use -path or -paths), got %q", opts.args)
+	}
+	if opts.offline {
+		return searchOffline(opts)
+	}
+	return searchAndPrint(ctx, client, opts, matcher)
+}
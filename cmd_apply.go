@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// readDiffFile reads a diff saved by fvf diff -out.
+func readDiffFile(file string) (*diffFile, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var d diffFile
+	if err := json.Unmarshal(b, &d); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if d.Format != diffFormat {
+		return nil, fmt.Errorf("%s: unsupported diff format %d", file, d.Format)
+	}
+	for _, c := range d.Changes {
+		if c.Type != "create" && c.Type != "update" && c.Type != "delete" {
+			return nil, fmt.Errorf("%s: unknown change %q for %s", file, c.Type, c.Path)
+		}
+	}
+	return &d, nil
+}
+
+// runApply applies a diff saved by fvf diff -out to its destination prefix,
+// or to the prefix given after the file. The diff holds no values: the source
+// secrets are read again, from the Vault apply talks to, and must still have
+// the reviewed digests, and the destination must still be as the diff found
+// it (created secrets absent, updated and deleted ones unchanged). Otherwise
+// nothing is applied. Writes use check-and-set on KV v2, and deletes are
+// confirmed as in sync.
+func runApply(ctx context.Context, client *vault.Client, opts options, _ *regexp.Regexp) error {
+	if len(opts.args) < 1 || len(opts.args) > 2 {
+		return fmt.Errorf("apply takes a diff file and optionally a destination prefix, got %d argument(s)", len(opts.args))
+	}
+	d, err := readDiffFile(opts.args[0])
+	if err != nil {
+		return err
+	}
+	if d.Keyed && opts.manifestKey == "" {
+		return fmt.Errorf("%s was written with -manifest-key; set it (or FVF_MANIFEST_KEY) to apply it", opts.args[0])
+	}
+	secret := ""
+	if d.Keyed {
+		secret = opts.manifestKey
+	}
+	key, err := manifestKey(d.Salt, secret)
+	if err != nil {
+		return err
+	}
+	dst := d.Dst
+	if len(opts.args) == 2 {
+		dst = strings.Trim(opts.args[1], "/") + "/"
+	}
+	if _, _, err := syncRoots("apply", []string{d.Src, dst}); err != nil {
+		return err
+	}
+
+	var srcPaths, dstPaths []string
+	for _, c := range d.Changes {
+		if c.Type != "delete" {
+			srcPaths = append(srcPaths, d.Src+c.Path)
+		}
+		dstPaths = append(dstPaths, dst+c.Path)
+	}
+	srcVals, _, srcErrs, err := readValues(ctx, client, opts, srcPaths)
+	if err != nil {
+		return err
+	}
+	dstVals, dstVersions, dstErrs, err := readValues(ctx, client, opts, dstPaths)
+	if err != nil {
+		return err
+	}
+
+	var actions []syncAction
+	var stale []string
+	for _, c := range d.Changes {
+		a := syncAction{Type: c.Type, Dst: dst + c.Path}
+		if c.Type != "delete" {
+			a.Src = d.Src + c.Path
+			if e := srcErrs[a.Src]; e != nil {
+				stale = append(stale, fmt.Sprintf("cannot read %s: %v", a.Src, e))
+				continue
+			}
+			a.data = srcVals[a.Src]
+			if got, err := valueDigest(key, a.data); err != nil {
+				return err
+			} else if got != c.Digest {
+				stale = append(stale, fmt.Sprintf("%s changed since the diff", a.Src))
+				continue
+			}
+		}
+		e := dstErrs[a.Dst]
+		exists := e == nil
+		if e != nil && !errors.Is(e, search.ErrNoData) {
+			stale = append(stale, fmt.Sprintf("cannot read %s: %v", a.Dst, e))
+			continue
+		}
+		switch {
+		case c.Type == "create" && exists:
+			stale = append(stale, fmt.Sprintf("%s exists, the diff creates it", a.Dst))
+			continue
+		case c.Type != "create" && !exists:
+			stale = append(stale, fmt.Sprintf("%s is missing, the diff %ss it", a.Dst, c.Type))
+			continue
+		case exists:
+			a.base, a.version = dstVals[a.Dst], dstVersions[a.Dst]
+			if got, err := valueDigest(key, a.base); err != nil {
+				return err
+			} else if got != c.Was {
+				stale = append(stale, fmt.Sprintf("%s changed since the diff", a.Dst))
+				continue
+			}
+		}
+		actions = append(actions, a)
+	}
+	if len(stale) > 0 {
+		for _, s := range stale {
+			fmt.Fprintf(os.Stderr, "fvf: apply: %s\n", s)
+		}
+		return fmt.Errorf("apply: %d change(s) no longer match %s, nothing applied (run fvf diff again)", len(stale), opts.args[0])
+	}
+
+	if opts.dryRun {
+		for _, a := range actions {
+			fmt.Printf("would %-6s %s\n", a.Type, a.Dst)
+		}
+		return nil
+	}
+	if err := confirmSyncDeletes(opts, "apply", actions); err != nil {
+		return err
+	}
+	failed, err := applySync(ctx, client, opts, actions, os.Stdout)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "fvf: apply: %d change(s) applied\n", len(actions)-failed)
+	if failed > 0 {
+		return fmt.Errorf("apply: %d change(s) failed", failed)
+	}
+	return nil
+}
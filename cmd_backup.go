@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// backupFormat is the version of the backup archive layout.
+const backupFormat = 1
+
+// backupBatch is how many secrets are read before they are written out, which
+// bounds the values held in memory.
+const backupBatch = 256
+
+// ageEncrypt returns a writer whose plaintext reaches out encrypted to the
+// recipients; Close flushes it. Replaced in tests.
+var ageEncrypt = runAge
+
+// backupHeader is the first line of a backup archive.
+type backupHeader struct {
+	Format    int       `json:"format"`
+	Created   time.Time `json:"created"`
+	Addr      string    `json:"addr"`
+	Namespace string    `json:"namespace,omitempty"`
+}
+
+// backupEntry is one secret in a backup archive.
+type backupEntry struct {
+	Path    string                 `json:"path"`
+	Version int                    `json:"version,omitempty"` // 0 on KV v1
+	Data    map[string]interface{} `json:"data"`
+}
+
+// runBackup writes the selected secrets (the path arguments, or the -path
+// walk) as an age-encrypted, gzipped JSON-lines archive to -out: a file or an
+// s3://, gs:// or az:// URL. The archive is streamed through age into the
+// upload in parts, so neither the plaintext nor the archive touches the disk.
+func runBackup(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) error {
+	if opts.outFile == "" {
+		return errors.New("backup needs a target: -out <file|s3://bucket/key|gs://bucket/object|az://account/container/blob>")
+	}
+	if len(opts.recipients) == 0 {
+		return errors.New("backup needs -recipient: the archive is only written encrypted")
+	}
+	paths, err := selectSecrets(ctx, client, opts, matcher, "backup")
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+	up, err := openUpload(ctx, opts.outFile)
+	if err != nil {
+		return err
+	}
+	n, err := writeBackup(ctx, client, opts, paths, up)
+	if err == nil {
+		err = up.Close()
+	}
+	if err != nil {
+		up.Abort()
+		return fmt.Errorf("backup to %s: %w", opts.outFile, err)
+	}
+	fmt.Fprintf(os.Stderr, "fvf: backed up %d secret(s) to %s\n", n, opts.outFile)
+	return nil
+}
+
+// writeBackup reads paths in batches and writes the archive to w, returning
+// how many secrets it holds. Secrets deleted since the walk are left out; any
+// other read failure fails the backup.
+func writeBackup(ctx context.Context, client *vault.Client, opts options, paths []string, w io.Writer) (int, error) {
+	enc, err := ageEncrypt(opts.recipients, w)
+	if err != nil {
+		return 0, err
+	}
+	gz := gzip.NewWriter(enc)
+	n, err := writeBackupEntries(ctx, client, opts, paths, json.NewEncoder(gz))
+	if err == nil {
+		err = gz.Close()
+	}
+	if cerr := enc.Close(); err == nil {
+		err = cerr
+	}
+	return n, err
+}
+
+func writeBackupEntries(ctx context.Context, client *vault.Client, opts options, paths []string, out *json.Encoder) (int, error) {
+	header := backupHeader{
+		Format:    backupFormat,
+		Created:   time.Now().UTC(),
+		Addr:      client.Address(),
+		Namespace: search.NormalizeNamespace(client.Namespace()),
+	}
+	if err := out.Encode(header); err != nil {
+		return 0, err
+	}
+	n := 0
+	for start := 0; start < len(paths); start += backupBatch {
+		batch := paths[start:min(start+backupBatch, len(paths))]
+		vals, versions, errs, err := readValues(ctx, client, opts, batch)
+		if err != nil {
+			return n, err
+		}
+		for _, p := range batch {
+			p = strings.Trim(p, "/")
+			if err := errs[p]; err != nil {
+				if errors.Is(err, search.ErrNoData) {
+					continue
+				}
+				return n, fmt.Errorf("%s: %w", p, err)
+			}
+			if err := out.Encode(backupEntry{Path: p, Version: versions[p], Data: vals[p]}); err != nil {
+				return n, err
+			}
+			n++
+		}
+	}
+	return n, nil
+}
+
+// runAge starts "age -r <recipient>..." with out as its output. Close ends
+// the input and waits for age to write the rest.
+func runAge(recipients []string, out io.Writer) (io.WriteCloser, error) {
+	bin, err := exec.LookPath("age")
+	if err != nil {
+		return nil, errors.New("age not found in PATH; install it from https://github.com/FiloSottile/age")
+	}
+	var args []string
+	for _, r := range recipients {
+		args = append(args, "-r", r)
+	}
+	cmd := exec.Command(bin, args...)
+	cmd.Stdout = out
+	a := &ageProcess{cmd: cmd}
+	cmd.Stderr = &a.stderr
+	if a.in, err = cmd.StdinPipe(); err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+type ageProcess struct {
+	cmd    *exec.Cmd
+	in     io.WriteCloser
+	stderr bytes.Buffer
+}
+
+func (a *ageProcess) Write(p []byte) (int, error) { return a.in.Write(p) }
+
+func (a *ageProcess) Close() error {
+	a.in.Close()
+	if err := a.cmd.Wait(); err != nil {
+		return fmt.Errorf("age: %w: %s", err, strings.TrimSpace(a.stderr.String()))
+	}
+	return nil
+}
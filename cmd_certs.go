@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fvf/search"
+	"fvf/ui"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// certFinding is a certificate that expires within the -expiry-within window.
+type certFinding struct {
+	Path     string    `json:"path"`
+	Key      string    `json:"key"`
+	Subject  string    `json:"subject"`
+	NotAfter time.Time `json:"not_after"`
+	DaysLeft int       `json:"days_left"` // negative once expired
+	Owners   []string  `json:"owners,omitempty"`
+}
+
+// findExpiringCerts returns the certificates in the string values of data
+// whose notAfter falls before now+within, sorted by key.
+func findExpiringCerts(p string, data map[string]interface{}, now time.Time, within time.Duration) []certFinding {
+	var out []certFinding
+	for k, v := range data {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		for _, c := range ui.ParseCertificates(s) {
+			if c.NotAfter.After(now.Add(within)) {
+				continue
+			}
+			out = append(out, certFinding{
+				Path:     p,
+				Key:      k,
+				Subject:  c.Subject,
+				NotAfter: c.NotAfter,
+				DaysLeft: int(c.NotAfter.Sub(now).Hours() / 24),
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// metadataOwners reads the comma-separated owner entry of custom_metadata.
+func metadataOwners(md *search.SecretMetadata) []string {
+	if md == nil {
+		return nil
+	}
+	var owners []string
+	for _, o := range strings.Split(md.CustomMetadata["owner"], ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			owners = append(owners, o)
+		}
+	}
+	return owners
+}
+
+// runScanCerts reads every secret below the roots (or the path arguments),
+// lists the PEM certificates expiring within -expiry-within, and with
+// -notify sends them to the configured notifier. Owners come from the
+// secret's custom_metadata "owner" entry (KV v2).
+func runScanCerts(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) error {
+	var n notifier
+	if opts.notify != "" {
+		var err error
+		if n, err = newNotifier(opts.notify); err != nil {
+			return err
+		}
+	}
+	paths, err := selectSecrets(ctx, client, opts, matcher, "scan-certs")
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var mu sync.Mutex
+	var findings []certFinding
+	err = forEachLimit(opts.concurrency, len(paths), func(i int) error {
+		p := strings.Trim(paths[i], "/")
+		mnt, inner := search.SplitMount(p)
+		kv2 := decideKV2ForPath(ctx, client, mnt, opts)
+		val, err := search.ReadSecret(ctx, client.Logical(), mnt, inner, kv2)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintf(os.Stderr, "fvf: skipping %s: %v\n", p, err)
+			return nil
+		}
+		data, _ := val.(map[string]interface{})
+		found := findExpiringCerts(p, data, now, opts.expiryWithin)
+		if len(found) > 0 && kv2 {
+			md, _ := search.ReadMetadata(ctx, client.Logical(), mnt, inner)
+			for j := range found {
+				found[j].Owners = metadataOwners(md)
+			}
+		}
+		mu.Lock()
+		findings = append(findings, found...)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Path < findings[j].Path })
+
+	if opts.jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(findings); err != nil {
+			return err
+		}
+	} else {
+		for _, f := range findings {
+			fmt.Println(formatCertFinding(f))
+		}
+	}
+	if n != nil && len(findings) > 0 {
+		return n.notify(ctx, findings)
+	}
+	return nil
+}
+
+// formatCertFinding renders one finding as a single line.
+func formatCertFinding(f certFinding) string {
+	when := fmt.Sprintf("expires in %dd", f.DaysLeft)
+	if f.DaysLeft < 0 || time.Now().After(f.NotAfter) {
+		when = "EXPIRED"
+	}
+	line := fmt.Sprintf("%s [%s] %s %s (%s)", f.Path, f.Key, f.NotAfter.UTC().Format(time.DateOnly), when, f.Subject)
+	if len(f.Owners) > 0 {
+		line += " owners: " + strings.Join(f.Owners, ", ")
+	}
+	return line
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"fvf/search"
+	"fvf/ui"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// copyStep is one secret of a cp/mv run and what happened to it.
+type copyStep struct {
+	Src    string `json:"src"`
+	Dst    string `json:"dst"`
+	Action string `json:"action"` // copy, overwrite or skip
+	DryRun bool   `json:"dry_run,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	srcLogical search.LogicalAPI
+	srcKV2     bool
+	dstKV2     bool
+	// dstBase and dstVersion are the KV v2 destination as checked, for the
+	// check-and-set write (version 0: it did not exist).
+	dstBase    map[string]interface{}
+	dstVersion int
+	// written is set once the step's copy (and delete for a move) succeeded.
+	written bool
+}
+
+// copyPlan maps the source paths to destinations. Recursively, each path keeps
+// its part below src under dst; otherwise src is a single secret and a dst
+// ending in "/" receives it under its own name, like cp.
+func copyPlan(src, dst string, paths []string, recursive bool) ([]copyStep, error) {
+	if !recursive {
+		if strings.HasSuffix(src, "/") {
+			return nil, fmt.Errorf("%s is a folder; use -r to copy it", src)
+		}
+		if strings.HasSuffix(dst, "/") {
+			dst += path.Base(src)
+		}
+		return []copyStep{{Src: strings.Trim(src, "/"), Dst: strings.Trim(dst, "/")}}, nil
+	}
+	srcRoot := strings.Trim(src, "/") + "/"
+	dstRoot := strings.Trim(dst, "/") + "/"
+	steps := make([]copyStep, 0, len(paths))
+	for _, p := range paths {
+		p = strings.Trim(p, "/")
+		rel, ok := strings.CutPrefix(p, srcRoot)
+		if !ok {
+			return nil, fmt.Errorf("walk of %s returned %s outside of it", srcRoot, p)
+		}
+		steps = append(steps, copyStep{Src: p, Dst: dstRoot + rel})
+	}
+	return steps, nil
+}
+
+// runCp copies the secret (or with -r every secret below the prefix) at the
+// first argument to the second.
+func runCp(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) error {
+	return copySecrets(ctx, client, opts, matcher, false)
+}
+
+// runMv is runCp that deletes each source once its copy is written.
+func runMv(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) error {
+	return copySecrets(ctx, client, opts, matcher, true)
+}
+
+// copySecrets plans the copy from the source and destination arguments and
+// runs it.
+func copySecrets(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp, move bool) error {
+	verb := "cp"
+	if move {
+		verb = "mv"
+	}
+	if len(opts.args) != 2 {
+		return fmt.Errorf("%s takes a source and a destination path, got %d argument(s)", verb, len(opts.args))
+	}
+	src, dst := opts.args[0], opts.args[1]
+	if strings.Trim(src, "/") == strings.Trim(dst, "/") {
+		return fmt.Errorf("%s: source and destination are the same", verb)
+	}
+	if opts.recursive {
+		// Like sync roots: copying a prefix into itself (or into its parent)
+		// would walk or overwrite what it just wrote.
+		s, d := strings.Trim(src, "/")+"/", strings.Trim(dst, "/")+"/"
+		if strings.HasPrefix(d, s) || strings.HasPrefix(s, d) {
+			return fmt.Errorf("%s: %s and %s overlap", verb, s, d)
+		}
+	}
+
+	var paths []string
+	if opts.recursive {
+		var skipped []ui.WalkError
+		var err error
+		paths, skipped, err = walkPaths(ctx, client, opts, matcher, []string{strings.Trim(src, "/") + "/"})
+		if err != nil {
+			return err
+		}
+		if err := strictResult(opts, reportWalkFailures(skipped)); err != nil {
+			return err
+		}
+	}
+	steps, err := copyPlan(src, dst, paths, opts.recursive)
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		return fmt.Errorf("no secrets below %s", src)
+	}
+	var confirm func() error
+	if move && !opts.yes {
+		confirm = confirmSteps("mv", "move", steps)
+	}
+	return runCopySteps(ctx, client, opts, verb, steps, move, confirm)
+}
+
+// confirmSteps lists the planned steps on stderr and asks to type "yes"
+// before a move or rename deletes any source.
+func confirmSteps(cmd, verb string, steps []copyStep) func() error {
+	return func() error {
+		for _, s := range steps {
+			note := ""
+			if s.Action != "copy" {
+				note = " (" + s.Action + " existing)"
+			}
+			fmt.Fprintf(os.Stderr, "  %s -> %s%s\n", s.Src, s.Dst, note)
+		}
+		return confirmTyped(cmd, fmt.Sprintf("fvf: %s these %d secret(s)? Type \"yes\" to continue: ", verb, len(steps)), "yes")
+	}
+}
+
+// resolveCopySteps looks up the mounts of every step: where to read the
+// source, whether it can be deleted for a move and whether the destination is
+// a writable KV mount.
+func resolveCopySteps(ctx context.Context, client *vault.Client, opts options, steps []copyStep, move bool) error {
+	for i := range steps {
+		s := &steps[i]
+		srcMnt, _ := search.SplitMount(s.Src)
+		s.srcLogical, s.srcKV2 = logicalFor(ctx, client, srcMnt, opts)
+		if _, ok := s.srcLogical.(*search.Plugin); ok && move {
+			return fmt.Errorf("%s is served by an engine plugin, which is read-only; use cp", srcMnt)
+		}
+		dstMnt, dstInner := search.SplitMount(s.Dst)
+		if dstInner == "" {
+			return fmt.Errorf("%s -> %s: the destination is a mount, not a secret path", s.Src, s.Dst)
+		}
+		var err error
+		if s.dstKV2, err = writableMount(ctx, client, dstMnt, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runCopySteps checks every destination against -on-conflict before writing
+// anything, then copies (and with move deletes the sources), carrying
+// custom_metadata over with -keep-metadata. -dry-run stops after printing
+// the plan; otherwise confirm, when set, is asked before the first write.
+// cmd names the command in errors.
+func runCopySteps(ctx context.Context, client *vault.Client, opts options, cmd string, steps []copyStep, move bool, confirm func() error) error {
+	if err := planCopySteps(ctx, client, opts, steps, move); err != nil {
+		return err
+	}
+	failed := 0
+	if opts.dryRun {
+		for i := range steps {
+			steps[i].DryRun = true
+		}
+	} else {
+		if confirm != nil {
+			if err := confirm(); err != nil {
+				return err
+			}
+		}
+		var err error
+		if failed, err = writeCopySteps(ctx, client, opts, os.Stderr, cmd, steps, move); err != nil {
+			return err
+		}
+	}
+
+	if err := printCopySteps(steps, move, opts); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%s: %d of %d secret(s) failed", cmd, failed, len(steps))
+	}
+	return nil
+}
+
+// planCopySteps resolves the mounts of every step and checks every
+// destination, setting each Action from -on-conflict; with "fail" an existing
+// destination is an error. Nothing is written.
+func planCopySteps(ctx context.Context, client *vault.Client, opts options, steps []copyStep, move bool) error {
+	switch opts.onConflict {
+	case "fail", "skip", "overwrite":
+	default:
+		return fmt.Errorf("-on-conflict %q: want fail, skip or overwrite", opts.onConflict)
+	}
+	if err := resolveCopySteps(ctx, client, opts, steps, move); err != nil {
+		return err
+	}
+
+	// Check every destination first so a conflict stops the run before any write
+	var mu sync.Mutex
+	var conflicts []string
+	err := forEachLimit(opts.concurrency, len(steps), func(i int) error {
+		mnt, inner := search.SplitMount(steps[i].Dst)
+		exists, err := search.SecretExists(ctx, client.Logical(), mnt, inner, steps[i].dstKV2)
+		if err != nil {
+			return fmt.Errorf("%s: %w", steps[i].Dst, err)
+		}
+		if steps[i].dstKV2 {
+			if steps[i].dstBase, steps[i].dstVersion, err = search.ReadSecretCurrent(ctx, client.Logical(), mnt, inner); err != nil {
+				return fmt.Errorf("%s: %w", steps[i].Dst, err)
+			}
+		}
+		steps[i].Action = "copy"
+		if exists {
+			steps[i].Action = opts.onConflict
+			mu.Lock()
+			conflicts = append(conflicts, steps[i].Dst)
+			mu.Unlock()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 && opts.onConflict == "fail" {
+		sort.Strings(conflicts)
+		return fmt.Errorf("%d destination(s) already exist, e.g. %s (use -on-conflict skip or overwrite)", len(conflicts), conflicts[0])
+	}
+	return nil
+}
+
+// writeCopySteps runs a checked plan, -concurrency steps at a time, recording
+// each failure in its step's Error and describing check-and-set conflicts on
+// w. It returns how many failed; the error is only set when ctx ended.
+func writeCopySteps(ctx context.Context, client *vault.Client, opts options, w io.Writer, cmd string, steps []copyStep, move bool) (int, error) {
+	var mu sync.Mutex
+	failed := 0
+	err := forEachLimit(opts.concurrency, len(steps), func(i int) error {
+		if steps[i].Action == "skip" {
+			return nil
+		}
+		if err := copyOne(ctx, client, opts, w, cmd, steps[i], move); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			mu.Lock()
+			steps[i].Error = err.Error()
+			failed++
+			mu.Unlock()
+			return nil
+		}
+		steps[i].written = true
+		return nil
+	})
+	return failed, err
+}
+
+// transferSteps converts steps for the TUI's copy and move.
+func transferSteps(steps []copyStep) []ui.TransferStep {
+	out := make([]ui.TransferStep, len(steps))
+	for i, s := range steps {
+		out[i] = ui.TransferStep{Src: s.Src, Dst: s.Dst, Action: s.Action}
+		if s.Error != "" {
+			out[i].Err = errors.New(s.Error)
+		}
+	}
+	return out
+}
+
+// copyOne copies one secret, with check-and-set against the checked version
+// on KV v2, then its custom_metadata when asked and both sides are KV v2,
+// then deletes the source for a move. A check-and-set conflict is described
+// on w.
+func copyOne(ctx context.Context, client *vault.Client, opts options, w io.Writer, cmd string, s copyStep, move bool) error {
+	srcMnt, srcInner := search.SplitMount(s.Src)
+	dstMnt, dstInner := search.SplitMount(s.Dst)
+	val, err := search.ReadSecret(ctx, s.srcLogical, srcMnt, srcInner, s.srcKV2)
+	if err != nil {
+		return err
+	}
+	data, ok := val.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected secret shape at %s", s.Src)
+	}
+	if s.dstKV2 {
+		if _, err := writeCAS(ctx, client, opts, w, cmd, s.Dst, s.dstBase, s.dstVersion, func(map[string]interface{}) map[string]interface{} { return data }, false); err != nil {
+			return err
+		}
+	} else if err := search.WriteSecret(ctx, client.Logical(), dstMnt, dstInner, false, data); err != nil {
+		return err
+	}
+	if opts.keepMetadata && s.srcKV2 && s.dstKV2 {
+		md, err := search.ReadMetadata(ctx, s.srcLogical, srcMnt, srcInner)
+		if err != nil {
+			return fmt.Errorf("custom_metadata: %w", err)
+		}
+		if len(md.CustomMetadata) > 0 {
+			if err := search.WriteCustomMetadata(ctx, client.Logical(), dstMnt, dstInner, md.CustomMetadata); err != nil {
+				return fmt.Errorf("custom_metadata: %w", err)
+			}
+		}
+	}
+	if move {
+		return search.DeleteSecret(ctx, client.Logical(), srcMnt, srcInner, s.srcKV2)
+	}
+	return nil
+}
+
+// printCopySteps prints one line per secret (a JSON array with -json) and a
+// summary on stderr.
+func printCopySteps(steps []copyStep, move bool, opts options) error {
+	if opts.jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(steps)
+	}
+	done, skipped := 0, 0
+	for _, s := range steps {
+		action := s.Action
+		if move && action != "skip" {
+			action = "move"
+		}
+		switch {
+		case s.Error != "":
+			fmt.Printf("failed    %s -> %s: %s\n", s.Src, s.Dst, s.Error)
+			continue
+		case s.DryRun:
+			action = "would " + action
+		}
+		fmt.Printf("%-9s %s -> %s\n", action, s.Src, s.Dst)
+		if s.Action == "skip" {
+			skipped++
+		} else {
+			done++
+		}
+	}
+	verb := "copied"
+	if move {
+		verb = "moved"
+	}
+	if opts.dryRun {
+		verb = "would have " + verb
+	}
+	fmt.Fprintf(os.Stderr, "fvf: %s %d secret(s), skipped %d existing\n", verb, done, skipped)
+	return nil
+}
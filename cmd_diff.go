@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// diffFormat is the version of the diff file layout.
+const diffFormat = 1
+
+// diffFile is a diff saved by fvf diff -out for fvf apply: the changes that
+// make dst match src, with digests of the values involved but no values, so
+// it can be reviewed (e.g. in a pull request) before it is applied. Digests
+// are keyed like a manifest's: the salt and, when set, -manifest-key.
+type diffFile struct {
+	Format  int          `json:"format"`
+	Created time.Time    `json:"created"`
+	Src     string       `json:"src"`
+	Dst     string       `json:"dst"`
+	Salt    string       `json:"salt"`
+	Keyed   bool         `json:"keyed"`
+	Changes []diffChange `json:"changes"`
+}
+
+// diffChange is one change of a diffFile. Path is relative to both prefixes;
+// Digest is the source value written by a create or update, Was the
+// destination value an update or delete expects to replace.
+type diffChange struct {
+	Type   string `json:"type"`
+	Path   string `json:"path"`
+	Digest string `json:"digest,omitempty"`
+	Was    string `json:"was,omitempty"`
+}
+
+// newDiffFile records actions, planned from src to dst, with digests keyed
+// by a fresh salt and secret.
+func newDiffFile(src, dst string, actions []syncAction, secret string) (*diffFile, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	d := &diffFile{
+		Format:  diffFormat,
+		Created: time.Now().UTC().Truncate(time.Second),
+		Src:     src,
+		Dst:     dst,
+		Salt:    base64.StdEncoding.EncodeToString(salt),
+		Keyed:   secret != "",
+		Changes: []diffChange{},
+	}
+	key, err := manifestKey(d.Salt, secret)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range actions {
+		c := diffChange{Type: a.Type, Path: strings.TrimPrefix(a.Dst, dst)}
+		if a.Type != "delete" {
+			if c.Digest, err = valueDigest(key, a.data); err != nil {
+				return nil, err
+			}
+		}
+		if a.Type != "create" {
+			if c.Was, err = valueDigest(key, a.base); err != nil {
+				return nil, err
+			}
+		}
+		d.Changes = append(d.Changes, c)
+	}
+	return d, nil
+}
+
+// runDiff prints what fvf sync would change to make the destination prefix
+// match the source: one "create", "update" or "delete" line per destination
+// secret, or with -json the same as a list of {type, src, dst}. Values are
+// compared by digest and never printed. -out also saves the diff for fvf
+// apply.
+func runDiff(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) error {
+	src, dst, err := syncRoots("diff", opts.args)
+	if err != nil {
+		return err
+	}
+	if opts.namePart != "" || matcher != nil {
+		return fmt.Errorf("diff compares whole prefixes; -name and -match are not supported")
+	}
+	// The file is meant to be committed; see runManifest
+	if opts.outFile != "" && opts.manifestKey == "" && !opts.unkeyed {
+		return fmt.Errorf("diff -out needs -manifest-key (or FVF_MANIFEST_KEY); pass -unkeyed-manifest to write it without")
+	}
+	actions, unchanged, err := diffPrefixes(ctx, client, opts, "diff", src, dst)
+	if err != nil {
+		return err
+	}
+	if opts.outFile != "" {
+		d, err := newDiffFile(src, dst, actions, opts.manifestKey)
+		if err != nil {
+			return err
+		}
+		b, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(opts.outFile, append(b, '\n'), 0o644); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "fvf: wrote %d change(s) to %s\n", len(d.Changes), opts.outFile)
+	}
+	if opts.jsonOut {
+		if actions == nil {
+			actions = []syncAction{}
+		}
+		b, err := json.MarshalIndent(actions, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+	} else {
+		for _, a := range actions {
+			fmt.Printf("%-6s %s\n", a.Type, a.Dst)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "fvf: diff: %d change(s), %d secret(s) unchanged\n", len(actions), unchanged)
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// direnvLib is the use_fvf function for ~/.config/direnv/direnvrc, enabling
+// "use fvf kv/app/dev" in any .envrc.
+const direnvLib = `# fvf: export a Vault secret's keys with "use fvf <path>"
+use_fvf() {
+  watch_file "${HOME}/.vault-token"
+  eval "$(fvf direnv "$@")" || log_error "fvf: cannot read $*"
+}
+`
+
+// runDirenv prints an .envrc for the secret at the path argument (or -path):
+// export lines with the values (-direnv-mode export, the default), a lazy
+// .envrc that runs fvf when direnv loads it, so values never hit disk
+// (lazy), or the use_fvf layout function (lib).
+func runDirenv(ctx context.Context, client *vault.Client, opts options, _ *regexp.Regexp) error {
+	if opts.direnvMode == "lib" {
+		fmt.Print(direnvLib)
+		return nil
+	}
+	secret := strings.TrimSpace(opts.startPath)
+	switch {
+	case len(opts.args) == 1:
+		secret = opts.args[0]
+	case len(opts.args) > 1:
+		return fmt.Errorf("direnv takes one path, got %d", len(opts.args))
+	}
+	secret = strings.Trim(secret, "/")
+	if secret == "" {
+		return fmt.Errorf("direnv needs a secret path (argument or -path)")
+	}
+	if opts.direnvMode == "lazy" {
+		fmt.Printf("# Generated by fvf: fetches %s from Vault on every direnv load\n", secret)
+		fmt.Printf("eval \"$(fvf direnv %s)\"\n", shellQuote(secret))
+		return nil
+	}
+
+	mnt, inner := search.SplitMount(secret)
+	if err := sessionMounts.CheckKV(ctx, client, mnt); err != nil {
+		return err
+	}
+	val, err := search.ReadSecret(ctx, client.Logical(), mnt, inner, decideKV2ForPath(ctx, client, mnt, opts))
+	if err != nil {
+		return err
+	}
+	data, ok := val.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s: secret is not a key/value map", secret)
+	}
+	maskForCI(data)
+	fmt.Print(envrcExports(data))
+	return nil
+}
+
+// envrcExports renders one export line per key, sorted by variable name.
+// Keys become upper-case shell identifiers; nested values are JSON.
+func envrcExports(data map[string]interface{}) string {
+	lines := make([]string, 0, len(data))
+	for k, v := range data {
+		s, ok := tryScalar(v)
+		if !ok {
+			b, err := json.Marshal(v)
+			if err != nil {
+				continue
+			}
+			s = string(b)
+		}
+		lines = append(lines, fmt.Sprintf("export %s=%s", envVarName(k), shellQuote(s)))
+	}
+	sort.Strings(lines)
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// envVarName maps a secret key to an environment variable name: "db-pass" -> "DB_PASS".
+func envVarName(key string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(key) {
+		if r == '_' || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	name := b.String()
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+// shellQuote single-quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// dockerSecretCreate stores value as a Docker swarm secret; replaced in tests.
+var dockerSecretCreate = runDockerSecretCreate
+
+// runDockerSecret hands the keys of the secret at the path argument to Docker:
+// one "docker secret create" per key, fed on stdin, or with -env-file a
+// compose env_file on stdout. -keys limits the selection.
+func runDockerSecret(ctx context.Context, client *vault.Client, opts options, _ *regexp.Regexp) error {
+	if len(opts.args) != 1 {
+		return fmt.Errorf("docker-secret takes exactly one path, got %d", len(opts.args))
+	}
+	secret := strings.Trim(opts.args[0], "/")
+	mnt, inner := search.SplitMount(secret)
+	if err := sessionMounts.CheckKV(ctx, client, mnt); err != nil {
+		return err
+	}
+	val, err := search.ReadSecret(ctx, client.Logical(), mnt, inner, decideKV2ForPath(ctx, client, mnt, opts))
+	if err != nil {
+		return err
+	}
+	data, ok := val.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s: secret is not a key/value map", secret)
+	}
+	keys, err := selectKeys(data, opts.keys)
+	if err != nil {
+		return err
+	}
+
+	if opts.envFile {
+		maskForCI(data)
+		out, err := composeEnvFile(data, keys)
+		if err != nil {
+			return err
+		}
+		fmt.Print(out)
+		return nil
+	}
+	for _, k := range keys {
+		name := dockerSecretName(path.Base(secret), k)
+		if err := dockerSecretCreate(name, []byte(scalarToString(data[k]))); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		fmt.Fprintf(os.Stderr, "fvf: created docker secret %s\n", name)
+	}
+	return nil
+}
+
+// selectKeys returns the wanted keys (all when empty), sorted; unknown keys are an error.
+func selectKeys(data map[string]interface{}, wanted []string) ([]string, error) {
+	if len(wanted) == 0 {
+		keys := make([]string, 0, len(data))
+		for k := range data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return keys, nil
+	}
+	for _, k := range wanted {
+		if _, ok := data[k]; !ok {
+			return nil, fmt.Errorf("secret has no key %q", k)
+		}
+	}
+	return wanted, nil
+}
+
+// composeEnvFile renders KEY=value lines for a compose env_file. Compose takes
+// the value verbatim up to the end of the line, so multi-line values are refused.
+func composeEnvFile(data map[string]interface{}, keys []string) (string, error) {
+	var b strings.Builder
+	for _, k := range keys {
+		v := scalarToString(data[k])
+		if strings.ContainsAny(v, "\r\n") {
+			return "", fmt.Errorf("key %q has a multi-line value, which env_file cannot hold; use docker secrets instead", k)
+		}
+		fmt.Fprintf(&b, "%s=%s\n", envVarName(k), v)
+	}
+	return b.String(), nil
+}
+
+// dockerSecretName builds "<secret>_<key>" from the characters Docker accepts.
+func dockerSecretName(base, key string) string {
+	clean := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r == '-' || r == '_' || r == '.' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+				return r
+			}
+			return '_'
+		}, s)
+	}
+	return clean(base) + "_" + clean(key)
+}
+
+// runDockerSecretCreate runs "docker secret create <name> -" with value on
+// stdin, so it never touches the disk.
+func runDockerSecretCreate(name string, value []byte) error {
+	bin, err := exec.LookPath("docker")
+	if err != nil {
+		return errors.New("docker not found in PATH")
+	}
+	cmd := exec.Command(bin, "secret", "create", name, "-")
+	cmd.Stdin = bytes.NewReader(value)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("docker secret create: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
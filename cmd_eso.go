@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+	"gopkg.in/yaml.v3"
+)
+
+// Minimal external-secrets.io resources; only the fields fvf fills are modelled.
+
+type k8sMeta struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+type secretStore struct {
+	APIVersion string          `yaml:"apiVersion"`
+	Kind       string          `yaml:"kind"`
+	Metadata   k8sMeta         `yaml:"metadata"`
+	Spec       secretStoreSpec `yaml:"spec"`
+}
+
+type secretStoreSpec struct {
+	Provider struct {
+		Vault vaultProvider `yaml:"vault"`
+	} `yaml:"provider"`
+}
+
+type vaultProvider struct {
+	Server    string `yaml:"server"`
+	Namespace string `yaml:"namespace,omitempty"`
+	Path      string `yaml:"path"`
+	Version   string `yaml:"version"`
+	Auth      struct {
+		TokenSecretRef struct {
+			Name string `yaml:"name"`
+			Key  string `yaml:"key"`
+		} `yaml:"tokenSecretRef"`
+	} `yaml:"auth"`
+}
+
+type externalSecret struct {
+	APIVersion string             `yaml:"apiVersion"`
+	Kind       string             `yaml:"kind"`
+	Metadata   k8sMeta            `yaml:"metadata"`
+	Spec       externalSecretSpec `yaml:"spec"`
+}
+
+type externalSecretSpec struct {
+	RefreshInterval string `yaml:"refreshInterval"`
+	SecretStoreRef  struct {
+		Name string `yaml:"name"`
+		Kind string `yaml:"kind"`
+	} `yaml:"secretStoreRef"`
+	Target struct {
+		Name string `yaml:"name"`
+	} `yaml:"target"`
+	Data []esoData `yaml:"data"`
+}
+
+type esoData struct {
+	SecretKey string `yaml:"secretKey"`
+	RemoteRef struct {
+		Key      string `yaml:"key"`
+		Property string `yaml:"property"`
+	} `yaml:"remoteRef"`
+}
+
+// esoSecret is one Vault secret to scaffold: its mount, KV version and keys.
+type esoSecret struct {
+	mount, inner string
+	kv2          bool
+	keys         []string
+}
+
+// runESO prints ExternalSecret manifests for the selected secrets (path
+// arguments, or the -path walk) plus one SecretStore per mount. Secrets are
+// read only to learn their keys; values are never written.
+func runESO(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) error {
+	paths, err := selectSecrets(ctx, client, opts, matcher, "eso")
+	if err != nil {
+		return err
+	}
+	var secrets []esoSecret
+	for _, p := range paths {
+		p = strings.Trim(p, "/")
+		mnt, inner := search.SplitMount(p)
+		kv2 := decideKV2ForPath(ctx, client, mnt, opts)
+		val, err := search.ReadSecret(ctx, client.Logical(), mnt, inner, kv2)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		data, ok := val.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: secret is not a key/value map", p)
+		}
+		keys, err := selectKeys(data, opts.keys)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		secrets = append(secrets, esoSecret{mount: mnt, inner: inner, kv2: kv2, keys: keys})
+	}
+	return writeESOManifests(os.Stdout, secrets, client.Address(), client.Namespace(), opts.k8sNamespace)
+}
+
+// writeESOManifests writes the SecretStores, then the ExternalSecrets, as one
+// multi-document YAML stream.
+func writeESOManifests(w io.Writer, secrets []esoSecret, server, vaultNS, k8sNS string) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	defer enc.Close()
+
+	stores := make(map[string]bool) // mount -> kv2
+	for _, s := range secrets {
+		stores[s.mount] = s.kv2
+	}
+	mounts := make([]string, 0, len(stores))
+	for m := range stores {
+		mounts = append(mounts, m)
+	}
+	sort.Strings(mounts)
+	for _, m := range mounts {
+		st := secretStore{APIVersion: "external-secrets.io/v1beta1", Kind: "SecretStore",
+			Metadata: k8sMeta{Name: esoStoreName(m), Namespace: k8sNS}}
+		v := &st.Spec.Provider.Vault
+		v.Server, v.Namespace, v.Path, v.Version = server, vaultNS, m, "v1"
+		if stores[m] {
+			v.Version = "v2"
+		}
+		v.Auth.TokenSecretRef.Name, v.Auth.TokenSecretRef.Key = "vault-token", "token"
+		if err := enc.Encode(st); err != nil {
+			return err
+		}
+	}
+	for _, s := range secrets {
+		name := k8sName(s.mount + "-" + s.inner)
+		es := externalSecret{APIVersion: "external-secrets.io/v1beta1", Kind: "ExternalSecret",
+			Metadata: k8sMeta{Name: name, Namespace: k8sNS}}
+		es.Spec.RefreshInterval = "1h"
+		es.Spec.SecretStoreRef.Name, es.Spec.SecretStoreRef.Kind = esoStoreName(s.mount), "SecretStore"
+		es.Spec.Target.Name = name
+		for _, k := range s.keys {
+			d := esoData{SecretKey: k}
+			d.RemoteRef.Key, d.RemoteRef.Property = s.inner, k
+			es.Spec.Data = append(es.Spec.Data, d)
+		}
+		if err := enc.Encode(es); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func esoStoreName(mount string) string { return k8sName("vault-" + mount) }
+
+// k8sName turns a Vault path into a DNS-1123 resource name: lower case,
+// alphanumerics and '-', at most 63 characters.
+func k8sName(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	name := b.String()
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return strings.Trim(name, "-")
+}
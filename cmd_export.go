@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+	"gopkg.in/yaml.v3"
+)
+
+// sopsEncrypt encrypts a plaintext document for the file at target; replaced in tests.
+var sopsEncrypt = runSops
+
+// runExport writes the selected secrets, keyed by path, to a SOPS-encrypted
+// file. Secrets are the path arguments, or every secret the walk finds below
+// -path/-paths (filtered by -name/-match) when no arguments are given.
+func runExport(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) error {
+	if opts.sopsFile == "" {
+		return errors.New("export needs a target: -sops <file>")
+	}
+	paths, err := selectSecrets(ctx, client, opts, matcher, "export")
+	if err != nil {
+		return err
+	}
+
+	doc := make(map[string]interface{}, len(paths))
+	for _, p := range paths {
+		p = strings.Trim(p, "/")
+		mnt, inner := search.SplitMount(p)
+		val, err := search.ReadSecret(ctx, client.Logical(), mnt, inner, decideKV2ForPath(ctx, client, mnt, opts))
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		doc[p] = val
+	}
+	return exportSOPS(doc, opts.sopsFile)
+}
+
+// selectSecrets returns the secret paths named by the arguments or, without
+// arguments, every secret the -path/-paths walk finds (filtered by
+// -name/-match). cmd names the command in errors.
+func selectSecrets(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp, cmd string) ([]string, error) {
+	paths := opts.args
+	if len(paths) == 0 {
+		roots := opts.paths
+		if len(roots) == 0 && strings.TrimSpace(opts.startPath) != "" {
+			roots = []string{opts.startPath}
+		}
+		if len(roots) == 0 {
+			return nil, fmt.Errorf("%s takes secret paths, or -path/-paths to walk", cmd)
+		}
+		found, skipped, err := walkPaths(ctx, client, opts, matcher, roots)
+		if err != nil {
+			return nil, err
+		}
+		if err := strictResult(opts, reportWalkFailures(skipped)); err != nil {
+			return nil, err
+		}
+		paths = found
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no secrets matched; nothing to %s", cmd)
+	}
+	return paths, nil
+}
+
+// exportSOPS encodes doc as JSON (for a .json target) or YAML and hands it to
+// sops; the plaintext never touches the disk.
+func exportSOPS(doc map[string]interface{}, target string) error {
+	format := sopsFormat(target)
+	var plain []byte
+	var err error
+	if format == "json" {
+		plain, err = json.MarshalIndent(doc, "", "  ")
+	} else {
+		plain, err = yaml.Marshal(doc)
+	}
+	if err != nil {
+		return err
+	}
+	enc, err := sopsEncrypt(plain, format, target)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(target, enc, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "fvf: exported %d secret(s) to %s\n", len(doc), target)
+	return nil
+}
+
+func sopsFormat(target string) string {
+	if strings.EqualFold(filepath.Ext(target), ".json") {
+		return "json"
+	}
+	return "yaml"
+}
+
+// runSops pipes plain through "sops --encrypt". Running next to target with
+// --filename-override makes sops pick keys (age, KMS, PGP) from the matching
+// .sops.yaml creation rule, as it would for the file itself.
+func runSops(plain []byte, format, target string) ([]byte, error) {
+	bin, err := exec.LookPath("sops")
+	if err != nil {
+		return nil, errors.New("sops not found in PATH; install it from https://github.com/getsops/sops")
+	}
+	abs, err := filepath.Abs(target)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(bin, "--encrypt",
+		"--input-type", format, "--output-type", format,
+		"--filename-override", abs, "/dev/stdin")
+	cmd.Dir = filepath.Dir(abs)
+	cmd.Stdin = bytes.NewReader(plain)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("sops: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+	"golang.org/x/term"
+)
+
+// getChoices bounds the candidates fvf get offers when a pattern is ambiguous.
+const getChoices = 9
+
+// pickInteractively reports whether an ambiguous fvf get may ask which
+// secret to print; tests replace it.
+var pickInteractively = func() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// runGet prints the secret at the single argument: "key: value" lines, the
+// data object with -output json, or the `vault kv get` layout with -output
+// vault. When nothing is stored at the argument it is taken as a fuzzy
+// pattern over the paths of the index (fvf index), or else of the
+// argument's directory or mount: a single match is printed, several are
+// offered for picking on a terminal.
+func runGet(ctx context.Context, client *vault.Client, opts options, _ *regexp.Regexp) error {
+	if len(opts.args) != 1 {
+		return fmt.Errorf("get takes exactly one path, got %d", len(opts.args))
+	}
+	target := strings.Trim(opts.args[0], "/")
+	err := printGet(ctx, client, opts, target)
+	if !errors.Is(err, search.ErrNoData) && !errors.Is(err, search.ErrMountNotFound) {
+		return err
+	}
+	matches, ferr := fuzzyCandidates(ctx, client, opts, target)
+	if ferr != nil {
+		slog.Debug("get: no fuzzy candidates", "pattern", target, "err", ferr)
+	}
+	if len(matches) == 0 {
+		return err
+	}
+	p, perr := pickMatch(target, matches)
+	if perr != nil {
+		return perr
+	}
+	fmt.Fprintln(os.Stderr, "fvf:", p)
+	return printGet(ctx, client, opts, p)
+}
+
+// printGet prints the secret at p in the -output format.
+func printGet(ctx context.Context, client *vault.Client, opts options, p string) error {
+	mnt, inner := search.SplitMount(p)
+	logical, kv2 := logicalFor(ctx, client, mnt, opts)
+	if _, ok := logical.(*search.Plugin); !ok {
+		if err := sessionMounts.CheckKV(ctx, client, mnt); err != nil {
+			return err
+		}
+	}
+	if opts.vaultOut {
+		return printVaultKVGet(ctx, logical, mnt, inner, kv2)
+	}
+	val, err := search.ReadSecretRetry(ctx, logical, mnt, inner, kv2, fetchPolicy(opts))
+	if err != nil {
+		return err
+	}
+	val = redactValue(val)
+	maskForCI(val)
+	if opts.jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(val)
+	}
+	fmt.Println(formatValueRaw(val, true))
+	return nil
+}
+
+// fuzzyCandidates returns the known paths matching pattern, best first. The
+// paths come from the index when there is one; otherwise the pattern's
+// directory is walked, or its mount when the directory lists nothing.
+func fuzzyCandidates(ctx context.Context, client *vault.Client, opts options, pattern string) ([]string, error) {
+	var paths []string
+	if file, err := indexPath(opts, client.Address(), client.Namespace()); err == nil {
+		if idx, err := readIndex(file); err == nil {
+			paths = idx.Paths
+		}
+	}
+	if paths == nil {
+		mnt, _ := search.SplitMount(pattern)
+		if err := sessionMounts.CheckKV(ctx, client, mnt); err != nil {
+			return nil, fmt.Errorf("%w (run fvf index to match paths across mounts)", err)
+		}
+		opts.printValues, opts.namePart = false, ""
+		var roots []string
+		if dir := path.Dir(pattern); dir != "." && dir != mnt {
+			roots = append(roots, dir+"/")
+		}
+		roots = append(roots, mnt+"/")
+		for _, root := range roots {
+			walked, _, err := walkPaths(ctx, client, opts, nil, []string{root})
+			if err != nil {
+				return nil, err
+			}
+			if paths = walked; len(paths) > 0 {
+				break
+			}
+		}
+	}
+	return fuzzyMatches(pattern, paths), nil
+}
+
+// fuzzyMatches returns the paths matching pattern, best first.
+func fuzzyMatches(pattern string, paths []string) []string {
+	type match struct {
+		path  string
+		score int
+	}
+	var ms []match
+	for _, p := range paths {
+		if score, ok := fuzzyScore(pattern, p); ok {
+			ms = append(ms, match{p, score})
+		}
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		if ms[i].score != ms[j].score {
+			return ms[i].score > ms[j].score
+		}
+		return ms[i].path < ms[j].path
+	})
+	out := make([]string, len(ms))
+	for i, m := range ms {
+		out[i] = m.path
+	}
+	return out
+}
+
+// fuzzyScore reports whether the characters of pattern appear in p in order,
+// ignoring case, and scores the match: substrings beat scattered characters,
+// a substring ending the path (the secret's name) beats one inside it, fewer
+// gaps beat more and shorter paths win ties.
+func fuzzyScore(pattern, p string) (int, bool) {
+	pat, s := strings.ToLower(pattern), strings.ToLower(p)
+	if i := strings.LastIndex(s, pat); i >= 0 {
+		score := 2000 - len(s)
+		if i+len(pat) == len(s) {
+			score += 500
+		}
+		return score, true
+	}
+	j, gaps, last := 0, 0, -1
+	for i := 0; i < len(s) && j < len(pat); i++ {
+		if s[i] != pat[j] {
+			continue
+		}
+		if last >= 0 && i != last+1 {
+			gaps++
+		}
+		last, j = i, j+1
+	}
+	if j < len(pat) {
+		return 0, false
+	}
+	return 1000 - 10*gaps - len(s), true
+}
+
+// pickMatch returns the only match, or lets the user choose among the best
+// getChoices on a terminal; elsewhere an ambiguous pattern is an error
+// listing them.
+func pickMatch(pattern string, matches []string) (string, error) {
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	shown := matches
+	if len(shown) > getChoices {
+		shown = shown[:getChoices]
+	}
+	var list strings.Builder
+	for i, p := range shown {
+		fmt.Fprintf(&list, "  %d  %s\n", i+1, p)
+	}
+	if len(matches) > len(shown) {
+		fmt.Fprintf(&list, "  … %d more\n", len(matches)-len(shown))
+	}
+	if !pickInteractively() {
+		return "", fmt.Errorf("%q matches %d secrets; use one of:\n%s", pattern, len(matches), strings.TrimRight(list.String(), "\n"))
+	}
+	fmt.Fprintf(os.Stderr, "fvf: %q matches %d secrets:\n%s", pattern, len(matches), list.String())
+	fmt.Fprintf(os.Stderr, "Pick one [1-%d]: ", len(shown))
+	line, err := bufio.NewReader(confirmInput).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("get: nothing picked")
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || n < 1 || n > len(shown) {
+		return "", fmt.Errorf("get: %q is not one of 1-%d", strings.TrimSpace(line), len(shown))
+	}
+	return shown[n-1], nil
+}
+
+// printVaultKVGet reads the whole response, KV v2 metadata included, and
+// prints it like `vault kv get`.
+func printVaultKVGet(ctx context.Context, logical search.LogicalAPI, mnt, inner string, kv2 bool) error {
+	apiPath := search.ReadAPIPath(mnt, inner, kv2)
+	sec, err := logical.ReadWithContext(ctx, apiPath)
+	if err != nil {
+		return err
+	}
+	if sec == nil || sec.Data == nil {
+		return fmt.Errorf("%w at %s", search.ErrNoData, apiPath)
+	}
+	if kv2 {
+		sec.Data["data"] = redactValue(sec.Data["data"])
+		maskForCI(sec.Data["data"])
+	} else {
+		redactValue(sec.Data)
+		maskForCI(sec.Data)
+	}
+	fmt.Print(formatVaultKVGet(apiPath, kv2, sec))
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// runLs lists the entries one level below the path argument as full logical
+// paths (folders end in "/"); without an argument it lists the KV mounts.
+func runLs(ctx context.Context, client *vault.Client, opts options, _ *regexp.Regexp) error {
+	if len(opts.args) > 1 {
+		return fmt.Errorf("ls takes at most one path, got %d", len(opts.args))
+	}
+	var out []string
+	if len(opts.args) == 0 {
+		mounts, err := sessionMounts.Mounts(ctx, client)
+		if err != nil {
+			return err
+		}
+		for _, m := range kvMounts(mounts) {
+			out = append(out, m+"/")
+		}
+	} else {
+		mnt, inner := search.SplitMount(opts.args[0])
+		if err := sessionMounts.CheckKV(ctx, client, mnt); err != nil {
+			return err
+		}
+		kv2 := decideKV2ForPath(ctx, client, mnt, opts)
+		keys, err := search.ListKeys(ctx, client.Logical(), mnt, inner, kv2)
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			p := path.Join(mnt, inner, k)
+			if strings.HasSuffix(k, "/") {
+				p += "/"
+			}
+			out = append(out, p)
+		}
+	}
+	if opts.jsonOut {
+		if out == nil {
+			out = []string{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+	for _, p := range out {
+		fmt.Println(p)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// manifestFormat is the version of the manifest file layout.
+const manifestFormat = 1
+
+// manifest records a digest and the KV v2 version of every secret below the
+// roots. Digests are HMAC-SHA256 over the canonical JSON of the value, keyed
+// with the manifest's random salt and, when set, -manifest-key; without the
+// key a low-entropy value could still be guessed offline from its digest.
+type manifest struct {
+	Format  int                      `json:"format"`
+	Created time.Time                `json:"created"`
+	Roots   []string                 `json:"roots"`
+	Name    string                   `json:"name,omitempty"`
+	Match   string                   `json:"match,omitempty"`
+	Salt    string                   `json:"salt"`
+	Keyed   bool                     `json:"keyed"`
+	Secrets map[string]manifestEntry `json:"secrets"`
+}
+
+type manifestEntry struct {
+	Digest  string `json:"digest"`
+	Version int    `json:"version,omitempty"` // 0 on KV v1
+}
+
+// manifestDrift is one difference between a manifest and Vault.
+type manifestDrift struct {
+	Path       string `json:"path"`
+	Type       string `json:"type"` // changed, rewritten, added, removed or unreadable
+	OldVersion int    `json:"old_version,omitempty"`
+	NewVersion int    `json:"new_version,omitempty"`
+}
+
+// valueDigest returns "hmac-sha256:<hex>" of the canonical JSON of val.
+func valueDigest(key []byte, val interface{}) (string, error) {
+	b, err := json.Marshal(val)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(b)
+	return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// manifestKey combines the stored salt with the secret -manifest-key.
+func manifestKey(salt, secret string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(salt)
+	if err != nil {
+		return nil, fmt.Errorf("manifest salt: %w", err)
+	}
+	return append(raw, secret...), nil
+}
+
+// snapshotManifest walks roots and digests every secret found. Secrets that
+// cannot be read are skipped with a warning and returned separately.
+func snapshotManifest(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp, roots []string, key []byte) (map[string]manifestEntry, []string, error) {
+	paths, skipped, err := walkPaths(ctx, client, opts, matcher, roots)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := strictResult(opts, reportWalkFailures(skipped)); err != nil {
+		return nil, nil, err
+	}
+	var mu sync.Mutex
+	entries := make(map[string]manifestEntry, len(paths))
+	var unreadable []string
+	err = forEachLimit(opts.concurrency, len(paths), func(i int) error {
+		p := strings.Trim(paths[i], "/")
+		mnt, inner := search.SplitMount(p)
+		logical, kv2 := logicalFor(ctx, client, mnt, opts)
+		val, err := search.ReadSecret(ctx, logical, mnt, inner, kv2)
+		var e manifestEntry
+		if err == nil {
+			e.Digest, err = valueDigest(key, val)
+		}
+		if err == nil && kv2 {
+			// Tokens may read data without metadata; the digest still tracks drift
+			if md, merr := search.ReadMetadata(ctx, logical, mnt, inner); merr == nil && md != nil {
+				e.Version = md.CurrentVersion
+			}
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintf(os.Stderr, "fvf: cannot read %s: %v\n", p, err)
+			mu.Lock()
+			unreadable = append(unreadable, p)
+			mu.Unlock()
+			return nil
+		}
+		mu.Lock()
+		entries[p] = e
+		mu.Unlock()
+		return nil
+	})
+	return entries, unreadable, err
+}
+
+// diffManifest compares a manifest with a fresh snapshot, sorted by path.
+func diffManifest(old, now map[string]manifestEntry, unreadable []string) []manifestDrift {
+	var out []manifestDrift
+	gone := make(map[string]bool, len(unreadable))
+	for _, p := range unreadable {
+		gone[p] = true
+		if _, ok := old[p]; ok {
+			out = append(out, manifestDrift{Path: p, Type: "unreadable", OldVersion: old[p].Version})
+		}
+	}
+	for p, n := range now {
+		o, ok := old[p]
+		switch {
+		case !ok:
+			out = append(out, manifestDrift{Path: p, Type: "added", NewVersion: n.Version})
+		case o.Digest != n.Digest:
+			out = append(out, manifestDrift{Path: p, Type: "changed", OldVersion: o.Version, NewVersion: n.Version})
+		case o.Version != n.Version:
+			// Written again with the same value
+			out = append(out, manifestDrift{Path: p, Type: "rewritten", OldVersion: o.Version, NewVersion: n.Version})
+		}
+	}
+	for p, o := range old {
+		if _, ok := now[p]; !ok && !gone[p] {
+			out = append(out, manifestDrift{Path: p, Type: "removed", OldVersion: o.Version})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+// runManifest writes a manifest of the -path/-paths walk to -out (stdout by
+// default), or with -verify compares Vault against an existing manifest and
+// fails when anything drifted.
+func runManifest(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) error {
+	if len(opts.args) > 0 {
+		return fmt.Errorf("manifest takes no arguments (use -path or -paths), got %q", opts.args)
+	}
+	if opts.verify != "" {
+		return verifyManifest(ctx, client, opts)
+	}
+	roots := opts.paths
+	if len(roots) == 0 && strings.TrimSpace(opts.startPath) != "" {
+		roots = []string{opts.startPath}
+	}
+	if len(roots) == 0 {
+		return fmt.Errorf("manifest needs -path or -paths")
+	}
+	// A file written to -out tends to be committed; without the key its
+	// digests of short values can be brute-forced from the salt alone.
+	if opts.outFile != "" && opts.manifestKey == "" && !opts.unkeyed {
+		return fmt.Errorf("manifest -out needs -manifest-key (or FVF_MANIFEST_KEY); pass -unkeyed-manifest to write it without")
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	m := manifest{
+		Format:  manifestFormat,
+		Created: time.Now().UTC().Truncate(time.Second),
+		Roots:   roots,
+		Name:    opts.namePart,
+		Match:   opts.match,
+		Salt:    base64.StdEncoding.EncodeToString(salt),
+		Keyed:   opts.manifestKey != "",
+	}
+	key, _ := manifestKey(m.Salt, opts.manifestKey)
+	entries, unreadable, err := snapshotManifest(ctx, client, opts, matcher, roots, key)
+	if err != nil {
+		return err
+	}
+	if len(unreadable) > 0 && opts.strict {
+		return fmt.Errorf("-strict: %d secret(s) could not be read", len(unreadable))
+	}
+	m.Secrets = entries
+
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if opts.outFile == "" {
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+	if err := os.WriteFile(opts.outFile, b, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "fvf: wrote %d secret digest(s) to %s\n", len(entries), opts.outFile)
+	return nil
+}
+
+// verifyManifest re-walks the manifest's roots with its filters (-path/-paths
+// override the roots) and reports every drift.
+func verifyManifest(ctx context.Context, client *vault.Client, opts options) error {
+	b, err := os.ReadFile(opts.verify)
+	if err != nil {
+		return err
+	}
+	var m manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return fmt.Errorf("%s: %w", opts.verify, err)
+	}
+	if m.Format != manifestFormat {
+		return fmt.Errorf("%s: unsupported manifest format %d", opts.verify, m.Format)
+	}
+	if m.Keyed && opts.manifestKey == "" {
+		return fmt.Errorf("%s was written with -manifest-key; set it (or FVF_MANIFEST_KEY) to verify", opts.verify)
+	}
+	roots := m.Roots
+	if len(opts.paths) > 0 {
+		roots = opts.paths
+	} else if strings.TrimSpace(opts.startPath) != "" {
+		roots = []string{opts.startPath}
+	}
+	opts.namePart = m.Name
+	matcher, err := buildMatcher(m.Match)
+	if err != nil {
+		return err
+	}
+	secret := ""
+	if m.Keyed {
+		secret = opts.manifestKey
+	}
+	key, err := manifestKey(m.Salt, secret)
+	if err != nil {
+		return err
+	}
+	now, unreadable, err := snapshotManifest(ctx, client, opts, matcher, roots, key)
+	if err != nil {
+		return err
+	}
+	drift := diffManifest(m.Secrets, now, unreadable)
+
+	if opts.jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if drift == nil {
+			drift = []manifestDrift{}
+		}
+		if err := enc.Encode(drift); err != nil {
+			return err
+		}
+	} else {
+		for _, d := range drift {
+			fmt.Printf("%-10s %s v%d -> v%d\n", d.Type, d.Path, d.OldVersion, d.NewVersion)
+		}
+	}
+	if len(drift) > 0 {
+		return fmt.Errorf("%d secret(s) drifted since %s", len(drift), m.Created.Format(time.RFC3339))
+	}
+	fmt.Fprintf(os.Stderr, "fvf: %d secret(s) match %s\n", len(now), opts.verify)
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// metaTarget resolves p to a KV v2 secret whose metadata fvf may change.
+func metaTarget(ctx context.Context, client *vault.Client, p string, opts options) (mnt, inner string, err error) {
+	mnt, inner = search.SplitMount(strings.Trim(p, "/"))
+	if inner == "" {
+		return "", "", fmt.Errorf("%q is a mount, not a secret path", p)
+	}
+	kv2, err := writableMount(ctx, client, mnt, opts)
+	if err != nil {
+		return "", "", err
+	}
+	if !kv2 {
+		return "", "", fmt.Errorf("%s is KV v1, which has no custom_metadata", mnt)
+	}
+	return mnt, inner, nil
+}
+
+// writeCustomMetadata replaces the custom_metadata of the secret at p.
+func writeCustomMetadata(ctx context.Context, client *vault.Client, p string, cm map[string]string, opts options) error {
+	mnt, inner, err := metaTarget(ctx, client, p, opts)
+	if err != nil {
+		return err
+	}
+	return search.WriteCustomMetadata(ctx, client.Logical(), mnt, inner, cm)
+}
+
+// runMeta shows or edits the KV v2 custom_metadata of a secret:
+//
+//	fvf meta get <path>
+//	fvf meta set <path> key=value...
+//	fvf meta rm <path> key...
+//
+// set and rm keep the keys they do not name.
+func runMeta(ctx context.Context, client *vault.Client, opts options, _ *regexp.Regexp) error {
+	if len(opts.args) < 2 {
+		return fmt.Errorf("meta takes get, set or rm and a path")
+	}
+	action, p, rest := opts.args[0], strings.Trim(opts.args[1], "/"), opts.args[2:]
+	switch action {
+	case "get", "set", "rm":
+	default:
+		return fmt.Errorf("meta: unknown action %q (want get, set or rm)", action)
+	}
+	mnt, inner, err := metaTarget(ctx, client, p, opts)
+	if err != nil {
+		return err
+	}
+	md, err := search.ReadMetadata(ctx, client.Logical(), mnt, inner)
+	if err != nil {
+		return err
+	}
+	cm := md.CustomMetadata
+	if cm == nil {
+		cm = map[string]string{}
+	}
+
+	switch action {
+	case "get":
+		if len(rest) > 0 {
+			return fmt.Errorf("meta get takes only a path, got %q", rest)
+		}
+		return printCustomMetadata(cm, opts)
+	case "set":
+		pairs, err := parseKVArgs(rest)
+		if err != nil {
+			return err
+		}
+		for k, v := range pairs {
+			cm[k] = fmt.Sprint(v)
+		}
+	case "rm":
+		if len(rest) == 0 {
+			return fmt.Errorf("meta rm needs the keys to remove")
+		}
+		for _, k := range rest {
+			if _, ok := cm[k]; !ok {
+				return fmt.Errorf("%s has no custom_metadata key %q", p, k)
+			}
+			delete(cm, k)
+		}
+	}
+	if err := search.WriteCustomMetadata(ctx, client.Logical(), mnt, inner, cm); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "fvf: %s now has %d custom_metadata key(s)\n", p, len(cm))
+	return nil
+}
+
+// printCustomMetadata prints key: value lines sorted by key, or a JSON object.
+func printCustomMetadata(cm map[string]string, opts options) error {
+	if opts.jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(cm)
+	}
+	keys := make([]string, 0, len(cm))
+	for k := range cm {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Printf("%s: %s\n", k, cm[k])
+	}
+	return nil
+}
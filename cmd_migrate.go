@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// migrateState is the -state file of migrate-kv1: the source paths already
+// copied, one per line, appended as each write succeeds.
+type migrateState struct {
+	mu   sync.Mutex
+	done map[string]bool
+	f    *os.File
+}
+
+// openMigrateState reads the paths recorded in file and opens it for
+// appending; an empty name keeps no state.
+func openMigrateState(file string) (*migrateState, error) {
+	s := &migrateState{done: map[string]bool{}}
+	if file == "" {
+		return s, nil
+	}
+	f, err := os.OpenFile(file, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if p := strings.TrimSpace(sc.Text()); p != "" {
+			s.done[p] = true
+		}
+	}
+	if err := sc.Err(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	s.f = f
+	return s, nil
+}
+
+// record marks p as migrated.
+func (s *migrateState) record(p string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done[p] = true
+	if s.f == nil {
+		return nil
+	}
+	_, err := fmt.Fprintln(s.f, p)
+	return err
+}
+
+func (s *migrateState) close() error {
+	if s.f == nil {
+		return nil
+	}
+	return s.f.Close()
+}
+
+// migrateRoots checks that src is on a KV v1 mount and dst on a writable KV
+// v2 mount and returns both as prefixes ending in "/" (empty for a mount).
+func migrateRoots(ctx context.Context, client *vault.Client, opts options) (src, dst string, err error) {
+	if len(opts.args) != 2 {
+		return "", "", fmt.Errorf("migrate-kv1 takes a KV v1 source and a KV v2 destination, got %d argument(s)", len(opts.args))
+	}
+	src, dst = strings.Trim(opts.args[0], "/")+"/", strings.Trim(opts.args[1], "/")+"/"
+	srcMnt, _ := search.SplitMount(strings.Trim(src, "/"))
+	if err := sessionMounts.CheckKV(ctx, client, srcMnt); err != nil {
+		return "", "", err
+	}
+	if _, kv2 := logicalFor(ctx, client, srcMnt, opts); kv2 {
+		return "", "", fmt.Errorf("%s is already KV v2", srcMnt)
+	}
+	dstMnt, _ := search.SplitMount(strings.Trim(dst, "/"))
+	kv2, err := writableMount(ctx, client, dstMnt, opts)
+	if err != nil {
+		return "", "", err
+	}
+	if !kv2 {
+		return "", "", fmt.Errorf("%s is KV v1; migrate-kv1 writes to a KV v2 mount", dstMnt)
+	}
+	return src, dst, nil
+}
+
+// runMigrateKV1 copies every secret below a KV v1 mount (or prefix) to the
+// same paths below a KV v2 one, at most -rate writes per second. Paths copied
+// are recorded in -state so a rerun after a failure or Ctrl-C resumes where
+// it stopped. A verification pass then reads both sides again and compares
+// value digests; any difference fails the run.
+func runMigrateKV1(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) error {
+	src, dst, err := migrateRoots(ctx, client, opts)
+	if err != nil {
+		return err
+	}
+	if opts.rate < 0 {
+		return fmt.Errorf("-rate must not be negative")
+	}
+	paths, skipped, err := walkPaths(ctx, client, opts, matcher, []string{src})
+	if err != nil {
+		return err
+	}
+	if n := reportWalkFailures(skipped); n > 0 {
+		return fmt.Errorf("migrate-kv1: %d mount(s) of the source could not be fully listed, nothing copied", n)
+	}
+	for i := range paths {
+		paths[i] = strings.Trim(paths[i], "/")
+	}
+	sort.Strings(paths)
+	state, err := openMigrateState(opts.stateFile)
+	if err != nil {
+		return err
+	}
+	defer state.close()
+
+	var todo []string
+	for _, p := range paths {
+		if !state.done[p] {
+			todo = append(todo, p)
+		}
+	}
+	if n := len(paths) - len(todo); n > 0 {
+		fmt.Fprintf(os.Stderr, "fvf: resuming, %d of %d secret(s) already migrated\n", n, len(paths))
+	}
+	if opts.dryRun {
+		for _, p := range todo {
+			fmt.Printf("would copy %s -> %s\n", p, dst+strings.TrimPrefix(p, src))
+		}
+		return nil
+	}
+
+	var tick <-chan time.Time
+	if opts.rate > 0 {
+		t := time.NewTicker(time.Duration(float64(time.Second) / opts.rate))
+		defer t.Stop()
+		tick = t.C
+	}
+	var (
+		mu     sync.Mutex
+		failed int
+	)
+	err = forEachLimit(opts.concurrency, len(todo), func(i int) error {
+		if tick != nil {
+			select {
+			case <-tick:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		p, target := todo[i], dst+strings.TrimPrefix(todo[i], src)
+		err := migrateOne(ctx, client, opts, p, target)
+		if err == nil {
+			err = state.record(p)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			mu.Lock()
+			failed++
+			mu.Unlock()
+			fmt.Fprintf(os.Stderr, "fvf: cannot migrate %s: %v\n", p, err)
+			return nil
+		}
+		fmt.Printf("copied %s -> %s\n", p, target)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, context.Canceled) && opts.stateFile != "" {
+			fmt.Fprintf(os.Stderr, "fvf: interrupted; rerun with -state %s to resume\n", opts.stateFile)
+		}
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "fvf: migrated %d of %d secret(s)\n", len(todo)-failed, len(todo))
+	if failed > 0 {
+		return fmt.Errorf("migrate-kv1: %d secret(s) failed; rerun with the same -state to retry them", failed)
+	}
+	return verifyMigration(ctx, client, opts, src, dst, paths)
+}
+
+// migrateOne copies the KV v1 secret at p to the KV v2 path target, with
+// check-and-set against the target's version read just before.
+func migrateOne(ctx context.Context, client *vault.Client, opts options, p, target string) error {
+	mnt, inner := search.SplitMount(p)
+	val, err := search.ReadSecret(ctx, client.Logical(), mnt, inner, false)
+	if err != nil {
+		return err
+	}
+	data, ok := val.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected secret shape at %s", p)
+	}
+	dstMnt, dstInner := search.SplitMount(target)
+	base, version, err := search.ReadSecretCurrent(ctx, client.Logical(), dstMnt, dstInner)
+	if err != nil {
+		return err
+	}
+	_, err = writeCAS(ctx, client, opts, os.Stderr, "migrate-kv1", target, base, version, func(map[string]interface{}) map[string]interface{} { return data }, false)
+	return err
+}
+
+// verifyMigration reads every source secret and its copy and compares their
+// value digests.
+func verifyMigration(ctx context.Context, client *vault.Client, opts options, src, dst string, paths []string) error {
+	targets := make([]string, len(paths))
+	for i, p := range paths {
+		targets[i] = dst + strings.TrimPrefix(p, src)
+	}
+	srcVals, _, srcErrs, err := readValues(ctx, client, opts, paths)
+	if err != nil {
+		return err
+	}
+	dstVals, _, dstErrs, err := readValues(ctx, client, opts, targets)
+	if err != nil {
+		return err
+	}
+	bad := 0
+	for i, p := range paths {
+		if e := srcErrs[p]; e != nil {
+			fmt.Fprintf(os.Stderr, "fvf: verify: cannot read %s: %v\n", p, e)
+			bad++
+			continue
+		}
+		if e := dstErrs[targets[i]]; e != nil {
+			fmt.Fprintf(os.Stderr, "fvf: verify: cannot read %s: %v\n", targets[i], e)
+			bad++
+			continue
+		}
+		a, err := valueDigest(nil, srcVals[p])
+		if err != nil {
+			return err
+		}
+		b, err := valueDigest(nil, dstVals[targets[i]])
+		if err != nil {
+			return err
+		}
+		if a != b {
+			// The source may have changed since it was copied
+			fmt.Printf("mismatch %s -> %s\n", p, targets[i])
+			bad++
+		}
+	}
+	if bad > 0 {
+		return fmt.Errorf("migrate-kv1: verification failed for %d of %d secret(s)", bad, len(paths))
+	}
+	fmt.Fprintf(os.Stderr, "fvf: verified %d secret(s): digests match\n", len(paths))
+	return nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"fvf/search"
+	"fvf/ui"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// orphanSecret is one walked secret as judged by fvf orphans.
+type orphanSecret struct {
+	Path     string
+	NoPolicy bool // no non-root policy grants reading or writing it
+	Stale    bool // KV v2 metadata shows no update within -stale-after
+}
+
+// orphanPrefix counts the orphaned secrets directly below one prefix.
+type orphanPrefix struct {
+	Prefix   string `json:"prefix"`
+	Secrets  int    `json:"secrets"`
+	NoPolicy int    `json:"no_policy"`
+	Stale    int    `json:"stale"`
+	// Archive is set when every secret below the prefix is orphaned.
+	Archive bool `json:"archive_candidate"`
+}
+
+// secretAccessCaps are the capabilities that make a secret reachable.
+var secretAccessCaps = map[string]bool{"read": true, "create": true, "update": true, "patch": true, "sudo": true}
+
+// policiesGrant reports whether one of the policies' effective rules for
+// apiPath grants reading or writing it.
+func policiesGrant(policies [][]search.PolicyRule, apiPath string) bool {
+	for _, rules := range policies {
+		r, ok := search.EffectiveRule(rules, apiPath)
+		if !ok || !r.Grants() {
+			continue
+		}
+		for _, c := range r.Capabilities {
+			if secretAccessCaps[c] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// orphanPrefixes groups secrets by their directory and returns the prefixes
+// holding at least one orphaned secret, sorted by prefix.
+func orphanPrefixes(secrets []orphanSecret) []orphanPrefix {
+	byPrefix := make(map[string]*orphanPrefix)
+	orphaned := make(map[string]int)
+	for _, s := range secrets {
+		prefix := path.Dir(s.Path) + "/"
+		op := byPrefix[prefix]
+		if op == nil {
+			op = &orphanPrefix{Prefix: prefix}
+			byPrefix[prefix] = op
+		}
+		op.Secrets++
+		if s.NoPolicy {
+			op.NoPolicy++
+		}
+		if s.Stale {
+			op.Stale++
+		}
+		if s.NoPolicy || s.Stale {
+			orphaned[prefix]++
+		}
+	}
+	var out []orphanPrefix
+	for prefix, op := range byPrefix {
+		if orphaned[prefix] == 0 {
+			continue
+		}
+		op.Archive = orphaned[prefix] == op.Secrets
+		out = append(out, *op)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Prefix < out[j].Prefix })
+	return out
+}
+
+// readACLPolicies parses every ACL policy except root.
+func readACLPolicies(ctx context.Context, client *vault.Client) ([][]search.PolicyRule, error) {
+	sec, err := client.Logical().ListWithContext(ctx, "sys/policies/acl")
+	if err != nil {
+		return nil, fmt.Errorf("listing policies: %w", err)
+	}
+	var names []string
+	if sec != nil && sec.Data != nil {
+		keys, _ := sec.Data["keys"].([]interface{})
+		for _, k := range keys {
+			if name, ok := k.(string); ok && name != "root" {
+				names = append(names, name)
+			}
+		}
+	}
+	policies := make([][]search.PolicyRule, len(names))
+	err = forEachLimit(4, len(names), func(i int) error {
+		hcl, err := client.Sys().GetPolicyWithContext(ctx, names[i])
+		if err != nil {
+			return fmt.Errorf("reading policy %s: %w", names[i], err)
+		}
+		policies[i] = search.ParsePolicyRules(hcl)
+		return nil
+	})
+	return policies, err
+}
+
+// runOrphans walks the secrets below -path/-paths (all KV mounts by default)
+// and reports the prefixes holding secrets that no non-root policy grants
+// access to, or whose KV v2 metadata shows no update within -stale-after:
+// candidates for archival.
+func runOrphans(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) error {
+	if len(opts.args) > 0 {
+		return fmt.Errorf("orphans takes no arguments (use -path or -paths), got %q", opts.args)
+	}
+	policies, err := readACLPolicies(ctx, client)
+	if err != nil {
+		return err
+	}
+	opts.printValues, opts.tableOut, opts.sortBy = false, "", "path"
+	failures := ui.NewWalkErrorLog()
+	items, err := collectItems(ctx, client, opts, matcher, failures.Reporter())
+	if err != nil {
+		return err
+	}
+
+	kv2 := make(map[string]bool)
+	for _, it := range items {
+		mnt, _ := search.SplitMount(it.Path)
+		if _, ok := kv2[mnt]; !ok {
+			kv2[mnt] = decideKV2ForPath(ctx, client, mnt, opts)
+		}
+	}
+	cutoff := time.Now().Add(-opts.staleAfter)
+	secrets := make([]orphanSecret, len(items))
+	err = forEachLimit(opts.concurrency, len(items), func(i int) error {
+		p := items[i].Path
+		mnt, inner := search.SplitMount(p)
+		s := orphanSecret{Path: p, NoPolicy: !policiesGrant(policies, search.ReadAPIPath(mnt, inner, kv2[mnt]))}
+		if opts.staleAfter > 0 && kv2[mnt] {
+			// Secrets whose metadata cannot be read are not judged stale
+			if md, err := search.ReadMetadata(ctx, client.Logical(), mnt, inner); err == nil && !md.UpdatedTime.IsZero() {
+				s.Stale = md.UpdatedTime.Before(cutoff)
+			} else if ctx.Err() != nil {
+				return ctx.Err()
+			}
+		}
+		secrets[i] = s
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	prefixes := orphanPrefixes(secrets)
+	if opts.jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(prefixes); err != nil {
+			return err
+		}
+	} else if err := printOrphans(os.Stdout, prefixes); err != nil {
+		return err
+	}
+	return strictResult(opts, reportWalkFailures(failures.Errors()))
+}
+
+// printOrphans writes the prefixes as a table.
+func printOrphans(w io.Writer, prefixes []orphanPrefix) error {
+	if len(prefixes) == 0 {
+		_, err := fmt.Fprintln(w, "no orphaned secrets found")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PREFIX\tSECRETS\tNO POLICY\tSTALE\tARCHIVE")
+	for _, p := range prefixes {
+		archive := "-"
+		if p.Archive {
+			archive = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\n", p.Prefix, p.Secrets, p.NoPolicy, p.Stale, archive)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"fvf/search"
+	"fvf/ui"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// policyGrant is a secret the policy lets the token read: the rule Vault
+// applies to its read path and that rule's capabilities.
+type policyGrant struct {
+	Path         string   `json:"path"`
+	Rule         string   `json:"rule"`
+	Capabilities []string `json:"capabilities"`
+}
+
+// policyUnusedRule is a rule under a walked mount that covers no secret.
+type policyUnusedRule struct {
+	Path string `json:"path"`
+	Line int    `json:"line"` // one-based
+}
+
+// policyCoverage is the result of fvf policy-coverage.
+type policyCoverage struct {
+	Policy  string             `json:"policy"`
+	Secrets int                `json:"secrets"`
+	Granted []policyGrant      `json:"granted"`
+	Denied  []string           `json:"denied,omitempty"`
+	Unused  []policyUnusedRule `json:"unmatched_rules"`
+}
+
+// kvAPIPaths are the API paths a policy may name for the secret at
+// mnt/inner: the data, metadata and version operation paths on KV v2, the
+// plain path on KV v1, plus the directories above it for list rules.
+func kvAPIPaths(mnt, inner string, kv2 bool) []string {
+	var out []string
+	if kv2 {
+		for _, op := range []string{"data", "metadata", "delete", "undelete", "destroy"} {
+			out = append(out, path.Join(mnt, op, inner))
+		}
+	} else {
+		out = append(out, path.Join(mnt, inner))
+	}
+	for dir := path.Dir(inner); dir != "."; dir = path.Dir(dir) {
+		if kv2 {
+			out = append(out, path.Join(mnt, "metadata", dir)+"/")
+		} else {
+			out = append(out, path.Join(mnt, dir)+"/")
+		}
+	}
+	if kv2 {
+		return append(out, mnt+"/metadata/")
+	}
+	return append(out, mnt+"/")
+}
+
+// coverPolicy intersects rules with the secrets at paths: the effective rule
+// of each secret's read path decides whether it is granted or denied, and
+// rules on one of mounts that match none of the secrets' API paths are
+// reported unused. Rules elsewhere (sys/, auth/, other mounts) are left out.
+func coverPolicy(name string, rules []search.PolicyRule, paths []string, mounts map[string]bool, kv2 func(mnt string) bool) policyCoverage {
+	cov := policyCoverage{Policy: name, Secrets: len(paths)}
+	used := make([]bool, len(rules))
+	for _, p := range paths {
+		mnt, inner := search.SplitMount(p)
+		v2 := kv2(mnt)
+		if r, ok := search.EffectiveRule(rules, search.ReadAPIPath(mnt, inner, v2)); ok {
+			if r.Grants() {
+				cov.Granted = append(cov.Granted, policyGrant{Path: p, Rule: r.Path, Capabilities: r.Capabilities})
+			} else {
+				cov.Denied = append(cov.Denied, p)
+			}
+		}
+		for _, api := range kvAPIPaths(mnt, inner, v2) {
+			for i, r := range rules {
+				if !used[i] && search.PolicyGlobMatch(r.Path, api) {
+					used[i] = true
+				}
+			}
+		}
+	}
+	for i, r := range rules {
+		first, _, _ := strings.Cut(r.Path, "/")
+		if used[i] || !(mounts[first] || first == "+" || strings.HasSuffix(first, "*")) {
+			continue
+		}
+		cov.Unused = append(cov.Unused, policyUnusedRule{Path: r.Path, Line: r.Start + 1})
+	}
+	return cov
+}
+
+// runPolicyCoverage reads the -policy ACL policy, walks the secrets below
+// -path/-paths (all KV mounts by default) and reports which of them the
+// policy grants access to and which of its rules match no secret.
+func runPolicyCoverage(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) error {
+	if opts.policy == "" {
+		return fmt.Errorf("policy-coverage needs -policy <name>")
+	}
+	if len(opts.args) > 0 {
+		return fmt.Errorf("policy-coverage takes no arguments (use -path or -paths), got %q", opts.args)
+	}
+	hcl, err := client.Sys().GetPolicyWithContext(ctx, opts.policy)
+	if err != nil {
+		return fmt.Errorf("reading policy %s: %w", opts.policy, err)
+	}
+	if strings.TrimSpace(hcl) == "" {
+		return fmt.Errorf("policy %s not found or empty", opts.policy)
+	}
+	rules := search.ParsePolicyRules(hcl)
+
+	opts.printValues, opts.tableOut, opts.sortBy = false, "", "path"
+	failures := ui.NewWalkErrorLog()
+	items, err := collectItems(ctx, client, opts, matcher, failures.Reporter())
+	if err != nil {
+		return err
+	}
+	paths := make([]string, 0, len(items))
+	mounts := make(map[string]bool)
+	for _, it := range items {
+		paths = append(paths, it.Path)
+		mnt, _ := search.SplitMount(it.Path)
+		mounts[mnt] = true
+	}
+	sort.Strings(paths)
+	kv2 := make(map[string]bool)
+	for mnt := range mounts {
+		kv2[mnt] = decideKV2ForPath(ctx, client, mnt, opts)
+	}
+	cov := coverPolicy(opts.policy, rules, paths, mounts, func(mnt string) bool { return kv2[mnt] })
+
+	if opts.jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(cov); err != nil {
+			return err
+		}
+	} else {
+		fmt.Print(formatPolicyCoverage(cov))
+	}
+	return strictResult(opts, reportWalkFailures(failures.Errors()))
+}
+
+// formatPolicyCoverage renders the coverage as text: the granted secrets
+// with their rule, the denied ones and the unused rules.
+func formatPolicyCoverage(cov policyCoverage) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "policy %s grants access to %d of %d secret(s)\n", cov.Policy, len(cov.Granted), cov.Secrets)
+	for _, g := range cov.Granted {
+		fmt.Fprintf(&b, "  %s  [%s]  via %q\n", g.Path, strings.Join(g.Capabilities, ", "), g.Rule)
+	}
+	if len(cov.Denied) > 0 {
+		fmt.Fprintf(&b, "denied explicitly (%d):\n", len(cov.Denied))
+		for _, p := range cov.Denied {
+			fmt.Fprintf(&b, "  %s\n", p)
+		}
+	}
+	fmt.Fprintf(&b, "rules matching no secret (%d):\n", len(cov.Unused))
+	for _, r := range cov.Unused {
+		fmt.Fprintf(&b, "  %q  (line %d)\n", r.Path, r.Line)
+	}
+	return b.String()
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// putStdin is where key=- values are read from; tests replace it.
+var putStdin io.Reader = os.Stdin
+
+// parseKVArgs turns key=value arguments into secret data. As with vault kv
+// put, key=@file reads the value from a file and key=- from stdin, which
+// keeps the value out of the shell history; key=gen:<spec> generates it (see
+// generateValue), a key pair adding key_pub.
+func parseKVArgs(args []string) (map[string]interface{}, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("no key=value pairs given")
+	}
+	data := make(map[string]interface{}, len(args))
+	usedStdin := false
+	for _, a := range args {
+		k, v, ok := strings.Cut(a, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("%q: want key=value, key=@file or key=-", a)
+		}
+		if _, dup := data[k]; dup {
+			return nil, fmt.Errorf("key %q given more than once", k)
+		}
+		switch {
+		case v == "-":
+			if usedStdin {
+				return nil, fmt.Errorf("%s=-: only one value can be read from stdin", k)
+			}
+			usedStdin = true
+			b, err := io.ReadAll(putStdin)
+			if err != nil {
+				return nil, fmt.Errorf("%s=-: %w", k, err)
+			}
+			v = strings.TrimSuffix(string(b), "\n")
+		case strings.HasPrefix(v, "@"):
+			b, err := os.ReadFile(v[1:])
+			if err != nil {
+				return nil, fmt.Errorf("%s=@: %w", k, err)
+			}
+			v = string(b)
+		case strings.HasPrefix(v, genPrefix):
+			val, pub, err := generateValue(v[len(genPrefix):])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			v = val
+			if pub != "" {
+				// A key pair also stores the public key next to the private one
+				if _, dup := data[k+"_pub"]; dup {
+					return nil, fmt.Errorf("key %q given more than once", k+"_pub")
+				}
+				data[k+"_pub"] = pub
+			}
+		}
+		data[k] = v
+	}
+	return data, nil
+}
+
+// writableMount checks that fvf can write secrets to mnt (a KV mount, not
+// one served by an engine plugin) and returns whether it is KV v2.
+func writableMount(ctx context.Context, client *vault.Client, mnt string, opts options) (bool, error) {
+	logical, kv2 := logicalFor(ctx, client, mnt, opts)
+	if _, ok := logical.(*search.Plugin); ok {
+		return false, fmt.Errorf("%s is served by an engine plugin, which is read-only", mnt)
+	}
+	if err := sessionMounts.CheckKV(ctx, client, mnt); err != nil {
+		return false, err
+	}
+	return kv2, nil
+}
+
+// putTarget resolves the path argument to a writable KV mount.
+func putTarget(ctx context.Context, client *vault.Client, p string, opts options) (mnt, inner string, kv2 bool, err error) {
+	mnt, inner = search.SplitMount(strings.Trim(p, "/"))
+	if inner == "" {
+		return "", "", false, fmt.Errorf("%q is a mount, not a secret path", p)
+	}
+	if kv2, err = writableMount(ctx, client, mnt, opts); err != nil {
+		return "", "", false, err
+	}
+	if opts.cas >= 0 && !kv2 {
+		return "", "", false, fmt.Errorf("-cas needs a KV v2 mount; %s is KV v1", mnt)
+	}
+	return mnt, inner, kv2, nil
+}
+
+// runPut writes the key=value arguments as the secret at the path argument,
+// replacing all its keys. -cas N only writes while the current version is N;
+// without it the version read just before is used, so a concurrent write is
+// shown and can be retried (see writeCAS). Like patch, it refuses data that
+// fails the -schemas schema for the path.
+func runPut(ctx context.Context, client *vault.Client, opts options, _ *regexp.Regexp) error {
+	if len(opts.args) < 2 {
+		return fmt.Errorf("put takes a path and at least one key=value")
+	}
+	p := strings.Trim(opts.args[0], "/")
+	data, err := parseKVArgs(opts.args[1:])
+	if err != nil {
+		return err
+	}
+	if err := validateSecret(opts, p, data); err != nil {
+		return err
+	}
+	mnt, inner, kv2, err := putTarget(ctx, client, p, opts)
+	if err != nil {
+		return err
+	}
+	if !kv2 {
+		if err := search.WriteSecret(ctx, client.Logical(), mnt, inner, false, data); err != nil {
+			return err
+		}
+		return reportWrite(p, 0, opts)
+	}
+	if opts.cas >= 0 {
+		version, err := search.WriteSecretCAS(ctx, client.Logical(), mnt, inner, data, opts.cas)
+		if err != nil {
+			return err
+		}
+		return reportWrite(p, version, opts)
+	}
+	base, current, err := search.ReadSecretCurrent(ctx, client.Logical(), mnt, inner)
+	if err != nil {
+		return err
+	}
+	version, err := writeCAS(ctx, client, opts, os.Stderr, "put", p, base, current, func(map[string]interface{}) map[string]interface{} { return data }, true)
+	if err != nil {
+		return err
+	}
+	return reportWrite(p, version, opts)
+}
+
+// runPatch sets the key=value arguments on the secret at the path argument
+// and keeps its other keys. On KV v2 the read version is written back with
+// check-and-set, so a concurrent change is not lost: it is shown and the patch
+// can be reapplied on top of it.
+func runPatch(ctx context.Context, client *vault.Client, opts options, _ *regexp.Regexp) error {
+	if len(opts.args) < 2 {
+		return fmt.Errorf("patch takes a path and at least one key=value")
+	}
+	p := strings.Trim(opts.args[0], "/")
+	changes, err := parseKVArgs(opts.args[1:])
+	if err != nil {
+		return err
+	}
+	mnt, inner, kv2, err := putTarget(ctx, client, p, opts)
+	if err != nil {
+		return err
+	}
+	if !kv2 {
+		val, err := search.ReadSecret(ctx, client.Logical(), mnt, inner, false)
+		if err != nil {
+			return err
+		}
+		data, ok := val.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("unexpected secret shape at %s", p)
+		}
+		for k, v := range changes {
+			data[k] = v
+		}
+		if err := validateSecret(opts, p, data); err != nil {
+			return err
+		}
+		if err := search.WriteSecret(ctx, client.Logical(), mnt, inner, false, data); err != nil {
+			return err
+		}
+		return reportWrite(p, 0, opts)
+	}
+	data, current, err := search.ReadSecretVersion(ctx, client.Logical(), mnt, inner)
+	if err != nil {
+		return err
+	}
+	if opts.cas >= 0 && opts.cas != current {
+		return fmt.Errorf("%s is at version %d, not %d (-cas)", p, current, opts.cas)
+	}
+	merge := func(current map[string]interface{}) map[string]interface{} {
+		merged := make(map[string]interface{}, len(current)+len(changes))
+		for k, v := range current {
+			merged[k] = v
+		}
+		for k, v := range changes {
+			merged[k] = v
+		}
+		return merged
+	}
+	if err := validateSecret(opts, p, merge(data)); err != nil {
+		return err
+	}
+	// An explicit -cas pins the version, so a conflict is not retried
+	version, err := writeCAS(ctx, client, opts, os.Stderr, "patch", p, data, current, merge, opts.cas < 0)
+	if err != nil {
+		return err
+	}
+	return reportWrite(p, version, opts)
+}
+
+// reportWrite prints what was written: the version on KV v2 mounts, nothing
+// but the path on KV v1.
+func reportWrite(p string, version int, opts options) error {
+	if opts.jsonOut {
+		out := map[string]interface{}{"path": p}
+		if version > 0 {
+			out["version"] = version
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+	if version > 0 {
+		fmt.Fprintf(os.Stderr, "fvf: wrote %s (version %d)\n", p, version)
+	} else {
+		fmt.Fprintf(os.Stderr, "fvf: wrote %s\n", p)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// renamePlan maps every path matching from to its rewrite by to ($1, ${name}
+// expand the submatches). Paths that do not match, or rewrite to themselves,
+// are left out; two sources landing on one destination, or a destination that
+// is itself renamed (a chain such as a -> b, b -> c), are an error.
+func renamePlan(paths []string, from *regexp.Regexp, to string) ([]copyStep, error) {
+	var steps []copyStep
+	seen := make(map[string]string)
+	for _, p := range paths {
+		p = strings.Trim(p, "/")
+		if !from.MatchString(p) {
+			continue
+		}
+		dst := strings.Trim(from.ReplaceAllString(p, to), "/")
+		if dst == p {
+			continue
+		}
+		if dst == "" {
+			return nil, fmt.Errorf("%s rewrites to an empty path", p)
+		}
+		if other, ok := seen[dst]; ok {
+			return nil, fmt.Errorf("%s and %s both rewrite to %s", other, p, dst)
+		}
+		seen[dst] = p
+		steps = append(steps, copyStep{Src: p, Dst: dst})
+	}
+	// Steps run in parallel and delete their source, so a destination that is
+	// also a source would be overwritten or deleted depending on the order.
+	for _, s := range steps {
+		if _, ok := seen[s.Src]; ok {
+			return nil, fmt.Errorf("%s is both renamed and a rename destination (of %s)", s.Src, seen[s.Src])
+		}
+	}
+	return steps, nil
+}
+
+// runRename moves every secret of the -path walk whose path matches -from to
+// the -to rewrite. Every rename is listed before anything is written and must
+// be confirmed unless -yes or -dry-run is given.
+func runRename(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) error {
+	if len(opts.args) > 0 {
+		return fmt.Errorf("rename takes no arguments (use -path, -from and -to), got %q", opts.args)
+	}
+	if opts.renameFrom == "" || opts.renameTo == "" {
+		return fmt.Errorf("rename needs -from and -to")
+	}
+	from, err := regexp.Compile(opts.renameFrom)
+	if err != nil {
+		return fmt.Errorf("-from: %w", err)
+	}
+	paths, err := selectSecrets(ctx, client, opts, matcher, "rename")
+	if err != nil {
+		return err
+	}
+	steps, err := renamePlan(paths, from, opts.renameTo)
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		return fmt.Errorf("no secret path matches -from %q", opts.renameFrom)
+	}
+	var confirm func() error
+	if !opts.yes {
+		confirm = confirmSteps("rename", "rename", steps)
+	}
+	return runCopySteps(ctx, client, opts, "rename", steps, true, confirm)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// confirmInput is where typed confirmations are read from; tests replace it.
+var confirmInput io.Reader = os.Stdin
+
+// rmFailure is a secret rm could not delete.
+type rmFailure struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// confirmTyped shows prompt on stderr and fails unless the answer is want;
+// no input (e.g. stdin closed) aborts as well. cmd names the command.
+func confirmTyped(cmd, prompt, want string) error {
+	fmt.Fprint(os.Stderr, prompt)
+	line, err := bufio.NewReader(confirmInput).ReadString('\n')
+	if err != nil && line == "" {
+		return fmt.Errorf("%s: not confirmed (use -yes in scripts)", cmd)
+	}
+	if strings.TrimSpace(line) != want {
+		return fmt.Errorf("%s: confirmation did not match %q, nothing changed", cmd, want)
+	}
+	return nil
+}
+
+// confirmDelete asks for the target to be typed back before n secrets are
+// deleted.
+func confirmDelete(target string, n int) error {
+	return confirmTyped("rm", fmt.Sprintf("fvf: this deletes %d secret(s) with all their versions. Type %q to confirm: ", n, target), target)
+}
+
+// runRm deletes the secret at the path argument, or with -r every secret
+// below it: the metadata on KV v2 (all versions), the secret on KV v1.
+// -dry-run only lists them; otherwise the path must be typed back unless
+// -yes is given.
+func runRm(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) error {
+	if len(opts.args) != 1 {
+		return fmt.Errorf("rm takes exactly one path, got %d", len(opts.args))
+	}
+	target := opts.args[0]
+	mnt, inner := search.SplitMount(strings.Trim(target, "/"))
+	if inner == "" && !opts.recursive {
+		return fmt.Errorf("%q is a mount, not a secret path", target)
+	}
+	kv2, err := writableMount(ctx, client, mnt, opts)
+	if err != nil {
+		return err
+	}
+
+	paths := []string{strings.Trim(target, "/")}
+	if opts.recursive {
+		walked, skipped, err := walkPaths(ctx, client, opts, matcher, []string{strings.Trim(target, "/") + "/"})
+		if err != nil {
+			return err
+		}
+		if err := strictResult(opts, reportWalkFailures(skipped)); err != nil {
+			return err
+		}
+		paths = walked
+		for i := range paths {
+			paths[i] = strings.Trim(paths[i], "/")
+		}
+		sort.Strings(paths)
+	} else if strings.HasSuffix(target, "/") {
+		return fmt.Errorf("%s is a folder; use -r to delete everything below it", target)
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no secrets below %s", target)
+	}
+
+	if opts.dryRun {
+		if opts.jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(paths)
+		}
+		for _, p := range paths {
+			fmt.Printf("would delete %s\n", p)
+		}
+		fmt.Fprintf(os.Stderr, "fvf: would delete %d secret(s)\n", len(paths))
+		return nil
+	}
+	if !opts.yes {
+		if err := confirmDelete(target, len(paths)); err != nil {
+			return err
+		}
+	}
+
+	var (
+		mu       sync.Mutex
+		deleted  []string
+		failures []rmFailure
+	)
+	err = forEachLimit(opts.concurrency, len(paths), func(i int) error {
+		m, in := search.SplitMount(paths[i])
+		err := search.DeleteSecret(ctx, client.Logical(), m, in, kv2)
+		if err != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			failures = append(failures, rmFailure{Path: paths[i], Error: err.Error()})
+		} else {
+			deleted = append(deleted, paths[i])
+			if !opts.jsonOut {
+				// One line per secret as it goes doubles as the progress report
+				fmt.Printf("deleted %s\n", paths[i])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(deleted)
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Path < failures[j].Path })
+
+	if opts.jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if deleted == nil {
+			deleted = []string{}
+		}
+		if failures == nil {
+			failures = []rmFailure{}
+		}
+		if err := enc.Encode(map[string]interface{}{"deleted": deleted, "failed": failures}); err != nil {
+			return err
+		}
+	}
+	for _, f := range failures {
+		fmt.Fprintf(os.Stderr, "fvf: cannot delete %s: %s\n", f.Path, f.Error)
+	}
+	fmt.Fprintf(os.Stderr, "fvf: deleted %d of %d secret(s)\n", len(deleted), len(paths))
+	if len(failures) > 0 {
+		return fmt.Errorf("rm: %d secret(s) could not be deleted", len(failures))
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// rollbackSecret makes version the current version of the KV v2 secret at p
+// and returns the version written.
+func rollbackSecret(ctx context.Context, client *vault.Client, p string, version int, opts options) (int, error) {
+	mnt, inner := search.SplitMount(strings.Trim(p, "/"))
+	kv2, err := writableMount(ctx, client, mnt, opts)
+	if err != nil {
+		return 0, err
+	}
+	if !kv2 {
+		return 0, fmt.Errorf("%s is KV v1, which keeps no versions", mnt)
+	}
+	return search.Rollback(ctx, client.Logical(), mnt, inner, version)
+}
+
+// runRollback rewrites -to-version of each secret named by the arguments (or
+// found by the -path walk) as its new current version.
+func runRollback(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) error {
+	if opts.toVersion < 1 {
+		return fmt.Errorf("rollback needs -to-version")
+	}
+	paths, err := selectSecrets(ctx, client, opts, matcher, "rollback")
+	if err != nil {
+		return err
+	}
+	type result struct {
+		Path    string `json:"path"`
+		Version int    `json:"version,omitempty"`
+		Error   string `json:"error,omitempty"`
+	}
+	results := make([]result, len(paths))
+	failed := 0
+	for i, p := range paths {
+		p = strings.Trim(p, "/")
+		results[i].Path = p
+		v, err := rollbackSecret(ctx, client, p, opts.toVersion, opts)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			results[i].Error = err.Error()
+			failed++
+			if !opts.jsonOut {
+				fmt.Fprintf(os.Stderr, "fvf: %s: %v\n", p, err)
+			}
+			continue
+		}
+		results[i].Version = v
+		if !opts.jsonOut {
+			fmt.Fprintf(os.Stderr, "fvf: %s: version %d written as version %d\n", p, opts.toVersion, v)
+		}
+	}
+	if opts.jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("rollback: %d of %d secret(s) failed", failed, len(paths))
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
+// mcpProtocolVersion is the Model Context Protocol revision spoken by fvf rpc.
+const mcpProtocolVersion = "2024-11-05"
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+// rpcSearchParams are the arguments of search / fvf_search.
+type rpcSearchParams struct {
+	Path  string `json:"path"`
+	Name  string `json:"name"`
+	Match string `json:"match"`
+}
+
+// rpcGetParams are the arguments of get / fvf_get.
+type rpcGetParams struct {
+	Path string `json:"path"`
+}
+
+// rpcServer answers JSON-RPC requests, one per line, on a stdio pair. It
+// speaks plain methods (search, get) as well as the MCP tools interface, and
+// writes an audit record for every request.
+type rpcServer struct {
+	search      func(ctx context.Context, p rpcSearchParams) ([]string, error)
+	read        func(ctx context.Context, path string) (interface{}, error)
+	allowValues bool
+	audit       *slog.Logger
+	timeout     time.Duration
+}
+
+// serve handles requests from in until EOF or ctx is done.
+func (s *rpcServer) serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	enc := json.NewEncoder(out)
+	for sc.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var req rpcRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			s.audit.Warn("rpc", "error", "parse error")
+			enc.Encode(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: rpcParseError, Message: err.Error()}})
+			continue
+		}
+		result, err := s.handle(ctx, req)
+		s.auditRequest(req, err)
+		if len(req.ID) == 0 {
+			continue // notification: no response
+		}
+		resp := rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result}
+		if err != nil {
+			var re *rpcError
+			if !errors.As(err, &re) {
+				re = &rpcError{Code: rpcInternalError, Message: err.Error()}
+			}
+			resp.Result, resp.Error = nil, re
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// auditRequest records who asked for what; values are never logged.
+func (s *rpcServer) auditRequest(req rpcRequest, err error) {
+	attrs := []any{"method", req.Method, "params", string(req.Params)}
+	if err != nil {
+		attrs = append(attrs, "error", err.Error())
+	}
+	s.audit.Info("rpc", attrs...)
+}
+
+func (s *rpcServer) handle(ctx context.Context, req rpcRequest) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	switch req.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"protocolVersion": mcpProtocolVersion,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]string{"name": "fvf", "version": version},
+		}, nil
+	case "ping", "notifications/initialized":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": s.tools()}, nil
+	case "tools/call":
+		return s.callTool(ctx, req.Params)
+	case "search":
+		var p rpcSearchParams
+		if err := decodeParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		paths, err := s.search(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		return map[string][]string{"paths": paths}, nil
+	case "get":
+		var p rpcGetParams
+		if err := decodeParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		return s.get(ctx, p)
+	}
+	return nil, &rpcError{Code: rpcMethodNotFound, Message: "unknown method " + req.Method}
+}
+
+func (s *rpcServer) get(ctx context.Context, p rpcGetParams) (interface{}, error) {
+	if !s.allowValues {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "reading values is disabled (start fvf rpc with -rpc-allow-values)"}
+	}
+	if strings.Trim(p.Path, "/") == "" {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "missing path"}
+	}
+	val, err := s.read(ctx, strings.Trim(p.Path, "/"))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"path": p.Path, "value": val}, nil
+}
+
+// tools describes the MCP tools; fvf_get is only offered when values are allowed.
+func (s *rpcServer) tools() []map[string]interface{} {
+	str := func(desc string) map[string]string { return map[string]string{"type": "string", "description": desc} }
+	tools := []map[string]interface{}{{
+		"name":        "fvf_search",
+		"description": "List Vault KV secret paths matching a name substring and/or a path regexp. Never returns values.",
+		"inputSchema": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path":  str("Start path, e.g. kv/app/ (default: all KV mounts)"),
+				"name":  str("Case-insensitive substring of the secret name (last segment)"),
+				"match": str("Regular expression on the full path"),
+			},
+		},
+	}}
+	if s.allowValues {
+		tools = append(tools, map[string]interface{}{
+			"name":        "fvf_get",
+			"description": "Read one Vault KV secret.",
+			"inputSchema": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"path": str("Secret path, e.g. kv/app/db")},
+				"required":   []string{"path"},
+			},
+		})
+	}
+	return tools
+}
+
+// callTool runs an MCP tool; tool failures are reported in the result, as MCP expects.
+func (s *rpcServer) callTool(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+	var call struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := decodeParams(raw, &call); err != nil {
+		return nil, err
+	}
+	var (
+		out interface{}
+		err error
+	)
+	switch call.Name {
+	case "fvf_search":
+		var p rpcSearchParams
+		if err = decodeParams(call.Arguments, &p); err != nil {
+			return nil, err
+		}
+		out, err = s.search(ctx, p)
+	case "fvf_get":
+		var p rpcGetParams
+		if err = decodeParams(call.Arguments, &p); err != nil {
+			return nil, err
+		}
+		out, err = s.get(ctx, p)
+	default:
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "unknown tool " + call.Name}
+	}
+	text := ""
+	if err != nil {
+		text = err.Error()
+	} else {
+		b, _ := json.MarshalIndent(out, "", "  ")
+		text = string(b)
+	}
+	return map[string]interface{}{
+		"content": []map[string]string{{"type": "text", "text": text}},
+		"isError": err != nil,
+	}, nil
+}
+
+func decodeParams(raw json.RawMessage, v interface{}) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// newRPCServer wires the JSON-RPC server to Vault. Searches walk Vault on
+// each call (path defaults to -path/-paths, else all KV mounts).
+func newRPCServer(client *vault.Client, opts options, audit *slog.Logger) *rpcServer {
+	roots := opts.paths
+	if len(roots) == 0 && strings.TrimSpace(opts.startPath) != "" {
+		roots = []string{opts.startPath}
+	}
+	return &rpcServer{
+		search: func(ctx context.Context, p rpcSearchParams) ([]string, error) {
+			matcher, err := buildMatcher(p.Match)
+			if err != nil {
+				return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+			}
+			walkOpts := opts
+			walkOpts.namePart = p.Name
+			walkRoots := roots
+			if p.Path != "" {
+				walkRoots = []string{p.Path}
+			}
+			paths, _, err := walkPaths(ctx, client, walkOpts, matcher, walkRoots)
+			return paths, err
+		},
+		read: func(ctx context.Context, p string) (interface{}, error) {
+			mnt, inner := search.SplitMount(p)
+			val, err := search.ReadSecret(ctx, client.Logical(), mnt, inner, decideKV2ForPath(ctx, client, mnt, opts))
+			return redactValue(val), err
+		},
+		allowValues: opts.rpcAllowValues,
+		audit:       audit,
+		timeout:     opts.timeout,
+	}
+}
+
+// runRPC serves JSON-RPC (and MCP) on stdin/stdout until stdin closes. Every
+// request is audited as a JSON line to -audit-log, or stderr when unset.
+func runRPC(_ context.Context, client *vault.Client, opts options, _ *regexp.Regexp) error {
+	if len(opts.args) > 0 {
+		return fmt.Errorf("rpc takes no arguments, got %q", opts.args)
+	}
+	var w io.Writer = os.Stderr
+	if opts.auditLog != "" {
+		f, err := os.OpenFile(opts.auditLog, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return fmt.Errorf("audit log: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	audit := slog.New(slog.NewJSONHandler(w, nil))
+	return newRPCServer(client, opts, audit).serve(context.Background(), os.Stdin, os.Stdout)
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"fvf/search"
+	"fvf/ui"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// daemonIndex is the daemon's view of Vault: every path found by the last
+// successful walk, sorted, plus bookkeeping for /stats.
+type daemonIndex struct {
+	mu        sync.RWMutex
+	paths     []string
+	updated   time.Time
+	took      time.Duration
+	refreshes int
+	skipped   int
+	lastErr   string
+}
+
+// daemonStats is the /stats response.
+type daemonStats struct {
+	Paths       int       `json:"paths"`
+	Updated     time.Time `json:"updated"`
+	WalkSeconds float64   `json:"walk_seconds"`
+	Refreshes   int       `json:"refreshes"`
+	Skipped     int       `json:"skipped"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// daemon serves the path index over a localhost HTTP/JSON API.
+type daemon struct {
+	index daemonIndex
+	// walk lists every path below the configured roots and the subtrees it skipped.
+	walk func(ctx context.Context) ([]string, []ui.WalkError, error)
+	// read returns the secret stored at a logical path.
+	read    func(ctx context.Context, p string) (interface{}, error)
+	token   string
+	timeout time.Duration
+	metrics *daemonMetrics // nil disables /metrics
+}
+
+// refresh walks Vault once. A failed walk keeps the previous index.
+func (d *daemon) refresh(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+	began := time.Now()
+	paths, skipped, err := d.walk(ctx)
+	took := time.Since(began)
+	if d.metrics != nil {
+		d.metrics.observeWalk(took, paths, skipped, err)
+	}
+
+	d.index.mu.Lock()
+	defer d.index.mu.Unlock()
+	d.index.refreshes++
+	if err != nil {
+		d.index.lastErr = err.Error()
+		slog.Warn("index refresh failed", "err", err, "elapsed", took)
+		return
+	}
+	sort.Strings(paths)
+	d.index.paths = paths
+	d.index.updated = time.Now()
+	d.index.took = took
+	d.index.skipped = len(skipped)
+	d.index.lastErr = ""
+	slog.Info("index refreshed", "paths", len(paths), "skipped", len(skipped), "elapsed", took)
+}
+
+// loop refreshes the index now and then every interval until ctx is done.
+func (d *daemon) loop(ctx context.Context, interval time.Duration) {
+	d.refresh(ctx)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			d.refresh(ctx)
+		}
+	}
+}
+
+func (d *daemon) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /search", d.handleSearch)
+	mux.HandleFunc("GET /get", d.handleGet)
+	mux.HandleFunc("GET /stats", d.handleStats)
+	if d.metrics != nil {
+		mux.Handle("GET /metrics", d.metrics)
+	}
+	return d.authorize(mux)
+}
+
+// authorize requires the -serve-token bearer token on every request when one is set.
+func (d *daemon) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if d.token != "" && subtle.ConstantTimeCompare(got, []byte("Bearer "+d.token)) != 1 {
+			writeJSONError(w, http.StatusUnauthorized, "missing or wrong token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleSearch returns the indexed paths matching the optional name (substring
+// of the last segment) and match (regexp on the full path) parameters.
+func (d *daemon) handleSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filters := search.Filters{NamePart: q.Get("name")}
+	if m := q.Get("match"); m != "" {
+		re, err := regexp.Compile(m)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		filters.Matcher = re
+	}
+	d.index.mu.RLock()
+	out := make([]string, 0, len(d.index.paths))
+	for _, p := range d.index.paths {
+		if filters.Match(path.Base(p), p) {
+			out = append(out, p)
+		}
+	}
+	d.index.mu.RUnlock()
+	writeJSON(w, map[string][]string{"paths": out})
+}
+
+// handleGet returns one secret. Values are only served when the daemon is
+// protected by a token.
+func (d *daemon) handleGet(w http.ResponseWriter, r *http.Request) {
+	if d.token == "" {
+		writeJSONError(w, http.StatusForbidden, "get needs the daemon to run with -serve-token")
+		return
+	}
+	secret := strings.Trim(r.URL.Query().Get("path"), "/")
+	if secret == "" {
+		writeJSONError(w, http.StatusBadRequest, "missing path")
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), d.timeout)
+	defer cancel()
+	val, err := d.read(ctx, secret)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, search.ErrPermissionDenied) {
+			status = http.StatusForbidden
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+	slog.Info("served secret", "path", secret, "remote", r.RemoteAddr)
+	writeJSON(w, map[string]interface{}{"path": secret, "value": val})
+}
+
+func (d *daemon) handleStats(w http.ResponseWriter, _ *http.Request) {
+	d.index.mu.RLock()
+	st := daemonStats{
+		Paths:       len(d.index.paths),
+		Updated:     d.index.updated,
+		WalkSeconds: d.index.took.Seconds(),
+		Refreshes:   d.index.refreshes,
+		Skipped:     d.index.skipped,
+		LastError:   d.index.lastErr,
+	}
+	d.index.mu.RUnlock()
+	writeJSON(w, st)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+// checkLoopback refuses listen addresses reachable from other hosts.
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("-listen: %w", err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("-listen must be a loopback address, got %q", addr)
+}
+
+// newDaemon wires a daemon to Vault: walks reuse the interactive stream walker
+// (all KV mounts unless -path/-paths), reads go straight to Vault. Every Vault
+// call is counted for /metrics.
+func newDaemon(client *vault.Client, opts options) *daemon {
+	metrics := newDaemonMetrics()
+	client = metrics.instrument(client)
+	roots := opts.paths
+	if len(roots) == 0 && strings.TrimSpace(opts.startPath) != "" {
+		roots = []string{opts.startPath}
+	}
+	// The index holds every path; -name and -match apply per query instead
+	walkOpts := opts
+	walkOpts.namePart = ""
+	return &daemon{
+		walk: func(ctx context.Context) ([]string, []ui.WalkError, error) {
+			// Pick up mounts added since the last refresh
+			sessionMounts.Reset()
+			return walkPaths(ctx, client, walkOpts, nil, roots)
+		},
+		read: func(ctx context.Context, p string) (interface{}, error) {
+			mnt, inner := search.SplitMount(p)
+			val, err := search.ReadSecret(ctx, client.Logical(), mnt, inner, decideKV2ForPath(ctx, client, mnt, opts))
+			return redactValue(val), err
+		},
+		token:   opts.serveToken,
+		timeout: opts.timeout,
+		metrics: metrics,
+	}
+}
+
+// runServe keeps the path index warm and serves it on -listen until SIGINT/SIGTERM.
+func runServe(_ context.Context, client *vault.Client, opts options, _ *regexp.Regexp) error {
+	if len(opts.args) > 0 {
+		return fmt.Errorf("serve takes no arguments, got %q", opts.args)
+	}
+	if err := checkLoopback(opts.listen); err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ln, err := net.Listen("tcp", opts.listen)
+	if err != nil {
+		return err
+	}
+	d := newDaemon(client, opts)
+	srv := &http.Server{Handler: d.handler(), ReadHeaderTimeout: 10 * time.Second}
+	go d.loop(ctx, opts.refresh)
+	go func() {
+		<-ctx.Done()
+		sctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(sctx)
+	}()
+	fmt.Fprintf(os.Stderr, "fvf: serving on http://%s (refresh every %s)\n", ln.Addr(), opts.refresh)
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// daemonPaths fetches every indexed path from a running fvf serve.
+func daemonPaths(ctx context.Context, base, token string) ([]string, error) {
+	u, err := url.JoinPath(base, "search")
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("daemon %s: %s", base, resp.Status)
+	}
+	var body struct {
+		Paths []string `json:"paths"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("daemon %s: %w", base, err)
+	}
+	return body.Paths, nil
+}
+
+// underRoots keeps the paths below any of roots; no roots keeps everything.
+func underRoots(paths, roots []string) []string {
+	if len(roots) == 0 {
+		return paths
+	}
+	var out []string
+	for _, p := range paths {
+		for _, r := range roots {
+			r = strings.Trim(r, "/")
+			if p == r || strings.HasPrefix(p, r+"/") {
+				out = append(out, p)
+				break
+			}
+		}
+	}
+	return out
+}
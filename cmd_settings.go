@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// secretSettings is one row of fvf settings output.
+type secretSettings struct {
+	Path               string `json:"path"`
+	MaxVersions        int    `json:"max_versions"`
+	CASRequired        bool   `json:"cas_required"`
+	DeleteVersionAfter string `json:"delete_version_after"`
+	Error              string `json:"error,omitempty"`
+}
+
+// settingsChange parses -max-versions, -cas-required and
+// -delete-version-after; ok is false when none is set.
+func settingsChange(opts options) (s search.MetadataSettings, ok bool, err error) {
+	if opts.maxVersions >= 0 {
+		n := opts.maxVersions
+		s.MaxVersions, ok = &n, true
+	}
+	if opts.casRequired != "" {
+		b, err := strconv.ParseBool(opts.casRequired)
+		if err != nil {
+			return s, false, fmt.Errorf("-cas-required %q: want true or false", opts.casRequired)
+		}
+		s.CASRequired, ok = &b, true
+	}
+	if opts.deleteAfter != "" {
+		d, err := time.ParseDuration(opts.deleteAfter)
+		if err != nil || d < 0 {
+			return s, false, fmt.Errorf("-delete-version-after %q: want a duration like 720h (0s disables)", opts.deleteAfter)
+		}
+		s.DeleteVersionAfter, ok = &d, true
+	}
+	return s, ok, nil
+}
+
+// applySettings returns row with the change applied.
+func applySettings(row secretSettings, s search.MetadataSettings) secretSettings {
+	if s.MaxVersions != nil {
+		row.MaxVersions = *s.MaxVersions
+	}
+	if s.CASRequired != nil {
+		row.CASRequired = *s.CASRequired
+	}
+	if s.DeleteVersionAfter != nil {
+		row.DeleteVersionAfter = s.DeleteVersionAfter.String()
+	}
+	return row
+}
+
+// settingsDiff describes what changes from old to new, e.g.
+// "max_versions 0 -> 5"; empty when nothing does.
+func settingsDiff(old, new secretSettings) string {
+	var parts []string
+	if old.MaxVersions != new.MaxVersions {
+		parts = append(parts, fmt.Sprintf("max_versions %d -> %d", old.MaxVersions, new.MaxVersions))
+	}
+	if old.CASRequired != new.CASRequired {
+		parts = append(parts, fmt.Sprintf("cas_required %t -> %t", old.CASRequired, new.CASRequired))
+	}
+	if old.DeleteVersionAfter != new.DeleteVersionAfter {
+		parts = append(parts, fmt.Sprintf("delete_version_after %s -> %s", old.DeleteVersionAfter, new.DeleteVersionAfter))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// runSettings shows the per-secret KV v2 settings (max_versions, cas_required,
+// delete_version_after) of the secrets named by the arguments or found by the
+// -path walk, or with -max-versions, -cas-required or -delete-version-after
+// changes them on all of those secrets. Changing more than one secret is
+// confirmed first unless -yes is given; -dry-run only lists the changes.
+func runSettings(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) error {
+	change, update, err := settingsChange(opts)
+	if err != nil {
+		return err
+	}
+	paths, err := selectSecrets(ctx, client, opts, matcher, "settings")
+	if err != nil {
+		return err
+	}
+	rows := make([]secretSettings, len(paths))
+	var mu sync.Mutex
+	failed := 0
+	err = forEachLimit(opts.concurrency, len(paths), func(i int) error {
+		p := strings.Trim(paths[i], "/")
+		rows[i].Path = p
+		mnt, inner, err := metaTarget(ctx, client, p, opts)
+		var md *search.SecretMetadata
+		if err == nil {
+			md, err = search.ReadMetadata(ctx, client.Logical(), mnt, inner)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			mu.Lock()
+			rows[i].Error = err.Error()
+			failed++
+			mu.Unlock()
+			return nil
+		}
+		rows[i].MaxVersions = md.MaxVersions
+		rows[i].CASRequired = md.CASRequired
+		rows[i].DeleteVersionAfter = md.DeleteVersionAfter.String()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !update {
+		if err := printSettings(rows, opts); err != nil {
+			return err
+		}
+		if failed > 0 {
+			return fmt.Errorf("settings: %d of %d secret(s) could not be read", failed, len(rows))
+		}
+		return nil
+	}
+	if failed > 0 {
+		for _, r := range rows {
+			if r.Error != "" {
+				fmt.Fprintf(os.Stderr, "fvf: %s: %s\n", r.Path, r.Error)
+			}
+		}
+		return fmt.Errorf("settings: %d secret(s) could not be read, nothing changed", failed)
+	}
+
+	// Only the secrets whose settings actually differ are written
+	var todo []int
+	for i, r := range rows {
+		if d := settingsDiff(r, applySettings(r, change)); d != "" {
+			todo = append(todo, i)
+			prefix := ""
+			if opts.dryRun {
+				prefix = "would change "
+			}
+			fmt.Printf("%s%s: %s\n", prefix, r.Path, d)
+		}
+	}
+	if len(todo) == 0 {
+		fmt.Fprintf(os.Stderr, "fvf: all %d secret(s) already have these settings\n", len(rows))
+		return nil
+	}
+	if opts.dryRun {
+		return nil
+	}
+	if len(todo) > 1 && !opts.yes {
+		if err := confirmTyped("settings", fmt.Sprintf("fvf: change the settings of these %d secret(s)? Type \"yes\" to continue: ", len(todo)), "yes"); err != nil {
+			return err
+		}
+	}
+	failed = 0
+	err = forEachLimit(opts.concurrency, len(todo), func(j int) error {
+		r := rows[todo[j]]
+		mnt, inner := search.SplitMount(r.Path)
+		if err := search.WriteMetadataSettings(ctx, client.Logical(), mnt, inner, change); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			mu.Lock()
+			failed++
+			fmt.Fprintf(os.Stderr, "fvf: %s: %v\n", r.Path, err)
+			mu.Unlock()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "fvf: updated %d of %d secret(s)\n", len(todo)-failed, len(todo))
+	if failed > 0 {
+		return fmt.Errorf("settings: %d secret(s) could not be updated", failed)
+	}
+	return nil
+}
+
+// printSettings prints a column per setting, or the rows as JSON.
+func printSettings(rows []secretSettings, opts options) error {
+	if opts.jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+	width := len("PATH")
+	for _, r := range rows {
+		width = max(width, len(r.Path))
+	}
+	fmt.Printf("%-*s  %-12s  %-12s  %s\n", width, "PATH", "MAX_VERSIONS", "CAS_REQUIRED", "DELETE_VERSION_AFTER")
+	for _, r := range rows {
+		if r.Error != "" {
+			fmt.Printf("%-*s  error: %s\n", width, r.Path, r.Error)
+			continue
+		}
+		fmt.Printf("%-*s  %-12d  %-12t  %s\n", width, r.Path, r.MaxVersions, r.CASRequired, r.DeleteVersionAfter)
+	}
+	return nil
+}
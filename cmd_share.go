@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// wrapSecret wraps the secret at p for -wrap-ttl. Engine plugin mounts are
+// refused: only Vault itself can wrap a response.
+func wrapSecret(ctx context.Context, client *vault.Client, p string, opts options) (*vault.SecretWrapInfo, error) {
+	mnt, inner := search.SplitMount(p)
+	logical, kv2 := logicalFor(ctx, client, mnt, opts)
+	if _, ok := logical.(*search.Plugin); ok {
+		return nil, fmt.Errorf("%s is served by an engine plugin and cannot be wrapped", mnt)
+	}
+	if err := sessionMounts.CheckKV(ctx, client, mnt); err != nil {
+		return nil, err
+	}
+	return search.WrapSecret(ctx, client, mnt, inner, kv2, opts.wrapTTL)
+}
+
+// runShare prints a single-use wrapping token for the secret at the path
+// argument; the recipient runs `vault unwrap <token>` within -wrap-ttl.
+func runShare(ctx context.Context, client *vault.Client, opts options, _ *regexp.Regexp) error {
+	if len(opts.args) != 1 {
+		return fmt.Errorf("share takes exactly one path, got %d", len(opts.args))
+	}
+	wi, err := wrapSecret(ctx, client, opts.args[0], opts)
+	if err != nil {
+		return err
+	}
+	ttl := time.Duration(wi.TTL) * time.Second
+	if opts.jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]interface{}{
+			"token":         wi.Token,
+			"accessor":      wi.Accessor,
+			"ttl":           wi.TTL,
+			"creation_time": wi.CreationTime,
+			"expires":       wi.CreationTime.Add(ttl),
+		})
+	}
+	fmt.Println(wi.Token)
+	fmt.Fprintf(os.Stderr, "fvf: single-use token, expires in %s; unwrap with: vault unwrap <token>\n", ttl)
+	return nil
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// syncAction is one change sync makes to the destination.
+type syncAction struct {
+	Type string `json:"type"`          // create, update or delete
+	Src  string `json:"src,omitempty"` // empty for delete
+	Dst  string `json:"dst"`
+	data map[string]interface{}
+
+	// base and version are the destination as read (KV v2), for check-and-set;
+	// version is 0 for a create.
+	base    map[string]interface{}
+	version int
+}
+
+// syncRoots normalises the source and destination arguments of cmd to
+// prefixes ending in "/".
+func syncRoots(cmd string, args []string) (src, dst string, err error) {
+	if len(args) != 2 {
+		return "", "", fmt.Errorf("%s takes a source and a destination prefix, got %d argument(s)", cmd, len(args))
+	}
+	src, dst = strings.Trim(args[0], "/")+"/", strings.Trim(args[1], "/")+"/"
+	if strings.HasPrefix(dst, src) || strings.HasPrefix(src, dst) {
+		return "", "", fmt.Errorf("%s: %s and %s overlap", cmd, src, dst)
+	}
+	return src, dst, nil
+}
+
+// readValues reads every path concurrently, along with the version of KV v2
+// secrets; paths that cannot be read are reported in errs.
+func readValues(ctx context.Context, client *vault.Client, opts options, paths []string) (vals map[string]map[string]interface{}, versions map[string]int, errs map[string]error, err error) {
+	var mu sync.Mutex
+	vals = make(map[string]map[string]interface{}, len(paths))
+	versions = make(map[string]int)
+	errs = make(map[string]error)
+	err = forEachLimit(opts.concurrency, len(paths), func(i int) error {
+		p := strings.Trim(paths[i], "/")
+		mnt, inner := search.SplitMount(p)
+		logical, kv2 := logicalFor(ctx, client, mnt, opts)
+		var (
+			data    map[string]interface{}
+			version int
+			err     error
+		)
+		if kv2 {
+			data, version, err = search.ReadSecretVersion(ctx, logical, mnt, inner)
+		} else {
+			var val interface{}
+			val, err = search.ReadSecret(ctx, logical, mnt, inner, false)
+			var ok bool
+			if data, ok = val.(map[string]interface{}); err == nil && !ok {
+				err = fmt.Errorf("unexpected secret shape at %s", p)
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs[p] = err
+		} else {
+			vals[p], versions[p] = data, version
+		}
+		return nil
+	})
+	return vals, versions, errs, err
+}
+
+// planSync compares the secrets below src and dst by value digest and returns
+// what makes dst match src, sorted by destination, plus the number of secrets
+// that are already equal. dstVersions are the KV v2 versions the destination
+// values were read at.
+func planSync(src, dst string, srcVals, dstVals map[string]map[string]interface{}, dstVersions map[string]int) ([]syncAction, int, error) {
+	var actions []syncAction
+	unchanged := 0
+	for p, v := range srcVals {
+		target := dst + strings.TrimPrefix(p, src)
+		cur, exists := dstVals[target]
+		if exists {
+			a, err := valueDigest(nil, v)
+			if err != nil {
+				return nil, 0, err
+			}
+			b, err := valueDigest(nil, cur)
+			if err != nil {
+				return nil, 0, err
+			}
+			if a == b {
+				unchanged++
+				continue
+			}
+		}
+		typ := "create"
+		if exists {
+			typ = "update"
+		}
+		actions = append(actions, syncAction{Type: typ, Src: p, Dst: target, data: v, base: cur, version: dstVersions[target]})
+	}
+	for p := range dstVals {
+		if _, ok := srcVals[src+strings.TrimPrefix(p, dst)]; !ok {
+			actions = append(actions, syncAction{Type: "delete", Dst: p, base: dstVals[p], version: dstVersions[p]})
+		}
+	}
+	sort.Slice(actions, func(i, j int) bool { return actions[i].Dst < actions[j].Dst })
+	return actions, unchanged, nil
+}
+
+// applySync writes or deletes each action's destination and reports failures
+// without stopping. KV v2 writes use check-and-set against the version read
+// when planning, so a secret changed meanwhile is reported (and with -yes
+// overwritten after all) instead of silently replaced.
+func applySync(ctx context.Context, client *vault.Client, opts options, actions []syncAction, out io.Writer) (failed int, err error) {
+	var mu sync.Mutex
+	err = forEachLimit(opts.concurrency, len(actions), func(i int) error {
+		a := actions[i]
+		mnt, inner := search.SplitMount(a.Dst)
+		kv2, err := writableMount(ctx, client, mnt, opts)
+		if err == nil {
+			switch {
+			case a.Type == "delete":
+				err = search.DeleteSecret(ctx, client.Logical(), mnt, inner, kv2)
+			case kv2:
+				_, err = writeCAS(ctx, client, opts, os.Stderr, "sync", a.Dst, a.base, a.version, func(map[string]interface{}) map[string]interface{} { return a.data }, opts.retryCAS)
+			default:
+				err = search.WriteSecret(ctx, client.Logical(), mnt, inner, false, a.data)
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "fvf: sync: cannot %s %s: %v\n", a.Type, a.Dst, err)
+			return nil
+		}
+		fmt.Fprintf(out, "%-6s %s\n", a.Type, a.Dst)
+		return nil
+	})
+	return failed, err
+}
+
+// diffPrefixes walks and reads everything below src and dst and plans what
+// makes dst match src (see planSync). A source that cannot be listed or read
+// is an error, since its secrets would otherwise look deleted, and so is a
+// destination, since it could only be written without check-and-set. cmd
+// prefixes the messages.
+func diffPrefixes(ctx context.Context, client *vault.Client, opts options, cmd, src, dst string) ([]syncAction, int, error) {
+	srcPaths, skipped, err := walkPaths(ctx, client, opts, nil, []string{src})
+	if err != nil {
+		return nil, 0, err
+	}
+	if n := reportWalkFailures(skipped); n > 0 {
+		return nil, 0, fmt.Errorf("%s: %d mount(s) of the source could not be fully listed", cmd, n)
+	}
+	dstPaths, skipped, err := walkPaths(ctx, client, opts, nil, []string{dst})
+	if err != nil {
+		return nil, 0, err
+	}
+	if n := reportWalkFailures(skipped); n > 0 {
+		return nil, 0, fmt.Errorf("%s: %d mount(s) of the destination could not be fully listed", cmd, n)
+	}
+	srcVals, _, srcErrs, err := readValues(ctx, client, opts, srcPaths)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(srcErrs) > 0 {
+		for p, e := range srcErrs {
+			fmt.Fprintf(os.Stderr, "fvf: %s: cannot read %s: %v\n", cmd, p, e)
+		}
+		return nil, 0, fmt.Errorf("%s: %d source secret(s) could not be read", cmd, len(srcErrs))
+	}
+	dstVals, dstVersions, dstErrs, err := readValues(ctx, client, opts, dstPaths)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(dstErrs) > 0 {
+		for p, e := range dstErrs {
+			fmt.Fprintf(os.Stderr, "fvf: %s: cannot read %s: %v\n", cmd, p, e)
+		}
+		return nil, 0, fmt.Errorf("%s: %d destination secret(s) could not be read", cmd, len(dstErrs))
+	}
+	return planSync(src, dst, srcVals, dstVals, dstVersions)
+}
+
+// syncOnce makes everything below dst match src: missing secrets are
+// created, different ones overwritten and extra ones deleted. Secrets with an
+// equal value digest are not written. Nothing is changed unless both sides
+// could be listed and read (see diffPrefixes).
+func syncOnce(ctx context.Context, client *vault.Client, opts options, src, dst string) error {
+	actions, unchanged, err := diffPrefixes(ctx, client, opts, "sync", src, dst)
+	if err != nil {
+		return err
+	}
+	if opts.dryRun {
+		for _, a := range actions {
+			fmt.Printf("would %-6s %s\n", a.Type, a.Dst)
+		}
+		fmt.Fprintf(os.Stderr, "fvf: sync: %d change(s), %d secret(s) unchanged\n", len(actions), unchanged)
+		return nil
+	}
+	if err := confirmSyncDeletes(opts, "sync", actions); err != nil {
+		return err
+	}
+	failed, err := applySync(ctx, client, opts, actions, os.Stdout)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "fvf: sync: %d change(s), %d secret(s) unchanged\n", len(actions)-failed, unchanged)
+	if failed > 0 {
+		return fmt.Errorf("sync: %d change(s) failed", failed)
+	}
+	return nil
+}
+
+// confirmSyncDeletes lists the deletes of cmd's plan and asks to type "yes"
+// unless -yes is given: on KV v2 a delete removes every version.
+func confirmSyncDeletes(opts options, cmd string, actions []syncAction) error {
+	var deletes []string
+	for _, a := range actions {
+		if a.Type == "delete" {
+			deletes = append(deletes, a.Dst)
+		}
+	}
+	if len(deletes) == 0 || opts.yes {
+		return nil
+	}
+	for _, p := range deletes {
+		fmt.Fprintf(os.Stderr, "  delete %s\n", p)
+	}
+	return confirmTyped(cmd, fmt.Sprintf("fvf: %s deletes these %d secret(s) with all their versions. Type \"yes\" to continue: ", cmd, len(deletes)), "yes")
+}
+
+// syncHook applies one source change reported by the watcher to dst.
+// Deletes are only propagated with -yes, as nobody is there to confirm them.
+// A KV v2 destination is read first for check-and-set; if it or its mount
+// cannot be read, the change is not applied.
+func syncHook(client *vault.Client, opts options, src, dst string) func(ctx context.Context, c watchChange) error {
+	return func(ctx context.Context, c watchChange) error {
+		target := dst + strings.TrimPrefix(c.Path, src)
+		a := syncAction{Type: "delete", Dst: target}
+		if c.Type == "deleted" && !opts.yes {
+			fmt.Fprintf(os.Stderr, "fvf: sync: %s was deleted; not deleting %s (-yes propagates deletes)\n", c.Path, target)
+			return nil
+		}
+		if c.Type != "deleted" {
+			vals, _, errs, err := readValues(ctx, client, opts, []string{c.Path})
+			if err != nil {
+				return err
+			}
+			if e := errs[c.Path]; e != nil {
+				return e
+			}
+			a = syncAction{Type: "update", Src: c.Path, Dst: target, data: vals[c.Path]}
+			mnt, inner := search.SplitMount(target)
+			kv2, err := writableMount(ctx, client, mnt, opts)
+			if err != nil {
+				return fmt.Errorf("sync of %s: %w", c.Path, err)
+			}
+			if kv2 {
+				if a.base, a.version, err = search.ReadSecretCurrent(ctx, client.Logical(), mnt, inner); err != nil {
+					return fmt.Errorf("sync of %s: cannot read %s: %w", c.Path, target, err)
+				}
+			}
+		}
+		if failed, err := applySync(ctx, client, opts, []syncAction{a}, os.Stdout); err != nil || failed > 0 {
+			return fmt.Errorf("sync of %s failed", c.Path)
+		}
+		return nil
+	}
+}
+
+// runSync makes the destination prefix match the source prefix once, or with
+// -watch keeps it in sync: after the first full pass, the source is polled
+// every -refresh like fvf watch and each change is copied over. Watching only
+// sees new KV v2 versions, so updates on a KV v1 source and edits made to the
+// destination are picked up by the next full run. Deleting destination
+// secrets (with all their KV v2 versions) needs a typed confirmation or -yes.
+func runSync(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) error {
+	src, dst, err := syncRoots("sync", opts.args)
+	if err != nil {
+		return err
+	}
+	if opts.namePart != "" || matcher != nil {
+		return fmt.Errorf("sync mirrors whole prefixes; -name and -match are not supported")
+	}
+	if !opts.watch {
+		return syncOnce(ctx, client, opts, src, dst)
+	}
+	if opts.dryRun {
+		return fmt.Errorf("-dry-run cannot be combined with -watch")
+	}
+
+	// The caller leaves -watch without the -timeout deadline (see untimed);
+	// the full pass is still bounded by it
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	wopts := opts
+	wopts.startPath, wopts.paths = src, nil
+	wopts.hookURL, wopts.hookCmd = "", ""
+	w, err := newWatcher(client, wopts, nil)
+	if err != nil {
+		return err
+	}
+	w.out = io.Discard
+	w.hooks = []func(ctx context.Context, c watchChange) error{syncHook(client, opts, src, dst)}
+
+	// Baseline the watcher first so changes during the full pass are not lost
+	w.poll(ctx)
+	passCtx, cancel := context.WithTimeout(ctx, opts.timeout)
+	err = syncOnce(passCtx, client, opts, src, dst)
+	cancel()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "fvf: syncing %s to %s every %s\n", src, dst, opts.refresh)
+	t := time.NewTicker(opts.refresh)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			w.poll(ctx)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// parseVersions parses a -versions list such as "1,2,5-7" into sorted,
+// distinct version numbers.
+func parseVersions(spec string) ([]int, error) {
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(part, "-")
+		a, err := strconv.Atoi(strings.TrimSpace(lo))
+		b := a
+		if err == nil && isRange {
+			b, err = strconv.Atoi(strings.TrimSpace(hi))
+		}
+		if err != nil || a < 1 || b < a {
+			return nil, fmt.Errorf("-versions: bad entry %q (want e.g. 1,2,5-7)", part)
+		}
+		for v := a; v <= b; v++ {
+			seen[v] = true
+		}
+	}
+	if len(seen) == 0 {
+		return nil, fmt.Errorf("-versions is required, e.g. -versions 1,2")
+	}
+	out := make([]int, 0, len(seen))
+	for v := range seen {
+		out = append(out, v)
+	}
+	sort.Ints(out)
+	return out, nil
+}
+
+// versionPlan is the versions of one secret a delete or destroy acts on.
+type versionPlan struct {
+	Path     string `json:"path"`
+	Versions []int  `json:"versions"`
+	Error    string `json:"error,omitempty"`
+}
+
+func joinVersions(vs []int) string {
+	s := make([]string, len(vs))
+	for i, v := range vs {
+		s[i] = "v" + strconv.Itoa(v)
+	}
+	return strings.Join(s, ",")
+}
+
+// runDelete soft-deletes the -versions of each selected KV v2 secret.
+func runDelete(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) error {
+	return removeVersions(ctx, client, opts, matcher, false)
+}
+
+// runDestroy permanently destroys the -versions of each selected KV v2 secret.
+func runDestroy(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) error {
+	return removeVersions(ctx, client, opts, matcher, true)
+}
+
+// removeVersions deletes or destroys the -versions of the secrets named by the
+// arguments or found by the -path walk. Versions a secret does not have, or
+// that are already deleted (destroyed), are left out of the plan, which is
+// printed first; -dry-run stops there. Destroying cannot be undone and is
+// confirmed unless -yes is given.
+func removeVersions(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp, destroy bool) error {
+	verb, done := "delete", "deleted"
+	if destroy {
+		verb, done = "destroy", "destroyed"
+	}
+	versions, err := parseVersions(opts.versions)
+	if err != nil {
+		return err
+	}
+	paths, err := selectSecrets(ctx, client, opts, matcher, verb)
+	if err != nil {
+		return err
+	}
+
+	plans := make([]versionPlan, len(paths))
+	err = forEachLimit(opts.concurrency, len(paths), func(i int) error {
+		p := strings.Trim(paths[i], "/")
+		plans[i].Path = p
+		mnt, inner, err := metaTarget(ctx, client, p, opts)
+		var md *search.SecretMetadata
+		if err == nil {
+			md, err = search.ReadMetadata(ctx, client.Logical(), mnt, inner)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			plans[i].Error = err.Error()
+			return nil
+		}
+		want := make(map[int]bool, len(versions))
+		for _, v := range versions {
+			want[v] = true
+		}
+		for _, v := range md.Versions {
+			if want[v.Version] && (v.Readable() || destroy && !v.Destroyed) {
+				plans[i].Versions = append(plans[i].Versions, v.Version)
+			}
+		}
+		sort.Ints(plans[i].Versions)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var todo []int
+	failed := 0
+	for i, pl := range plans {
+		switch {
+		case pl.Error != "":
+			failed++
+			fmt.Fprintf(os.Stderr, "fvf: %s: %s\n", pl.Path, pl.Error)
+		case len(pl.Versions) > 0:
+			todo = append(todo, i)
+		}
+	}
+	if !opts.jsonOut {
+		for _, i := range todo {
+			prefix := ""
+			if opts.dryRun {
+				prefix = "would "
+			}
+			fmt.Printf("%s%s %s %s\n", prefix, verb, plans[i].Path, joinVersions(plans[i].Versions))
+		}
+	}
+	if opts.dryRun || len(todo) == 0 {
+		if opts.jsonOut {
+			if err := encodeVersionPlans(plans, todo); err != nil {
+				return err
+			}
+		}
+		if len(todo) == 0 {
+			fmt.Fprintf(os.Stderr, "fvf: nothing to %s\n", verb)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%s: %d secret(s) could not be read", verb, failed)
+		}
+		return nil
+	}
+	if destroy && !opts.yes {
+		n := 0
+		for _, i := range todo {
+			n += len(plans[i].Versions)
+		}
+		prompt := fmt.Sprintf("fvf: destroy %d version(s) of %d secret(s)? This cannot be undone. Type \"yes\" to continue: ", n, len(todo))
+		if err := confirmTyped(verb, prompt, "yes"); err != nil {
+			return err
+		}
+	}
+
+	var mu sync.Mutex
+	err = forEachLimit(opts.concurrency, len(todo), func(j int) error {
+		pl := &plans[todo[j]]
+		mnt, inner := search.SplitMount(pl.Path)
+		var err error
+		if destroy {
+			err = search.DestroyVersions(ctx, client.Logical(), mnt, inner, pl.Versions)
+		} else {
+			err = search.DeleteVersions(ctx, client.Logical(), mnt, inner, pl.Versions)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			mu.Lock()
+			pl.Error = err.Error()
+			failed++
+			mu.Unlock()
+			fmt.Fprintf(os.Stderr, "fvf: %s: %v\n", pl.Path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if opts.jsonOut {
+		if err := encodeVersionPlans(plans, todo); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%s: %d secret(s) failed", verb, failed)
+	}
+	fmt.Fprintf(os.Stderr, "fvf: %s versions of %d secret(s)\n", done, len(todo))
+	return nil
+}
+
+// encodeVersionPlans prints the planned (or done) secrets as JSON.
+func encodeVersionPlans(plans []versionPlan, todo []int) error {
+	out := make([]versionPlan, 0, len(todo))
+	for _, i := range todo {
+		out = append(out, plans[i])
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
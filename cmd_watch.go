@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"syscall"
+	"text/template"
+	"time"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// versionUnknown marks a secret whose metadata could not be read this round;
+// it keeps the version seen before, so a flaky read is not reported as a change.
+const versionUnknown = -1
+
+// watchChange is one detected change. Versions are KV v2 metadata versions;
+// they stay 0 on KV v1 mounts, where only created and deleted are detected.
+type watchChange struct {
+	Path       string    `json:"path"`
+	Type       string    `json:"type"` // created, updated or deleted
+	OldVersion int       `json:"old_version"`
+	NewVersion int       `json:"new_version"`
+	Time       time.Time `json:"time"`
+}
+
+// diffVersions compares two snapshots (path -> version) and returns the
+// changes sorted by path. Unknown versions in next are taken from prev.
+func diffVersions(prev, next map[string]int, now time.Time) []watchChange {
+	var out []watchChange
+	for p, v := range next {
+		old, ok := prev[p]
+		switch {
+		case v == versionUnknown:
+			next[p] = old
+		case !ok:
+			out = append(out, watchChange{Path: p, Type: "created", NewVersion: v, Time: now})
+		case v != old:
+			out = append(out, watchChange{Path: p, Type: "updated", OldVersion: old, NewVersion: v, Time: now})
+		}
+	}
+	for p, old := range prev {
+		if _, ok := next[p]; !ok {
+			out = append(out, watchChange{Path: p, Type: "deleted", OldVersion: old, Time: now})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+// watcher polls Vault and reports what changed since the previous poll.
+type watcher struct {
+	// snapshot returns the version of every secret below the watched roots.
+	snapshot func(ctx context.Context) (map[string]int, error)
+	hooks    []func(ctx context.Context, c watchChange) error
+	out      io.Writer
+	jsonOut  bool
+	prev     map[string]int
+}
+
+// poll takes one snapshot. The first one is the baseline and reports nothing;
+// a failed snapshot keeps the previous one.
+func (w *watcher) poll(ctx context.Context) {
+	next, err := w.snapshot(ctx)
+	if err != nil {
+		slog.Warn("watch poll failed", "err", err)
+		return
+	}
+	if w.prev == nil {
+		for p, v := range next {
+			if v == versionUnknown {
+				next[p] = 0
+			}
+		}
+		w.prev = next
+		slog.Info("watch baseline", "secrets", len(next))
+		return
+	}
+	changes := diffVersions(w.prev, next, time.Now())
+	w.prev = next
+	for _, c := range changes {
+		w.report(c)
+		for _, h := range w.hooks {
+			if err := h(ctx, c); err != nil {
+				slog.Warn("watch hook failed", "path", c.Path, "type", c.Type, "err", err)
+			}
+		}
+	}
+}
+
+func (w *watcher) report(c watchChange) {
+	if w.jsonOut {
+		json.NewEncoder(w.out).Encode(c)
+		return
+	}
+	fmt.Fprintf(w.out, "%s %-7s %s v%d -> v%d\n", c.Time.Format(time.RFC3339), c.Type, c.Path, c.OldVersion, c.NewVersion)
+}
+
+// webhookHook POSTs each change as JSON to url.
+func webhookHook(url string) func(ctx context.Context, c watchChange) error {
+	return func(ctx context.Context, c watchChange) error {
+		body, err := json.Marshal(c)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook %s: %s", url, resp.Status)
+		}
+		return nil
+	}
+}
+
+// commandHook runs the text/template tmpl through sh -c for each change. The
+// template sees .Path, .Type, .OldVersion and .NewVersion, with the path
+// shell-quoted; the same values are in FVF_PATH, FVF_CHANGE, FVF_OLD_VERSION
+// and FVF_NEW_VERSION.
+func commandHook(tmpl string) (func(ctx context.Context, c watchChange) error, error) {
+	t, err := template.New("hook").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("-hook-cmd: %w", err)
+	}
+	return func(ctx context.Context, c watchChange) error {
+		quoted := c
+		quoted.Path = shellQuote(c.Path)
+		var cmdline bytes.Buffer
+		if err := t.Execute(&cmdline, quoted); err != nil {
+			return err
+		}
+		cmd := exec.CommandContext(ctx, "sh", "-c", cmdline.String())
+		cmd.Env = append(os.Environ(),
+			"FVF_PATH="+c.Path,
+			"FVF_CHANGE="+c.Type,
+			"FVF_OLD_VERSION="+strconv.Itoa(c.OldVersion),
+			"FVF_NEW_VERSION="+strconv.Itoa(c.NewVersion),
+		)
+		cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+		return cmd.Run()
+	}, nil
+}
+
+// newWatcher wires a watcher to Vault: each poll walks the roots (all KV
+// mounts unless -path/-paths, filtered by -name/-match) and reads the KV v2
+// metadata of every secret found.
+func newWatcher(client *vault.Client, opts options, matcher *regexp.Regexp) (*watcher, error) {
+	roots := opts.paths
+	if len(roots) == 0 && opts.startPath != "" {
+		roots = []string{opts.startPath}
+	}
+	w := &watcher{out: os.Stdout, jsonOut: opts.jsonOut}
+	w.snapshot = func(ctx context.Context) (map[string]int, error) {
+		ctx, cancel := context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+		// Pick up mounts added since the last poll
+		sessionMounts.Reset()
+		paths, _, err := walkPaths(ctx, client, opts, matcher, roots)
+		if err != nil {
+			return nil, err
+		}
+		var mu sync.Mutex
+		out := make(map[string]int, len(paths))
+		err = forEachLimit(opts.concurrency, len(paths), func(i int) error {
+			v := 0
+			mnt, inner := search.SplitMount(paths[i])
+			if decideKV2ForPath(ctx, client, mnt, opts) {
+				md, err := search.ReadMetadata(ctx, client.Logical(), mnt, inner)
+				switch {
+				case ctx.Err() != nil:
+					return ctx.Err()
+				case err != nil || md == nil:
+					v = versionUnknown
+				default:
+					v = md.CurrentVersion
+				}
+			}
+			mu.Lock()
+			out[paths[i]] = v
+			mu.Unlock()
+			return nil
+		})
+		return out, err
+	}
+	if opts.hookURL != "" {
+		w.hooks = append(w.hooks, webhookHook(opts.hookURL))
+	}
+	if opts.hookCmd != "" {
+		h, err := commandHook(opts.hookCmd)
+		if err != nil {
+			return nil, err
+		}
+		w.hooks = append(w.hooks, h)
+	}
+	return w, nil
+}
+
+// runWatch polls every -refresh until SIGINT/SIGTERM, printing each change
+// and running the -hook-url / -hook-cmd hooks for it.
+func runWatch(_ context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) error {
+	if len(opts.args) > 0 {
+		return fmt.Errorf("watch takes no arguments (use -path or -paths), got %q", opts.args)
+	}
+	w, err := newWatcher(client, opts, matcher)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	fmt.Fprintf(os.Stderr, "fvf: watching for changes every %s\n", opts.refresh)
+	w.poll(ctx)
+	t := time.NewTicker(opts.refresh)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			w.poll(ctx)
+		}
+	}
+}
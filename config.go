@@ -0,0 +1,221 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigPath returns $XDG_CONFIG_HOME/fvf/config.yaml, falling back to
+// ~/.config/fvf/config.yaml; empty when no home directory is known.
+func defaultConfigPath() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "fvf", "config.yaml")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".config", "fvf", "config.yaml")
+}
+
+// projectConfigName is the per-project defaults file teams can commit.
+const projectConfigName = ".fvf.yaml"
+
+// findProjectConfig looks for .fvf.yaml in dir and, inside a git checkout, in
+// each parent up to the repository root. It returns "" when there is none.
+func findProjectConfig(dir string) string {
+	inRepo := false
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			inRepo = true
+			break
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	for d := dir; ; d = filepath.Dir(d) {
+		p := filepath.Join(d, projectConfigName)
+		if fi, err := os.Stat(p); err == nil && !fi.IsDir() {
+			return p
+		}
+		if !inRepo {
+			return ""
+		}
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil || filepath.Dir(d) == d {
+			return ""
+		}
+	}
+}
+
+// projectSettings are the only settings a discovered .fvf.yaml may carry:
+// scope, display, redaction and validation. Anything that starts programs,
+// opens listeners, writes files or lifts -read-only stays with the user's own
+// config and the command line, since cloning a repository must not be enough
+// to change what fvf runs. Aliases stay there too: a committed alias could
+// point a user's "fvf put @db" at a path of the committer's choosing.
+var projectSettings = map[string]bool{
+	"paths": true, "path": true, "name": true, "match": true, "max-depth": true,
+	"sort": true, "group": true, "relative": true, "line-numbers": true,
+	"color": true, "status-bar": true, "confirm-print": true,
+	"redact-keys": true, "redact-values": true, "schemas": true,
+}
+
+// checkProjectConfig rejects the settings of a project file that are not in
+// projectSettings.
+func checkProjectConfig(values map[string]string, source string) error {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !projectSettings[k] {
+			allowed := make([]string, 0, len(projectSettings))
+			for a := range projectSettings {
+				allowed = append(allowed, a)
+			}
+			sort.Strings(allowed)
+			return fmt.Errorf("%s: setting %q is not allowed in a project %s (only %s); set it in your own config or on the command line", source, k, projectConfigName, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// configFiles lists the config files to apply, lowest precedence first: the
+// user config and the project's .fvf.yaml (also returned as project), or only
+// the file given with -config (or FVF_CONFIG).
+func configFiles(args []string) (files []string, project string, explicit bool) {
+	if p, ok := configFlagValue(args); ok {
+		return []string{p}, "", true
+	}
+	if p := os.Getenv(envName("config")); p != "" {
+		return []string{p}, "", true
+	}
+	if p := defaultConfigPath(); p != "" {
+		files = append(files, p)
+	}
+	if wd, err := os.Getwd(); err == nil {
+		if project = findProjectConfig(wd); project != "" {
+			files = append(files, project)
+		}
+	}
+	return files, project, false
+}
+
+// configFlagValue finds -config in args before the flag set is parsed, since
+// the config file has to be applied first.
+func configFlagValue(args []string) (string, bool) {
+	for i, a := range args {
+		if a == "--" {
+			break
+		}
+		name, val, hasVal := strings.Cut(strings.TrimLeft(a, "-"), "=")
+		if !strings.HasPrefix(a, "-") || name != "config" {
+			continue
+		}
+		if hasVal {
+			return val, true
+		}
+		if i+1 < len(args) {
+			return args[i+1], true
+		}
+		return "", true
+	}
+	return "", false
+}
+
+// loadConfig reads a YAML mapping of flag names to values. Lists become
+// comma-separated values (e.g. paths: [kv/a/, kv/b/]) and mappings
+// comma-separated name=value pairs (e.g. aliases: {db: kv/app/db}). A missing
+// file yields no settings unless required is set.
+func loadConfig(path string, required bool) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if !required && errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	out := make(map[string]string, len(raw))
+	for k, v := range raw {
+		switch v := v.(type) {
+		case nil:
+			out[k] = ""
+		case []interface{}:
+			parts := make([]string, len(v))
+			for i, p := range v {
+				parts[i] = fmt.Sprint(p)
+			}
+			out[k] = strings.Join(parts, ",")
+		case map[string]interface{}:
+			// Mappings become name=value lists, e.g. for aliases or plugins
+			pairs := make([]string, 0, len(v))
+			for mk, mv := range v {
+				if _, nested := mv.(map[string]interface{}); nested {
+					return nil, fmt.Errorf("%s: setting %q: %q must be a value", path, k, mk)
+				}
+				pairs = append(pairs, mk+"="+fmt.Sprint(mv))
+			}
+			sort.Strings(pairs)
+			out[k] = strings.Join(pairs, ",")
+		default:
+			out[k] = fmt.Sprint(v)
+		}
+	}
+	return out, nil
+}
+
+// applyConfig sets flag values from a config file. It runs before the command
+// line is parsed, so values override defaults (including environment
+// defaults) and explicit flags still win.
+func applyConfig(fs *flag.FlagSet, values map[string]string, source string) error {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if k == "config" || fs.Lookup(k) == nil {
+			return fmt.Errorf("%s: unknown setting %q (settings are flag names, e.g. max-depth)", source, k)
+		}
+		if err := fs.Set(k, values[k]); err != nil {
+			return fmt.Errorf("%s: %s: %w", source, k, err)
+		}
+	}
+	return nil
+}
+
+// envName maps a flag name to its environment variable, e.g. max-depth -> FVF_MAX_DEPTH.
+func envName(flagName string) string {
+	return "FVF_" + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyEnv sets every flag that has a non-empty FVF_<NAME> variable. It runs
+// before config files and the command line, which both take precedence.
+func applyEnv(fs *flag.FlagSet) error {
+	var firstErr error
+	fs.VisitAll(func(f *flag.Flag) {
+		name := envName(f.Name)
+		v := os.Getenv(name)
+		if v == "" || f.Name == "config" || firstErr != nil {
+			return
+		}
+		if err := fs.Set(f.Name, v); err != nil {
+			firstErr = fmt.Errorf("%s: %w", name, err)
+		}
+	})
+	return firstErr
+}
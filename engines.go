@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// pluginEngines starts one engine plugin per mount type on first use and
+// keeps it for the session. Only the types mapped with -plugins have one:
+// plugins receive the token, so nothing is discovered on PATH.
+type pluginEngines struct {
+	mu      sync.Mutex
+	bins    map[string]string // -plugins: mount type -> binary
+	running map[string]*search.Plugin
+	failed  map[string]bool // types whose plugin is missing or failed to start
+}
+
+// sessionPlugins serves the non-KV mounts whose type -plugins maps.
+var sessionPlugins = &pluginEngines{}
+
+// configure sets the -plugins mapping.
+func (e *pluginEngines) configure(bins map[string]string) {
+	e.mu.Lock()
+	e.bins = bins
+	e.mu.Unlock()
+}
+
+// get returns the plugin for mountType, starting it when needed; false when
+// -plugins maps no binary to the type.
+func (e *pluginEngines) get(client *vault.Client, mountType string) (*search.Plugin, bool) {
+	if mountType == "" || mountType == "kv" {
+		return nil, false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if p, ok := e.running[mountType]; ok {
+		return p, true
+	}
+	if e.failed[mountType] {
+		return nil, false
+	}
+	bin, ok := e.bins[mountType]
+	if !ok {
+		return nil, false
+	}
+	p, err := search.StartPlugin(bin, mountType, search.PluginEnv(client))
+	if err != nil {
+		slog.Warn("engine plugin failed to start", "type", mountType, "bin", bin, "err", err)
+		e.markFailed(mountType)
+		return nil, false
+	}
+	slog.Debug("engine plugin started", "type", mountType, "bin", bin)
+	if e.running == nil {
+		e.running = make(map[string]*search.Plugin)
+	}
+	e.running[mountType] = p
+	return p, true
+}
+
+func (e *pluginEngines) markFailed(mountType string) {
+	if e.failed == nil {
+		e.failed = make(map[string]bool)
+	}
+	e.failed[mountType] = true
+}
+
+// stop closes every running plugin; it is safe to call more than once.
+func (e *pluginEngines) stop() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for t, p := range e.running {
+		if err := p.Close(); err != nil {
+			slog.Debug("engine plugin exit", "type", t, "err", err)
+		}
+	}
+	e.running = nil
+}
+
+// pluginMounts returns the mounts of the table served by a plugin, without
+// trailing slash, sorted.
+func pluginMounts(client *vault.Client, mounts map[string]*vault.MountOutput) []string {
+	var out []string
+	for mntPath, m := range mounts {
+		if _, ok := sessionPlugins.get(client, m.Type); ok {
+			out = append(out, strings.TrimSuffix(mntPath, "/"))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// logicalFor returns the API serving path p and whether it uses the KV v2
+// layout: the engine plugin for the mount's type when there is one, else
+// Vault itself.
+func logicalFor(ctx context.Context, client *vault.Client, p string, opts options) (search.LogicalAPI, bool) {
+	if client == nil {
+		return nil, decideKV2ForPath(ctx, client, p, opts)
+	}
+	if mounts, err := sessionMounts.Mounts(ctx, client); err == nil {
+		mnt, _ := search.SplitMount(strings.Trim(p, "/"))
+		if m, ok := mounts[mnt+"/"]; ok {
+			if plugin, ok := sessionPlugins.get(client, m.Type); ok {
+				return plugin, false
+			}
+		}
+	}
+	return client.Logical(), decideKV2ForPath(ctx, client, p, opts)
+}
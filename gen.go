@@ -0,0 +1,153 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// genPrefix marks a put/patch value to be generated, e.g. password=gen:alnum32.
+const genPrefix = "gen:"
+
+// genCharsets are the alphabets of the random string generators; the number
+// after the name is the length in characters.
+var genCharsets = map[string]string{
+	"alnum":   "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789",
+	"alpha":   "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+	"digits":  "0123456789",
+	"symbols": "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789!#$%&()*+,-./:;<=>?@[]^_{|}~",
+}
+
+// genNames are the generators taking a size, longest first so "base64"
+// is not read as "base" plus 64.
+var genNames = []string{"symbols", "base64", "digits", "alnum", "alpha", "ecdsa", "hex", "rsa"}
+
+// genSpec splits "alnum32" (or "alnum-32") into its name and size; size is -1
+// when there is none.
+func genSpec(spec string) (name string, size int) {
+	for _, n := range genNames {
+		rest, ok := strings.CutPrefix(spec, n)
+		if !ok {
+			continue
+		}
+		v, err := strconv.Atoi(strings.TrimPrefix(rest, "-"))
+		if err != nil {
+			return spec, -1
+		}
+		return n, v
+	}
+	return spec, -1
+}
+
+// generateValue produces a random value for a gen: spec:
+//
+//	alnum32, alpha16, digits6, symbols24  random characters
+//	hex32, base64-32                      random bytes, encoded
+//	uuid                                  random (v4) UUID
+//	rsa2048..rsa8192, ecdsa256/384/521, ed25519
+//	                                      key pair: PKCS#8 private key PEM,
+//	                                      pub the PKIX public key PEM
+//
+// pub is empty for everything but key pairs.
+func generateValue(spec string) (val, pub string, err error) {
+	name, size := genSpec(strings.ToLower(spec))
+	if charset, ok := genCharsets[name]; ok {
+		if size < 1 || size > 4096 {
+			return "", "", fmt.Errorf("gen:%s: want a length of 1..4096, e.g. gen:%s32", spec, name)
+		}
+		return randomString(charset, size)
+	}
+	switch name {
+	case "hex", "base64":
+		if size < 1 || size > 4096 {
+			return "", "", fmt.Errorf("gen:%s: want a byte count of 1..4096, e.g. gen:hex32", spec)
+		}
+		b := make([]byte, size)
+		if _, err := rand.Read(b); err != nil {
+			return "", "", err
+		}
+		if name == "hex" {
+			return hex.EncodeToString(b), "", nil
+		}
+		return base64.StdEncoding.EncodeToString(b), "", nil
+	case "uuid":
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return "", "", err
+		}
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), "", nil
+	case "rsa":
+		if size < 2048 || size > 8192 {
+			return "", "", fmt.Errorf("gen:%s: RSA keys are 2048..8192 bits", spec)
+		}
+		k, err := rsa.GenerateKey(rand.Reader, size)
+		if err != nil {
+			return "", "", err
+		}
+		return pemKeyPair(k, &k.PublicKey)
+	case "ecdsa":
+		var curve elliptic.Curve
+		switch size {
+		case 256:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		default:
+			return "", "", fmt.Errorf("gen:%s: want ecdsa256, ecdsa384 or ecdsa521", spec)
+		}
+		k, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			return "", "", err
+		}
+		return pemKeyPair(k, &k.PublicKey)
+	case "ed25519":
+		pk, k, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return "", "", err
+		}
+		return pemKeyPair(k, pk)
+	}
+	return "", "", fmt.Errorf("gen:%s: unknown generator (alnumN, alphaN, digitsN, symbolsN, hexN, base64-N, uuid, rsaN, ecdsaN, ed25519)", spec)
+}
+
+// randomString draws n characters uniformly from charset.
+func randomString(charset string, n int) (string, string, error) {
+	max := big.NewInt(int64(len(charset)))
+	b := make([]byte, n)
+	for i := range b {
+		j, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", "", err
+		}
+		b[i] = charset[j.Int64()]
+	}
+	return string(b), "", nil
+}
+
+// pemKeyPair encodes a private key as PKCS#8 and its public key as PKIX PEM.
+func pemKeyPair(priv, pub interface{}) (string, string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return "", "", err
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})), nil
+}
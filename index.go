@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"fvf/search"
+	"fvf/ui"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// pathIndex is the on-disk index written by fvf index and read by -offline:
+// every secret path found below the walked roots, without values. Roots are
+// the walked -path/-paths; AllMounts is set when every KV mount was walked.
+type pathIndex struct {
+	Addr      string    `json:"addr"`
+	Namespace string    `json:"namespace,omitempty"`
+	Updated   time.Time `json:"updated"`
+	Roots     []string  `json:"roots,omitempty"`
+	AllMounts bool      `json:"all_mounts,omitempty"`
+	Paths     []string  `json:"paths"`
+}
+
+// covers reports whether the index walked all of mount ("kv/"): every KV
+// mount, or a root at or above the mount. Indexes written before roots were
+// recorded cover no mount.
+func (idx pathIndex) covers(mount string) bool {
+	if idx.AllMounts {
+		return true
+	}
+	m := strings.Trim(mount, "/")
+	for _, r := range idx.Roots {
+		r = strings.Trim(r, "/")
+		if r != "" && (r == m || strings.HasPrefix(m, r+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// indexPath returns -index-file, or a file per Vault address and namespace
+// under the user cache directory, e.g. ~/.cache/fvf/index-<hash>.json.
+func indexPath(opts options, addr, ns string) (string, error) {
+	if opts.indexFile != "" {
+		return opts.indexFile, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("no cache directory for the index (use -index-file): %w", err)
+	}
+	sum := sha256.Sum256([]byte(addr + "|" + search.NormalizeNamespace(ns)))
+	return filepath.Join(dir, "fvf", "index-"+hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// writeIndex replaces the index file; only the user can read it, since
+// secret paths can be sensitive too.
+func writeIndex(file string, idx pathIndex) error {
+	b, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	return writePrivateFile(file, b)
+}
+
+// writePrivateFile atomically replaces file with b, readable by the user
+// only, creating its directory as needed.
+func writePrivateFile(file string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+func readIndex(file string) (pathIndex, error) {
+	var idx pathIndex
+	b, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return idx, fmt.Errorf("no index at %s; run fvf index while Vault is reachable", file)
+	}
+	if err != nil {
+		return idx, err
+	}
+	if err := json.Unmarshal(b, &idx); err != nil {
+		return idx, fmt.Errorf("index %s: %w", file, err)
+	}
+	return idx, nil
+}
+
+// runIndex walks -path/-paths (all KV mounts by default) and saves every path
+// found for -offline. Filters are ignored: the index is meant to answer any
+// later search. Failing subtrees are reported as in a search.
+func runIndex(ctx context.Context, client *vault.Client, opts options, _ *regexp.Regexp) error {
+	if len(opts.args) > 0 {
+		return fmt.Errorf("index takes no arguments (use -path or -paths), got %q", opts.args)
+	}
+	file, err := indexPath(opts, client.Address(), client.Namespace())
+	if err != nil {
+		return err
+	}
+	opts.namePart, opts.printValues, opts.jsonOut, opts.tableOut, opts.sortBy = "", false, false, "", "path"
+	failures := ui.NewWalkErrorLog()
+	items, err := collectItems(ctx, client, opts, nil, failures.Reporter())
+	if err != nil {
+		return err
+	}
+	idx := pathIndex{Addr: client.Address(), Namespace: search.NormalizeNamespace(client.Namespace()), Updated: time.Now().UTC()}
+	switch {
+	case len(opts.paths) > 0:
+		idx.Roots = opts.paths
+	case strings.TrimSpace(opts.startPath) != "":
+		idx.Roots = []string{opts.startPath}
+	default:
+		idx.AllMounts = true
+	}
+	for _, it := range items {
+		idx.Paths = append(idx.Paths, it.Path)
+	}
+	if err := writeIndex(file, idx); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "fvf: indexed %d path(s) to %s\n", len(idx.Paths), file)
+	return strictResult(opts, reportWalkFailures(failures.Errors()))
+}
+
+// searchOffline answers a search from the index of VAULT_ADDR/VAULT_NAMESPACE
+// without contacting Vault. Only paths are known, so options that need values
+// are refused rather than answered with empty ones.
+func searchOffline(opts options) error {
+	if opts.tableOut != "" || opts.sortBy == "size" || opts.sortBy == "keys" {
+		return fmt.Errorf("-offline: value sizes are not available offline")
+	}
+	matcher, err := buildMatcher(opts.match)
+	if err != nil {
+		return err
+	}
+	addr, ns := vault.DefaultConfig().Address, os.Getenv("VAULT_NAMESPACE")
+	file, err := indexPath(opts, addr, ns)
+	if err != nil {
+		return err
+	}
+	idx, err := readIndex(file)
+	if err != nil {
+		return err
+	}
+	roots := opts.paths
+	if len(roots) == 0 && opts.startPath != "" {
+		roots = []string{opts.startPath}
+	}
+	filters := search.Filters{NamePart: opts.namePart, Matcher: matcher}
+	var items []search.FoundItem
+	for _, p := range underRoots(idx.Paths, roots) {
+		if filters.Match(path.Base(p), p) {
+			items = append(items, search.FoundItem{Path: p})
+		}
+	}
+	fmt.Fprintf(os.Stderr, "fvf: offline: %d of %d indexed path(s) of %s, indexed %s ago; values are not available\n",
+		len(items), len(idx.Paths), idx.Addr, formatTTLHuman(int64(time.Since(idx.Updated).Seconds())))
+	return printItems(items, opts)
+}
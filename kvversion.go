@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// mountKVVersion is the KV version the mount table shows for m: 1 or 2, or
+// 0 when m is not a KV mount.
+func mountKVVersion(m *vault.MountOutput) int {
+	if m == nil || m.Type != "kv" {
+		return 0
+	}
+	if m.Options["version"] == "2" {
+		return 2
+	}
+	return 1
+}
+
+// kvOverrideFlag names the flag that skips KV version detection, if any.
+func kvOverrideFlag(opts options) string {
+	switch {
+	case opts.kv1:
+		return "-kv1"
+	case opts.forceKV2:
+		return "-force-kv2"
+	}
+	return ""
+}
+
+// kvOverrideWarnings lists the KV mounts of the walk whose version, as the
+// mount table shows it, differs from the one -kv1 or -force-kv2 imposes. A
+// mismatch otherwise only shows up as empty listings and failing reads.
+func kvOverrideWarnings(mounts map[string]*vault.MountOutput, opts options) []string {
+	flag := kvOverrideFlag(opts)
+	if flag == "" {
+		return nil
+	}
+	forced := 1
+	if decideKV2ForMountMeta(opts, nil) {
+		forced = 2
+	}
+	var roots []string
+	for _, p := range append([]string{opts.startPath}, opts.paths...) {
+		if mnt, _ := search.SplitMount(strings.Trim(p, "/")); mnt != "" {
+			roots = append(roots, mnt)
+		}
+	}
+	if len(roots) == 0 {
+		roots = kvMounts(mounts)
+	}
+	sort.Strings(roots)
+	var out []string
+	for i, mnt := range roots {
+		if i > 0 && roots[i-1] == mnt {
+			continue
+		}
+		if v := mountKVVersion(mounts[mnt+"/"]); v != 0 && v != forced {
+			out = append(out, fmt.Sprintf("%s reads %s/ as KV v%d, but the mount is KV v%d; expect empty listings and failed reads", flag, mnt, forced, v))
+		}
+	}
+	return out
+}
+
+// checkKVOverrides is kvOverrideWarnings for the session's mount table; it
+// returns nothing when the table cannot be read.
+func checkKVOverrides(ctx context.Context, client *vault.Client, opts options) []string {
+	if kvOverrideFlag(opts) == "" || client == nil {
+		return nil
+	}
+	mounts, err := sessionMounts.Mounts(ctx, client)
+	if err != nil {
+		return nil
+	}
+	return kvOverrideWarnings(mounts, opts)
+}
+
+// kvVersions reports the KV version reads of p use and the version its mount
+// shows (0 when not a KV mount or unknown), for the preview badge.
+func kvVersions(ctx context.Context, client *vault.Client, opts options, p string) (used, detected int) {
+	mnt, _ := search.SplitMount(strings.Trim(p, "/"))
+	mounts, err := sessionMounts.Mounts(ctx, client)
+	if err == nil {
+		m, ok := mounts[mnt+"/"]
+		if ok && m.Type != "kv" {
+			return 0, 0
+		}
+		detected = mountKVVersion(m)
+	}
+	used = 1
+	if decideKV2ForPath(ctx, client, mnt, opts) {
+		used = 2
+	}
+	return used, detected
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// logFile is the -log-file destination; nil while logging goes to stderr.
+var logFile *os.File
+
+// parseLogLevel maps a -log-level value (debug, info, warn, error) to a slog level.
+func parseLogLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("invalid -log-level %q (want debug, info, warn or error)", s)
+	}
+	return l, nil
+}
+
+// setupLogging installs the default slog logger used by main, search and ui:
+// text records at level and above, appended to path, or written to stderr when
+// path is empty.
+func setupLogging(level slog.Level, path string) error {
+	var w io.Writer = os.Stderr
+	if path != "" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return fmt.Errorf("log file: %w", err)
+		}
+		logFile = f
+		w = f
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level})))
+	return nil
+}
+
+// quietStderrLog drops records while the TUI owns the terminal, unless they go
+// to a -log-file. The returned func restores the previous logger.
+func quietStderrLog() (restore func()) {
+	prev := slog.Default()
+	if logFile == nil {
+		slog.SetDefault(slog.New(slog.DiscardHandler))
+	}
+	return func() { slog.SetDefault(prev) }
+}
@@ -1,15 +1,24 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"os/signal"
+	"path"
 	"regexp"
+	"runtime"
+	"runtime/pprof"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"fvf/search"
@@ -27,20 +36,109 @@ var (
 )
 
 type options struct {
-	startPath     string
-	kv2           bool
-	kv1           bool
-	forceKV2      bool
-	match         string
-	namePart      string
-	printValues   bool
-	maxDepth      int
-	jsonOut       bool
-	timeout       time.Duration
-	interactive   bool
-	showVersion   bool
-	paths         []string
-	idleExitAfter time.Duration
+	startPath      string
+	kv2            bool
+	kv1            bool
+	forceKV2       bool
+	match          string
+	namePart       string
+	printValues    bool
+	maxDepth       int
+	maxListKeys    int
+	jsonOut        bool
+	vaultOut       bool   // -output vault: get prints like `vault kv get`
+	tableOut       string // -output table or csv: path, key count and size per secret
+	sortBy         string
+	timeout        time.Duration
+	fetchTimeout   time.Duration // per value read
+	fetchRetries   int           // repeats of a value read that timed out
+	interactive    bool
+	showVersion    bool
+	paths          []string
+	idleExitAfter  time.Duration
+	pickMounts     bool
+	statusLayout   ui.StatusLayout
+	fromFile       string
+	pickerPaths    []string
+	stdinPicker    bool // piped stdin may become the picker's paths
+	noTUI          bool // -interactive=false was given
+	printPath      bool
+	policyPane     int
+	lockAfter      time.Duration
+	cacheEntries   int
+	cacheMB        int
+	maxValueKB     int
+	readsPerMinute int
+	transport      search.TransportOptions
+	cpuProfile     string
+	memProfile     string
+	concurrency    int
+	logLevel       slog.Level
+	logFile        string
+	color          string
+	strict         bool
+	listen         string
+	refresh        time.Duration
+	daemon         string
+	serveToken     string
+	rpcAllowValues bool
+	auditLog       string
+	sopsFile       string
+	direnvMode     string
+	keys           []string
+	envFile        bool
+	k8sNamespace   string
+	ci             string
+	otlpEndpoint   string
+	auditSource    string
+	hookURL        string
+	hookCmd        string
+	notify         string
+	expiryWithin   time.Duration
+	plugins        map[string]string
+	bindings       []ui.Binding
+	wrapTTL        time.Duration
+	outFile        string
+	recipients     []string
+	verify         string
+	manifestKey    string
+	unkeyed        bool
+	cas            int
+	recursive      bool
+	dryRun         bool
+	onConflict     string
+	keepMetadata   bool
+	yes            bool
+	retryCAS       bool
+	toVersion      int
+	renameFrom     string
+	renameTo       string
+	maxVersions    int
+	casRequired    string
+	deleteAfter    string
+	versions       string
+	watch          bool
+	rate           float64
+	stateFile      string
+	schemas        map[string]string
+	groupByMount   bool
+	relative       bool
+	lineNumbers    bool
+	confirmPrint   bool
+	redactKeys     string
+	redactValues   string
+	noRedact       bool
+	offline        bool
+	indexFile      string
+	sessionFile    string
+	batchFormat    string
+	reportValues   string // -report-values: none, mask or hash
+	reportKey      string // -report-key: secret mixed into hashed report values
+	policy         string // policy-coverage: ACL policy to check
+	staleAfter     time.Duration
+	aliases        map[string]string
+	readOnly       bool
+	args           []string
 }
 
 // formatTTLHuman converts seconds into a compact human readable TTL like:
@@ -153,20 +251,65 @@ func formatTTLHuman(secs int64) string {
 }
 
 func main() {
-	opts := parseFlags()
-	search.SetNamePart(opts.namePart)
+	args := os.Args[1:]
+	cmd := findSubcommand(args)
+	if cmd != nil {
+		args = args[1:]
+	}
+	opts := parseCommandFlags(cmd, args)
+	if err := checkReadOnly(cmd, opts); err != nil {
+		fatal(err)
+	}
+	if cmd == nil && opts.offline {
+		// There is no TUI offline: -offline is a search of the index
+		cmd = findSubcommand([]string{"search"})
+	}
+	if cmd != nil && (cmd.offline || cmd.offlineWhen != nil && cmd.offlineWhen(opts)) {
+		if err := cmd.run(context.Background(), nil, opts, nil); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	colorMode = opts.color
+	ciMode = opts.ci
+	rules, err := newRedactRules(opts.redactKeys, opts.redactValues, opts.noRedact)
+	if err != nil {
+		fatal(err)
+	}
+	redaction = rules
+	if err := setupLogging(opts.logLevel, opts.logFile); err != nil {
+		fatal(err)
+	}
+	if err := startProfiling(opts.cpuProfile, opts.memProfile); err != nil {
+		fatal(err)
+	}
+	defer stopProfiling()
+	if err := setupTracing(opts.otlpEndpoint); err != nil {
+		fatal(err)
+	}
+	defer stopTracing()
 
 	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+	if cmd != nil && cmd.untimed != nil && cmd.untimed(opts) {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
 	defer cancel()
 
-	client, err := search.NewVaultClient()
+	client, err := search.NewVaultClientWithTransport(opts.transport)
 	if err != nil {
 		fatal(err)
 	}
+	sessionPlugins.configure(opts.plugins)
+	defer sessionPlugins.stop()
 
 	if err := search.CheckConnection(ctx, client); err != nil {
+		slog.Debug("connection check failed", "addr", client.Address(), "err", err)
+		if errors.Is(err, search.ErrSealed) {
+			printGreenHint("fvf: Vault is sealed; unseal it or point VAULT_ADDR at an unsealed node.")
+		}
 		fmt.Fprintln(os.Stderr, "Cannot connect to Vault:", err)
-		os.Exit(1)
+		exit(1)
 	}
 
 	matcher, err := buildMatcher(opts.match)
@@ -174,36 +317,84 @@ func main() {
 		fatal(err)
 	}
 
-	if opts.interactive {
-		if err := runInteractiveStream(opts, client, matcher); err != nil {
+	for _, w := range checkKVOverrides(ctx, client, opts) {
+		fmt.Fprintln(os.Stderr, "Warning:", w)
+	}
+
+	if cmd != nil {
+		opts.interactive = false
+		if err := cmd.run(ctx, client, opts, matcher); err != nil {
 			fatal(err)
 		}
 		return
 	}
 
-	items, err := collectItems(ctx, client, opts, matcher)
-	if err != nil {
-		fatal(err)
+	// Picker mode: candidate paths come from a file or piped stdin, no walk.
+	// Piped stdin is only picked up when no walk or output flag was given.
+	if opts.fromFile == "" && opts.stdinPicker && stdinIsPipe() {
+		opts.fromFile = "-"
+	}
+	if opts.fromFile != "" {
+		paths, err := readPickerPaths(opts.fromFile)
+		if err != nil {
+			fatal(err)
+		}
+		if !opts.interactive && (opts.noTUI || !term.IsTerminal(int(os.Stdout.Fd()))) {
+			// Non-interactive: print the paths left by -name/-match
+			if err := printPickerPaths(paths, opts, matcher); err != nil {
+				fatal(err)
+			}
+			return
+		}
+		opts.pickerPaths = paths
+		opts.interactive = true
 	}
 
-	if err := printItems(items, opts); err != nil {
-		fatal(err)
+	if opts.interactive {
+		if err := runInteractiveStream(opts, client, matcher); err != nil {
+			fatal(err)
+		}
+		return
 	}
-}
 
-func parseFlags() options {
-	// Delegate to the args-based parser for testability
-	return parseFlagsWithArgs(os.Args[1:])
+	if err := searchAndPrint(ctx, client, opts, matcher); err != nil {
+		fatal(err)
+	}
 }
 
 // parseFlagsWithArgs builds a local FlagSet to allow deterministic tests.
 func parseFlagsWithArgs(args []string) options {
+	return parseCommandFlags(nil, args)
+}
+
+// parseCommandFlags parses args for cmd, or for the interactive search when
+// cmd is nil. Every flag is defined (and set from the environment and config
+// files) once; a command then parses the command line with its own FlagSet.
+func parseCommandFlags(cmd *subcommand, args []string) options {
 	var opts options
 	fs := flag.NewFlagSet("fvf", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
 
 	// multi-paths as a simple comma-separated string flag
 	pathsRaw := fs.String("paths", "", "Comma-separated list of start paths, e.g. kv/app1/,kv/app2/")
+	fs.BoolVar(&opts.groupByMount, "group", false, "Interactive: group results under collapsible per-mount headers with counts (Alt-g toggles, Alt-c collapses)")
+	fs.BoolVar(&opts.lineNumbers, "line-numbers", false, "Interactive: number the rows of the result list (Alt-n toggles); typing :N after the query jumps to row N")
+	fs.BoolVar(&opts.relative, "relative", false, "Show paths relative to -path/-paths: in the TUI list (Alt-p toggles) and in search output (not with -json)")
+	fs.BoolVar(&opts.confirmPrint, "confirm-print", false, "Interactive: ask before Enter prints a secret to the terminal, offering to copy it instead (set it in .fvf.yaml for shared sessions)")
+	fs.StringVar(&opts.redactKeys, "redact-keys", "", "Mask the values of keys matching these comma-separated globs, e.g. '*private_key*,*password*', in previews and output")
+	fs.StringVar(&opts.redactValues, "redact-values", "", "Mask values matching this regexp (use | for several) in previews and output")
+	fs.BoolVar(&opts.noRedact, "no-redact", false, "Show values matched by -redact-keys/-redact-values")
+	fs.BoolVar(&opts.offline, "offline", false, "Search the path index saved by fvf index instead of Vault, without a connection; values are not available")
+	fs.StringVar(&opts.indexFile, "index-file", "", "fvf index, -offline: index file (default: one per VAULT_ADDR and namespace in the user cache directory)")
+	fs.StringVar(&opts.sessionFile, "session", "", "Interactive: restore tabs, queries, roots and selections from this file when it exists, and save them there on exit")
+	fs.StringVar(&opts.policy, "policy", "", "policy-coverage: name of the ACL policy whose rules are intersected with the walked secrets")
+	fs.DurationVar(&opts.staleAfter, "stale-after", 180*24*time.Hour, "orphans: KV v2 secrets not updated for this long count as stale (0 skips the metadata reads)")
+	fs.StringVar(&opts.reportValues, "report-values", "mask", "Interactive: values in Alt-e reports, none (paths and metadata only, no value reads), mask (key names only) or hash (HMAC-SHA-256 prefix per value, salted per report)")
+	fs.StringVar(&opts.reportKey, "report-key", "", "Interactive: secret mixed into -report-values hash digests, so values cannot be brute-forced from a report without it")
+	fs.StringVar(&opts.batchFormat, "batch-format", "json", "Interactive: how Enter prints multi-selected secrets (fetched -concurrency at a time): json (object keyed by path) or dotenv (PREFIX_KEY='value' lines)")
+	printRaw := fs.String("print", "value", "Interactive: what Enter prints, 'value' or 'path' (Alt-Enter prints the other)")
+	bindRaw := fs.String("bind", "", "Interactive: run commands on keys, fzf style: 'ctrl-o:execute(psql -U {user} {host}),alt-s:execute-silent(notify {path})'; {path}/{}, {mount}, {name} and {<field>} (secret value field) are shell-quoted")
+	statusRaw := fs.String("status-bar", ui.DefaultStatusLayout, "Status bar layout \"left|middle|right\" of comma-separated segments: ttl, idle, addr, version, identity, namespace, counts, filter, roots, match, errors")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "fvf %s (commit %s, built %s)\n\n", version, commit, date)
@@ -220,22 +411,137 @@ func parseFlagsWithArgs(args []string) options {
 	fs.StringVar(&opts.namePart, "name", "", "Case-insensitive substring to match secret name (last segment)")
 	fs.BoolVar(&opts.printValues, "values", true, "Print values (interactive preview when stdout is a TTY)")
 	fs.IntVar(&opts.maxDepth, "max-depth", 0, "Maximum recursion depth (0 = unlimited)")
+	fs.IntVar(&opts.maxListKeys, "max-list-keys", 0, "Skip directories listing more keys than this, reported like other walk failures (0 = unlimited)")
+	fs.IntVar(&opts.concurrency, "concurrency", 4, "Number of mounts walked, and of secrets read or written by bulk commands such as cp, mv, rm and sync, in parallel")
 	fs.BoolVar(&opts.jsonOut, "json", false, "Output JSON array instead of lines")
+	outputRaw := fs.String("output", "", "Output format: text, json (the same as -json), vault (get: the `vault kv get` table layout), or table/csv (search: path, key count and value size per secret)")
+	fs.StringVar(&opts.sortBy, "sort", "path", "search: order of the output, path, size or keys (largest first)")
 	fs.DurationVar(&opts.timeout, "timeout", 30*time.Second, "Total timeout for the operation")
+	fs.DurationVar(&opts.fetchTimeout, "fetch-timeout", 15*time.Second, "Timeout of each value read (preview, get, values during the walk) and interactive lookup (metadata, capabilities, policies, mounts); 0 = only -timeout applies")
+	fs.IntVar(&opts.fetchRetries, "fetch-retries", 1, "How often a value read that hit -fetch-timeout is retried")
 	fs.BoolVar(&opts.interactive, "interactive", false, "Interactive TUI filter (like fzf): type to filter, Enter prints secret value (interactive uses streaming by default)")
 	fs.BoolVar(&opts.showVersion, "version", false, "Print version information and exit")
+	fs.StringVar(&opts.fromFile, "from-file", "", "Pick from the paths listed in this file ('-' for stdin) instead of walking Vault; piped stdin is used automatically unless -path, -paths, -json, -values, -offline or -interactive is given")
+	fs.IntVar(&opts.policyPane, "policies-pane", 50, "Interactive: percent of the preview used by the policies section (0 hides it; Ctrl-L cycles)")
+	fs.DurationVar(&opts.idleExitAfter, "idle-exit", 5*time.Minute, "Interactive: exit once the token has expired and there was no input for this long (0 = never)")
+	fs.DurationVar(&opts.lockAfter, "lock-after", 2*time.Minute, "Interactive: hide list and preview behind a lock screen after this much inactivity (0 disables)")
+	fs.IntVar(&opts.cacheEntries, "preview-cache", 500, "Interactive: maximum number of secret values kept in the preview cache (0 = unlimited)")
+	fs.IntVar(&opts.cacheMB, "preview-cache-mb", 32, "Interactive: maximum size of the preview cache in MiB (0 = unlimited)")
+	fs.IntVar(&opts.maxValueKB, "max-value-kb", 1024, "Interactive: larger values are not shown in the preview until Alt-v forces them (checked after the read; 0 = unlimited)")
+	fs.IntVar(&opts.readsPerMinute, "max-reads-per-minute", 0, "Interactive: value reads per minute (preview, bindings, Enter on a selection, Alt-e reports) before further reads wait for Alt-v or the next minute; neighbours are not prefetched (0 = unlimited)")
+	fs.IntVar(&opts.transport.MaxIdleConnsPerHost, "http-max-idle-per-host", 0, "Idle HTTP connections kept per Vault host for reuse (0 = client default)")
+	keepAlive := fs.Bool("http-keepalive", true, "Reuse HTTP connections to Vault (keep-alive)")
+	http2 := fs.Bool("http2", true, "Allow HTTP/2 to Vault (disable for load balancers with broken h2)")
+	fs.StringVar(&opts.cpuProfile, "cpuprofile", "", "Write a CPU profile to this file (inspect with go tool pprof)")
+	fs.StringVar(&opts.memProfile, "memprofile", "", "Write a heap profile to this file on exit")
+	logLevel := fs.String("log-level", "warn", "Log level: debug, info, warn or error")
+	fs.StringVar(&opts.logFile, "log-file", "", "Append logs to this file (default stderr; interactive mode logs only to a file)")
+	fs.BoolVar(&opts.strict, "strict", false, "Exit non-zero if any mount or subtree failed during the walk (failures are otherwise skipped and summarised)")
+	fs.StringVar(&opts.listen, "listen", "127.0.0.1:7373", "serve: loopback address for the HTTP/JSON API")
+	fs.DurationVar(&opts.refresh, "refresh", 5*time.Minute, "serve: how often the path index is rebuilt; watch, sync -watch: how often Vault is polled")
+	fs.StringVar(&opts.daemon, "daemon", "", "Interactive: load the initial paths from a running fvf serve at this URL, e.g. http://127.0.0.1:7373")
+	fs.StringVar(&opts.serveToken, "serve-token", "", "Bearer token required by fvf serve (and sent by -daemon); get is only served with a token")
+	fs.BoolVar(&opts.rpcAllowValues, "rpc-allow-values", false, "rpc: offer the get method/tool that returns secret values (search only returns paths)")
+	fs.StringVar(&opts.auditLog, "audit-log", "", "rpc: append a JSON audit record per request to this file (default stderr)")
+	fs.StringVar(&opts.sopsFile, "sops", "", "export: write the secrets to this SOPS-encrypted file (.json target → JSON, else YAML; keys from .sops.yaml)")
+	fs.StringVar(&opts.direnvMode, "direnv-mode", "export", "direnv: export (values in the .envrc), lazy (.envrc runs fvf on load, values never hit disk) or lib (use_fvf function for direnvrc)")
+	pluginsRaw := fs.String("plugins", "", "Engine plugins for non-KV mount types as type=binary pairs, comma-separated; only the listed types start a plugin")
+	fs.BoolVar(&opts.readOnly, "read-only", true, "Refuse every command and TUI action that writes or deletes secrets; set read-only: false in the config to enable them")
+	aliasesRaw := fs.String("aliases", "", "Path aliases as name=path pairs, comma-separated (a YAML mapping in the config file); @name or @name/rest works wherever a path is accepted")
+	schemasRaw := fs.String("schemas", "", "put, patch: JSON Schema files secrets below a prefix must match, as prefix=file pairs, comma-separated (the longest prefix applies)")
+	keysRaw := fs.String("keys", "", "docker-secret, eso: comma-separated keys to hand over (default all)")
+	fs.BoolVar(&opts.envFile, "env-file", false, "docker-secret: print a compose env_file (KEY=value) instead of creating docker secrets")
+	fs.StringVar(&opts.k8sNamespace, "k8s-namespace", "", "eso: Kubernetes namespace set on the generated manifests")
+	fs.StringVar(&opts.color, "color", "auto", "Colored non-interactive output: auto (stderr is a terminal and NO_COLOR is unset), always or never")
+	fs.StringVar(&opts.otlpEndpoint, "otlp-endpoint", "", "Export walk, LIST and READ spans over OTLP/HTTP to this URL, e.g. http://localhost:4318 (OTEL_EXPORTER_OTLP_* variables apply)")
+	fs.StringVar(&opts.auditSource, "audit-source", "", "Interactive: Vault audit log (file device JSON lines) or http(s) URL of an indexed audit store for the Ctrl-A audit panel")
+	fs.StringVar(&opts.hookURL, "hook-url", "", "watch: POST each change as JSON to this URL")
+	fs.StringVar(&opts.hookCmd, "hook-cmd", "", "watch: run this command template (sh -c) per change, e.g. 'notify {{.Path}} {{.Type}} v{{.NewVersion}}'; FVF_PATH, FVF_CHANGE, FVF_OLD_VERSION and FVF_NEW_VERSION are set")
+	fs.StringVar(&opts.notify, "notify", "", "scan-certs: send findings to slack:<webhook-url>, an http(s) URL (JSON) or smtp://[user:pass@]host:port?from=..&to=..")
+	fs.DurationVar(&opts.wrapTTL, "wrap-ttl", time.Hour, "share, Ctrl-W: lifetime of the single-use wrapping token")
+	fs.StringVar(&opts.outFile, "out", "", "manifest: write the manifest to this file instead of stdout; diff: save the diff to this file for fvf apply; backup: archive file or s3://bucket/key, gs://bucket/object or az://account/container/blob URL")
+	recipientsRaw := fs.String("recipient", "", "backup: comma-separated age recipients (age1… or SSH public keys) the archive is encrypted to")
+	fs.StringVar(&opts.verify, "verify", "", "manifest: compare Vault against this manifest and report drift (exit 1 on drift)")
+	fs.StringVar(&opts.manifestKey, "manifest-key", "", "manifest, diff, apply: secret mixed into the value digests so they cannot be brute-forced without it (prefer FVF_MANIFEST_KEY)")
+	fs.BoolVar(&opts.unkeyed, "unkeyed-manifest", false, "manifest, diff: allow -out without -manifest-key, leaving low-entropy values guessable from the file")
+	fs.IntVar(&opts.cas, "cas", -1, "put, patch: only write while the secret is at this KV v2 version (0: only create); -1 disables")
+	fs.BoolVar(&opts.recursive, "r", false, "cp, mv: copy every secret below the source prefix to the destination prefix; rm: delete every secret below the path")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "cp, mv, rm, rename, settings, delete, destroy, sync, apply, migrate-kv1: print what would change without writing")
+	fs.StringVar(&opts.onConflict, "on-conflict", "fail", "cp, mv, rename: when a destination exists: fail (before writing anything), skip or overwrite")
+	fs.BoolVar(&opts.yes, "yes", false, "rm, mv, rename, settings, destroy, sync and apply deletes: skip the typed confirmation")
+	fs.BoolVar(&opts.retryCAS, "retry-cas", false, "put, patch, sync, apply: on a check-and-set conflict, apply the write again on top of the new version without asking")
+	fs.IntVar(&opts.toVersion, "to-version", 0, "rollback: KV v2 version to make current again")
+	fs.StringVar(&opts.renameFrom, "from", "", "rename: regexp selecting the secret paths to rename, e.g. '^kv/team-a/(.*)$'")
+	fs.StringVar(&opts.renameTo, "to", "", "rename: replacement for -from matches; $1 or ${name} insert submatches")
+	fs.IntVar(&opts.maxVersions, "max-versions", -1, "settings: set max_versions on the secrets (0: mount default); -1 leaves it")
+	fs.StringVar(&opts.casRequired, "cas-required", "", "settings: set cas_required on the secrets: true or false")
+	fs.StringVar(&opts.deleteAfter, "delete-version-after", "", "settings: set delete_version_after on the secrets, e.g. 2160h (0s disables)")
+	fs.StringVar(&opts.versions, "versions", "", "delete, destroy: KV v2 versions to act on, e.g. 1,2,5-7")
+	fs.BoolVar(&opts.watch, "watch", false, "sync: after the first pass, keep copying source changes every -refresh")
+	fs.Float64Var(&opts.rate, "rate", 0, "migrate-kv1: at most this many writes per second (0: no limit)")
+	fs.StringVar(&opts.stateFile, "state", "", "migrate-kv1: file recording the secrets already copied; a rerun with it resumes")
+	fs.BoolVar(&opts.keepMetadata, "keep-metadata", false, "cp, mv, rename: also copy KV v2 custom_metadata")
+	fs.DurationVar(&opts.expiryWithin, "expiry-within", 30*24*time.Hour, "scan-certs: report certificates expiring within this window")
+	fs.StringVar(&opts.ci, "ci", "", "Before printing values, emit CI log masking commands for them: github")
+	fs.BoolVar(&opts.pickMounts, "pick-mounts", false, "Interactive: choose which KV mounts to walk before starting (also Ctrl-O in the UI)")
+
+	fs.String("config", "", "Config file with flag defaults (default ~/.config/fvf/config.yaml, then ./.fvf.yaml up to the repo root)")
+
+	// Precedence: defaults < FVF_* environment < config files < command-line flags
+	if err := applyEnv(fs); err != nil {
+		usageAndExit(err.Error())
+	}
+	cfgFiles, project, explicit := configFiles(args)
+	for _, cfgPath := range cfgFiles {
+		values, err := loadConfig(cfgPath, explicit)
+		if err != nil {
+			usageAndExit(err.Error())
+		}
+		if cfgPath == project {
+			if err := checkProjectConfig(values, cfgPath); err != nil {
+				usageAndExit(err.Error())
+			}
+		}
+		if err := applyConfig(fs, values, cfgPath); err != nil {
+			usageAndExit(err.Error())
+		}
+	}
+
+	// Piped stdin replaces the walk only when nothing on the command line
+	// asks for a walk or printed output; -interactive=false also prints a
+	// -from-file picker's paths instead of opening the TUI
+	given := cmdlineFlags(args)
+	opts.stdinPicker = !given["path"] && !given["paths"] && !given["json"] && !given["values"] && !given["offline"] && !given["interactive"]
+
+	// A command only accepts the shared flags and its own, so a flag it
+	// would ignore (fvf rm -on-conflict skip) is an error
+	ps := fs
+	if cmd != nil {
+		ps = cmd.flagSet(fs)
+	}
 
-	if err := fs.Parse(args); err != nil {
-		if err == flag.ErrHelp {
-			// Help was requested; usage already printed by fs.Parse.
-			os.Exit(0)
+	// Positional arguments (subcommand operands) may sit between flags
+	for {
+		if err := ps.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				// Help was requested; usage already printed by fs.Parse.
+				os.Exit(0)
+			}
+			// Other parsing errors: show usage with the error message.
+			usageAndExit(err.Error())
 		}
-		// Other parsing errors: show usage with the error message.
-		usageAndExit(err.Error())
+		if ps.NArg() == 0 {
+			break
+		}
+		opts.args = append(opts.args, ps.Arg(0))
+		args = ps.Args()[1:]
 	}
 
+	opts.noTUI = given["interactive"] && !opts.interactive
+
 	// Default/interactive determination is factored for testing
-	opts.interactive = determineInteractive(opts, len(args), term.IsTerminal(int(os.Stdout.Fd())))
+	opts.interactive = determineInteractive(opts, len(args), term.IsTerminal(int(os.Stdout.Fd()))) ||
+		selectOnTTY(opts, len(args), stdoutIsPipe(), term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stderr.Fd())))
 
 	if opts.showVersion {
 		fmt.Printf("fvf %s (commit %s, built %s)\n", version, commit, date)
@@ -251,9 +557,166 @@ func parseFlagsWithArgs(args []string) options {
 			}
 		}
 	}
+	for _, kv := range strings.Split(*pluginsRaw, ",") {
+		if kv = strings.TrimSpace(kv); kv == "" {
+			continue
+		}
+		typ, bin, ok := strings.Cut(kv, "=")
+		if !ok || typ == "" || bin == "" {
+			usageAndExit(fmt.Sprintf("-plugins entries must be type=binary, got %q", kv))
+		}
+		if opts.plugins == nil {
+			opts.plugins = make(map[string]string)
+		}
+		opts.plugins[typ] = bin
+	}
+	for _, kv := range strings.Split(*schemasRaw, ",") {
+		if kv = strings.TrimSpace(kv); kv == "" {
+			continue
+		}
+		prefix, file, ok := strings.Cut(kv, "=")
+		if !ok || strings.Trim(prefix, "/") == "" || file == "" {
+			usageAndExit(fmt.Sprintf("-schemas entries must be prefix=file, got %q", kv))
+		}
+		if opts.schemas == nil {
+			opts.schemas = make(map[string]string)
+		}
+		opts.schemas[prefix] = file
+	}
+	for _, kv := range strings.Split(*aliasesRaw, ",") {
+		if kv = strings.TrimSpace(kv); kv == "" {
+			continue
+		}
+		name, target, ok := strings.Cut(kv, "=")
+		if !ok || name == "" || strings.ContainsAny(name, "/@") || strings.Trim(target, "/") == "" {
+			usageAndExit(fmt.Sprintf("-aliases entries must be name=path, got %q", kv))
+		}
+		if opts.aliases == nil {
+			opts.aliases = make(map[string]string)
+		}
+		opts.aliases[name] = target
+	}
+	if err := expandAliases(&opts); err != nil {
+		usageAndExit(err.Error())
+	}
+	if *recipientsRaw != "" {
+		for _, r := range strings.Split(*recipientsRaw, ",") {
+			if r = strings.TrimSpace(r); r != "" {
+				opts.recipients = append(opts.recipients, r)
+			}
+		}
+	}
+	if *keysRaw != "" {
+		for _, k := range strings.Split(*keysRaw, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				opts.keys = append(opts.keys, k)
+			}
+		}
+	}
+
+	switch *outputRaw {
+	case "":
+	case "text":
+		opts.jsonOut = false
+	case "json":
+		opts.jsonOut = true
+	case "vault":
+		opts.jsonOut = false
+		opts.vaultOut = true
+	case "table", "csv":
+		opts.jsonOut = false
+		opts.tableOut = *outputRaw
+	default:
+		usageAndExit(fmt.Sprintf("-output must be 'text', 'json', 'vault', 'table' or 'csv', got %q", *outputRaw))
+	}
+	switch opts.sortBy {
+	case "path", "size", "keys":
+	default:
+		usageAndExit(fmt.Sprintf("-sort must be 'path', 'size' or 'keys', got %q", opts.sortBy))
+	}
+
+	switch opts.color {
+	case "auto", "always", "never":
+	default:
+		usageAndExit(fmt.Sprintf("-color must be 'auto', 'always' or 'never', got %q", opts.color))
+	}
+	switch opts.ci {
+	case "", "github":
+	case "gitlab":
+		usageAndExit("-ci gitlab: GitLab CI cannot mask values at runtime; store them as masked CI/CD variables instead")
+	default:
+		usageAndExit(fmt.Sprintf("-ci must be 'github', got %q", opts.ci))
+	}
+	switch opts.batchFormat {
+	case "json", "dotenv":
+	default:
+		usageAndExit(fmt.Sprintf("-batch-format must be 'json' or 'dotenv', got %q", opts.batchFormat))
+	}
+	switch opts.reportValues {
+	case "none", "mask", "hash":
+	default:
+		usageAndExit(fmt.Sprintf("-report-values must be 'none', 'mask' or 'hash', got %q", opts.reportValues))
+	}
+	switch opts.direnvMode {
+	case "export", "lazy", "lib":
+	default:
+		usageAndExit(fmt.Sprintf("-direnv-mode must be 'export', 'lazy' or 'lib', got %q", opts.direnvMode))
+	}
+	if opts.fetchTimeout < 0 || opts.fetchRetries < 0 {
+		usageAndExit("-fetch-timeout and -fetch-retries must not be negative")
+	}
+
+	level, err := parseLogLevel(*logLevel)
+	if err != nil {
+		usageAndExit(err.Error())
+	}
+	opts.logLevel = level
+
+	switch *printRaw {
+	case "value":
+	case "path":
+		opts.printPath = true
+	default:
+		usageAndExit(fmt.Sprintf("-print must be 'value' or 'path', got %q", *printRaw))
+	}
+
+	if opts.policyPane < 0 || opts.policyPane > 90 {
+		usageAndExit(fmt.Sprintf("-policies-pane must be between 0 and 90, got %d", opts.policyPane))
+	}
+
+	layout, err := ui.ParseStatusLayout(*statusRaw)
+	if err != nil {
+		usageAndExit(err.Error())
+	}
+	opts.statusLayout = layout
+
+	if opts.bindings, err = ui.ParseBindings(*bindRaw); err != nil {
+		usageAndExit("-bind: " + err.Error())
+	}
+
+	opts.transport.DisableKeepAlives = !*keepAlive
+	opts.transport.DisableHTTP2 = !*http2
+	if opts.transport.MaxIdleConnsPerHost < 0 {
+		usageAndExit("-http-max-idle-per-host must not be negative")
+	}
 
-	// Set fixed idle timeout regardless of flags
-	opts.idleExitAfter = 5 * time.Minute
+	if opts.refresh <= 0 {
+		usageAndExit(fmt.Sprintf("-refresh must be positive, got %s", opts.refresh))
+	}
+	if opts.concurrency < 1 {
+		usageAndExit(fmt.Sprintf("-concurrency must be at least 1, got %d", opts.concurrency))
+	}
+
+	if opts.cacheEntries < 0 || opts.cacheMB < 0 {
+		usageAndExit("-preview-cache and -preview-cache-mb must not be negative")
+	}
+	if opts.maxValueKB < 0 || opts.readsPerMinute < 0 {
+		usageAndExit("-max-value-kb and -max-reads-per-minute must not be negative")
+	}
+
+	if opts.idleExitAfter < 0 {
+		usageAndExit("-idle-exit must not be negative")
+	}
 
 	if strings.TrimSpace(opts.startPath) == "" {
 		return opts
@@ -277,6 +740,20 @@ func determineInteractive(opts options, argsLen int, stdoutIsTTY bool) bool {
 	return opts.interactive
 }
 
+// selectOnTTY reports whether the TUI should run on the terminal while stdout
+// is a pipe, fzf style, so that only the selection reaches the pipe, as in
+// SECRET=$(fvf -path kv/app/). Without a terminal (scripts, CI), with stdout
+// redirected to a file, and for fvf search the output stays non-interactive.
+func selectOnTTY(opts options, argsLen int, stdoutIsPipe, haveTTY bool) bool {
+	return argsLen > 0 && stdoutIsPipe && haveTTY && (opts.printValues || opts.jsonOut)
+}
+
+// stdoutIsPipe reports whether stdout is a pipe, not a terminal or a file.
+func stdoutIsPipe() bool {
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeNamedPipe != 0
+}
+
 func usageAndExit(msg string) {
 	if msg != "" {
 		fmt.Fprintln(os.Stderr, "Error:", msg)
@@ -285,12 +762,73 @@ func usageAndExit(msg string) {
 	fmt.Fprintf(os.Stderr, "Usage: fvf [-path <mount/inner/>] [flags]\n\n")
 	fmt.Fprintf(os.Stderr, "Note: Running with no flags starts Interactive mode by default.\n\n")
 	flag.PrintDefaults()
-	os.Exit(2)
+	osExit(2)
 }
 
 func fatal(err error) {
+	if logFile != nil {
+		slog.Error("exiting", "err", err)
+	}
+	if errors.Is(err, errInterrupted) {
+		fmt.Fprintln(os.Stderr, "Warning:", err)
+		exit(exitInterrupted)
+	}
 	fmt.Fprintln(os.Stderr, "Error:", err)
-	os.Exit(1)
+	exit(1)
+}
+
+// exit flushes any running profiles before exiting, since deferred calls do not run on os.Exit.
+func exit(code int) {
+	stopProfiling()
+	stopTracing()
+	sessionPlugins.stop()
+	osExit(code)
+}
+
+// osExit ends the process; tests replace it to observe usage errors.
+var osExit = os.Exit
+
+// stopProfiling finishes the profiles started by startProfiling; it is safe to call more than once.
+var stopProfiling = func() {}
+
+// startProfiling starts a CPU profile written to cpuPath and arranges for a heap
+// profile to be written to memPath when stopProfiling runs. Empty paths disable either.
+func startProfiling(cpuPath, memPath string) error {
+	var cpuFile *os.File
+	if cpuPath != "" {
+		f, err := os.Create(cpuPath)
+		if err != nil {
+			return fmt.Errorf("cpu profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return fmt.Errorf("cpu profile: %w", err)
+		}
+		cpuFile = f
+	}
+	var once sync.Once
+	stopProfiling = func() {
+		once.Do(func() {
+			if cpuFile != nil {
+				pprof.StopCPUProfile()
+				cpuFile.Close()
+			}
+			if memPath == "" {
+				return
+			}
+			f, err := os.Create(memPath)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error: memory profile:", err)
+				return
+			}
+			defer f.Close()
+			runtime.GC() // up-to-date allocation statistics
+			if err := pprof.WriteHeapProfile(f); err != nil {
+				fmt.Fprintln(os.Stderr, "Error: memory profile:", err)
+			}
+		})
+	}
+	return nil
 }
 
 // buildMatcher compiles a regexp pattern if provided, else returns nil.
@@ -305,8 +843,36 @@ func buildMatcher(pattern string) (*regexp.Regexp, error) {
 // In interactive mode we avoid fetching to keep the UI responsive.
 func valuesDuringWalk(opts options) bool {
 	// When producing JSON output in non-interactive mode, include values.
-	// Also include values when -values is explicitly requested.
-	return (opts.printValues || opts.jsonOut) && !opts.interactive
+	// Also include values when -values is explicitly requested, and for the
+	// sizes of the table/csv output or -sort size/keys.
+	return (opts.printValues || opts.jsonOut || opts.tableOut != "" || opts.sortBy == "size" || opts.sortBy == "keys") && !opts.interactive
+}
+
+// walkOptions bundles the walk settings derived from flags for one start path.
+func walkOptions(opts options, matcher *regexp.Regexp, kv2, withValues bool, onErr search.ErrorHandler) search.WalkOptions {
+	return search.WalkOptions{
+		KV2:         kv2,
+		MaxDepth:    opts.maxDepth,
+		MaxListKeys: opts.maxListKeys,
+		Filters:     search.Filters{NamePart: opts.namePart, Matcher: matcher},
+		WithValues:  withValues,
+		OnError:     onErr,
+		Read:        fetchPolicy(opts),
+	}
+}
+
+// requestTimeout bounds one interactive lookup (metadata, capabilities,
+// policies, mounts, writes): -fetch-timeout, or -timeout when that is 0.
+func requestTimeout(opts options) time.Duration {
+	if opts.fetchTimeout > 0 {
+		return opts.fetchTimeout
+	}
+	return opts.timeout
+}
+
+// fetchPolicy is the timeout and retry policy of single value reads.
+func fetchPolicy(opts options) search.RetryPolicy {
+	return search.RetryPolicy{Timeout: opts.fetchTimeout, Retries: opts.fetchRetries}
 }
 
 // decideKV2ForMountMeta determines kv2 based on CLI flags and mount metadata.
@@ -324,6 +890,10 @@ func decideKV2ForMountMeta(opts options, mountOptions map[string]string) bool {
 	return false
 }
 
+// sessionMounts caches mount tables for KV version detection, which otherwise
+// re-lists all mounts on every preview fetch.
+var sessionMounts = search.NewMountCache()
+
 // decideKV2ForPath uses DetectKV2 unless forced by flags.
 func decideKV2ForPath(ctx context.Context, client *vault.Client, start string, opts options) bool {
 	if opts.kv1 {
@@ -335,89 +905,236 @@ func decideKV2ForPath(ctx context.Context, client *vault.Client, start string, o
 	if client == nil {
 		return opts.kv2
 	}
-	if v, ok := search.DetectKV2(ctx, client, start); ok {
+	if v, ok := sessionMounts.DetectKV2(ctx, client, start); ok {
 		return v
 	}
 	return opts.kv2
 }
 
+// exitInterrupted is the exit status after Ctrl-C cut a non-interactive walk short.
+const exitInterrupted = 3
+
+// errInterrupted marks a walk stopped by Ctrl-C whose partial results were printed.
+var errInterrupted = errors.New("interrupted")
+
+// searchAndPrint walks and prints the matching items. Subtrees that fail are
+// skipped and summarised per mount on stderr; with -strict any failure makes
+// the run fail. Ctrl-C cancels the walk but still prints what was found so far
+// and returns errInterrupted; a second Ctrl-C while printing terminates as usual.
+func searchAndPrint(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) error {
+	sigCtx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	failures := ui.NewWalkErrorLog()
+	items, err := collectItems(sigCtx, client, opts, matcher, failures.Reporter())
+	interrupted := err != nil && sigCtx.Err() != nil && ctx.Err() == nil
+	stop()
+	if err != nil && !interrupted {
+		return err
+	}
+	if perr := printItems(items, opts); perr != nil {
+		return perr
+	}
+	if interrupted {
+		return fmt.Errorf("%w: output is partial (%d item(s) found before Ctrl-C)", errInterrupted, len(items))
+	}
+	return strictResult(opts, reportWalkFailures(failures.Errors()))
+}
+
+// reportWalkFailures prints the skipped paths grouped by mount to stderr and
+// returns the number of mounts with failures.
+func reportWalkFailures(errs []ui.WalkError) int {
+	if len(errs) == 0 {
+		return 0
+	}
+	byMount := make(map[string][]ui.WalkError)
+	var mounts []string
+	for _, e := range errs {
+		m, _ := search.SplitMount(e.Path)
+		if _, ok := byMount[m]; !ok {
+			mounts = append(mounts, m)
+		}
+		byMount[m] = append(byMount[m], e)
+	}
+	sort.Strings(mounts)
+	fmt.Fprintf(os.Stderr, "fvf: skipped %d path(s) in %d mount(s) due to errors:\n", len(errs), len(mounts))
+	for _, m := range mounts {
+		first := byMount[m][0]
+		fmt.Fprintf(os.Stderr, "  %s: %d path(s), first: %s: %v\n", m, len(byMount[m]), first.Path, first.Err)
+	}
+	return len(mounts)
+}
+
+// strictResult turns walk failures into an error under -strict.
+func strictResult(opts options, failedMounts int) error {
+	if opts.strict && failedMounts > 0 {
+		return fmt.Errorf("-strict: %d mount(s) had failures", failedMounts)
+	}
+	return nil
+}
+
 // collectItems routes to the correct collection strategy.
-func collectItems(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) ([]search.FoundItem, error) {
+func collectItems(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp, onErr search.ErrorHandler) ([]search.FoundItem, error) {
 	if strings.TrimSpace(opts.startPath) == "" && len(opts.paths) == 0 {
-		return collectAcrossAllMounts(ctx, client, opts, matcher)
+		return collectAcrossAllMounts(ctx, client, opts, matcher, onErr)
 	}
 	if len(opts.paths) > 0 {
-		return collectForPaths(ctx, client, opts, matcher)
+		return collectForPaths(ctx, client, opts, matcher, onErr)
 	}
-	return collectForSinglePath(ctx, client, opts, matcher)
+	return collectForSinglePath(ctx, client, opts, matcher, onErr)
 }
 
-func collectAcrossAllMounts(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) ([]search.FoundItem, error) {
+func collectAcrossAllMounts(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp, onErr search.ErrorHandler) ([]search.FoundItem, error) {
 	mounts, err := search.ListMountsWithFallback(ctx, client)
 	if err != nil {
-		var respErr *vault.ResponseError
-		if errors.As(err, &respErr) && respErr.StatusCode == 403 {
+		if errors.Is(err, search.ErrPermissionDenied) {
 			printGreenHint("fvf: permission denied listing mounts (sys/mounts). Fallback to sys/internal/ui/mounts also failed. Use -path to target a known mount. If your mount is KV v1, add -kv1.")
 			fmt.Fprintln(os.Stderr, "Vault error:", err)
-			os.Exit(1)
+			exit(1)
 		}
 		printGreenHint("fvf: cannot list mounts (provide -path to search a known mount). If your mount is KV v1, add -kv1.")
 		fmt.Fprintln(os.Stderr, "Vault/Client error:", err)
-		os.Exit(1)
+		exit(1)
 	}
-	var items []search.FoundItem
-	for mntPath, m := range mounts {
-		if m.Type != "kv" {
-			continue
+	kv := kvMounts(mounts)
+	roots := append(kv, pluginMounts(client, mounts)...)
+	results := make([][]search.FoundItem, len(roots))
+	err = forEachLimit(opts.concurrency, len(roots), func(i int) error {
+		var logical search.LogicalAPI = client.Logical()
+		kv2 := decideKV2ForMountMeta(opts, mounts[roots[i]+"/"].Options)
+		if i >= len(kv) {
+			logical, kv2 = logicalFor(ctx, client, roots[i], opts)
 		}
-		mnt := strings.TrimSuffix(mntPath, "/")
-		kv2 := decideKV2ForMountMeta(opts, m.Options)
-		sub, err := search.WalkVault(ctx, client.Logical(), mnt, kv2, opts.maxDepth, matcher, valuesDuringWalk(opts))
+		sub, err := search.Walk(ctx, logical, roots[i], walkOptions(opts, matcher, kv2, valuesDuringWalk(opts), onErr))
+		results[i] = sub
 		if err != nil {
-			return nil, fmt.Errorf("error walking mount %s: %w", mnt, err)
+			return fmt.Errorf("error walking mount %s: %w", roots[i], err)
 		}
+		return nil
+	})
+	// On error the items found so far are still returned (see searchAndPrint)
+	var items []search.FoundItem
+	for _, sub := range results {
 		items = append(items, sub...)
 	}
-	return items, nil
+	return items, err
+}
+
+// kvMounts returns the KV mount paths of a mount table, without trailing slash, sorted.
+func kvMounts(mounts map[string]*vault.MountOutput) []string {
+	var out []string
+	for mntPath, m := range mounts {
+		if m.Type == "kv" {
+			out = append(out, strings.TrimSuffix(mntPath, "/"))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// forEachLimit runs fn for 0..n-1 with at most limit calls in flight (limit < 1
+// means one). After the first error no further calls start; that error is returned.
+func forEachLimit(limit, n int, fn func(i int) error) error {
+	if limit < 1 {
+		limit = 1
+	}
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, limit)
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(i); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	return firstErr
 }
 
-func collectForPaths(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) ([]search.FoundItem, error) {
+func collectForPaths(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp, onErr search.ErrorHandler) ([]search.FoundItem, error) {
 	var items []search.FoundItem
 	for _, p := range opts.paths {
-		kv2 := decideKV2ForPath(ctx, client, p, opts)
-		sub, err := search.WalkVault(ctx, client.Logical(), p, kv2, opts.maxDepth, matcher, valuesDuringWalk(opts))
+		logical, kv2 := logicalFor(ctx, client, p, opts)
+		sub, err := search.Walk(ctx, logical, p, walkOptions(opts, matcher, kv2, valuesDuringWalk(opts), onErr))
+		items = append(items, sub...)
 		if err != nil {
-			return nil, fmt.Errorf("error walking path %s: %w", p, err)
+			return items, fmt.Errorf("error walking path %s: %w", p, err)
 		}
-		items = append(items, sub...)
 	}
 	return items, nil
 }
 
-func collectForSinglePath(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp) ([]search.FoundItem, error) {
-	kv2 := decideKV2ForPath(ctx, client, opts.startPath, opts)
-	return search.WalkVault(ctx, client.Logical(), opts.startPath, kv2, opts.maxDepth, matcher, valuesDuringWalk(opts))
+func collectForSinglePath(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp, onErr search.ErrorHandler) ([]search.FoundItem, error) {
+	logical, kv2 := logicalFor(ctx, client, opts.startPath, opts)
+	return search.Walk(ctx, logical, opts.startPath, walkOptions(opts, matcher, kv2, valuesDuringWalk(opts), onErr))
 }
 
 // (legacy non-stream interactive runner removed; interactive now streams by default)
 
 func runInteractiveStream(opts options, client *vault.Client, matcher *regexp.Regexp) error {
-	// Build the same lazy fetcher used by non-streaming interactive mode
-	fetcher := func(p string) (string, error) {
-		perReqTimeout := 15 * time.Second
-		attempt := func() (interface{}, error) {
-			reqCtx, cancel := context.WithTimeout(context.Background(), perReqTimeout)
-			defer cancel()
-			mnt, inner := search.SplitMount(p)
-			kv2 := decideKV2ForPath(reqCtx, client, mnt, opts)
-			return search.ReadSecret(reqCtx, client.Logical(), mnt, inner, kv2)
+	// Stderr belongs to the TUI from here on; only a -log-file keeps logging
+	restoreLog := quietStderrLog()
+	defer restoreLog()
+
+	// -session: a saved session brings back its namespace and, unless -path
+	// or -paths is given, the roots of its active tab
+	var (
+		session     *ui.Session
+		saveSession func(ui.Session)
+	)
+	if opts.sessionFile != "" {
+		s, err := loadSession(opts.sessionFile)
+		if err != nil {
+			return err
 		}
-		val, err := attempt()
+		if s != nil && s.Namespace != "" && client.Namespace() == "" {
+			client.SetNamespace(strings.TrimSuffix(s.Namespace, "/"))
+		}
+		session, saveSession = s, sessionSaver(opts.sessionFile)
+	}
+
+	// readValueContext reads one secret for the preview or the printed output
+	// under -fetch-timeout and -fetch-retries, with the redaction rules
+	// applied; it gives up as soon as ctx is cancelled
+	readValueContext := func(ctx context.Context, p string) (interface{}, error) {
+		var val interface{}
+		err := fetchPolicy(opts).Do(ctx, func(reqCtx context.Context) error {
+			mnt, inner := search.SplitMount(p)
+			logical, kv2 := logicalFor(reqCtx, client, mnt, opts)
+			var err error
+			val, err = search.ReadSecret(reqCtx, logical, mnt, inner, kv2)
+			return err
+		})
 		if err != nil {
-			if errors.Is(err, context.DeadlineExceeded) || strings.Contains(err.Error(), "context deadline exceeded") {
-				val, err = attempt()
-			}
+			return nil, err
 		}
+		return redactValue(val), nil
+	}
+	readValue := func(p string) (interface{}, error) {
+		return readValueContext(context.Background(), p)
+	}
+	// The preview reads values in the background and cancels the reads the
+	// cursor has moved away from
+	previewFetcher := func(ctx context.Context, p string) (string, error) {
+		val, err := readValueContext(ctx, p)
 		if err != nil {
 			return "", err
 		}
@@ -430,6 +1147,137 @@ func runInteractiveStream(opts options, client *vault.Client, matcher *regexp.Re
 		// Otherwise return a human-friendly raw representation where strings are unquoted.
 		return formatValueRaw(val, true), nil
 	}
+	// Build the same lazy fetcher used by non-streaming interactive mode
+	fetcher := func(p string) (string, error) {
+		return previewFetcher(context.Background(), p)
+	}
+
+	// Enter with a multi-selection prints every selected secret as one document
+	batchFetcher := func(paths []string) (string, error) {
+		return batchDocument(paths, readValue, opts.concurrency, opts.batchFormat)
+	}
+
+	// KV v2 metadata for the preview header; KV v1 mounts have none
+	metadataFetcher := func(p string) (*search.SecretMetadata, error) {
+		reqCtx, cancel := context.WithTimeout(context.Background(), requestTimeout(opts))
+		defer cancel()
+		mnt, inner := search.SplitMount(p)
+		if _, kv2 := logicalFor(reqCtx, client, mnt, opts); !kv2 {
+			return nil, nil
+		}
+		return search.ReadMetadata(reqCtx, client.Logical(), mnt, inner)
+	}
+
+	// Alt-e writes a report on the results for tickets and access reviews
+	reporter := func(file, query string, paths []string) error {
+		h := reportHeader{
+			Addr:      client.Address(),
+			Namespace: search.NormalizeNamespace(client.Namespace()),
+			Query:     query,
+			Generated: time.Now(),
+		}
+		var key []byte
+		if opts.reportValues == "hash" {
+			var err error
+			if h.Salt, key, err = newReportKey(opts.reportKey); err != nil {
+				return err
+			}
+		}
+		entries := buildReport(paths, metadataFetcher, readValue, opts.concurrency, opts.reportValues, key)
+		return writeReport(file, h, entries)
+	}
+
+	// Capabilities of the token on the API path backing a secret (data path on KV v2)
+	capabilityFetcher := func(paths []string) (map[string][]string, error) {
+		reqCtx, cancel := context.WithTimeout(context.Background(), requestTimeout(opts))
+		defer cancel()
+		apiPaths := make([]string, len(paths))
+		for i, p := range paths {
+			mnt, inner := search.SplitMount(p)
+			apiPaths[i] = search.ReadAPIPath(mnt, inner, decideKV2ForPath(reqCtx, client, mnt, opts))
+		}
+		caps, err := search.CapabilitiesSelf(reqCtx, client.Logical(), apiPaths)
+		if err != nil {
+			return nil, err
+		}
+		out := make(map[string][]string, len(paths))
+		for i, p := range paths {
+			out[p] = caps[apiPaths[i]]
+		}
+		return out, nil
+	}
+
+	// Copy (or move) secrets to another prefix, possibly across mounts and KV
+	// versions: checked like cp/mv (-on-conflict, fail by default), written
+	// with check-and-set on KV v2
+	copier := func(srcs, dsts []string, move bool) (*ui.TransferPlan, error) {
+		reqCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		steps := make([]copyStep, len(srcs))
+		for i := range srcs {
+			steps[i] = copyStep{Src: srcs[i], Dst: dsts[i]}
+		}
+		if err := planCopySteps(reqCtx, client, opts, steps, move); err != nil {
+			return nil, err
+		}
+		cmd := "cp"
+		if move {
+			cmd = "mv"
+		}
+		plan := &ui.TransferPlan{Steps: transferSteps(steps)}
+		plan.Run = func() []ui.TransferStep {
+			reqCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			defer cancel()
+			// The TUI owns the terminal: conflicts are reported per step instead
+			if _, err := writeCopySteps(reqCtx, client, opts, io.Discard, cmd, steps, move); err != nil {
+				// Timed out: the steps not reached are reported as not written
+				for i := range steps {
+					if !steps[i].written && steps[i].Error == "" && steps[i].Action != "skip" {
+						steps[i].Error = "not written: " + err.Error()
+					}
+				}
+			}
+			return transferSteps(steps)
+		}
+		return plan, nil
+	}
+
+	// ACL policy rules for the policy drill-down
+	policyReader := func(name string) (string, error) {
+		reqCtx, cancel := context.WithTimeout(context.Background(), requestTimeout(opts))
+		defer cancel()
+		return client.Sys().GetPolicyWithContext(reqCtx, name)
+	}
+
+	// Wrapped share token for the current secret (Ctrl-W)
+	sharer := func(p string) (string, time.Duration, error) {
+		reqCtx, cancel := context.WithTimeout(context.Background(), requestTimeout(opts))
+		defer cancel()
+		wi, err := wrapSecret(reqCtx, client, p, opts)
+		if err != nil {
+			return "", 0, err
+		}
+		return wi.Token, time.Duration(wi.TTL) * time.Second, nil
+	}
+
+	// Roll a secret back to an earlier version from the version browser (Ctrl-V)
+	rollbacker := func(p string, version int) (int, error) {
+		reqCtx, cancel := context.WithTimeout(context.Background(), requestTimeout(opts))
+		defer cancel()
+		return rollbackSecret(reqCtx, client, p, version, opts)
+	}
+
+	// Edit custom_metadata from the TUI (Ctrl-D)
+	metaWriter := func(p string, cm map[string]string) error {
+		reqCtx, cancel := context.WithTimeout(context.Background(), requestTimeout(opts))
+		defer cancel()
+		return writeCustomMetadata(reqCtx, client, p, cm, opts)
+	}
+
+	// Read-only mode leaves the TUI's write actions (Ctrl-K, Ctrl-V rollback, Ctrl-D) unwired
+	if opts.readOnly {
+		copier, rollbacker, metaWriter = nil, nil, nil
+	}
 
 	// Policy fetcher for the UI
 	policyFetcher := func(p string) ([]string, error) {
@@ -462,66 +1310,112 @@ func runInteractiveStream(opts options, client *vault.Client, matcher *regexp.Re
 		return policies, nil
 	}
 
-	// Stream items into the UI
-	itemsCh := make(chan search.FoundItem, 256)
-	errCh := make(chan error, 1)
-
-	// Context to allow cancellation when UI exits (no deadline for interactive session)
+	// Stream items into the UI. The walker is restartable so the UI can switch roots.
 	ctx, cancel := context.WithCancel(context.Background())
-	go func() {
-		defer close(itemsCh)
-		defer close(errCh)
-
-		// Helper to walk a single start path
-		walkOne := func(start string) error {
-			kv2 := decideKV2ForPath(ctx, client, start, opts)
-			return search.WalkVaultStream(ctx, client.Logical(), start, kv2, opts.maxDepth, matcher, false /*withValues*/, itemsCh)
+	var (
+		walkMu     sync.Mutex
+		walkCancel context.CancelFunc = func() {}
+		walkErrCh  <-chan error
+	)
+	// Subtrees that fail to list or read are skipped and shown in the UI (Ctrl-E)
+	walkErrs := ui.NewWalkErrorLog()
+	startWalk := func(roots []string) <-chan search.FoundItem {
+		walkMu.Lock()
+		defer walkMu.Unlock()
+		walkCancel()
+		wctx, wcancel := context.WithCancel(ctx)
+		walkCancel = wcancel
+		itemsCh := make(chan search.FoundItem, 256)
+		errCh := make(chan error, 1)
+		walkErrCh = errCh
+		go streamRoots(wctx, client, opts, matcher, roots, itemsCh, errCh, walkErrs.Reporter())
+		return itemsCh
+	}
+	initialRoots := opts.paths
+	if len(initialRoots) == 0 && strings.TrimSpace(opts.startPath) != "" {
+		initialRoots = []string{opts.startPath}
+	}
+	if len(initialRoots) == 0 && session != nil {
+		initialRoots = session.ActiveRoots()
+	}
+	// With -pick-mounts the first walk starts once the user has chosen mounts.
+	// In picker mode the candidates are fixed and the walk controls are disabled.
+	var itemsCh <-chan search.FoundItem
+	restart := ui.StreamStarter(startWalk)
+	switch {
+	case opts.pickerPaths != nil:
+		itemsCh = pickerItems(opts.pickerPaths, search.Filters{NamePart: opts.namePart, Matcher: matcher})
+		restart = nil
+	case opts.daemon != "" && !opts.pickMounts:
+		// Instant startup from fvf serve; Ctrl-G and Ctrl-O still walk Vault directly
+		dctx, dcancel := context.WithTimeout(ctx, 5*time.Second)
+		paths, err := daemonPaths(dctx, opts.daemon, opts.serveToken)
+		dcancel()
+		if err != nil {
+			slog.Warn("daemon unavailable, walking Vault instead", "daemon", opts.daemon, "err", err)
+			itemsCh = startWalk(initialRoots)
+			break
 		}
-
-		// Route by input, mirroring collectItems()
-		var err error
-		if strings.TrimSpace(opts.startPath) == "" && len(opts.paths) == 0 {
-			var mounts map[string]*vault.MountOutput
-			mounts, err = search.ListMountsWithFallback(ctx, client)
-			if err != nil {
-				errCh <- err
-				return
-			}
-			for mntPath, m := range mounts {
-				if m.Type != "kv" {
-					continue
-				}
-				mnt := strings.TrimSuffix(mntPath, "/")
-				kv2 := decideKV2ForMountMeta(opts, m.Options)
-				if e := search.WalkVaultStream(ctx, client.Logical(), mnt, kv2, opts.maxDepth, matcher, false, itemsCh); e != nil {
-					err = e
-					break
-				}
+		itemsCh = pickerItems(underRoots(paths, initialRoots), search.Filters{NamePart: opts.namePart, Matcher: matcher})
+	case !opts.pickMounts:
+		itemsCh = startWalk(initialRoots)
+	}
+	mountLister := func() ([]ui.MountInfo, error) {
+		mctx, mcancel := context.WithTimeout(ctx, requestTimeout(opts))
+		defer mcancel()
+		mounts, err := search.ListMountsWithFallback(mctx, client)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]ui.MountInfo, 0, len(mounts))
+		for p, m := range mounts {
+			if _, ok := sessionPlugins.get(client, m.Type); m.Type != "kv" && !ok {
+				continue
 			}
-		} else if len(opts.paths) > 0 {
-			for _, p := range opts.paths {
-				if e := walkOne(p); e != nil {
-					err = e
-					break
+			out = append(out, ui.MountInfo{Path: p, Type: m.Type, Version: m.Options["version"], Secrets: -1})
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+		// The picker opens before any walk, so counts come from the index
+		paths := make([]string, len(out))
+		for i, m := range out {
+			paths[i] = m.Path
+		}
+		if counts, _, ok := indexedSecrets(opts, client, paths); ok {
+			for i := range out {
+				if n, ok := counts[out[i].Path]; ok {
+					out[i].Secrets = n
 				}
 			}
-		} else {
-			err = walkOne(opts.startPath)
 		}
-		errCh <- err
-	}()
+		return out, nil
+	}
+	mountInspector := func(p string) (*ui.MountDetails, error) {
+		mctx, mcancel := context.WithTimeout(ctx, requestTimeout(opts))
+		defer mcancel()
+		return inspectMount(mctx, client, opts, p)
+	}
+
+	// KV version badge of the preview header, flagging overrides that disagree with the mount
+	kvVersion := func(p string) (int, int) {
+		kctx, kcancel := context.WithTimeout(ctx, requestTimeout(opts))
+		defer kcancel()
+		return kvVersions(kctx, client, opts, p)
+	}
 
 	lastActivity := time.Now()
 
-	// Build StatusProvider for the UI status bar
-	// Right: version, Middle: server, Left: token TTL and Idle timer
+	// Build the status bar segments; the layout (-status-bar) decides where they go
+	// Default: Left: token TTL and Idle timer, Middle: server, Right: version
 	addr := client.Address()
 	versionStr := fmt.Sprintf("fvf %s", version)
 	var (
-		lastTTLDisp string
-		lastTTLAt   time.Time
+		lastTTLDisp  string
+		lastTTLAt    time.Time
+		lastIdentity string
+		entityID     string
+		entityName   string
 	)
-	statusProvider := func() (string, string, string) {
+	statusSegments := func() map[string]string {
 		// TTL refresh every 10s; UI redraws periodically so no keypress needed
 		if time.Since(lastTTLAt) > 10*time.Second {
 			ctxTTL, cancelTTL := context.WithTimeout(context.Background(), 2*time.Second)
@@ -557,23 +1451,53 @@ func runInteractiveStream(opts options, client *vault.Client, matcher *regexp.Re
 				} else {
 					lastTTLDisp = "TTL: " + formatTTLHuman(ttlSeconds)
 				}
+				// The entity name is looked up once per entity, if the token may read it
+				if id, _ := sec.Data["entity_id"].(string); id != entityID {
+					entityID, entityName = id, lookupEntityName(ctxTTL, client, id)
+				}
+				lastIdentity = tokenIdentity(sec.Data, entityName)
 			} else {
 				lastTTLDisp = "TTL: ?"
 			}
 			cancelTTL()
 			lastTTLAt = time.Now()
 		}
-		idle := time.Since(lastActivity)
-		// Cap displayed idle at the threshold; internal timer continues to grow
-		shown := idle
-		if shown > opts.idleExitAfter {
-			shown = opts.idleExitAfter
+		seg := map[string]string{
+			"ttl":      lastTTLDisp,
+			"idle":     "Idle: " + formatIdle(time.Since(lastActivity), opts.idleExitAfter),
+			"addr":     addr,
+			"version":  versionStr,
+			"identity": lastIdentity,
+		}
+		if opts.match != "" || opts.namePart != "" {
+			var m []string
+			if opts.match != "" {
+				m = append(m, "match: "+opts.match)
+			}
+			if opts.namePart != "" {
+				m = append(m, "name: "+opts.namePart)
+			}
+			seg["match"] = strings.Join(m, " ")
 		}
-		idleDisp := formatTTLHuman(int64(shown.Seconds())) + "/" + formatTTLHuman(int64(opts.idleExitAfter.Seconds()))
-		left := lastTTLDisp + " | Idle: " + idleDisp
-		middle := addr
-		right := versionStr
-		return left, middle, right
+		return seg
+	}
+
+	// Ctrl-T: renew the token and refresh the TTL segment immediately.
+	// Runs on the UI goroutine, like statusSegments, so the cached TTL needs no lock.
+	renewToken := func() (time.Duration, error) {
+		rctx, rcancel := context.WithTimeout(ctx, 10*time.Second)
+		defer rcancel()
+		sec, err := client.Auth().Token().RenewSelfWithContext(rctx, 0)
+		if err != nil {
+			return 0, err
+		}
+		if sec == nil || sec.Auth == nil {
+			return 0, errors.New("renew-self returned no auth data")
+		}
+		ttl := int64(sec.Auth.LeaseDuration)
+		lastTTLDisp = "TTL: " + formatTTLHuman(ttl)
+		lastTTLAt = time.Now()
+		return time.Duration(ttl) * time.Second, nil
 	}
 
 	// Idle + token-expired auto-exit wiring
@@ -638,7 +1562,7 @@ func runInteractiveStream(opts options, client *vault.Client, matcher *regexp.Re
 				}
 				cancelTTL()
 
-				if expired && time.Since(lastActivity) >= opts.idleExitAfter {
+				if expired && opts.idleExitAfter > 0 && time.Since(lastActivity) >= opts.idleExitAfter {
 					if !signaled {
 						// Signal quit and provide reason; the caller will print after UI teardown
 						msg := "fvf: Vault token expired and no activity — exiting"
@@ -655,7 +1579,56 @@ func runInteractiveStream(opts options, client *vault.Client, matcher *regexp.Re
 	}()
 
 	// Start UI; preview enabled if -values or -json
-	uiErr := ui.RunStream(itemsCh, opts.printValues || opts.jsonOut, opts.jsonOut, fetcher, policyFetcher, statusProvider, quitCh, activityCh)
+	uiErr := ui.RunStream(itemsCh, opts.printValues || opts.jsonOut, opts.jsonOut, fetcher, policyFetcher, nil, quitCh, activityCh, ui.StreamOptions{
+		Roots:      initialRoots,
+		Restart:    restart,
+		Mounts:     mountLister,
+		MountInfo:  mountInspector,
+		KVVersion:  kvVersion,
+		Warning:    strings.Join(checkKVOverrides(ctx, client, opts), "; "),
+		PickMounts: opts.pickMounts,
+		Namespace:  search.NormalizeNamespace(client.Namespace()),
+		Namespaces: func(parent string) ([]string, error) {
+			nctx, ncancel := context.WithTimeout(ctx, requestTimeout(opts))
+			defer ncancel()
+			return search.ListNamespaces(nctx, client, parent)
+		},
+		SetNamespace:      func(ns string) { client.SetNamespace(strings.TrimSuffix(ns, "/")) },
+		Metadata:          metadataFetcher,
+		Capabilities:      capabilityFetcher,
+		RenewToken:        renewToken,
+		PrintPath:         opts.printPath,
+		GroupByMount:      opts.groupByMount,
+		RelativePaths:     opts.relative,
+		LineNumbers:       opts.lineNumbers,
+		ConfirmPrint:      opts.confirmPrint,
+		Copy:              copier,
+		FetchContext:      previewFetcher,
+		FetchBatch:        batchFetcher,
+		Report:            reporter,
+		ReportReadsValues: opts.reportValues != "none",
+		ReadOnly:          opts.readOnly,
+		Aliases:           aliasNames(opts.aliases),
+		ExpandRoot:        func(root string) (string, error) { return expandAlias(root, opts.aliases) },
+		Policy:            policyReader,
+		Audit:             auditFetcher(opts.auditSource),
+		Bindings:          opts.bindings,
+		Share:             sharer,
+		Rollback:          rollbacker,
+		WriteMetadata:     metaWriter,
+		PolicyPane:        uiPolicyPane(opts.policyPane),
+		LockAfter:         opts.lockAfter,
+		StatusSegments:    statusSegments,
+		StatusLayout:      opts.statusLayout,
+		WalkErrors:        walkErrs,
+		Session:           session,
+		SaveSession:       saveSession,
+
+		PreviewCacheEntries: opts.cacheEntries,
+		PreviewCacheBytes:   opts.cacheMB << 20,
+		MaxValueBytes:       opts.maxValueKB << 10,
+		MaxReadsPerMinute:   opts.readsPerMinute,
+	})
 	// Ensure we stop walking
 	cancel()
 	// Prefer UI error if any, else walker error (non-blocking read if goroutine still running)
@@ -668,6 +1641,15 @@ func runInteractiveStream(opts options, client *vault.Client, matcher *regexp.Re
 		printGreenHint(msg)
 	default:
 	}
+	if err := strictResult(opts, reportWalkFailures(walkErrs.Errors())); err != nil {
+		return err
+	}
+	walkMu.Lock()
+	errCh := walkErrCh
+	walkMu.Unlock()
+	if errCh == nil {
+		return nil
+	}
 	select {
 	case e := <-errCh:
 		return e
@@ -676,18 +1658,228 @@ func runInteractiveStream(opts options, client *vault.Client, matcher *regexp.Re
 	}
 }
 
+// formatIdle renders the idle timer as "idle/limit", capping the shown idle at
+// the limit; with no limit (0) only the idle time is shown.
+func formatIdle(idle, limit time.Duration) string {
+	if limit <= 0 {
+		return formatTTLHuman(int64(idle.Seconds()))
+	}
+	if idle > limit {
+		idle = limit
+	}
+	return formatTTLHuman(int64(idle.Seconds())) + "/" + formatTTLHuman(int64(limit.Seconds()))
+}
+
+// tokenIdentity renders whose token is in use for the "identity" status
+// segment, from a lookup-self response: its display name, plus the identity
+// entity's name when known and different, e.g. "as: ldap-alice (alice)".
+func tokenIdentity(lookup map[string]interface{}, entity string) string {
+	name, _ := lookup["display_name"].(string)
+	switch {
+	case name == "" && entity == "":
+		return ""
+	case name == "":
+		return "as: " + entity
+	case entity == "" || entity == name:
+		return "as: " + name
+	}
+	return "as: " + name + " (" + entity + ")"
+}
+
+// lookupEntityName returns the name of the identity entity id, or "" when
+// there is none or the token may not read identity/entity/id/<id>.
+func lookupEntityName(ctx context.Context, client *vault.Client, id string) string {
+	if id == "" {
+		return ""
+	}
+	sec, err := client.Logical().ReadWithContext(ctx, "identity/entity/id/"+id)
+	if err != nil || sec == nil {
+		return ""
+	}
+	name, _ := sec.Data["name"].(string)
+	return name
+}
+
+// uiPolicyPane maps the -policies-pane percentage to the UI setting, where 0 means the default and negative hides.
+func uiPolicyPane(pct int) int {
+	if pct == 0 {
+		return -1
+	}
+	return pct
+}
+
+// stdinIsPipe reports whether stdin is a pipe or a redirected file (not a terminal or /dev/null).
+func stdinIsPipe() bool {
+	fi, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice == 0
+}
+
+// cmdlineFlags returns the names of the flags given in args, which are not
+// parsed yet, so settings from the environment or config files do not count.
+func cmdlineFlags(args []string) map[string]bool {
+	out := make(map[string]bool)
+	for _, a := range args {
+		if a == "--" {
+			break
+		}
+		if !strings.HasPrefix(a, "-") || a == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(strings.TrimLeft(a, "-"), "=")
+		out[name] = true
+	}
+	return out
+}
+
+// printPickerPaths prints the picker paths kept by -name/-match, one per
+// line (or as JSON with -json), for a picker that does not open the TUI.
+func printPickerPaths(paths []string, opts options, matcher *regexp.Regexp) error {
+	var items []search.FoundItem
+	for it := range pickerItems(paths, search.Filters{NamePart: opts.namePart, Matcher: matcher}) {
+		items = append(items, it)
+	}
+	opts.printValues = false
+	return printItems(items, opts)
+}
+
+// readPickerPaths reads candidate paths from name ("-" for stdin).
+func readPickerPaths(name string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	return parsePickerPaths(r)
+}
+
+// parsePickerPaths returns one path per non-empty line, trimmed and de-duplicated in input order.
+func parsePickerPaths(r io.Reader) ([]string, error) {
+	seen := make(map[string]bool)
+	out := []string{}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		p := strings.TrimSpace(sc.Text())
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		out = append(out, p)
+	}
+	return out, sc.Err()
+}
+
+// pickerItems streams the given paths as found items, honouring -match/-name.
+func pickerItems(paths []string, filters search.Filters) <-chan search.FoundItem {
+	ch := make(chan search.FoundItem, len(paths))
+	for _, p := range paths {
+		if filters.Match(path.Base(p), p) {
+			ch <- search.FoundItem{Path: p}
+		}
+	}
+	close(ch)
+	return ch
+}
+
+// streamRoots walks the given start paths (or all KV mounts when roots is empty),
+// sending items to itemsCh. Failing subtrees are passed to onErr, which decides
+// whether the walk goes on (see search.ErrorHandler). It closes itemsCh when
+// done and reports the error that stopped the walk, if any, on errCh.
+func streamRoots(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp, roots []string, itemsCh chan<- search.FoundItem, errCh chan<- error, onErr search.ErrorHandler) {
+	defer close(itemsCh)
+	defer close(errCh)
+
+	// Helper to walk a single start path
+	walkOne := func(start string) error {
+		logical, kv2 := logicalFor(ctx, client, start, opts)
+		return search.WalkStream(ctx, logical, start, walkOptions(opts, matcher, kv2, false /*withValues*/, onErr), itemsCh)
+	}
+
+	// Route by input, mirroring collectItems()
+	var err error
+	if len(roots) == 0 {
+		var mounts map[string]*vault.MountOutput
+		mounts, err = sessionMounts.Mounts(ctx, client)
+		if err != nil {
+			if onErr != nil && ctx.Err() == nil {
+				err = onErr("sys/mounts", err)
+			}
+			errCh <- err
+			return
+		}
+		// Independent mounts are walked concurrently, bounded by -concurrency
+		kv := kvMounts(mounts)
+		roots := append(kv, pluginMounts(client, mounts)...)
+		err = forEachLimit(opts.concurrency, len(roots), func(i int) error {
+			if i >= len(kv) {
+				return walkOne(roots[i])
+			}
+			kv2 := decideKV2ForMountMeta(opts, mounts[kv[i]+"/"].Options)
+			return search.WalkStream(ctx, client.Logical(), kv[i], walkOptions(opts, matcher, kv2, false, onErr), itemsCh)
+		})
+	} else {
+		for _, p := range roots {
+			if e := walkOne(p); e != nil {
+				err = e
+				break
+			}
+		}
+	}
+	errCh <- err
+}
+
+// walkPaths collects the paths below roots (all KV mounts when empty) with the
+// stream walker, skipping failing subtrees, which are returned alongside.
+func walkPaths(ctx context.Context, client *vault.Client, opts options, matcher *regexp.Regexp, roots []string) ([]string, []ui.WalkError, error) {
+	itemsCh := make(chan search.FoundItem, 256)
+	errCh := make(chan error, 1)
+	failures := ui.NewWalkErrorLog()
+	go streamRoots(ctx, client, opts, matcher, roots, itemsCh, errCh, failures.Reporter())
+	var paths []string
+	for it := range itemsCh {
+		paths = append(paths, it.Path)
+	}
+	if err := <-errCh; err != nil {
+		return nil, nil, err
+	}
+	return paths, failures.Errors(), nil
+}
+
 func printItems(items []search.FoundItem, opts options) error {
+	sortItems(items, opts.sortBy)
+	for i := range items {
+		items[i].Value = redactValue(items[i].Value)
+		maskForCI(items[i].Value)
+	}
 	if opts.jsonOut {
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
 		return enc.Encode(items)
 	}
+	if opts.tableOut != "" {
+		return printSizeTable(os.Stdout, items, opts.tableOut == "csv")
+	}
+	var roots []string
+	if opts.relative {
+		roots = opts.paths
+		if len(roots) == 0 && strings.TrimSpace(opts.startPath) != "" {
+			roots = []string{opts.startPath}
+		}
+	}
 	for _, it := range items {
+		p := ui.RelativePath(it.Path, roots)
 		if opts.printValues {
 			// Print values in raw form (unquoted strings). For maps, print concise k: v pairs.
-			fmt.Printf("%s = %s\n", it.Path, formatValueRaw(it.Value, false))
+			fmt.Printf("%s = %s\n", p, formatValueRaw(it.Value, false))
 		} else {
-			fmt.Println(it.Path)
+			fmt.Println(p)
 		}
 	}
 	return nil
@@ -792,10 +1984,25 @@ func scalarToString(v interface{}) string {
 	return fmt.Sprintf("%v", v)
 }
 
-// printGreenHint prints a friendly fvf hint message in green when stderr is a TTY,
-// and as plain text otherwise. The Vault/raw error should be printed separately.
+// colorMode is the -color setting: auto, always or never.
+var colorMode = "auto"
+
+// colorEnabled reports whether colored output may be written to f. In auto
+// mode that requires a terminal and no NO_COLOR in the environment.
+func colorEnabled(f *os.File) bool {
+	switch colorMode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	return os.Getenv("NO_COLOR") == "" && term.IsTerminal(int(f.Fd()))
+}
+
+// printGreenHint prints a friendly fvf hint message in green when -color allows
+// it, and as plain text otherwise. The Vault/raw error should be printed separately.
 func printGreenHint(msg string) {
-	if term.IsTerminal(int(os.Stderr.Fd())) {
+	if colorEnabled(os.Stderr) {
 		// ANSI green
 		fmt.Fprintf(os.Stderr, "\x1b[32m%s\x1b[0m\n", msg)
 		return
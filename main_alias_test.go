@@ -0,0 +1,40 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandAlias(t *testing.T) {
+	aliases := map[string]string{"db-prod": "kv/payments/prod/db", "app": "kv/app/"}
+	cases := map[string]string{
+		"@db-prod":    "kv/payments/prod/db",
+		"@app/config": "kv/app/config",
+		"kv/other":    "kv/other",
+		"":            "",
+		"@app":        "kv/app/",
+	}
+	for in, want := range cases {
+		got, err := expandAlias(in, aliases)
+		if err != nil || got != want {
+			t.Errorf("expandAlias(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := expandAlias("@nope", aliases); err == nil {
+		t.Fatal("unknown alias should fail")
+	}
+}
+
+func TestParseFlags_AliasesFromConfig(t *testing.T) {
+	cfg := writeConfig(t, "aliases:\n  db-prod: kv/payments/prod/db\n  app: kv/app/\n")
+	opts := parseFlagsWithArgs([]string{"-config", cfg, "-paths", "@app,kv/x/", "@db-prod"})
+	if !reflect.DeepEqual(opts.paths, []string{"kv/app/", "kv/x/"}) {
+		t.Fatalf("paths %v", opts.paths)
+	}
+	if !reflect.DeepEqual(opts.args, []string{"kv/payments/prod/db"}) {
+		t.Fatalf("args %v", opts.args)
+	}
+	if got := aliasNames(opts.aliases); !reflect.DeepEqual(got, []string{"@app", "@db-prod"}) {
+		t.Fatalf("alias names %v", got)
+	}
+}
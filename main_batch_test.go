@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestEnvPrefixes(t *testing.T) {
+	got := envPrefixes([]string{"kv/app/db", "kv/web/db", "kv/app/api-key"})
+	want := []string{"APP_DB", "WEB_DB", "API_KEY"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+}
+
+func TestBatchDocument(t *testing.T) {
+	vals := map[string]interface{}{
+		"kv/app/db":  map[string]interface{}{"user": "app", "pass": "it's"},
+		"kv/app/api": map[string]interface{}{"token": "t", "scopes": []interface{}{"r"}},
+	}
+	var inFlight, peak atomic.Int32
+	read := func(p string) (interface{}, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		return vals[p], nil
+	}
+	paths := []string{"kv/app/api", "kv/app/db"}
+
+	out, err := batchDocument(paths, read, 1, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `"kv/app/db": {`) || !strings.Contains(out, `"token": "t"`) {
+		t.Fatalf("json document:\n%s", out)
+	}
+	if peak.Load() != 1 {
+		t.Fatalf("limit 1 ran %d reads at once", peak.Load())
+	}
+
+	out, err = batchDocument(paths, read, 4, "dotenv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "API_SCOPES='[\"r\"]'\nAPI_TOKEN='t'\nDB_PASS='it'\\''s'\nDB_USER='app'\n"
+	if out != want {
+		t.Fatalf("dotenv document:\n%s\nwant:\n%s", out, want)
+	}
+
+	failing := func(p string) (interface{}, error) { return nil, errors.New("permission denied") }
+	if _, err := batchDocument(paths, failing, 2, "json"); err == nil || !strings.Contains(err.Error(), "kv/app/") {
+		t.Fatalf("want the failing path in the error, got %v", err)
+	}
+}
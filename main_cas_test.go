@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPrintThreeWay(t *testing.T) {
+	var buf bytes.Buffer
+	base := map[string]interface{}{"user": "bob", "password": "one", "host": "db"}
+	theirs := map[string]interface{}{"user": "bob", "password": "two", "host": "db", "port": "5432"}
+	ours := map[string]interface{}{"user": "eve", "password": "three", "host": "db"}
+	printThreeWay(&buf, base, theirs, ours)
+	out := buf.String()
+	for _, want := range []string{"password  changed    changed    conflict", "port      added      -\n", "user      unchanged  changed\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "host") || strings.Contains(out, "three") {
+		t.Errorf("unchanged keys or values printed:\n%s", out)
+	}
+}
+
+func TestWriteCAS_Conflict(t *testing.T) {
+	kv := &casKV{data: map[string]interface{}{"password": "theirs"}, version: 3}
+	c := fakeVaultClient(t, kv)
+	ctx := context.Background()
+	ours := func(map[string]interface{}) map[string]interface{} { return map[string]interface{}{"password": "ours"} }
+	base := map[string]interface{}{"password": "old"}
+
+	var err error
+	captureOutput(t, func() { _, err = writeCAS(ctx, c, options{}, os.Stderr, "put", "kv/app/db", base, 2, ours, false) })
+	if err == nil || !strings.Contains(err.Error(), "read version 2, now 3") {
+		t.Fatalf("expected a conflict, got %v", err)
+	}
+	if kv.data["password"] != "theirs" {
+		t.Fatal("a conflicting write went through")
+	}
+
+	var v int
+	captureOutput(t, func() {
+		v, err = writeCAS(ctx, c, options{retryCAS: true}, os.Stderr, "put", "kv/app/db", base, 2, ours, true)
+	})
+	if err != nil || v != 4 || kv.data["password"] != "ours" {
+		t.Fatalf("retry: v%d %v %v", v, err, kv.data)
+	}
+}
+
+func TestCopyOne_DestinationChangedSinceCheck(t *testing.T) {
+	kv := &casKV{data: map[string]interface{}{"password": "theirs"}, version: 3}
+	c := fakeVaultClient(t, kv)
+	s := copyStep{Src: "kv/app/db", Dst: "kv/app/db", srcLogical: c.Logical(), srcKV2: true, dstKV2: true, dstBase: map[string]interface{}{}, dstVersion: 2}
+	var buf bytes.Buffer
+	var err error
+	out := captureOutput(t, func() { err = copyOne(context.Background(), c, options{}, &buf, "cp", s, false) })
+	if err == nil || !strings.Contains(err.Error(), "read version 2, now 3") {
+		t.Fatalf("expected a check-and-set conflict, got %v", err)
+	}
+	if out != "" || !strings.Contains(buf.String(), "changed by someone else") {
+		t.Fatalf("conflict written to %q, stdout %q", buf.String(), out)
+	}
+	if kv.version != 3 {
+		t.Fatal("the destination was overwritten")
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestMaskForCI(t *testing.T) {
+	var buf bytes.Buffer
+	old := ciOut
+	ciOut, ciMode = &buf, "github"
+	defer func() { ciOut, ciMode = old, "" }()
+
+	maskForCI(map[string]interface{}{
+		"pass": "s3cr3t",
+		"cert": "line1\r\nline2\n",
+		"port": float64(5432),
+		"tags": []interface{}{"x"},
+		"none": "",
+	})
+	want := "::add-mask::5432\n::add-mask::line1\n::add-mask::line2\n::add-mask::s3cr3t\n::add-mask::x\n"
+	if buf.String() != want {
+		t.Fatalf("got:\n%s", buf.String())
+	}
+}
+
+func TestRunGet_MasksBeforePrinting(t *testing.T) {
+	var buf bytes.Buffer
+	old := ciOut
+	ciOut, ciMode = &buf, "github"
+	defer func() { ciOut, ciMode = old, "" }()
+
+	c := newFakeVault(t, fakeKV)
+	captureOutput(t, func() {
+		if err := runGet(context.Background(), c, options{args: []string{"kv/app/web"}}, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if buf.String() != "::add-mask::bob\n::add-mask::s3cr3t\n" {
+		t.Fatalf("masks %q", buf.String())
+	}
+}
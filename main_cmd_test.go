@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// newFakeVault serves canned JSON bodies keyed by "METHOD /v1/path"; LIST
+// requests also match "LIST /v1/path" when sent as GET ?list=true.
+func newFakeVault(t *testing.T, routes map[string]string) *vault.Client {
+	t.Helper()
+	return fakeVaultClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := r.Method
+		if method == http.MethodGet && r.URL.Query().Get("list") == "true" {
+			method = "LIST"
+		}
+		body, ok := routes[method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+}
+
+// fakeVaultClient returns a client talking to h.
+func fakeVaultClient(t *testing.T, h http.Handler) *vault.Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	cfg := vault.DefaultConfig()
+	cfg.Address = srv.URL
+	c, err := vault.NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetToken("test")
+	return c
+}
+
+// captureOutput returns what fn writes to os.Stdout.
+func captureOutput(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = old }()
+	fn()
+	w.Close()
+	b, _ := io.ReadAll(r)
+	return string(b)
+}
+
+func TestFindSubcommand(t *testing.T) {
+	if c := findSubcommand([]string{"get", "kv/a"}); c == nil || c.name != "get" {
+		t.Fatalf("got %v", c)
+	}
+	if c := findSubcommand([]string{"-path", "kv/"}); c != nil {
+		t.Fatalf("flags only should keep the default mode, got %s", c.name)
+	}
+	if c := findSubcommand(nil); c != nil {
+		t.Fatal("bare fvf should have no subcommand")
+	}
+}
+
+func TestParseFlags_PositionalArgsAndOutput(t *testing.T) {
+	opts := parseFlagsWithArgs([]string{"-kv1", "kv/app", "-output", "json", "extra"})
+	if !reflect.DeepEqual(opts.args, []string{"kv/app", "extra"}) || !opts.jsonOut || !opts.kv1 {
+		t.Fatalf("args=%v json=%v kv1=%v", opts.args, opts.jsonOut, opts.kv1)
+	}
+	if opts := parseFlagsWithArgs([]string{"-json", "-output", "text"}); opts.jsonOut {
+		t.Fatal("-output text should override -json")
+	}
+}
+
+func TestParseCommandFlags_OwnFlagSets(t *testing.T) {
+	old := osExit
+	t.Cleanup(func() { osExit = old })
+	osExit = func(code int) { panic(code) }
+	parse := func(name string, args ...string) (opts options, failure interface{}) {
+		defer func() { failure = recover() }()
+		return parseCommandFlags(findSubcommand([]string{name}), args), nil
+	}
+
+	// Every command's flag list names defined flags
+	for _, c := range subcommands {
+		if _, failure := parse(c.name); failure != nil {
+			t.Fatalf("%s: %v", c.name, failure)
+		}
+	}
+	opts, failure := parse("rm", "-r", "-yes", "kv/app/", "-json")
+	if failure != nil || !opts.recursive || !opts.yes || !opts.jsonOut || !reflect.DeepEqual(opts.args, []string{"kv/app/"}) {
+		t.Fatalf("rm flags: %+v (%v)", opts, failure)
+	}
+	if _, failure := parse("rm", "-on-conflict", "skip", "kv/app/db"); failure != 2 {
+		t.Fatalf("rm -on-conflict should be a usage error, got %v", failure)
+	}
+	if _, failure := parse("ls", "-group"); failure != 2 {
+		t.Fatalf("interactive flags should be rejected by commands, got %v", failure)
+	}
+}
+
+var fakeKV = map[string]string{
+	"GET /v1/sys/mounts":       `{"data":{"kv/":{"type":"kv","options":{"version":"2"}},"sys/":{"type":"system"}}}`,
+	"LIST /v1/kv/metadata/app": `{"data":{"keys":["web","db/"]}}`,
+	"GET /v1/kv/data/app/web":  `{"data":{"data":{"user":"bob","pass":"s3cr3t"}}}`,
+}
+
+func TestRunLs(t *testing.T) {
+	c := newFakeVault(t, fakeKV)
+	out := captureOutput(t, func() {
+		if err := runLs(context.Background(), c, options{args: []string{"kv/app"}}, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if out != "kv/app/db/\nkv/app/web\n" {
+		t.Fatalf("ls output %q", out)
+	}
+	out = captureOutput(t, func() {
+		if err := runLs(context.Background(), c, options{jsonOut: true}, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if strings.Join(strings.Fields(out), "") != `["kv/"]` {
+		t.Fatalf("ls mounts output %q", out)
+	}
+}
+
+func TestRunGet(t *testing.T) {
+	c := newFakeVault(t, fakeKV)
+	out := captureOutput(t, func() {
+		if err := runGet(context.Background(), c, options{args: []string{"kv/app/web"}}, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if out != "pass: s3cr3t\nuser: bob\n" {
+		t.Fatalf("get output %q", out)
+	}
+	if err := runGet(context.Background(), c, options{}, nil); err == nil {
+		t.Fatal("get without a path should fail")
+	}
+}
+
+func TestSearchAndPrint_InterruptPrintsPartialResults(t *testing.T) {
+	client := fakeVaultClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/kv/metadata":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"data":{"keys":["a","slow/"]}}`))
+		case "/v1/kv/metadata/slow":
+			// Simulate Ctrl-C during a long listing
+			p, _ := os.FindProcess(os.Getpid())
+			p.Signal(os.Interrupt)
+			<-r.Context().Done()
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	opts := options{startPath: "kv", kv2: true, forceKV2: true}
+	var runErr error
+	out := captureOutput(t, func() {
+		runErr = searchAndPrint(context.Background(), client, opts, nil)
+	})
+	if !errors.Is(runErr, errInterrupted) {
+		t.Fatalf("expected errInterrupted, got %v", runErr)
+	}
+	if out != "kv/a\n" {
+		t.Fatalf("expected the partial result, got %q", out)
+	}
+}
+
+func TestSearchAndPrint_SkipsFailingMountsUnlessStrict(t *testing.T) {
+	client := fakeVaultClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/sys/mounts":
+			w.Write([]byte(`{"data":{"ok/":{"type":"kv","options":{"version":"2"}},"locked/":{"type":"kv","options":{"version":"2"}}}}`))
+		case "/v1/ok/metadata":
+			w.Write([]byte(`{"data":{"keys":["a"]}}`))
+		case "/v1/locked/metadata":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	for _, strict := range []bool{false, true} {
+		opts := options{kv2: true, concurrency: 2, strict: strict}
+		var runErr error
+		out := captureOutput(t, func() {
+			runErr = searchAndPrint(context.Background(), client, opts, nil)
+		})
+		if out != "ok/a\n" {
+			t.Fatalf("strict=%v: expected the healthy mount's items, got %q", strict, out)
+		}
+		if (runErr != nil) != strict {
+			t.Fatalf("strict=%v: unexpected error %v", strict, runErr)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, body string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(p, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestConfigFlagValue(t *testing.T) {
+	cases := []struct {
+		args []string
+		want string
+		ok   bool
+	}{
+		{[]string{"-config", "a.yaml"}, "a.yaml", true},
+		{[]string{"--config=b.yaml", "-json"}, "b.yaml", true},
+		{[]string{"-json"}, "", false},
+		{[]string{"--", "-config", "x"}, "", false},
+	}
+	for _, c := range cases {
+		got, ok := configFlagValue(c.args)
+		if got != c.want || ok != c.ok {
+			t.Fatalf("configFlagValue(%v)=%q,%v want %q,%v", c.args, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestParseFlags_ConfigPrecedence(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("FVF_STATUS_BAR", "ttl||")
+	cfg := writeConfig(t, "paths: [kv/a/, kv/b/]\nmax-depth: 3\ntimeout: 1m\nvalues: false\nstatus-bar: \"idle||\"\n")
+
+	opts := parseFlagsWithArgs([]string{"-config", cfg, "-max-depth", "5"})
+	if !reflect.DeepEqual(opts.paths, []string{"kv/a/", "kv/b/"}) {
+		t.Fatalf("paths=%v", opts.paths)
+	}
+	if opts.maxDepth != 5 {
+		t.Fatalf("flag should override config, max-depth=%d", opts.maxDepth)
+	}
+	if opts.timeout != time.Minute || opts.printValues {
+		t.Fatalf("timeout=%v values=%v", opts.timeout, opts.printValues)
+	}
+	// Config overrides the environment default
+	if opts.statusLayout[0][0] != "idle" {
+		t.Fatalf("status layout=%v", opts.statusLayout)
+	}
+}
+
+func TestParseFlags_DefaultConfigLocation(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	if err := os.MkdirAll(filepath.Join(dir, "fvf"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "fvf", "config.yaml"), []byte("concurrency: 9\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if opts := parseFlagsWithArgs(nil); opts.concurrency != 9 {
+		t.Fatalf("concurrency=%d", opts.concurrency)
+	}
+}
+
+func TestApplyConfig_RejectsUnknownSettings(t *testing.T) {
+	values, err := loadConfig(writeConfig(t, "max-dept: 3\n"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("fvf", flag.ContinueOnError)
+	fs.Int("max-depth", 0, "")
+	if err := applyConfig(fs, values, "cfg"); err == nil {
+		t.Fatal("expected error for unknown setting")
+	}
+	if _, err := loadConfig(filepath.Join(t.TempDir(), "missing.yaml"), false); err != nil {
+		t.Fatalf("missing default config should be ignored: %v", err)
+	}
+	if _, err := loadConfig(filepath.Join(t.TempDir(), "missing.yaml"), true); err == nil {
+		t.Fatal("missing explicit config should fail")
+	}
+}
+
+func TestFindProjectConfig(t *testing.T) {
+	repo := t.TempDir()
+	sub := filepath.Join(repo, "svc", "api")
+	if err := os.MkdirAll(sub, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if got := findProjectConfig(sub); got != "" {
+		t.Fatalf("no config anywhere, got %q", got)
+	}
+	if err := os.WriteFile(filepath.Join(repo, projectConfigName), []byte("paths: [kv/team-payments/]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// Outside a git checkout only the current directory counts
+	if got := findProjectConfig(sub); got != "" {
+		t.Fatalf("parent config used outside a repo: %q", got)
+	}
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := findProjectConfig(sub), filepath.Join(repo, projectConfigName); got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
+
+func TestParseFlags_ProjectConfigOverridesUserConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, "fvf"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, "fvf", "config.yaml"), []byte("concurrency: 9\nmax-depth: 2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	proj := t.TempDir()
+	if err := os.WriteFile(filepath.Join(proj, projectConfigName), []byte("paths: [kv/team-payments/]\nmax-depth: 6\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(proj)
+	opts := parseFlagsWithArgs(nil)
+	if opts.concurrency != 9 || opts.maxDepth != 6 || !reflect.DeepEqual(opts.paths, []string{"kv/team-payments/"}) {
+		t.Fatalf("concurrency=%d max-depth=%d paths=%v", opts.concurrency, opts.maxDepth, opts.paths)
+	}
+}
+
+func TestParseFlags_EnvBelowConfigAndFlags(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("FVF_PATH", "kv/env/")
+	t.Setenv("FVF_CONCURRENCY", "7")
+	t.Setenv("FVF_MAX_DEPTH", "1")
+	t.Setenv("FVF_VALUES", "false")
+	cfg := writeConfig(t, "max-depth: 3\n")
+
+	opts := parseFlagsWithArgs([]string{"-config", cfg, "-path", "kv/flag/"})
+	if opts.startPath != "kv/flag/" || opts.concurrency != 7 || opts.maxDepth != 3 || opts.printValues {
+		t.Fatalf("path=%q concurrency=%d max-depth=%d values=%v", opts.startPath, opts.concurrency, opts.maxDepth, opts.printValues)
+	}
+}
+
+func TestParseFlags_ConfigFromEnv(t *testing.T) {
+	t.Setenv("FVF_CONFIG", writeConfig(t, "concurrency: 11\n"))
+	if opts := parseFlagsWithArgs(nil); opts.concurrency != 11 {
+		t.Fatalf("concurrency=%d", opts.concurrency)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	if got := envName("http-max-idle-per-host"); got != "FVF_HTTP_MAX_IDLE_PER_HOST" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestCheckProjectConfig(t *testing.T) {
+	if err := checkProjectConfig(map[string]string{"paths": "kv/a/", "redact-keys": "*password*"}, "p/.fvf.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"plugins", "bind", "hook-cmd", "daemon", "serve-token", "session", "audit-source", "read-only", "aliases"} {
+		err := checkProjectConfig(map[string]string{"paths": "kv/a/", k: "x"}, "p/.fvf.yaml")
+		if err == nil || !strings.Contains(err.Error(), "p/.fvf.yaml") || !strings.Contains(err.Error(), strconv.Quote(k)) {
+			t.Fatalf("%s: expected an error naming the file and setting, got %v", k, err)
+		}
+	}
+}
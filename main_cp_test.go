@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memKV2 is a KV v2 mount "kv" kept in memory: data, custom_metadata, LIST
+// and metadata DELETE. Reads of the data in denied fail with 403.
+type memKV2 struct {
+	mu     sync.Mutex
+	data   map[string]map[string]interface{}
+	custom map[string]map[string]string
+	denied map[string]bool
+}
+
+func (m *memKV2) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	reply := func(v interface{}) {
+		b, _ := json.Marshal(map[string]interface{}{"data": v})
+		w.Write(b)
+	}
+	notFound := func() {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errors":[]}`))
+	}
+	p := strings.TrimPrefix(r.URL.Path, "/v1/")
+	switch {
+	case p == "sys/mounts":
+		w.Write([]byte(`{"data":{"kv/":{"type":"kv","options":{"version":"2"}}}}`))
+	case r.Method == http.MethodGet && r.URL.Query().Get("list") == "true":
+		prefix := strings.TrimPrefix(p, "kv/metadata/")
+		if prefix != "" {
+			prefix = strings.TrimSuffix(prefix, "/") + "/"
+		}
+		seen := map[string]bool{}
+		var keys []string
+		for k := range m.data {
+			rest, ok := strings.CutPrefix(k, prefix)
+			if !ok {
+				continue
+			}
+			if i := strings.IndexByte(rest, '/'); i >= 0 {
+				rest = rest[:i+1]
+			}
+			if !seen[rest] {
+				seen[rest] = true
+				keys = append(keys, rest)
+			}
+		}
+		if keys == nil {
+			notFound()
+			return
+		}
+		sort.Strings(keys)
+		reply(map[string]interface{}{"keys": keys})
+	case strings.HasPrefix(p, "kv/data/"):
+		key := strings.TrimPrefix(p, "kv/data/")
+		if r.Method == http.MethodGet {
+			if m.denied[key] {
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"errors":["permission denied"]}`))
+				return
+			}
+			d, ok := m.data[key]
+			if !ok {
+				notFound()
+				return
+			}
+			reply(map[string]interface{}{"data": d, "metadata": map[string]interface{}{"version": 1}})
+			return
+		}
+		var body struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		m.data[key] = body.Data
+		reply(map[string]interface{}{"version": 1})
+	case strings.HasPrefix(p, "kv/metadata/"):
+		key := strings.TrimPrefix(p, "kv/metadata/")
+		switch r.Method {
+		case http.MethodDelete:
+			delete(m.data, key)
+			delete(m.custom, key)
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodGet:
+			if _, ok := m.data[key]; !ok {
+				notFound()
+				return
+			}
+			reply(map[string]interface{}{"current_version": 1, "custom_metadata": m.custom[key]})
+		default:
+			var body struct {
+				Custom map[string]string `json:"custom_metadata"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			m.custom[key] = body.Custom
+			w.WriteHeader(http.StatusNoContent)
+		}
+	default:
+		notFound()
+	}
+}
+
+func newMemKV2() *memKV2 {
+	return &memKV2{
+		data: map[string]map[string]interface{}{
+			"app/staging/db":      {"password": "s1"},
+			"app/staging/api/key": {"token": "t1"},
+			"app/prod/db":         {"password": "p1"},
+		},
+		custom: map[string]map[string]string{"app/staging/db": {"owner": "team-a"}},
+	}
+}
+
+func TestCopyPlan(t *testing.T) {
+	steps, err := copyPlan("kv/app/staging/", "kv/app/prod", []string{"kv/app/staging/db", "kv/app/staging/api/key"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if steps[0].Dst != "kv/app/prod/db" || steps[1].Dst != "kv/app/prod/api/key" {
+		t.Fatalf("recursive plan %+v", steps)
+	}
+	steps, _ = copyPlan("kv/app/db", "kv/other/", nil, false)
+	if len(steps) != 1 || steps[0].Dst != "kv/other/db" {
+		t.Fatalf("single plan %+v", steps)
+	}
+	if _, err := copyPlan("kv/app/", "kv/other/", nil, false); err == nil {
+		t.Fatal("copying a folder without -r should fail")
+	}
+}
+
+func TestRunCp_Recursive(t *testing.T) {
+	kv := newMemKV2()
+	c := fakeVaultClient(t, kv)
+	ctx := context.Background()
+	base := options{recursive: true, concurrency: 2, onConflict: "fail", args: []string{"kv/app/staging/", "kv/app/prod/"}}
+
+	if err := runCp(ctx, c, base, nil); err == nil || !strings.Contains(err.Error(), "kv/app/prod/db") {
+		t.Fatalf("expected a conflict on kv/app/prod/db, got %v", err)
+	}
+	if _, ok := kv.data["app/prod/api/key"]; ok {
+		t.Fatal("a failing conflict check must not write anything")
+	}
+
+	dry := base
+	dry.onConflict, dry.dryRun = "skip", true
+	out := captureOutput(t, func() {
+		if err := runCp(ctx, c, dry, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !strings.Contains(out, "would copy kv/app/staging/api/key -> kv/app/prod/api/key") || !strings.Contains(out, "would skip kv/app/staging/db") {
+		t.Fatalf("dry-run output %q", out)
+	}
+	if len(kv.data) != 3 {
+		t.Fatal("-dry-run wrote secrets")
+	}
+
+	over := base
+	over.onConflict, over.keepMetadata = "overwrite", true
+	captureOutput(t, func() {
+		if err := runCp(ctx, c, over, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if kv.data["app/prod/db"]["password"] != "s1" || kv.data["app/prod/api/key"]["token"] != "t1" {
+		t.Fatalf("copy result %v", kv.data)
+	}
+	if kv.custom["app/prod/db"]["owner"] != "team-a" {
+		t.Fatalf("custom_metadata not kept: %v", kv.custom)
+	}
+	if _, ok := kv.data["app/staging/db"]; !ok {
+		t.Fatal("cp must keep the source")
+	}
+}
+
+func TestRunMv_Single(t *testing.T) {
+	kv := newMemKV2()
+	c := fakeVaultClient(t, kv)
+	captureOutput(t, func() {
+		if err := runMv(context.Background(), c, options{concurrency: 1, onConflict: "fail", yes: true, args: []string{"kv/app/staging/db", "kv/app/archive/"}}, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if _, ok := kv.data["app/staging/db"]; ok {
+		t.Fatal("mv must delete the source")
+	}
+	if kv.data["app/archive/db"]["password"] != "s1" {
+		t.Fatalf("mv result %v", kv.data)
+	}
+}
+
+func TestRunMv_ConfirmAndOverlap(t *testing.T) {
+	kv := newMemKV2()
+	c := fakeVaultClient(t, kv)
+	ctx := context.Background()
+	old := confirmInput
+	t.Cleanup(func() { confirmInput = old })
+
+	confirmInput = strings.NewReader("no\n")
+	captureOutput(t, func() {
+		if err := runMv(ctx, c, options{concurrency: 1, onConflict: "fail", args: []string{"kv/app/staging/db", "kv/app/archive/"}}, nil); err == nil {
+			t.Fatal("mv without typing yes should fail")
+		}
+	})
+	if _, ok := kv.data["app/staging/db"]; !ok {
+		t.Fatal("an unconfirmed mv deleted the source")
+	}
+
+	for _, args := range [][]string{{"kv/app/", "kv/app/staging/"}, {"kv/app/staging/", "kv/app"}} {
+		err := runMv(ctx, c, options{recursive: true, yes: true, concurrency: 1, onConflict: "fail", args: args}, nil)
+		if err == nil || !strings.Contains(err.Error(), "overlap") {
+			t.Fatalf("mv -r %v: expected an overlap error, got %v", args, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunDiff(t *testing.T) {
+	kv := newMemKV2()
+	kv.data["app/prod/old"] = map[string]interface{}{"x": "1"}
+	kv.data["app/prod/api/key"] = map[string]interface{}{"token": "t1"}
+	c := fakeVaultClient(t, kv)
+	ctx := context.Background()
+	opts := options{concurrency: 2, args: []string{"kv/app/staging", "kv/app/prod"}}
+
+	out := captureOutput(t, func() {
+		if err := runDiff(ctx, c, opts, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if out != "update kv/app/prod/db\ndelete kv/app/prod/old\n" {
+		t.Fatalf("diff output %q", out)
+	}
+
+	opts.jsonOut = true
+	out = captureOutput(t, func() {
+		if err := runDiff(ctx, c, opts, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	var got []syncAction
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("%v: %q", err, out)
+	}
+	if len(got) != 2 || got[0].Type != "update" || got[0].Src != "kv/app/staging/db" || got[0].Dst != "kv/app/prod/db" || got[1].Type != "delete" {
+		t.Fatalf("diff %+v", got)
+	}
+	if kv.data["app/prod/db"]["password"] != "p1" {
+		t.Fatal("diff wrote a secret")
+	}
+}
+
+func TestDiffOutThenApply(t *testing.T) {
+	kv := newMemKV2()
+	kv.data["app/prod/old"] = map[string]interface{}{"x": "1"}
+	c := fakeVaultClient(t, kv)
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), "diff.json")
+	diff := options{concurrency: 2, outFile: file, args: []string{"kv/app/staging/", "kv/app/prod/"}}
+
+	if err := runDiff(ctx, c, diff, nil); err == nil || !strings.Contains(err.Error(), "-unkeyed-manifest") {
+		t.Fatalf("-out without -manifest-key: %v", err)
+	}
+	diff.manifestKey = "k"
+	captureOutput(t, func() {
+		if err := runDiff(ctx, c, diff, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "s1") || strings.Contains(string(b), "p1") {
+		t.Fatalf("the diff file holds values: %s", b)
+	}
+
+	apply := options{concurrency: 2, yes: true, args: []string{file}}
+	if err := runApply(ctx, c, apply, nil); err == nil || !strings.Contains(err.Error(), "manifest-key") {
+		t.Fatalf("a keyed diff without the key: %v", err)
+	}
+	apply.manifestKey = "k"
+
+	// A source changed after review is not applied
+	kv.data["app/staging/db"] = map[string]interface{}{"password": "s2"}
+	captureOutput(t, func() { err = runApply(ctx, c, apply, nil) })
+	if err == nil || !strings.Contains(err.Error(), "nothing applied") || kv.data["app/prod/db"]["password"] != "p1" {
+		t.Fatalf("stale source: %v, prod %v", err, kv.data["app/prod/db"])
+	}
+	kv.data["app/staging/db"] = map[string]interface{}{"password": "s1"}
+
+	// Another destination prefix must be in the state the diff was made against
+	other := apply
+	other.args = []string{file, "kv/app/qa"}
+	captureOutput(t, func() { err = runApply(ctx, c, other, nil) })
+	if err == nil || len(kv.data) != 4 {
+		t.Fatalf("apply to a prefix in another state: %v, %v", err, kv.data)
+	}
+
+	out := captureOutput(t, func() {
+		if err := runApply(ctx, c, apply, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	for _, want := range []string{"update kv/app/prod/db\n", "create kv/app/prod/api/key\n", "delete kv/app/prod/old\n"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("apply output %q lacks %q", out, want)
+		}
+	}
+	if kv.data["app/prod/db"]["password"] != "s1" || kv.data["app/prod/api/key"]["token"] != "t1" {
+		t.Fatalf("apply result %v", kv.data)
+	}
+	if _, ok := kv.data["app/prod/old"]; ok {
+		t.Fatal("the diff's delete was not applied")
+	}
+
+	// Applied once, the diff no longer matches the destination
+	captureOutput(t, func() { err = runApply(ctx, c, apply, nil) })
+	if err == nil {
+		t.Fatal("applying the same diff twice should fail")
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestEnvrcExports(t *testing.T) {
+	got := envrcExports(map[string]interface{}{
+		"db-pass": "it's",
+		"user":    "bob",
+		"1port":   float64(5432),
+		"tags":    []interface{}{"a", "b"},
+	})
+	want := "export DB_PASS='it'\\''s'\nexport TAGS='[\"a\",\"b\"]'\nexport USER='bob'\nexport _1PORT='5432'\n"
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRunDirenv(t *testing.T) {
+	c := newFakeVault(t, fakeKV)
+	out := captureOutput(t, func() {
+		if err := runDirenv(context.Background(), c, options{args: []string{"kv/app/web"}, direnvMode: "export"}, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if out != "export PASS='s3cr3t'\nexport USER='bob'\n" {
+		t.Fatalf("export mode: %q", out)
+	}
+	out = captureOutput(t, func() {
+		if err := runDirenv(context.Background(), nil, options{startPath: "kv/app/web/", direnvMode: "lazy"}, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !strings.Contains(out, `eval "$(fvf direnv 'kv/app/web')"`) || strings.Contains(out, "s3cr3t") {
+		t.Fatalf("lazy mode: %q", out)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestRunDockerSecret(t *testing.T) {
+	created := map[string]string{}
+	old := dockerSecretCreate
+	dockerSecretCreate = func(name string, value []byte) error {
+		created[name] = string(value)
+		return nil
+	}
+	defer func() { dockerSecretCreate = old }()
+
+	c := newFakeVault(t, fakeKV)
+	if err := runDockerSecret(context.Background(), c, options{args: []string{"kv/app/web"}, keys: []string{"pass"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(created, map[string]string{"web_pass": "s3cr3t"}) {
+		t.Fatalf("created %v", created)
+	}
+	if err := runDockerSecret(context.Background(), c, options{args: []string{"kv/app/web"}, keys: []string{"nope"}}, nil); err == nil {
+		t.Fatal("unknown key should fail")
+	}
+
+	out := captureOutput(t, func() {
+		if err := runDockerSecret(context.Background(), c, options{args: []string{"kv/app/web"}, envFile: true}, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if out != "PASS=s3cr3t\nUSER=bob\n" {
+		t.Fatalf("env_file %q", out)
+	}
+}
+
+func TestComposeEnvFile_RefusesMultiline(t *testing.T) {
+	if _, err := composeEnvFile(map[string]interface{}{"cert": "a\nb"}, []string{"cert"}); err == nil {
+		t.Fatal("multi-line value should be refused")
+	}
+}
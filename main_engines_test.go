@@ -0,0 +1,25 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPluginEngines_OnlyListedTypes(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "started")
+	script := "#!/bin/sh\ntouch " + marker + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "fvf-engine-cubbyhole"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	e := &pluginEngines{}
+	if _, ok := e.get(nil, "cubbyhole"); ok {
+		t.Fatal("an unlisted mount type must not get a plugin")
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("a binary on PATH was started")
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestWriteESOManifests(t *testing.T) {
+	var buf bytes.Buffer
+	secrets := []esoSecret{{mount: "kv", inner: "app/web", kv2: true, keys: []string{"pass", "user"}}}
+	if err := writeESOManifests(&buf, secrets, "https://vault:8200", "", "apps"); err != nil {
+		t.Fatal(err)
+	}
+	want := `apiVersion: external-secrets.io/v1beta1
+kind: SecretStore
+metadata:
+  name: vault-kv
+  namespace: apps
+spec:
+  provider:
+    vault:
+      server: https://vault:8200
+      path: kv
+      version: v2
+      auth:
+        tokenSecretRef:
+          name: vault-token
+          key: token
+---
+apiVersion: external-secrets.io/v1beta1
+kind: ExternalSecret
+metadata:
+  name: kv-app-web
+  namespace: apps
+spec:
+  refreshInterval: 1h
+  secretStoreRef:
+    name: vault-kv
+    kind: SecretStore
+  target:
+    name: kv-app-web
+  data:
+    - secretKey: pass
+      remoteRef:
+        key: app/web
+        property: pass
+    - secretKey: user
+      remoteRef:
+        key: app/web
+        property: user
+`
+	if buf.String() != want {
+		t.Fatalf("got:\n%s", buf.String())
+	}
+}
+
+func TestRunESO_NoValues(t *testing.T) {
+	c := newFakeVault(t, fakeKV)
+	out := captureOutput(t, func() {
+		if err := runESO(context.Background(), c, options{args: []string{"kv/app/web"}}, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !strings.Contains(out, "property: pass") || strings.Contains(out, "s3cr3t") {
+		t.Fatalf("manifests %q", out)
+	}
+}
+
+func TestK8sName(t *testing.T) {
+	for in, want := range map[string]string{"kv-app/DB_main": "kv-app-db-main", "/x//y/": "x-y", strings.Repeat("a", 70): strings.Repeat("a", 63)} {
+		if got := k8sName(in); got != want {
+			t.Errorf("k8sName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
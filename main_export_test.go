@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunExport_SOPS(t *testing.T) {
+	var gotPlain, gotFormat string
+	old := sopsEncrypt
+	sopsEncrypt = func(plain []byte, format, target string) ([]byte, error) {
+		gotPlain, gotFormat = string(plain), format
+		return []byte("ENC[" + format + "]"), nil
+	}
+	defer func() { sopsEncrypt = old }()
+
+	routes := map[string]string{"LIST /v1/kv/metadata/app/db": `{"data":{"keys":[]}}`}
+	for k, v := range fakeKV {
+		routes[k] = v
+	}
+	c := newFakeVault(t, routes)
+	target := filepath.Join(t.TempDir(), "app.enc.yaml")
+	if err := runExport(context.Background(), c, options{sopsFile: target, startPath: "kv/app/"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if gotFormat != "yaml" || gotPlain != "kv/app/web:\n    pass: s3cr3t\n    user: bob\n" {
+		t.Fatalf("plaintext %s %q", gotFormat, gotPlain)
+	}
+	if b, _ := os.ReadFile(target); string(b) != "ENC[yaml]" {
+		t.Fatalf("target holds %q, want the sops output", b)
+	}
+
+	target = filepath.Join(t.TempDir(), "app.enc.json")
+	if err := runExport(context.Background(), c, options{sopsFile: target, args: []string{"kv/app/web"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if gotFormat != "json" || !strings.Contains(gotPlain, `"kv/app/web": {`) {
+		t.Fatalf("plaintext %s %q", gotFormat, gotPlain)
+	}
+
+	if err := runExport(context.Background(), c, options{args: []string{"kv/app/web"}}, nil); err == nil {
+		t.Fatal("export without -sops should fail")
+	}
+}
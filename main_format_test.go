@@ -1,6 +1,7 @@
 package main
 
 import (
+	"os"
 	"strings"
 	"testing"
 )
@@ -55,3 +56,33 @@ func TestFormatValueRaw_NestedCompactSliceInMap(t *testing.T) {
         t.Fatalf("expected compact slice rendering inside map, got %q", out)
     }
 }
+
+func TestColorEnabled(t *testing.T) {
+	defer func() { colorMode = "auto" }()
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	colorMode = "auto"
+	if colorEnabled(f) {
+		t.Fatal("auto should not color a regular file")
+	}
+	colorMode = "always"
+	if !colorEnabled(f) {
+		t.Fatal("always should color even when not a terminal")
+	}
+	colorMode = "never"
+	if colorEnabled(os.Stderr) {
+		t.Fatal("never should not color")
+	}
+}
+
+func TestParseFlags_Color(t *testing.T) {
+	if got := parseFlagsWithArgs([]string{"-color", "never"}); got.color != "never" {
+		t.Fatalf("color = %q", got.color)
+	}
+	if got := parseFlagsWithArgs(nil); got.color != "auto" {
+		t.Fatalf("default color = %q", got.color)
+	}
+}
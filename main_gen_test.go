@@ -0,0 +1,65 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"regexp"
+	"testing"
+)
+
+func TestGenerateValue(t *testing.T) {
+	for spec, re := range map[string]string{
+		"alnum32":   `^[A-Za-z0-9]{32}$`,
+		"digits-6":  `^[0-9]{6}$`,
+		"hex16":     `^[0-9a-f]{32}$`,
+		"base64-12": `^[A-Za-z0-9+/]{16}$`,
+		"uuid":      `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`,
+	} {
+		v, pub, err := generateValue(spec)
+		if err != nil {
+			t.Fatalf("%s: %v", spec, err)
+		}
+		if !regexp.MustCompile(re).MatchString(v) || pub != "" {
+			t.Errorf("gen:%s = %q (pub %q)", spec, v, pub)
+		}
+	}
+	a, _, _ := generateValue("alnum32")
+	b, _, _ := generateValue("alnum32")
+	if a == b {
+		t.Fatal("two generated values are equal")
+	}
+	for _, spec := range []string{"ed25519", "ecdsa256"} {
+		priv, pub, err := generateValue(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block, _ := pem.Decode([]byte(priv))
+		if block == nil || block.Type != "PRIVATE KEY" {
+			t.Fatalf("%s private key %q", spec, priv)
+		}
+		if _, err := x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+			t.Fatal(err)
+		}
+		if block, _ = pem.Decode([]byte(pub)); block == nil || block.Type != "PUBLIC KEY" {
+			t.Fatalf("%s public key %q", spec, pub)
+		}
+	}
+	for _, bad := range []string{"alnum", "alnum0", "rsa1024", "ecdsa255", "pw32", "hex-x"} {
+		if _, _, err := generateValue(bad); err == nil {
+			t.Errorf("gen:%s should fail", bad)
+		}
+	}
+}
+
+func TestParseKVArgs_Gen(t *testing.T) {
+	data, err := parseKVArgs([]string{"password=gen:alnum20", "key=gen:ed25519"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data["password"].(string)) != 20 || data["key_pub"] == nil {
+		t.Fatalf("generated %v", data)
+	}
+	if _, err := parseKVArgs([]string{"key=gen:ed25519", "key_pub=x"}); err == nil {
+		t.Fatal("an explicit key_pub next to a generated key pair should fail")
+	}
+}
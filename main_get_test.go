@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFuzzyMatches(t *testing.T) {
+	paths := []string{"kv/app/db-password", "kv/app/web", "kv/db/password-old", "kv/dashboard/pw"}
+	got := fuzzyMatches("db-pass", paths)
+	want := []string{"kv/app/db-password"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	// Substrings first, the one ending the path ahead; then scattered matches
+	got = fuzzyMatches("password", append(paths, "kv/team/password"))
+	want = []string{"kv/team/password", "kv/app/db-password", "kv/db/password-old"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got := fuzzyMatches("dbpw", paths); !reflect.DeepEqual(got, []string{"kv/dashboard/pw", "kv/app/db-password", "kv/db/password-old"}) {
+		t.Fatalf("subsequence matches %v", got)
+	}
+}
+
+func TestRunGet_FuzzyFallback(t *testing.T) {
+	c := newFakeVault(t, map[string]string{
+		"GET /v1/sys/mounts":         `{"data":{"kv/":{"type":"kv","options":{"version":"2"}}}}`,
+		"LIST /v1/kv/metadata/app":   `{"data":{"keys":["web","worker"]}}`,
+		"GET /v1/kv/data/app/web":    `{"data":{"data":{"user":"bob"}}}`,
+		"GET /v1/kv/data/app/worker": `{"data":{"data":{"user":"wanda"}}}`,
+	})
+	opts := options{indexFile: filepath.Join(t.TempDir(), "none.json")}
+
+	// One match below the argument's directory is printed directly
+	opts.args = []string{"kv/app/wb"}
+	out := captureOutput(t, func() {
+		if err := runGet(context.Background(), c, opts, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if out != "user: bob\n" {
+		t.Fatalf("get output %q", out)
+	}
+
+	// Several matches: an error listing them, or a pick on a terminal
+	opts.args = []string{"kv/app/w"}
+	old, oldInput := pickInteractively, confirmInput
+	t.Cleanup(func() { pickInteractively, confirmInput = old, oldInput })
+	pickInteractively = func() bool { return false }
+	err := runGet(context.Background(), c, opts, nil)
+	if err == nil || !strings.Contains(err.Error(), "matches 2 secrets") || !strings.Contains(err.Error(), "2  kv/app/worker") {
+		t.Fatalf("expected the candidates, got %v", err)
+	}
+	pickInteractively = func() bool { return true }
+	confirmInput = strings.NewReader("2\n")
+	out = captureOutput(t, func() {
+		if err := runGet(context.Background(), c, opts, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if out != "user: wanda\n" {
+		t.Fatalf("picked output %q", out)
+	}
+
+	// With an index, a bare name is matched across mounts
+	opts.indexFile = filepath.Join(t.TempDir(), "index.json")
+	if err := writeIndex(opts.indexFile, pathIndex{Paths: []string{"kv/app/web", "kv/app/worker"}}); err != nil {
+		t.Fatal(err)
+	}
+	opts.args = []string{"web"}
+	out = captureOutput(t, func() {
+		if err := runGet(context.Background(), c, opts, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if out != "user: bob\n" {
+		t.Fatalf("indexed get output %q", out)
+	}
+
+	// Nothing matches: the read's own error
+	opts.args = []string{"kv/app/zzz"}
+	if err := runGet(context.Background(), c, opts, nil); err == nil || !strings.Contains(err.Error(), "no data at kv/data/app/zzz") {
+		t.Fatalf("expected the not-found error, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTokenIdentity(t *testing.T) {
+	cases := []struct {
+		display, entity, want string
+	}{
+		{"ldap-alice", "alice", "as: ldap-alice (alice)"},
+		{"ldap-alice", "", "as: ldap-alice"},
+		{"alice", "alice", "as: alice"},
+		{"", "alice", "as: alice"},
+		{"", "", ""},
+	}
+	for _, c := range cases {
+		lookup := map[string]interface{}{}
+		if c.display != "" {
+			lookup["display_name"] = c.display
+		}
+		if got := tokenIdentity(lookup, c.entity); got != c.want {
+			t.Errorf("tokenIdentity(%q, %q) = %q, want %q", c.display, c.entity, got, c.want)
+		}
+	}
+}
+
+func TestLookupEntityName(t *testing.T) {
+	client := newFakeVault(t, map[string]string{
+		"GET /v1/identity/entity/id/e1": `{"data":{"name":"alice"}}`,
+	})
+	if got := lookupEntityName(context.Background(), client, "e1"); got != "alice" {
+		t.Fatalf("entity name %q", got)
+	}
+	// Not readable by the token: no name rather than an error
+	if got := lookupEntityName(context.Background(), client, "e2"); got != "" {
+		t.Fatalf("unreadable entity gave %q", got)
+	}
+}
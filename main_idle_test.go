@@ -6,9 +6,30 @@ import (
 )
 
 func TestParseFlags_SetsFixedIdleTimeout(t *testing.T) {
-	// Ensure opts.idleExitAfter is set to fixed 5 minutes regardless of flags
+	// Without -idle-exit, opts.idleExitAfter defaults to 5 minutes
 	opts := parseFlagsWithArgs([]string{"-timeout", "10s"})
 	if opts.idleExitAfter != 5*time.Minute {
 		t.Fatalf("idleExitAfter = %v, want 5m", opts.idleExitAfter)
 	}
 }
+
+func TestParseFlags_IdleExitFlag(t *testing.T) {
+	if opts := parseFlagsWithArgs([]string{"-idle-exit", "30m"}); opts.idleExitAfter != 30*time.Minute {
+		t.Fatalf("idleExitAfter = %v, want 30m", opts.idleExitAfter)
+	}
+	if opts := parseFlagsWithArgs([]string{"-idle-exit", "0"}); opts.idleExitAfter != 0 {
+		t.Fatalf("idleExitAfter = %v, want 0 (never)", opts.idleExitAfter)
+	}
+}
+
+func TestFormatIdle(t *testing.T) {
+	if got := formatIdle(90*time.Second, 5*time.Minute); got != "1m 30s/5m" {
+		t.Fatalf("got %q", got)
+	}
+	if got := formatIdle(10*time.Minute, 5*time.Minute); got != "5m/5m" {
+		t.Fatalf("idle should be capped at the limit, got %q", got)
+	}
+	if got := formatIdle(10*time.Minute, 0); got != "10m" {
+		t.Fatalf("no limit should show idle only, got %q", got)
+	}
+}
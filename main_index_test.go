@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIndexThenOfflineSearch(t *testing.T) {
+	client := newFakeVault(t, map[string]string{
+		"LIST /v1/kv/metadata":     `{"data":{"keys":["db","app/"]}}`,
+		"LIST /v1/kv/metadata/app": `{"data":{"keys":["api-key","config"]}}`,
+	})
+	file := filepath.Join(t.TempDir(), "index.json")
+	opts := options{startPath: "kv", kv2: true, forceKV2: true, indexFile: file, namePart: "ignored"}
+	if err := runIndex(context.Background(), client, opts, nil); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := readIndex(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(idx.Paths, ",") != "kv/app/api-key,kv/app/config,kv/db" || idx.Addr != client.Address() {
+		t.Fatalf("index %+v", idx)
+	}
+	if idx.AllMounts || !idx.covers("kv/") || idx.covers("secret/") {
+		t.Fatalf("index roots %v (all %v)", idx.Roots, idx.AllMounts)
+	}
+
+	// Offline: no client at all, filters apply to the indexed paths
+	out := captureOutput(t, func() {
+		if err := searchOffline(options{indexFile: file, namePart: "key", startPath: "kv/app/", sortBy: "path"}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if out != "kv/app/api-key\n" {
+		t.Fatalf("offline output %q", out)
+	}
+	if err := searchOffline(options{indexFile: file, sortBy: "size"}); err == nil {
+		t.Fatal("-sort size offline should fail")
+	}
+}
+
+func TestReadIndex_Missing(t *testing.T) {
+	_, err := readIndex(filepath.Join(t.TempDir(), "none.json"))
+	if err == nil || !strings.Contains(err.Error(), "fvf index") {
+		t.Fatalf("want a hint to run fvf index, got %v", err)
+	}
+}
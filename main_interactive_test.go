@@ -1,6 +1,9 @@
 package main
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestDetermineInteractive_DefaultNoArgs(t *testing.T) {
 	// No args -> interactive regardless of TTY
@@ -36,3 +39,28 @@ func TestDetermineInteractive_NonInteractive(t *testing.T) {
 		t.Fatalf("expected non-interactive when args present, not TTY, no flags")
 	}
 }
+
+func TestSelectOnTTY(t *testing.T) {
+	opts := options{printValues: true}
+	if !selectOnTTY(opts, 2, true, true) {
+		t.Fatalf("expected the TUI on the terminal when stdout is a pipe")
+	}
+	if selectOnTTY(opts, 2, true, false) {
+		t.Fatalf("without a terminal (scripts, CI) the output must stay non-interactive")
+	}
+	if selectOnTTY(opts, 2, false, true) {
+		t.Fatalf("stdout redirected to a file must stay non-interactive")
+	}
+	if selectOnTTY(options{}, 2, true, true) {
+		t.Fatalf("-values=false keeps the plain path list")
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	if got := requestTimeout(options{fetchTimeout: 3 * time.Second, timeout: time.Minute}); got != 3*time.Second {
+		t.Fatalf("got %v, want -fetch-timeout", got)
+	}
+	if got := requestTimeout(options{timeout: time.Minute}); got != time.Minute {
+		t.Fatalf("got %v, want -timeout when -fetch-timeout is 0", got)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+func TestKVOverrideWarnings(t *testing.T) {
+	mounts := map[string]*vault.MountOutput{
+		"kv/":     {Type: "kv", Options: map[string]string{"version": "2"}},
+		"legacy/": {Type: "kv", Options: map[string]string{"version": "1"}},
+		"pki/":    {Type: "pki"},
+	}
+	if got := kvOverrideWarnings(mounts, options{kv2: true}); got != nil {
+		t.Fatalf("detection on: no warnings expected, got %v", got)
+	}
+	got := kvOverrideWarnings(mounts, options{kv1: true})
+	want := []string{"-kv1 reads kv/ as KV v1, but the mount is KV v2; expect empty listings and failed reads"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	got = kvOverrideWarnings(mounts, options{forceKV2: true, kv2: true, startPath: "legacy/app", paths: []string{"pki/x"}})
+	want = []string{"-force-kv2 reads legacy/ as KV v2, but the mount is KV v1; expect empty listings and failed reads"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestKVVersions(t *testing.T) {
+	client := newFakeVault(t, map[string]string{
+		"GET /v1/sys/mounts": `{"data":{"kv/":{"type":"kv","options":{"version":"2"}},"pki/":{"type":"pki"}}}`,
+	})
+	ctx := context.Background()
+	for _, tc := range []struct {
+		opts           options
+		path           string
+		used, detected int
+	}{
+		{options{kv2: true}, "kv/app", 2, 2},
+		{options{kv1: true}, "kv/app", 1, 2},
+		{options{kv2: true}, "pki/issuer", 0, 0},
+		{options{kv2: true}, "gone/x", 2, 0},
+	} {
+		used, detected := kvVersions(ctx, client, tc.opts, tc.path)
+		if used != tc.used || detected != tc.detected {
+			t.Fatalf("%s %+v: got %d/%d, want %d/%d", tc.path, tc.opts, used, detected, tc.used, tc.detected)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	for in, want := range map[string]slog.Level{"debug": slog.LevelDebug, "INFO": slog.LevelInfo, "warn": slog.LevelWarn, "error": slog.LevelError} {
+		got, err := parseLogLevel(in)
+		if err != nil || got != want {
+			t.Fatalf("%q: got %v, %v want %v", in, got, err, want)
+		}
+	}
+	if _, err := parseLogLevel("loud"); err == nil {
+		t.Fatal("expected error for unknown level")
+	}
+}
+
+func TestSetupLogging_FileKeepsRecordsWhileTUIQuiet(t *testing.T) {
+	prev := slog.Default()
+	defer func() {
+		slog.SetDefault(prev)
+		if logFile != nil {
+			logFile.Close()
+			logFile = nil
+		}
+	}()
+	p := filepath.Join(t.TempDir(), "fvf.log")
+	if err := setupLogging(slog.LevelInfo, p); err != nil {
+		t.Fatal(err)
+	}
+	restore := quietStderrLog()
+	slog.Debug("hidden")
+	slog.Warn("preview fetch failed", "path", "kv/app")
+	restore()
+
+	b, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+	if strings.Contains(out, "hidden") || !strings.Contains(out, "path=kv/app") {
+		t.Fatalf("unexpected log contents: %q", out)
+	}
+}
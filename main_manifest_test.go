@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManifest_WriteAndVerify(t *testing.T) {
+	routes := map[string]string{
+		"GET /v1/sys/mounts":          `{"data":{"kv/":{"type":"kv","options":{"version":"2"}}}}`,
+		"LIST /v1/kv/metadata/app":    `{"data":{"keys":["web","api"]}}`,
+		"GET /v1/kv/data/app/web":     `{"data":{"data":{"user":"bob","pass":"s3cr3t"}}}`,
+		"GET /v1/kv/metadata/app/web": `{"data":{"current_version":2}}`,
+		"GET /v1/kv/data/app/api":     `{"data":{"data":{"token":"t1"}}}`,
+		"GET /v1/kv/metadata/app/api": `{"data":{"current_version":1}}`,
+	}
+	c := newFakeVault(t, routes)
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), "manifest.json")
+	unkeyed := options{startPath: "kv/app", outFile: file}
+	if err := runManifest(ctx, c, unkeyed, nil); err == nil || !strings.Contains(err.Error(), "-unkeyed-manifest") {
+		t.Fatalf("-out without -manifest-key: %v", err)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Fatalf("refused manifest was written: %v", err)
+	}
+	unkeyed.unkeyed = true
+	if err := runManifest(ctx, c, unkeyed, nil); err != nil {
+		t.Fatal(err)
+	}
+	opts := options{startPath: "kv/app", outFile: file, manifestKey: "k"}
+	if err := runManifest(ctx, c, opts, nil); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "s3cr3t") || strings.Contains(string(b), "bob") {
+		t.Fatalf("manifest leaks values:\n%s", b)
+	}
+	var m manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Secrets) != 2 || m.Secrets["kv/app/web"].Version != 2 || !m.Keyed || m.Roots[0] != "kv/app" {
+		t.Fatalf("manifest %+v", m)
+	}
+
+	verify := options{verify: file, manifestKey: "k"}
+	if err := runManifest(ctx, c, verify, nil); err != nil {
+		t.Fatalf("unchanged Vault should verify: %v", err)
+	}
+	if err := runManifest(ctx, c, options{verify: file}, nil); err == nil || !strings.Contains(err.Error(), "manifest-key") {
+		t.Fatalf("verify without the key: %v", err)
+	}
+	if err := runManifest(ctx, c, options{verify: file, manifestKey: "other"}, nil); err == nil {
+		t.Fatal("a different key should report every secret as changed")
+	}
+
+	routes["GET /v1/kv/data/app/web"] = `{"data":{"data":{"user":"bob","pass":"rotated"}}}`
+	routes["GET /v1/kv/metadata/app/web"] = `{"data":{"current_version":3}}`
+	routes["GET /v1/kv/metadata/app/api"] = `{"data":{"current_version":2}}`
+	routes["LIST /v1/kv/metadata/app"] = `{"data":{"keys":["web","api","new"]}}`
+	routes["GET /v1/kv/data/app/new"] = `{"data":{"data":{"a":"b"}}}`
+	verify.jsonOut = true
+	var err2 error
+	out := captureOutput(t, func() { err2 = runManifest(ctx, c, verify, nil) })
+	if err2 == nil {
+		t.Fatal("drift should fail verification")
+	}
+	var drift []manifestDrift
+	if err := json.Unmarshal([]byte(out), &drift); err != nil {
+		t.Fatalf("%v: %q", err, out)
+	}
+	want := []manifestDrift{
+		{Path: "kv/app/api", Type: "rewritten", OldVersion: 1, NewVersion: 2},
+		{Path: "kv/app/new", Type: "added"},
+		{Path: "kv/app/web", Type: "changed", OldVersion: 2, NewVersion: 3},
+	}
+	if len(drift) != len(want) {
+		t.Fatalf("drift %+v", drift)
+	}
+	for i := range want {
+		if drift[i] != want[i] {
+			t.Fatalf("drift[%d] = %+v, want %+v", i, drift[i], want[i])
+		}
+	}
+}
+
+func TestDiffManifest_RemovedAndUnreadable(t *testing.T) {
+	old := map[string]manifestEntry{"a": {Digest: "x", Version: 1}, "b": {Digest: "y", Version: 4}}
+	got := diffManifest(old, map[string]manifestEntry{}, []string{"b"})
+	if len(got) != 2 || got[0].Type != "removed" || got[1].Type != "unreadable" || got[1].OldVersion != 4 {
+		t.Fatalf("drift %+v", got)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRunMeta(t *testing.T) {
+	kv := newMemKV2()
+	c := fakeVaultClient(t, kv)
+	ctx := context.Background()
+	run := func(args ...string) error {
+		return runMeta(ctx, c, options{args: args}, nil)
+	}
+
+	if err := run("set", "kv/app/staging/db", "ttl-owner=alice", "tier=1"); err != nil {
+		t.Fatal(err)
+	}
+	got := kv.custom["app/staging/db"]
+	if got["owner"] != "team-a" || got["ttl-owner"] != "alice" || got["tier"] != "1" {
+		t.Fatalf("set must keep the other keys: %v", got)
+	}
+	if err := run("rm", "kv/app/staging/db", "tier"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := kv.custom["app/staging/db"]["tier"]; ok {
+		t.Fatal("rm left the key")
+	}
+	if err := run("rm", "kv/app/staging/db", "nope"); err == nil {
+		t.Fatal("removing a missing key should fail")
+	}
+	out := captureOutput(t, func() {
+		if err := run("get", "kv/app/staging/db"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if out != "owner: team-a\nttl-owner: alice\n" {
+		t.Fatalf("get output %q", out)
+	}
+	if err := run("list", "kv/app/staging/db"); err == nil {
+		t.Fatal("unknown action should fail")
+	}
+}
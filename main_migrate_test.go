@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// kv1AndKV2 serves a KV v1 mount "secret" from data and passes everything
+// else to a memKV2 mount "kv".
+type kv1AndKV2 struct {
+	kv1 map[string]map[string]interface{}
+	kv2 *memKV2
+}
+
+func (h *kv1AndKV2) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p := strings.TrimPrefix(r.URL.Path, "/v1/")
+	if p == "sys/mounts" {
+		w.Write([]byte(`{"data":{"secret/":{"type":"kv","options":{"version":"1"}},"kv/":{"type":"kv","options":{"version":"2"}}}}`))
+		return
+	}
+	key, ok := strings.CutPrefix(p+"/", "secret/")
+	if !ok {
+		h.kv2.ServeHTTP(w, r)
+		return
+	}
+	if r.URL.Query().Get("list") == "true" {
+		seen := map[string]bool{}
+		var keys []string
+		prefix := strings.TrimSuffix(key, "/")
+		if prefix != "" {
+			prefix += "/"
+		}
+		for k := range h.kv1 {
+			rest, ok := strings.CutPrefix(k, prefix)
+			if !ok {
+				continue
+			}
+			if i := strings.IndexByte(rest, '/'); i >= 0 {
+				rest = rest[:i+1]
+			}
+			if !seen[rest] {
+				seen[rest] = true
+				keys = append(keys, rest)
+			}
+		}
+		if keys == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		sort.Strings(keys)
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"keys": keys}})
+		return
+	}
+	d, ok := h.kv1[strings.TrimSuffix(key, "/")]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": d})
+}
+
+func TestRunMigrateKV1(t *testing.T) {
+	h := &kv1AndKV2{
+		kv1: map[string]map[string]interface{}{
+			"app/db":      {"password": "s1"},
+			"app/api/key": {"token": "t1"},
+			"other":       {"x": "1"},
+		},
+		// other was copied by an earlier, interrupted run
+		kv2: &memKV2{data: map[string]map[string]interface{}{"other": {"x": "1"}}, custom: map[string]map[string]string{}},
+	}
+	c := fakeVaultClient(t, h)
+	ctx := context.Background()
+	state := filepath.Join(t.TempDir(), "state")
+	if err := os.WriteFile(state, []byte("secret/other\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	opts := options{concurrency: 2, rate: 1000, stateFile: state, args: []string{"secret/", "kv/"}}
+
+	out := captureOutput(t, func() {
+		if err := runMigrateKV1(ctx, c, opts, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !strings.Contains(out, "copied secret/app/db -> kv/app/db") || strings.Contains(out, "copied secret/other") {
+		t.Fatalf("output %q", out)
+	}
+	if h.kv2.data["app/api/key"]["token"] != "t1" {
+		t.Fatalf("migrated %v", h.kv2.data)
+	}
+	b, _ := os.ReadFile(state)
+	if got := strings.Fields(string(b)); len(got) != 3 {
+		t.Fatalf("state file %q", b)
+	}
+
+	// A copy that no longer matches its source fails the verification pass
+	h.kv2.data["other"] = map[string]interface{}{"x": "2"}
+	err := func() (err error) {
+		captureOutput(t, func() { err = runMigrateKV1(ctx, c, opts, nil) })
+		return err
+	}()
+	if err == nil || !strings.Contains(err.Error(), "verification failed for 1 of 3") {
+		t.Fatalf("expected a verification failure, got %v", err)
+	}
+
+	if err := runMigrateKV1(ctx, c, options{args: []string{"kv/", "secret/"}}, nil); err == nil {
+		t.Fatal("a KV v2 source should be refused")
+	}
+}
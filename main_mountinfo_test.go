@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInspectMount(t *testing.T) {
+	client := newFakeVault(t, map[string]string{
+		"GET /v1/sys/mounts":                   `{"data":{"kv/":{"type":"kv","description":"apps","options":{"version":"2"}},"pki/":{"type":"pki"}}}`,
+		"GET /v1/sys/mounts/kv/tune":           `{"data":{"default_lease_ttl":3600,"max_lease_ttl":7200}}`,
+		"LIST /v1/sys/quotas/rate-limit":       `{"data":{"keys":["global","pki"]}}`,
+		"GET /v1/sys/quotas/rate-limit/global": `{"data":{"path":"","rate":100,"interval":1}}`,
+		"GET /v1/sys/quotas/rate-limit/pki":    `{"data":{"path":"pki/","rate":5,"interval":1}}`,
+	})
+	file := filepath.Join(t.TempDir(), "index.json")
+	updated := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	if err := writeIndex(file, pathIndex{Updated: updated, AllMounts: true, Paths: []string{"kv/a", "kv/b/c", "kvx/d", "pki/e"}}); err != nil {
+		t.Fatal(err)
+	}
+	d, err := inspectMount(context.Background(), client, options{indexFile: file}, "kv/b/c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Path != "kv/" || d.Type != "kv" || d.Version != "2" || d.Description != "apps" {
+		t.Fatalf("mount %+v", d.MountInfo)
+	}
+	if d.Secrets != 2 || !d.IndexUpdated.Equal(updated) {
+		t.Fatalf("secrets %d at %v", d.Secrets, d.IndexUpdated)
+	}
+	if d.TuneErr != nil || d.DefaultLeaseTTL != time.Hour || d.MaxLeaseTTL != 2*time.Hour {
+		t.Fatalf("ttls %v %v (%v)", d.DefaultLeaseTTL, d.MaxLeaseTTL, d.TuneErr)
+	}
+	if d.QuotaErr != nil || len(d.Quotas) != 1 || d.Quotas[0].Name != "global" {
+		t.Fatalf("quotas %+v (%v)", d.Quotas, d.QuotaErr)
+	}
+
+	// Without an index or quota access the panel still opens
+	d, err = inspectMount(context.Background(), client, options{indexFile: filepath.Join(t.TempDir(), "none.json")}, "pki/e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Secrets != -1 || d.TuneErr == nil {
+		t.Fatalf("expected unknown count and tune error, got %+v", d)
+	}
+	if _, err := inspectMount(context.Background(), client, options{}, "nope/x"); err == nil {
+		t.Fatal("expected an error for an unknown mount")
+	}
+}
+
+func TestIndexedSecrets_PerMount(t *testing.T) {
+	client := newFakeVault(t, nil)
+	file := filepath.Join(t.TempDir(), "index.json")
+	// Walked with -paths kv/,kvx/app/: kvx/ and secret/ are not covered
+	if err := writeIndex(file, pathIndex{Roots: []string{"kv/", "kvx/app/"}, Paths: []string{"kv/a", "kv/b/c", "kvx/app/d"}}); err != nil {
+		t.Fatal(err)
+	}
+	counts, _, ok := indexedSecrets(options{indexFile: file}, client, []string{"kv/", "kvx/", "secret/"})
+	if !ok || len(counts) != 1 || counts["kv/"] != 2 {
+		t.Fatalf("counts %v (ok %v)", counts, ok)
+	}
+	d, err := inspectMount(context.Background(), newFakeVault(t, map[string]string{
+		"GET /v1/sys/mounts": `{"data":{"kvx/":{"type":"kv","options":{"version":"2"}}}}`,
+	}), options{indexFile: file}, "kvx/app/d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Secrets != -1 {
+		t.Fatalf("partially indexed mount counted as %d", d.Secrets)
+	}
+	if _, _, ok := indexedSecrets(options{indexFile: filepath.Join(t.TempDir(), "none.json")}, client, []string{"kv/"}); ok {
+		t.Fatal("expected no counts without an index")
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestOrphans(t *testing.T) {
+	policy := `path \"kv/data/app/*\" {\n  capabilities = [\"read\"]\n}\npath \"kv/data/app/admin\" {\n  capabilities = [\"deny\"]\n}`
+	old := time.Now().Add(-400 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	recent := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	client := newFakeVault(t, map[string]string{
+		"LIST /v1/sys/policies/acl":        `{"data":{"keys":["default","root","team-a"]}}`,
+		"GET /v1/sys/policies/acl/default": `{"data":{"name":"default","policy":""}}`,
+		"GET /v1/sys/policies/acl/team-a":  `{"data":{"name":"team-a","policy":"` + policy + `"}}`,
+		"LIST /v1/kv/metadata":             `{"data":{"keys":["app/","legacy/"]}}`,
+		"LIST /v1/kv/metadata/app":         `{"data":{"keys":["admin","api","cache"]}}`,
+		"LIST /v1/kv/metadata/legacy":      `{"data":{"keys":["ftp"]}}`,
+		"GET /v1/kv/metadata/app/admin":    `{"data":{"updated_time":"` + recent + `"}}`,
+		"GET /v1/kv/metadata/app/api":      `{"data":{"updated_time":"` + recent + `"}}`,
+		"GET /v1/kv/metadata/app/cache":    `{"data":{"updated_time":"` + old + `"}}`,
+		"GET /v1/kv/metadata/legacy/ftp":   `{"data":{"updated_time":"` + recent + `"}}`,
+	})
+	opts := options{startPath: "kv", kv2: true, forceKV2: true, concurrency: 2, staleAfter: 180 * 24 * time.Hour}
+	out := captureOutput(t, func() {
+		if err := runOrphans(context.Background(), client, opts, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	want := `PREFIX      SECRETS  NO POLICY  STALE  ARCHIVE
+kv/app/     3        1          1      -
+kv/legacy/  1        1          0      yes
+`
+	if out != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", out, want)
+	}
+}
+
+func TestOrphanPrefixes(t *testing.T) {
+	got := orphanPrefixes([]orphanSecret{
+		{Path: "kv/a/x", NoPolicy: true, Stale: true},
+		{Path: "kv/a/y", Stale: true},
+		{Path: "kv/b/z"},
+	})
+	if len(got) != 1 || got[0] != (orphanPrefix{Prefix: "kv/a/", Secrets: 2, NoPolicy: 1, Stale: 2, Archive: true}) {
+		t.Fatalf("got %+v", got)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"fvf/search"
+)
+
+func TestParsePickerPaths_TrimsAndDedupes(t *testing.T) {
+	got, err := parsePickerPaths(strings.NewReader("kv/app/db\n\n  kv/app/api  \nkv/app/db\r\nkv/other\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"kv/app/db", "kv/app/api", "kv/other"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("got %v want %v", got, want)
+	}
+}
+
+func TestPickerItems_HonoursMatcher(t *testing.T) {
+	var got []string
+	for it := range pickerItems([]string{"kv/app/db", "kv/app/api", "kv/other/db"}, search.Filters{Matcher: regexp.MustCompile(`/db$`)}) {
+		got = append(got, it.Path)
+	}
+	if strings.Join(got, ",") != "kv/app/db,kv/other/db" {
+		t.Fatalf("unexpected items: %v", got)
+	}
+}
+
+func TestParseFlagsWithArgs_FromFile(t *testing.T) {
+	got := parseFlagsWithArgs([]string{"-json", "-from-file", "paths.txt"})
+	if got.fromFile != "paths.txt" {
+		t.Fatalf("expected fromFile=paths.txt, got %q", got.fromFile)
+	}
+}
+
+func TestParseFlagsWithArgs_StdinPicker(t *testing.T) {
+	if got := parseFlagsWithArgs([]string{"-name", "db"}); !got.stdinPicker || got.noTUI {
+		t.Fatalf("-name alone should keep the piped picker: %+v", got)
+	}
+	for _, args := range [][]string{{"-path", "kv/app/"}, {"-json"}, {"--paths=kv/a/,kv/b/"}, {"-interactive=false"}} {
+		if got := parseFlagsWithArgs(args); got.stdinPicker {
+			t.Fatalf("%v should turn off the piped picker", args)
+		}
+	}
+	if got := parseFlagsWithArgs([]string{"-from-file", "paths.txt", "-interactive=false"}); !got.noTUI {
+		t.Fatal("-interactive=false should print the picker paths")
+	}
+}
+
+func TestPrintPickerPaths(t *testing.T) {
+	out := captureOutput(t, func() {
+		if err := printPickerPaths([]string{"kv/app/db", "kv/app/api", "kv/other/db"}, options{printValues: true, namePart: "db"}, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if out != "kv/app/db\nkv/other/db\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPolicyCoverage(t *testing.T) {
+	policy := `path \"kv/data/app/*\" {\n  capabilities = [\"read\"]\n}\npath \"kv/data/app/admin\" {\n  capabilities = [\"deny\"]\n}\npath \"kv/metadata/app/\" {\n  capabilities = [\"list\"]\n}\npath \"kv/data/old/*\" {\n  capabilities = [\"read\"]\n}\npath \"sys/health\" {\n  capabilities = [\"read\"]\n}`
+	client := newFakeVault(t, map[string]string{
+		"GET /v1/sys/policies/acl/team-a": `{"data":{"name":"team-a","policy":"` + policy + `"}}`,
+		"LIST /v1/kv/metadata":            `{"data":{"keys":["app/","db"]}}`,
+		"LIST /v1/kv/metadata/app":        `{"data":{"keys":["admin","api"]}}`,
+	})
+	opts := options{startPath: "kv", kv2: true, forceKV2: true, policy: "team-a", concurrency: 1}
+	out := captureOutput(t, func() {
+		if err := runPolicyCoverage(context.Background(), client, opts, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	want := `policy team-a grants access to 1 of 3 secret(s)
+  kv/app/api  [read]  via "kv/data/app/*"
+denied explicitly (1):
+  kv/app/admin
+rules matching no secret (1):
+  "kv/data/old/*"  (line 10)
+`
+	if out != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", out, want)
+	}
+
+	if err := runPolicyCoverage(context.Background(), client, options{startPath: "kv"}, nil); err == nil || !strings.Contains(err.Error(), "-policy") {
+		t.Fatalf("expected a missing -policy error, got %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// casKV serves one KV v2 secret at kv/app/db (data and metadata) and
+// enforces check-and-set.
+type casKV struct {
+	mu      sync.Mutex
+	data    map[string]interface{}
+	version int
+}
+
+func (f *casKV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/v1/sys/mounts":
+		w.Write([]byte(`{"data":{"kv/":{"type":"kv","options":{"version":"2"}}}}`))
+	case r.URL.Path == "/v1/kv/data/app/db" && r.Method == http.MethodGet:
+		if f.version == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		b, _ := json.Marshal(map[string]interface{}{"data": map[string]interface{}{
+			"data": f.data, "metadata": map[string]interface{}{"version": f.version},
+		}})
+		w.Write(b)
+	case r.URL.Path == "/v1/kv/metadata/app/db" && r.Method == http.MethodGet:
+		if f.version == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		fmt.Fprintf(w, `{"data":{"current_version":%d}}`, f.version)
+	case r.URL.Path == "/v1/kv/data/app/db":
+		var body struct {
+			Data    map[string]interface{} `json:"data"`
+			Options map[string]int         `json:"options"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if cas, ok := body.Options["cas"]; ok && cas != f.version {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":["check-and-set parameter did not match the current version"]}`))
+			return
+		}
+		f.data, f.version = body.Data, f.version+1
+		fmt.Fprintf(w, `{"data":{"version":%d}}`, f.version)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestParseKVArgs(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "pem")
+	os.WriteFile(file, []byte("-----BEGIN-----\n"), 0o600)
+	old := putStdin
+	putStdin = strings.NewReader("s3cret\n")
+	t.Cleanup(func() { putStdin = old })
+
+	got, err := parseKVArgs([]string{"user=bob", "password=-", "cert=@" + file, "url=a=b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"user": "bob", "password": "s3cret", "cert": "-----BEGIN-----\n", "url": "a=b"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for _, bad := range [][]string{{"novalue"}, {"=x"}, {"a=1", "a=2"}, {"a=-", "b=-"}, nil} {
+		if _, err := parseKVArgs(bad); err == nil {
+			t.Errorf("parseKVArgs(%q) should fail", bad)
+		}
+	}
+}
+
+func TestRunPutPatch(t *testing.T) {
+	kv := &casKV{}
+	c := fakeVaultClient(t, kv)
+	ctx := context.Background()
+
+	if err := runPut(ctx, c, options{cas: 0, args: []string{"kv/app/db", "user=bob", "password=one"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := runPut(ctx, c, options{cas: 0, args: []string{"kv/app/db", "user=eve"}}, nil); err == nil {
+		t.Fatal("-cas 0 must not overwrite an existing secret")
+	}
+	out := captureOutput(t, func() {
+		if err := runPatch(ctx, c, options{cas: -1, jsonOut: true, args: []string{"kv/app/db", "password=two"}}, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !strings.Contains(out, `"version": 2`) {
+		t.Fatalf("patch output %q", out)
+	}
+	if kv.data["user"] != "bob" || kv.data["password"] != "two" {
+		t.Fatalf("patch lost keys: %v", kv.data)
+	}
+	if err := runPatch(ctx, c, options{cas: 1, args: []string{"kv/app/db", "password=three"}}, nil); err == nil {
+		t.Fatal("patch with a stale -cas should fail")
+	}
+	if err := runPut(ctx, c, options{cas: -1, args: []string{"kv/app/db", "token=x"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(kv.data) != 1 || kv.version != 3 {
+		t.Fatalf("put should replace the secret: %v (v%d)", kv.data, kv.version)
+	}
+	if err := runPut(ctx, c, options{cas: -1, args: []string{"kv", "a=b"}}, nil); err == nil {
+		t.Fatal("writing to a bare mount should fail")
+	}
+}
+
+func TestRunPatch_KV1(t *testing.T) {
+	var written map[string]interface{}
+	c := fakeVaultClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/v1/sys/mounts":
+			w.Write([]byte(`{"data":{"old/":{"type":"kv","options":{"version":"1"}}}}`))
+		case r.URL.Path == "/v1/old/app" && r.Method == http.MethodGet:
+			w.Write([]byte(`{"data":{"user":"bob","password":"one"}}`))
+		case r.URL.Path == "/v1/old/app":
+			json.NewDecoder(r.Body).Decode(&written)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	ctx := context.Background()
+	if err := runPatch(ctx, c, options{cas: -1, args: []string{"old/app", "password=two"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if written["user"] != "bob" || written["password"] != "two" {
+		t.Fatalf("KV v1 patch wrote %v", written)
+	}
+	if err := runPut(ctx, c, options{cas: 2, args: []string{"old/app", "a=b"}}, nil); err == nil {
+		t.Fatal("-cas on KV v1 should fail")
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckReadOnly(t *testing.T) {
+	cases := []struct {
+		args  []string
+		block bool
+	}{
+		{[]string{"put", "kv/a", "k=v"}, true},
+		{[]string{"rm", "kv/a"}, true},
+		{[]string{"rm", "-dry-run", "kv/a"}, false},
+		{[]string{"meta", "get", "kv/a"}, false},
+		{[]string{"meta", "set", "kv/a", "owner=me"}, true},
+		{[]string{"settings", "kv/a"}, false},
+		{[]string{"settings", "-max-versions", "5", "kv/a"}, true},
+		{[]string{"rollback", "-to-version", "2", "kv/a"}, true},
+		{[]string{"get", "kv/a"}, false},
+		{[]string{"-read-only=false", "put", "kv/a", "k=v"}, false},
+	}
+	for _, c := range cases {
+		args := c.args
+		readOnlyOff := args[0] == "-read-only=false"
+		if readOnlyOff {
+			args = args[1:]
+		}
+		cmd := findSubcommand(args)
+		flags := args[1:]
+		if readOnlyOff {
+			flags = append([]string{"-read-only=false"}, flags...)
+		}
+		err := checkReadOnly(cmd, parseFlagsWithArgs(flags))
+		if (err != nil) != c.block {
+			t.Errorf("%v: err=%v, want blocked=%v", c.args, err, c.block)
+		}
+		if err != nil && !strings.Contains(err.Error(), "read-only") {
+			t.Errorf("%v: unhelpful error %v", c.args, err)
+		}
+	}
+}
+
+func TestParseFlags_ReadOnlyFromConfig(t *testing.T) {
+	if !parseFlagsWithArgs(nil).readOnly {
+		t.Fatal("read-only should be on by default")
+	}
+	cfg := writeConfig(t, "read-only: false\n")
+	if parseFlagsWithArgs([]string{"-config", cfg}).readOnly {
+		t.Fatal("read-only: false in the config should enable writes")
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRedactValue(t *testing.T) {
+	r, err := newRedactRules("*private_key*, PASS", `^AKIA[0-9A-Z]{4}$`, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	redaction = r
+	defer func() { redaction = nil }()
+
+	v := redactValue(map[string]interface{}{
+		"tls_private_key": "-----BEGIN",
+		"Pass":            "s3cr3t",
+		"id":              "AKIAABCD",
+		"nested":          map[string]interface{}{"list": []interface{}{"AKIAWXYZ", "ok"}},
+		"user":            "bob",
+	}).(map[string]interface{})
+	if v["tls_private_key"] != redactMask || v["Pass"] != redactMask || v["id"] != redactMask || v["user"] != "bob" {
+		t.Fatalf("redacted %v", v)
+	}
+	if l := v["nested"].(map[string]interface{})["list"].([]interface{}); l[0] != redactMask || l[1] != "ok" {
+		t.Fatalf("nested %v", l)
+	}
+
+	if r, _ := newRedactRules("*key*", "x", true); r != nil {
+		t.Fatal("-no-redact should disable the rules")
+	}
+	if _, err := newRedactRules("[", "", false); err == nil {
+		t.Fatal("a bad glob should be refused")
+	}
+}
+
+func TestRunGet_Redacts(t *testing.T) {
+	redaction, _ = newRedactRules("pass", "", false)
+	defer func() { redaction = nil }()
+	c := newFakeVault(t, fakeKV)
+	out := captureOutput(t, func() {
+		if err := runGet(context.Background(), c, options{args: []string{"kv/app/web"}}, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if strings.Contains(out, "s3cr3t") || !strings.Contains(out, "pass: "+redactMask) {
+		t.Fatalf("get output %q", out)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRenamePlan(t *testing.T) {
+	from := regexp.MustCompile(`^kv/team-a/(.*)$`)
+	steps, err := renamePlan([]string{"kv/team-a/db", "kv/team-b/db", "kv/team-a/x/y"}, from, "kv/platform/$1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 2 || steps[0].Dst != "kv/platform/db" || steps[1].Dst != "kv/platform/x/y" {
+		t.Fatalf("plan %+v", steps)
+	}
+	if _, err := renamePlan([]string{"kv/a/db", "kv/b/db"}, regexp.MustCompile(`^kv/[ab]/`), "kv/c/"); err == nil {
+		t.Fatal("two sources on one destination should fail")
+	}
+	if _, err := renamePlan([]string{"kv/a", "kv/b"}, regexp.MustCompile(`^kv/(a|b)$`), "kv/${1}b"); err != nil {
+		t.Fatalf("kv/a -> kv/ab, kv/b -> kv/bb: %v", err)
+	}
+	_, err = renamePlan([]string{"kv/env/a", "kv/env/a/b"}, regexp.MustCompile(`^kv/env/a(/b)?$`), "kv/env/a/b$1")
+	if err == nil || !strings.Contains(err.Error(), "kv/env/a/b is both renamed") {
+		t.Fatalf("a chain a -> a/b, a/b -> a/b/b should fail, got %v", err)
+	}
+}
+
+func TestRunRename(t *testing.T) {
+	kv := newMemKV2()
+	c := fakeVaultClient(t, kv)
+	ctx := context.Background()
+	old := confirmInput
+	t.Cleanup(func() { confirmInput = old })
+	opts := options{startPath: "kv/app/", concurrency: 2, onConflict: "fail", renameFrom: `^kv/app/staging/(.*)$`, renameTo: "kv/app/qa/$1"}
+
+	confirmInput = strings.NewReader("no\n")
+	if err := runRename(ctx, c, opts, nil); err == nil {
+		t.Fatal("an unconfirmed rename should fail")
+	}
+	if len(kv.data) != 3 || kv.data["app/staging/db"] == nil {
+		t.Fatalf("unconfirmed rename changed %v", kv.data)
+	}
+
+	confirmInput = strings.NewReader("yes\n")
+	out := captureOutput(t, func() {
+		if err := runRename(ctx, c, opts, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !strings.Contains(out, "kv/app/staging/db -> kv/app/qa/db") {
+		t.Fatalf("rename output %q", out)
+	}
+	if kv.data["app/qa/db"]["password"] != "s1" || kv.data["app/qa/api/key"] == nil || kv.data["app/staging/db"] != nil {
+		t.Fatalf("rename result %v", kv.data)
+	}
+}
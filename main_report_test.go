@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"fvf/search"
+)
+
+func TestBuildAndWriteReport(t *testing.T) {
+	meta := func(p string) (*search.SecretMetadata, error) {
+		if p != "kv/app/db" {
+			return nil, nil
+		}
+		return &search.SecretMetadata{
+			CurrentVersion: 3,
+			UpdatedTime:    time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC),
+			CustomMetadata: map[string]string{"owner": "payments|team"},
+		}, nil
+	}
+	read := func(p string) (interface{}, error) {
+		if p == "kv/app/locked" {
+			return nil, errors.New("permission denied")
+		}
+		return map[string]interface{}{"user": "app", "password": "s3cret"}, nil
+	}
+	paths := []string{"kv/app/db", "kv/app/locked"}
+	h := reportHeader{Addr: "https://vault:8200", Query: "app", Generated: time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)}
+
+	entries := buildReport(paths, meta, read, 2, "mask", nil)
+	md := markdownReport(h, entries)
+	for _, want := range []string{
+		`2024-06-02 00:00:00 UTC · Vault https://vault:8200 · filter "app" · 2 secret(s)`,
+		`| kv/app/db | v3 | 2024-06-01 10:00:00 | owner=payments\|team | password=•••, user=••• |`,
+		`| kv/app/locked |  |  |  | error: permission denied |`,
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("missing %q in:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "s3cret") {
+		t.Fatal("report leaks a value")
+	}
+
+	salt, key, err := newReportKey("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashed := buildReport(paths[:1], nil, read, 1, "hash", key)
+	if k := hashed[0].Keys; len(k) != 2 || !strings.HasPrefix(k[0], "password=hmac-sha256:") || len(k[0]) != len("password=hmac-sha256:")+12 {
+		t.Fatalf("hashed keys %v", k)
+	}
+	_, other, _ := newReportKey("")
+	if again := buildReport(paths[:1], nil, read, 1, "hash", other); again[0].Keys[0] == hashed[0].Keys[0] {
+		t.Fatal("each report should hash with its own salt")
+	}
+	if sum := reportSummary(reportHeader{Salt: salt}, 1); !strings.Contains(sum, "salt "+salt) {
+		t.Fatalf("summary %q does not show the salt", sum)
+	}
+	if none := buildReport(paths, nil, func(string) (interface{}, error) {
+		t.Fatal("values read with -report-values none")
+		return nil, nil
+	}, 1, "none", nil); none[1].Keys != nil || none[1].Err != "" {
+		t.Fatalf("unexpected entry %+v", none[1])
+	}
+
+	file := filepath.Join(t.TempDir(), "report.html")
+	if err := writeReport(file, h, entries); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "<td>kv/app/db</td>") || !strings.Contains(string(b), "&#34;app&#34;") {
+		t.Fatalf("unexpected HTML report:\n%s", b)
+	}
+	if fi, _ := os.Stat(file); fi.Mode().Perm() != 0o600 {
+		t.Fatalf("report mode %v", fi.Mode().Perm())
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRunRm_Recursive(t *testing.T) {
+	kv := newMemKV2()
+	c := fakeVaultClient(t, kv)
+	ctx := context.Background()
+	old := confirmInput
+	t.Cleanup(func() { confirmInput = old })
+	base := options{recursive: true, concurrency: 2, args: []string{"kv/app/staging/"}}
+
+	dry := base
+	dry.dryRun = true
+	out := captureOutput(t, func() {
+		if err := runRm(ctx, c, dry, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if out != "would delete kv/app/staging/api/key\nwould delete kv/app/staging/db\n" {
+		t.Fatalf("dry-run output %q", out)
+	}
+
+	confirmInput = strings.NewReader("kv/app/\n")
+	if err := runRm(ctx, c, base, nil); err == nil {
+		t.Fatal("a wrong confirmation should abort")
+	}
+	confirmInput = strings.NewReader("")
+	if err := runRm(ctx, c, base, nil); err == nil {
+		t.Fatal("no confirmation should abort")
+	}
+	if len(kv.data) != 3 {
+		t.Fatal("aborted rm deleted secrets")
+	}
+
+	confirmInput = strings.NewReader("kv/app/staging/\n")
+	captureOutput(t, func() {
+		if err := runRm(ctx, c, base, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if len(kv.data) != 1 || kv.data["app/prod/db"] == nil {
+		t.Fatalf("rm -r left %v", kv.data)
+	}
+}
+
+func TestRunRm_Single(t *testing.T) {
+	kv := newMemKV2()
+	c := fakeVaultClient(t, kv)
+	ctx := context.Background()
+	if err := runRm(ctx, c, options{concurrency: 1, args: []string{"kv/app/"}}, nil); err == nil {
+		t.Fatal("deleting a folder without -r should fail")
+	}
+	captureOutput(t, func() {
+		if err := runRm(ctx, c, options{concurrency: 1, yes: true, args: []string{"kv/app/prod/db"}}, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if _, ok := kv.data["app/prod/db"]; ok || len(kv.data) != 2 {
+		t.Fatalf("rm left %v", kv.data)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestRPCServer(allowValues bool, audit *bytes.Buffer) *rpcServer {
+	return &rpcServer{
+		search: func(_ context.Context, p rpcSearchParams) ([]string, error) {
+			if p.Name == "db" {
+				return []string{"kv/app/db"}, nil
+			}
+			return []string{"kv/app/db", "kv/app/web"}, nil
+		},
+		read: func(_ context.Context, p string) (interface{}, error) {
+			return map[string]interface{}{"user": "bob"}, nil
+		},
+		allowValues: allowValues,
+		audit:       slog.New(slog.NewJSONHandler(audit, nil)),
+		timeout:     time.Second,
+	}
+}
+
+// rpcRoundTrip sends the request lines and decodes one response per line.
+func rpcRoundTrip(t *testing.T, s *rpcServer, lines ...string) []rpcResponse {
+	t.Helper()
+	var out bytes.Buffer
+	if err := s.serve(context.Background(), strings.NewReader(strings.Join(lines, "\n")), &out); err != nil {
+		t.Fatal(err)
+	}
+	var resps []rpcResponse
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var r rpcResponse
+		if err := dec.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		resps = append(resps, r)
+	}
+	return resps
+}
+
+func TestRPC_SearchAndAudit(t *testing.T) {
+	var audit bytes.Buffer
+	resps := rpcRoundTrip(t, newTestRPCServer(false, &audit),
+		`{"jsonrpc":"2.0","id":1,"method":"search","params":{"name":"db"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"nope"}`,
+	)
+	if len(resps) != 2 {
+		t.Fatalf("want 2 responses (notification unanswered), got %d", len(resps))
+	}
+	b, _ := json.Marshal(resps[0].Result)
+	if string(b) != `{"paths":["kv/app/db"]}` || string(resps[0].ID) != "1" {
+		t.Fatalf("search: id=%s result=%s", resps[0].ID, b)
+	}
+	if resps[1].Error == nil || resps[1].Error.Code != rpcMethodNotFound {
+		t.Fatalf("unknown method: %+v", resps[1])
+	}
+	if n := strings.Count(audit.String(), "\n"); n != 3 {
+		t.Fatalf("want one audit record per request, got %d:\n%s", n, audit.String())
+	}
+	if !strings.Contains(audit.String(), `"method":"search"`) || !strings.Contains(audit.String(), `\"name\":\"db\"`) {
+		t.Fatalf("audit record lacks method/params:\n%s", audit.String())
+	}
+}
+
+func TestRPC_GetNeedsAllowValues(t *testing.T) {
+	var audit bytes.Buffer
+	get := `{"jsonrpc":"2.0","id":1,"method":"get","params":{"path":"kv/app/db"}}`
+	resps := rpcRoundTrip(t, newTestRPCServer(false, &audit), get)
+	if resps[0].Error == nil || !strings.Contains(resps[0].Error.Message, "-rpc-allow-values") {
+		t.Fatalf("get without -rpc-allow-values: %+v", resps[0])
+	}
+	resps = rpcRoundTrip(t, newTestRPCServer(true, &audit), get)
+	if resps[0].Error != nil {
+		t.Fatalf("get with -rpc-allow-values: %+v", resps[0].Error)
+	}
+	if strings.Contains(audit.String(), "bob") {
+		t.Fatalf("audit log leaked a value:\n%s", audit.String())
+	}
+}
+
+func TestRPC_MCPTools(t *testing.T) {
+	var audit bytes.Buffer
+	toolNames := func(r rpcResponse) []string {
+		var res struct{ Tools []struct{ Name string } }
+		b, _ := json.Marshal(r.Result)
+		json.Unmarshal(b, &res)
+		var names []string
+		for _, tl := range res.Tools {
+			names = append(names, tl.Name)
+		}
+		return names
+	}
+	list := `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`
+	if got := toolNames(rpcRoundTrip(t, newTestRPCServer(false, &audit), list)[0]); !reflect.DeepEqual(got, []string{"fvf_search"}) {
+		t.Fatalf("tools without values: %v", got)
+	}
+	if got := toolNames(rpcRoundTrip(t, newTestRPCServer(true, &audit), list)[0]); !reflect.DeepEqual(got, []string{"fvf_search", "fvf_get"}) {
+		t.Fatalf("tools with values: %v", got)
+	}
+
+	resps := rpcRoundTrip(t, newTestRPCServer(false, &audit),
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"fvf_search","arguments":{"name":"db"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"fvf_get","arguments":{"path":"kv/app/db"}}}`,
+	)
+	var res struct {
+		Content []struct{ Text string }
+		IsError bool
+	}
+	b, _ := json.Marshal(resps[0].Result)
+	json.Unmarshal(b, &res)
+	if res.IsError || len(res.Content) != 1 || !strings.Contains(res.Content[0].Text, "kv/app/db") {
+		t.Fatalf("fvf_search: %s", b)
+	}
+	b, _ = json.Marshal(resps[1].Result)
+	res.IsError = false
+	json.Unmarshal(b, &res)
+	if !res.IsError {
+		t.Fatalf("fvf_get without values should be a tool error: %s", b)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const appSchema = `{
+  "type": "object",
+  "required": ["password", "port"],
+  "properties": {
+    "password": {"type": "string", "minLength": 12},
+    "port": {"type": "string", "pattern": "^[0-9]+$"},
+    "mode": {"enum": ["rw", "ro"]}
+  },
+  "additionalProperties": false
+}`
+
+func TestValidateSecret(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.json")
+	if err := os.WriteFile(file, []byte(appSchema), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	anything := filepath.Join(t.TempDir(), "any.json")
+	if err := os.WriteFile(anything, []byte(`{}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts := options{schemas: map[string]string{"kv/app/": file, "kv/app/legacy/": anything}}
+
+	ok := map[string]interface{}{"password": "0123456789ab", "port": "5432", "mode": "ro"}
+	if err := validateSecret(opts, "kv/app/db", ok); err != nil {
+		t.Fatal(err)
+	}
+	bad := map[string]interface{}{"password": "short", "mode": "rx", "extra": 1.0}
+	err := validateSecret(opts, "kv/app/db", bad)
+	if err == nil {
+		t.Fatal("expected schema errors")
+	}
+	for _, want := range []string{`missing required key "port"`, "password: shorter than 12", "mode: rx is not one of", "extra: not allowed"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q lacks %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "short\"") {
+		t.Errorf("error echoes the value: %q", err)
+	}
+	// The longer prefix wins, here with an empty schema
+	if err := validateSecret(opts, "kv/app/legacy/db", bad); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateSecret(opts, "kv/other/db", bad); err != nil {
+		t.Fatal(err)
+	}
+	if got := jsonType(3.0); got != "integer" {
+		t.Fatalf("jsonType(3.0) = %s", got)
+	}
+}
+
+func TestRunPut_Schema(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.json")
+	if err := os.WriteFile(file, []byte(appSchema), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f := &casKV{}
+	c := fakeVaultClient(t, f)
+	opts := options{cas: -1, schemas: map[string]string{"kv/app": file}, args: []string{"kv/app/db", "password=short"}}
+	if err := runPut(context.Background(), c, opts, nil); err == nil || !strings.Contains(err.Error(), "does not match the schema") {
+		t.Fatalf("expected a schema error, got %v", err)
+	}
+	if f.version != 0 {
+		t.Fatal("a secret failing its schema was written")
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"fvf/ui"
+)
+
+func newTestDaemon(token string) *daemon {
+	return &daemon{
+		walk: func(context.Context) ([]string, []ui.WalkError, error) {
+			return []string{"kv/app/web", "kv/app/db", "secret/ops/token"}, []ui.WalkError{{Path: "kv/locked", Err: errors.New("denied")}}, nil
+		},
+		read: func(_ context.Context, p string) (interface{}, error) {
+			return map[string]interface{}{"user": "bob"}, nil
+		},
+		token:   token,
+		timeout: time.Second,
+	}
+}
+
+func getJSON(t *testing.T, srv *httptest.Server, path, token string, v interface{}) int {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if v != nil {
+		json.NewDecoder(resp.Body).Decode(v)
+	}
+	return resp.StatusCode
+}
+
+func TestDaemon_SearchAndStats(t *testing.T) {
+	d := newTestDaemon("")
+	d.refresh(context.Background())
+	srv := httptest.NewServer(d.handler())
+	defer srv.Close()
+
+	var res struct{ Paths []string }
+	getJSON(t, srv, "/search?name=DB", "", &res)
+	if !reflect.DeepEqual(res.Paths, []string{"kv/app/db"}) {
+		t.Fatalf("search by name: %v", res.Paths)
+	}
+	getJSON(t, srv, "/search?match=^kv/", "", &res)
+	if !reflect.DeepEqual(res.Paths, []string{"kv/app/db", "kv/app/web"}) {
+		t.Fatalf("search by match: %v", res.Paths)
+	}
+	if code := getJSON(t, srv, "/search?match=(", "", nil); code != http.StatusBadRequest {
+		t.Fatalf("bad regexp status %d", code)
+	}
+
+	// A failed refresh keeps serving the previous index
+	d.walk = func(context.Context) ([]string, []ui.WalkError, error) { return nil, nil, errors.New("sealed") }
+	d.refresh(context.Background())
+	var st daemonStats
+	getJSON(t, srv, "/stats", "", &st)
+	if st.Paths != 3 || st.Refreshes != 2 || st.Skipped != 1 || st.LastError != "sealed" {
+		t.Fatalf("stats %+v", st)
+	}
+}
+
+func TestDaemon_GetNeedsToken(t *testing.T) {
+	open := httptest.NewServer(newTestDaemon("").handler())
+	defer open.Close()
+	if code := getJSON(t, open, "/get?path=kv/app/web", "", nil); code != http.StatusForbidden {
+		t.Fatalf("get without -serve-token: status %d", code)
+	}
+
+	srv := httptest.NewServer(newTestDaemon("s3cret").handler())
+	defer srv.Close()
+	if code := getJSON(t, srv, "/stats", "", nil); code != http.StatusUnauthorized {
+		t.Fatalf("missing token: status %d", code)
+	}
+	var res struct {
+		Path  string
+		Value map[string]interface{}
+	}
+	if code := getJSON(t, srv, "/get?path=kv/app/web", "s3cret", &res); code != http.StatusOK || res.Value["user"] != "bob" {
+		t.Fatalf("get: status %d, %+v", code, res)
+	}
+}
+
+func TestDaemonPaths_UnderRoots(t *testing.T) {
+	d := newTestDaemon("tok")
+	d.refresh(context.Background())
+	srv := httptest.NewServer(d.handler())
+	defer srv.Close()
+
+	paths, err := daemonPaths(context.Background(), srv.URL, "tok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := underRoots(paths, []string{"kv/app/"}); !reflect.DeepEqual(got, []string{"kv/app/db", "kv/app/web"}) {
+		t.Fatalf("underRoots: %v", got)
+	}
+	if _, err := daemonPaths(context.Background(), srv.URL, "wrong"); err == nil {
+		t.Fatal("expected an error with the wrong token")
+	}
+}
+
+func TestCheckLoopback(t *testing.T) {
+	for _, ok := range []string{"127.0.0.1:7373", "localhost:0", "[::1]:80"} {
+		if err := checkLoopback(ok); err != nil {
+			t.Errorf("%s: %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"0.0.0.0:7373", ":7373", "10.0.0.1:80"} {
+		if err := checkLoopback(bad); err == nil {
+			t.Errorf("%s should be refused", bad)
+		}
+	}
+}
+
+func TestDaemon_Metrics(t *testing.T) {
+	d := newTestDaemon("")
+	d.metrics = newDaemonMetrics()
+	d.refresh(context.Background())
+	d.walk = func(context.Context) ([]string, []ui.WalkError, error) { return nil, nil, errors.New("sealed") }
+	d.refresh(context.Background())
+
+	c := d.metrics.instrument(newFakeVault(t, fakeKV))
+	if _, err := c.Logical().List("kv/metadata/app"); err != nil {
+		t.Fatal(err)
+	}
+	c.Logical().Read("kv/data/missing")
+
+	srv := httptest.NewServer(d.handler())
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	out := string(b)
+	for _, want := range []string{
+		"fvf_walk_duration_seconds_count 2\n",
+		`fvf_walks_total{result="error"} 1`,
+		`fvf_walks_total{result="ok"} 1`,
+		`fvf_vault_requests_total{method="LIST"} 1`,
+		`fvf_vault_requests_total{method="GET"} 1`,
+		`fvf_vault_responses_total{code="2xx"} 1`,
+		`fvf_vault_responses_total{code="4xx"} 1`,
+		`fvf_secrets{mount="kv"} 2`,
+		`fvf_secrets{mount="secret"} 1`,
+		`fvf_walk_skipped_paths{mount="kv"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+}
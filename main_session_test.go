@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"fvf/ui"
+)
+
+func TestSessionFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "sub", "fvf.session")
+	if s, err := loadSession(file); s != nil || err != nil {
+		t.Fatalf("missing file: %v %v", s, err)
+	}
+	want := ui.Session{Tabs: []ui.SessionTab{{Query: "db", Roots: []string{"kv/app"}}}, Selected: []string{"kv/app/db"}}
+	sessionSaver(file)(want)
+	fi, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Fatalf("session file mode %v", fi.Mode().Perm())
+	}
+	got, err := loadSession(file)
+	if err != nil || !reflect.DeepEqual(*got, want) {
+		t.Fatalf("loaded %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRunSettings(t *testing.T) {
+	var mu sync.Mutex
+	writes := map[string]map[string]interface{}{}
+	c := fakeVaultClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/v1/sys/mounts":
+			w.Write([]byte(`{"data":{"kv/":{"type":"kv","options":{"version":"2"}}}}`))
+		case r.URL.Path == "/v1/kv/metadata/app" && r.URL.Query().Get("list") == "true":
+			w.Write([]byte(`{"data":{"keys":["db","web"]}}`))
+		case r.URL.Path == "/v1/kv/metadata/app/db" && r.Method == http.MethodGet:
+			w.Write([]byte(`{"data":{"current_version":2,"max_versions":10,"cas_required":true,"delete_version_after":"0s"}}`))
+		case r.URL.Path == "/v1/kv/metadata/app/web" && r.Method == http.MethodGet:
+			w.Write([]byte(`{"data":{"current_version":1,"max_versions":0,"cas_required":false,"delete_version_after":"0s"}}`))
+		case strings.HasPrefix(r.URL.Path, "/v1/kv/metadata/app/"):
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			writes[strings.TrimPrefix(r.URL.Path, "/v1/kv/metadata/")] = body
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	ctx := context.Background()
+	old := confirmInput
+	t.Cleanup(func() { confirmInput = old })
+	base := options{startPath: "kv/app", concurrency: 2, maxVersions: -1}
+
+	out := captureOutput(t, func() {
+		if err := runSettings(ctx, c, base, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !strings.Contains(out, "kv/app/db   10            true") || !strings.Contains(out, "kv/app/web  0             false") {
+		t.Fatalf("settings table %q", out)
+	}
+
+	set := base
+	set.maxVersions, set.deleteAfter = 10, "2160h"
+	confirmInput = strings.NewReader("yes\n")
+	out = captureOutput(t, func() {
+		if err := runSettings(ctx, c, set, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !strings.Contains(out, "kv/app/web: max_versions 0 -> 10, delete_version_after 0s -> 2160h0m0s") {
+		t.Fatalf("change list %q", out)
+	}
+	if len(writes) != 2 || writes["app/web"]["max_versions"] != float64(10) || writes["app/db"]["delete_version_after"] != "2160h0m0s" {
+		t.Fatalf("writes %v", writes)
+	}
+	if _, ok := writes["app/db"]["cas_required"]; ok {
+		t.Fatal("unset settings must not be sent")
+	}
+
+	bad := base
+	bad.casRequired = "maybe"
+	if err := runSettings(ctx, c, bad, nil); err == nil {
+		t.Fatal("-cas-required maybe should fail")
+	}
+}
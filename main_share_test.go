@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRunShare(t *testing.T) {
+	var wrapTTL, token, ns string
+	c := fakeVaultClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/sys/mounts":
+			w.Write([]byte(`{"data":{"kv/":{"type":"kv","options":{"version":"2"}}}}`))
+		case "/v1/kv/data/app/db":
+			wrapTTL, token, ns = r.Header.Get("X-Vault-Wrap-TTL"), r.Header.Get("X-Vault-Token"), r.Header.Get("X-Vault-Namespace")
+			w.Write([]byte(`{"wrap_info":{"token":"hvs.wrapped","accessor":"acc","ttl":900,"creation_time":"2024-05-01T10:00:00Z","creation_path":"kv/data/app/db"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	c.SetNamespace("team-a")
+	out := captureOutput(t, func() {
+		if err := runShare(context.Background(), c, options{wrapTTL: 15 * time.Minute, args: []string{"kv/app/db"}}, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if out != "hvs.wrapped\n" {
+		t.Fatalf("share output %q", out)
+	}
+	if wrapTTL != "900s" || token != "test" || ns != "team-a" {
+		t.Fatalf("wrapped read sent ttl=%q token=%q namespace=%q", wrapTTL, token, ns)
+	}
+	if c.Headers().Get("X-Vault-Wrap-TTL") != "" {
+		t.Fatal("wrapping must not leak into the session client")
+	}
+	if err := runShare(context.Background(), c, options{wrapTTL: time.Minute, args: []string{"kv/app/missing"}}, nil); err == nil {
+		t.Fatal("sharing a missing secret should fail")
+	}
+}
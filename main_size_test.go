@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	"fvf/search"
+)
+
+func TestSortItems(t *testing.T) {
+	items := []search.FoundItem{
+		{Path: "kv/b", Size: 10, Keys: 3},
+		{Path: "kv/a", Size: 10, Keys: 1},
+		{Path: "kv/c", Size: 2048, Keys: 2},
+	}
+	sortItems(items, "size")
+	if items[0].Path != "kv/c" || items[1].Path != "kv/a" || items[2].Path != "kv/b" {
+		t.Fatalf("by size: %+v", items)
+	}
+	sortItems(items, "keys")
+	if items[0].Path != "kv/b" || items[2].Path != "kv/a" {
+		t.Fatalf("by keys: %+v", items)
+	}
+	sortItems(items, "path")
+	if items[0].Path != "kv/a" || items[2].Path != "kv/c" {
+		t.Fatalf("by path: %+v", items)
+	}
+}
+
+func TestPrintItems_TableAndCSV(t *testing.T) {
+	items := []search.FoundItem{
+		{Path: "kv/small", Size: 12, Keys: 1, Value: map[string]interface{}{"a": "b"}},
+		{Path: "kv/big", Size: 3 << 20, Keys: 40},
+	}
+	out := captureOutput(t, func() {
+		if err := printItems(items, options{tableOut: "csv", sortBy: "size"}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if out != "path,keys,bytes\nkv/big,40,3145728\nkv/small,1,12\n" {
+		t.Fatalf("csv output %q", out)
+	}
+	out = captureOutput(t, func() {
+		if err := printItems(items, options{tableOut: "table", sortBy: "path"}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	want := "  KEYS     SIZE PATH\n    40  3.0 MiB kv/big\n     1     12 B kv/small\n"
+	if out != want {
+		t.Fatalf("table output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestValuesDuringWalk_Sizes(t *testing.T) {
+	if !valuesDuringWalk(options{tableOut: "table"}) || !valuesDuringWalk(options{sortBy: "size"}) {
+		t.Fatal("table output and -sort size need values")
+	}
+	if valuesDuringWalk(options{sortBy: "path"}) {
+		t.Fatal("-sort path does not need values")
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSyncRoots(t *testing.T) {
+	src, dst, err := syncRoots("sync", []string{"kv/app/prod", "/kv-dr/app/prod/"})
+	if err != nil || src != "kv/app/prod/" || dst != "kv-dr/app/prod/" {
+		t.Fatalf("got %q %q %v", src, dst, err)
+	}
+	if _, _, err := syncRoots("sync", []string{"kv/app/", "kv/app/prod/"}); err == nil {
+		t.Fatal("overlapping prefixes should be refused")
+	}
+}
+
+func TestRunSync(t *testing.T) {
+	kv := newMemKV2()
+	kv.data["app/prod/old"] = map[string]interface{}{"x": "1"}
+	kv.data["app/prod/api/key"] = map[string]interface{}{"token": "t1"}
+	c := fakeVaultClient(t, kv)
+	ctx := context.Background()
+	opts := options{concurrency: 2, args: []string{"kv/app/staging/", "kv/app/prod/"}}
+
+	dry := opts
+	dry.dryRun = true
+	out := captureOutput(t, func() {
+		if err := runSync(ctx, c, dry, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !strings.Contains(out, "would update kv/app/prod/db") || !strings.Contains(out, "would delete kv/app/prod/old") {
+		t.Fatalf("dry-run output %q", out)
+	}
+	if strings.Contains(out, "api/key") {
+		t.Fatalf("an unchanged secret was planned: %q", out)
+	}
+	if kv.data["app/prod/db"]["password"] != "p1" {
+		t.Fatal("-dry-run wrote secrets")
+	}
+
+	old := confirmInput
+	t.Cleanup(func() { confirmInput = old })
+	confirmInput = strings.NewReader("no\n")
+	captureOutput(t, func() {
+		if err := runSync(ctx, c, opts, nil); err == nil {
+			t.Fatal("deletes without typing yes should fail")
+		}
+	})
+	if _, ok := kv.data["app/prod/old"]; !ok || kv.data["app/prod/db"]["password"] != "p1" {
+		t.Fatal("an unconfirmed sync changed the destination")
+	}
+
+	opts.yes = true
+	captureOutput(t, func() {
+		if err := runSync(ctx, c, opts, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if kv.data["app/prod/db"]["password"] != "s1" {
+		t.Fatalf("sync result %v", kv.data)
+	}
+	if _, ok := kv.data["app/prod/old"]; ok {
+		t.Fatal("a secret missing from the source must be deleted")
+	}
+	if _, ok := kv.data["app/staging/db"]; !ok {
+		t.Fatal("sync must not touch the source")
+	}
+
+	out = captureOutput(t, func() {
+		if err := runSync(ctx, c, opts, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if strings.TrimSpace(out) != "" {
+		t.Fatalf("a second sync should change nothing, got %q", out)
+	}
+}
+
+func TestRunSync_UnreadableDestinationStops(t *testing.T) {
+	kv := newMemKV2()
+	kv.denied = map[string]bool{"app/prod/db": true}
+	c := fakeVaultClient(t, kv)
+	opts := options{concurrency: 2, yes: true, args: []string{"kv/app/staging/", "kv/app/prod/"}}
+	var err error
+	captureOutput(t, func() { err = runSync(context.Background(), c, opts, nil) })
+	if err == nil || !strings.Contains(err.Error(), "destination secret(s) could not be read") {
+		t.Fatalf("expected an unreadable destination to stop the sync, got %v", err)
+	}
+	if kv.data["app/prod/db"]["password"] != "p1" {
+		t.Fatal("an unreadable destination was overwritten")
+	}
+	if _, ok := kv.data["app/prod/api/key"]; ok {
+		t.Fatal("nothing should change when a destination cannot be read")
+	}
+
+	hook := syncHook(c, opts, "kv/app/staging/", "kv/app/prod/")
+	if err := hook(context.Background(), watchChange{Type: "updated", Path: "kv/app/staging/db"}); err == nil {
+		t.Fatal("the hook should not write a destination it cannot read")
+	}
+	if kv.data["app/prod/db"]["password"] != "p1" {
+		t.Fatal("the hook overwrote an unreadable destination")
+	}
+}
+
+func TestSyncHook_DeletesOnlyWithYes(t *testing.T) {
+	kv := newMemKV2()
+	c := fakeVaultClient(t, kv)
+	ctx := context.Background()
+	change := watchChange{Type: "deleted", Path: "kv/app/staging/db"}
+
+	if err := syncHook(c, options{concurrency: 1}, "kv/app/staging/", "kv/app/prod/")(ctx, change); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := kv.data["app/prod/db"]; !ok {
+		t.Fatal("-watch deleted a destination secret without -yes")
+	}
+	captureOutput(t, func() {
+		if err := syncHook(c, options{concurrency: 1, yes: true}, "kv/app/staging/", "kv/app/prod/")(ctx, change); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if _, ok := kv.data["app/prod/db"]; ok {
+		t.Fatal("-yes should propagate the delete")
+	}
+}
@@ -4,13 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"fvf/search"
 
@@ -42,6 +46,17 @@ func TestParseFlagsWithArgs_PathsParsing(t *testing.T) {
     }
 }
 
+func TestParseFlagsWithArgs_StatusBar(t *testing.T) {
+    got := parseFlagsWithArgs([]string{"-json", "-status-bar", "namespace,counts|addr|ttl"})
+    if len(got.statusLayout[0]) != 2 || got.statusLayout[0][0] != "namespace" || got.statusLayout[2][0] != "ttl" {
+        t.Fatalf("unexpected status layout: %#v", got.statusLayout)
+    }
+    def := parseFlagsWithArgs([]string{"-json"})
+    if len(def.statusLayout[0]) != 2 || def.statusLayout[0][0] != "ttl" || def.statusLayout[1][0] != "addr" {
+        t.Fatalf("unexpected default status layout: %#v", def.statusLayout)
+    }
+}
+
 func TestDetermineInteractive(t *testing.T) {
     // values + tty -> interactive
     if !determineInteractive(options{printValues: true}, 2, true) {
@@ -334,6 +349,18 @@ func TestPrintItems_JSONAndLines(t *testing.T) {
 	}
 }
 
+func TestPrintItems_Relative(t *testing.T) {
+	items := []search.FoundItem{{Path: "kv/app/config/db"}, {Path: "kv/other/x"}}
+	out := captureOutput(t, func() {
+		if err := printItems(items, options{relative: true, startPath: "kv/app/"}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if out != "config/db\nkv/other/x\n" {
+		t.Fatalf("relative output %q", out)
+	}
+}
+
 // Swap stdout via os.Stdout using a pipe to capture output into a buffer.
 
 // stdOutSwap redirects os.Stdout to the provided buffer.
@@ -395,3 +422,82 @@ func TestDecideKV2ForPath_Fallback(t *testing.T) {
 		t.Fatal("expected fallback to opts.kv2=true when detection not ok")
 	}
 }
+
+func TestUIPolicyPane(t *testing.T) {
+    if uiPolicyPane(0) >= 0 || uiPolicyPane(30) != 30 {
+        t.Fatalf("unexpected mapping: 0->%d 30->%d", uiPolicyPane(0), uiPolicyPane(30))
+    }
+}
+
+func TestParseFlags_PreviewCacheBudget(t *testing.T) {
+	opts := parseFlagsWithArgs([]string{"-preview-cache", "50", "-preview-cache-mb", "4"})
+	if opts.cacheEntries != 50 || opts.cacheMB != 4 {
+		t.Fatalf("got entries=%d mb=%d", opts.cacheEntries, opts.cacheMB)
+	}
+	if opts := parseFlagsWithArgs(nil); opts.cacheEntries != 500 || opts.cacheMB != 32 {
+		t.Fatalf("defaults: entries=%d mb=%d", opts.cacheEntries, opts.cacheMB)
+	}
+}
+
+func TestStartProfiling_WritesProfiles(t *testing.T) {
+	dir := t.TempDir()
+	cpu, mem := filepath.Join(dir, "cpu.out"), filepath.Join(dir, "mem.out")
+	if err := startProfiling(cpu, mem); err != nil {
+		t.Fatal(err)
+	}
+	stopProfiling()
+	stopProfiling() // idempotent
+	for _, p := range []string{cpu, mem} {
+		if fi, err := os.Stat(p); err != nil || fi.Size() == 0 {
+			t.Fatalf("profile %s not written: %v", p, err)
+		}
+	}
+	if err := startProfiling(filepath.Join(dir, "missing", "cpu.out"), ""); err == nil {
+		t.Fatal("expected error for unwritable cpu profile path")
+	}
+}
+
+func TestForEachLimit_BoundsConcurrencyAndStopsOnError(t *testing.T) {
+	var mu sync.Mutex
+	running, peak, calls := 0, 0, 0
+	err := forEachLimit(3, 20, func(i int) error {
+		mu.Lock()
+		running++
+		calls++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+		time.Sleep(2 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+	if err != nil || calls != 20 || peak > 3 || peak < 2 {
+		t.Fatalf("err=%v calls=%d peak=%d", err, calls, peak)
+	}
+
+	calls = 0
+	err = forEachLimit(1, 10, func(i int) error {
+		calls++
+		if i == 2 {
+			return fmt.Errorf("mount %d failed", i)
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "mount 2 failed" || calls != 3 {
+		t.Fatalf("err=%v calls=%d", err, calls)
+	}
+}
+
+func TestKVMounts_SortedKVOnly(t *testing.T) {
+	got := kvMounts(map[string]*vault.MountOutput{
+		"team-b/": {Type: "kv"},
+		"sys/":    {Type: "system"},
+		"team-a/": {Type: "kv"},
+	})
+	if !reflect.DeepEqual(got, []string{"team-a", "team-b"}) {
+		t.Fatalf("got %v", got)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+func TestRunGet_VaultOutput(t *testing.T) {
+	routes := map[string]string{
+		"GET /v1/sys/mounts":     `{"data":{"kv/":{"type":"kv","options":{"version":"2"}},"old/":{"type":"kv","options":{"version":"1"}}}}`,
+		"GET /v1/kv/data/app/db": `{"data":{"data":{"password":"s3cr3t","user":"bob","note":""},"metadata":{"created_time":"2024-05-01T10:00:00.123456Z","custom_metadata":null,"deletion_time":"","destroyed":false,"version":3}}}`,
+		"GET /v1/old/app":        `{"data":{"foo":"bar"}}`,
+	}
+	c := newFakeVault(t, routes)
+	out := captureOutput(t, func() {
+		if err := runGet(context.Background(), c, options{vaultOut: true, args: []string{"kv/app/db"}}, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	want := `= Secret Path =
+kv/data/app/db
+
+======= Metadata =======
+Key                Value
+---                -----
+created_time       2024-05-01T10:00:00.123456Z
+custom_metadata    <nil>
+deletion_time      n/a
+destroyed          false
+version            3
+
+====== Data ======
+Key         Value
+---         -----
+note        n/a
+password    s3cr3t
+user        bob
+`
+	if out != want {
+		t.Fatalf("kv2 output\n%s\nwant\n%s", out, want)
+	}
+
+	out = captureOutput(t, func() {
+		if err := runGet(context.Background(), c, options{vaultOut: true, args: []string{"old/app"}}, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if want := "=== Data ===\nKey    Value\n---    -----\nfoo    bar\n"; out != want {
+		t.Fatalf("kv1 output %q, want %q", out, want)
+	}
+}
+
+func TestVaultPadEqualSigns(t *testing.T) {
+	for _, tc := range []struct {
+		header string
+		width  int
+		want   string
+	}{
+		{"Secret Path", 17, "== Secret Path =="},
+		{"Secret Path", 14, "= Secret Path ="},
+		{"Data", 2, "== Data =="},
+	} {
+		if got := vaultPadEqualSigns(tc.header, tc.width); got != tc.want {
+			t.Errorf("vaultPadEqualSigns(%q, %d) = %q, want %q", tc.header, tc.width, got, tc.want)
+		}
+	}
+	if got := formatVaultKVGet("kv/data/x", true, &vault.Secret{Data: map[string]interface{}{}}); got != "== Secret Path ==\nkv/data/x\n\n" {
+		t.Errorf("empty kv2 secret %q", got)
+	}
+}
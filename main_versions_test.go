@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParseVersions(t *testing.T) {
+	got, err := parseVersions("5-7, 1,2,6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != "[1 2 5 6 7]" {
+		t.Fatalf("got %v", got)
+	}
+	for _, bad := range []string{"", "0", "a", "3-1", "1-x"} {
+		if _, err := parseVersions(bad); err == nil {
+			t.Errorf("parseVersions(%q) should fail", bad)
+		}
+	}
+}
+
+func TestRemoveVersions(t *testing.T) {
+	calls := map[string][]int{}
+	c := fakeVaultClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/v1/sys/mounts":
+			w.Write([]byte(`{"data":{"kv/":{"type":"kv","options":{"version":"2"}}}}`))
+		case r.URL.Path == "/v1/kv/metadata/app/db":
+			w.Write([]byte(`{"data":{"current_version":3,"versions":{
+				"1":{"created_time":"2024-01-01T00:00:00Z","deletion_time":"2024-01-02T00:00:00Z","destroyed":false},
+				"2":{"created_time":"2024-02-01T00:00:00Z","deletion_time":"","destroyed":false},
+				"3":{"created_time":"2024-03-01T00:00:00Z","deletion_time":"","destroyed":false}}}}`))
+		case strings.HasPrefix(r.URL.Path, "/v1/kv/delete/"), strings.HasPrefix(r.URL.Path, "/v1/kv/destroy/"):
+			var body struct{ Versions []int }
+			json.NewDecoder(r.Body).Decode(&body)
+			calls[strings.TrimPrefix(r.URL.Path, "/v1/")] = body.Versions
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	ctx := context.Background()
+	old := confirmInput
+	t.Cleanup(func() { confirmInput = old })
+
+	out := captureOutput(t, func() {
+		if err := runDelete(ctx, c, options{concurrency: 1, versions: "1-2,9", dryRun: true, args: []string{"kv/app/db"}}, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	// v1 is already deleted and v9 does not exist
+	if out != "would delete kv/app/db v2\n" || len(calls) != 0 {
+		t.Fatalf("dry-run output %q, calls %v", out, calls)
+	}
+
+	captureOutput(t, func() {
+		if err := runDelete(ctx, c, options{concurrency: 1, versions: "1,2", args: []string{"kv/app/db"}}, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if fmt.Sprint(calls["kv/delete/app/db"]) != "[2]" {
+		t.Fatalf("delete calls %v", calls)
+	}
+
+	confirmInput = strings.NewReader("\n")
+	if err := runDestroy(ctx, c, options{concurrency: 1, versions: "1,2", args: []string{"kv/app/db"}}, nil); err == nil {
+		t.Fatal("destroy without confirmation should fail")
+	}
+	confirmInput = strings.NewReader("yes\n")
+	captureOutput(t, func() {
+		if err := runDestroy(ctx, c, options{concurrency: 1, versions: "1,2", args: []string{"kv/app/db"}}, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	// Destroying also purges the data of soft-deleted versions
+	if fmt.Sprint(calls["kv/destroy/app/db"]) != "[1 2]" {
+		t.Fatalf("destroy calls %v", calls)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffVersions(t *testing.T) {
+	now := time.Now()
+	prev := map[string]int{"kv/a": 1, "kv/b": 2, "kv/gone": 3, "kv/flaky": 4}
+	next := map[string]int{"kv/a": 1, "kv/b": 3, "kv/new": 1, "kv/flaky": versionUnknown}
+	got := diffVersions(prev, next, now)
+	want := []watchChange{
+		{Path: "kv/b", Type: "updated", OldVersion: 2, NewVersion: 3, Time: now},
+		{Path: "kv/gone", Type: "deleted", OldVersion: 3, Time: now},
+		{Path: "kv/new", Type: "created", NewVersion: 1, Time: now},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v", got)
+	}
+	if next["kv/flaky"] != 4 {
+		t.Fatalf("unknown version should carry the previous one, got %d", next["kv/flaky"])
+	}
+}
+
+func TestWatcher_PollRunsHooks(t *testing.T) {
+	var posted []watchChange
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var c watchChange
+		json.NewDecoder(r.Body).Decode(&c)
+		posted = append(posted, c)
+	}))
+	defer srv.Close()
+
+	marker := filepath.Join(t.TempDir(), "hook.out")
+	cmdHook, err := commandHook(`printf '%s %s %s\n' {{.Path}} {{.Type}} "$FVF_NEW_VERSION" >> ` + marker)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snaps := []map[string]int{{"kv/a": 1}, nil, {"kv/a": 2, "kv/it's": 1}}
+	poll := 0
+	var out bytes.Buffer
+	w := &watcher{
+		snapshot: func(context.Context) (map[string]int, error) {
+			s := snaps[poll]
+			poll++
+			if s == nil {
+				return nil, errors.New("vault down")
+			}
+			return s, nil
+		},
+		hooks: []func(context.Context, watchChange) error{webhookHook(srv.URL), cmdHook},
+		out:   &out,
+	}
+	for range snaps {
+		w.poll(context.Background())
+	}
+
+	if len(posted) != 2 || posted[0].Path != "kv/a" || posted[0].Type != "updated" || posted[1].Path != "kv/it's" {
+		t.Fatalf("webhook got %+v", posted)
+	}
+	b, _ := os.ReadFile(marker)
+	if string(b) != "kv/a updated 2\nkv/it's created 1\n" {
+		t.Fatalf("command hook wrote %q", b)
+	}
+	if !strings.Contains(out.String(), "updated kv/a v1 -> v2") {
+		t.Fatalf("report %q", out.String())
+	}
+}
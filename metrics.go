@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"fvf/search"
+	"fvf/ui"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// daemonMetrics collects the fvf serve counters exposed on /metrics in the
+// Prometheus text format.
+type daemonMetrics struct {
+	mu        sync.Mutex
+	requests  map[string]int // Vault API requests by method
+	responses map[string]int // Vault API responses by status class, e.g. "4xx"
+	walks     map[string]int // walks by result: ok, error
+	walkSum   float64
+	lastWalk  time.Duration
+	secrets   map[string]int // secrets per mount in the last successful walk
+	skipped   map[string]int // paths skipped per mount in the last successful walk
+}
+
+func newDaemonMetrics() *daemonMetrics {
+	return &daemonMetrics{
+		requests:  make(map[string]int),
+		responses: make(map[string]int),
+		walks:     make(map[string]int),
+		secrets:   make(map[string]int),
+		skipped:   make(map[string]int),
+	}
+}
+
+// instrument returns a client that counts its Vault API calls into m.
+func (m *daemonMetrics) instrument(c *vault.Client) *vault.Client {
+	return c.WithRequestCallbacks(func(r *vault.Request) {
+		method := r.Method
+		if method == http.MethodGet && r.Params.Get("list") == "true" {
+			method = "LIST" // the client sends LIST as GET ?list=true
+		}
+		m.mu.Lock()
+		m.requests[method]++
+		m.mu.Unlock()
+	}).WithResponseCallbacks(func(r *vault.Response) {
+		m.mu.Lock()
+		m.responses[fmt.Sprintf("%dxx", r.StatusCode/100)]++
+		m.mu.Unlock()
+	})
+}
+
+// observeWalk records one index walk; a failed walk keeps the last inventory.
+func (m *daemonMetrics) observeWalk(took time.Duration, paths []string, skipped []ui.WalkError, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.walkSum += took.Seconds()
+	m.lastWalk = took
+	if err != nil {
+		m.walks["error"]++
+		return
+	}
+	m.walks["ok"]++
+	m.secrets = make(map[string]int)
+	for _, p := range paths {
+		mnt, _ := search.SplitMount(p)
+		m.secrets[mnt]++
+	}
+	m.skipped = make(map[string]int)
+	for _, e := range skipped {
+		mnt, _ := search.SplitMount(e.Path)
+		m.skipped[mnt]++
+	}
+}
+
+func (m *daemonMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.write(w)
+}
+
+// write renders the metrics in the Prometheus text exposition format.
+func (m *daemonMetrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	walks := m.walks["ok"] + m.walks["error"]
+	fmt.Fprintln(w, "# HELP fvf_walk_duration_seconds Duration of index walks.")
+	fmt.Fprintln(w, "# TYPE fvf_walk_duration_seconds summary")
+	fmt.Fprintf(w, "fvf_walk_duration_seconds_sum %g\n", m.walkSum)
+	fmt.Fprintf(w, "fvf_walk_duration_seconds_count %d\n", walks)
+	fmt.Fprintln(w, "# HELP fvf_last_walk_duration_seconds Duration of the most recent index walk.")
+	fmt.Fprintln(w, "# TYPE fvf_last_walk_duration_seconds gauge")
+	fmt.Fprintf(w, "fvf_last_walk_duration_seconds %g\n", m.lastWalk.Seconds())
+	writeLabeled(w, "fvf_walks_total", "Index walks by result.", "counter", "result", m.walks)
+	writeLabeled(w, "fvf_vault_requests_total", "Vault API requests by method.", "counter", "method", m.requests)
+	writeLabeled(w, "fvf_vault_responses_total", "Vault API responses by status class.", "counter", "code", m.responses)
+	writeLabeled(w, "fvf_secrets", "Secrets per mount found by the last successful walk.", "gauge", "mount", m.secrets)
+	writeLabeled(w, "fvf_walk_skipped_paths", "Paths per mount the last successful walk skipped because of errors.", "gauge", "mount", m.skipped)
+}
+
+func writeLabeled(w io.Writer, name, help, typ, label string, values map[string]int) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s{%s=%q} %d\n", name, label, k, values[k])
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"fvf/search"
+	"fvf/ui"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// inspectMount gathers the mount panel details (Alt-m) for the mount holding
+// p: engine and version, the secret count from the index, lease TTLs and
+// the rate-limit quotas covering it. Only an unknown mount is an error; the
+// parts the token may not read are reported in the details.
+func inspectMount(ctx context.Context, client *vault.Client, opts options, p string) (*ui.MountDetails, error) {
+	mnt, _ := search.SplitMount(p)
+	mounts, err := search.ListMountsWithFallback(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := mounts[mnt+"/"]
+	if !ok {
+		return nil, fmt.Errorf("no mount found for %s", p)
+	}
+	d := &ui.MountDetails{
+		MountInfo:   ui.MountInfo{Path: mnt + "/", Type: m.Type, Version: m.Options["version"], Secrets: -1},
+		Description: m.Description,
+	}
+	if tune, err := search.ReadMountTune(ctx, client.Logical(), mnt); err != nil {
+		d.TuneErr = err
+	} else {
+		d.DefaultLeaseTTL, d.MaxLeaseTTL = tune.DefaultLeaseTTL, tune.MaxLeaseTTL
+		if d.Description == "" {
+			d.Description = tune.Description
+		}
+	}
+	if counts, updated, ok := indexedSecrets(opts, client, []string{d.Path}); ok {
+		if n, ok := counts[d.Path]; ok {
+			d.Secrets, d.IndexUpdated = n, updated
+		}
+	}
+	quotas, err := search.ReadRateLimitQuotas(ctx, client.Logical())
+	if err != nil {
+		d.QuotaErr = err
+	}
+	full := search.NormalizeNamespace(client.Namespace()) + d.Path
+	for _, q := range quotas {
+		if q.Applies(full) {
+			d.Quotas = append(d.Quotas, q)
+		}
+	}
+	return d, nil
+}
+
+// indexedSecrets counts the secrets of the path index (fvf index) below each
+// of mounts ("kv/") the index walked completely and returns when the index
+// was written; mounts it does not cover are left out of counts. ok is false
+// when there is no readable index.
+func indexedSecrets(opts options, client *vault.Client, mounts []string) (counts map[string]int, updated time.Time, ok bool) {
+	file, err := indexPath(opts, client.Address(), client.Namespace())
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+	idx, err := readIndex(file)
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+	counts = make(map[string]int, len(mounts))
+	for _, m := range mounts {
+		if !idx.covers(m) {
+			continue
+		}
+		counts[m] = 0
+		for _, p := range idx.Paths {
+			if strings.HasPrefix(p, m) {
+				counts[m]++
+			}
+		}
+	}
+	return counts, idx.Updated, true
+}
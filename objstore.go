@@ -0,0 +1,537 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// uploadPartSize is the size of each part sent to object storage: within the
+// S3 (5 MiB minimum) and GCS (multiple of 256 KiB) limits and small enough to
+// keep in memory. Replaced in tests.
+var uploadPartSize = 8 << 20
+
+// upload is a destination being written: Close finishes it, Abort discards
+// whatever was written so far.
+type upload interface {
+	io.WriteCloser
+	Abort()
+}
+
+// openUpload starts writing target: a local file, or an s3://bucket/key,
+// gs://bucket/object or az://account/container/blob URL uploaded part by
+// part, so a large archive never needs a local copy. Credentials come from
+// the usual environment variables of each provider.
+func openUpload(ctx context.Context, target string) (upload, error) {
+	scheme, rest, ok := strings.Cut(target, "://")
+	if !ok {
+		f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		return &fileUpload{f}, nil
+	}
+	var (
+		sink partSink
+		err  error
+	)
+	switch scheme {
+	case "s3":
+		sink, err = newS3Sink(ctx, rest)
+	case "gs":
+		sink, err = newGCSSink(ctx, rest)
+	case "az":
+		sink, err = newAzureSink(rest)
+	default:
+		return nil, fmt.Errorf("unsupported upload target %s://; use a file, s3://, gs:// or az://", scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &partWriter{ctx: ctx, sink: sink}, nil
+}
+
+// fileUpload writes a local file, removed again on Abort.
+type fileUpload struct{ *os.File }
+
+func (f *fileUpload) Abort() {
+	f.File.Close()
+	os.Remove(f.Name())
+}
+
+// partSink receives an upload one numbered part (from 1) at a time. The last
+// part, which may be empty, comes with last set.
+type partSink interface {
+	put(ctx context.Context, n int, part []byte, last bool) error
+	complete(ctx context.Context) error
+	abort(ctx context.Context)
+}
+
+// partWriter cuts what is written into uploadPartSize parts for a sink. A
+// full part is only sent once more data follows, so the sink learns which
+// part is the last.
+type partWriter struct {
+	ctx  context.Context
+	sink partSink
+	buf  []byte
+	n    int
+	err  error
+}
+
+func (w *partWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if w.err != nil {
+			return written, w.err
+		}
+		if len(w.buf) == uploadPartSize {
+			w.send(false)
+			continue
+		}
+		if w.buf == nil {
+			w.buf = make([]byte, 0, uploadPartSize)
+		}
+		k := min(uploadPartSize-len(w.buf), len(p))
+		w.buf = append(w.buf, p[:k]...)
+		p, written = p[k:], written+k
+	}
+	return written, nil
+}
+
+func (w *partWriter) send(last bool) {
+	w.n++
+	if err := w.sink.put(w.ctx, w.n, w.buf, last); err != nil {
+		w.err = fmt.Errorf("upload part %d: %w", w.n, err)
+		return
+	}
+	w.buf = w.buf[:0]
+}
+
+// Close sends the last part and completes the upload.
+func (w *partWriter) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	w.send(true)
+	if w.err != nil {
+		return w.err
+	}
+	w.err = errors.New("upload already closed")
+	return w.sink.complete(w.ctx)
+}
+
+// Abort discards the parts sent so far. It runs even after the command's
+// context ended, which is usually why the upload is abandoned.
+func (w *partWriter) Abort() {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(w.ctx), 15*time.Second)
+	defer cancel()
+	w.sink.abort(ctx)
+}
+
+// splitBucket splits "bucket/key" (the URL after the scheme).
+func splitBucket(scheme, rest string) (string, string, error) {
+	bucket, key, _ := strings.Cut(rest, "/")
+	if bucket == "" || strings.Trim(key, "/") == "" {
+		return "", "", fmt.Errorf("%s://%s: want %s://<bucket>/<object>", scheme, rest, scheme)
+	}
+	return bucket, key, nil
+}
+
+// sendUpload runs req and returns the response if its status is one of want;
+// otherwise the status and the start of the body become the error.
+func sendUpload(req *http.Request, want ...int) (*http.Response, []byte, error) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, code := range want {
+		if resp.StatusCode == code {
+			return resp, body, nil
+		}
+	}
+	msg := strings.TrimSpace(string(body))
+	if len(msg) > 300 {
+		msg = msg[:300] + "…"
+	}
+	// The query is left out: it may carry a SAS token.
+	where := *req.URL
+	where.RawQuery = ""
+	return nil, nil, fmt.Errorf("%s %s: %s: %s", req.Method, where.Redacted(), resp.Status, msg)
+}
+
+// s3Sink uploads to S3 (or an S3-compatible store) with a multipart upload
+// signed with Signature Version 4.
+type s3Sink struct {
+	object   string // object URL, path escaped, without a query
+	region   string
+	creds    s3Creds
+	uploadID string
+	etags    []string
+}
+
+type s3Creds struct {
+	accessKey, secretKey, token string
+}
+
+// newS3Sink starts a multipart upload for "bucket/key". Credentials come from
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN, the region
+// from AWS_REGION or AWS_DEFAULT_REGION (us-east-1), and AWS_ENDPOINT_URL_S3
+// or AWS_ENDPOINT_URL point at another S3-compatible endpoint (path-style).
+func newS3Sink(ctx context.Context, rest string) (*s3Sink, error) {
+	bucket, key, err := splitBucket("s3", rest)
+	if err != nil {
+		return nil, err
+	}
+	creds := s3Creds{os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), os.Getenv("AWS_SESSION_TOKEN")}
+	if creds.accessKey == "" || creds.secretKey == "" {
+		return nil, errors.New("s3: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	region := firstEnv("AWS_REGION", "AWS_DEFAULT_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+	var object string
+	switch endpoint := firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"); {
+	case endpoint != "":
+		object = strings.TrimRight(endpoint, "/") + "/" + awsEscape(bucket, false) + "/" + awsEscape(key, true)
+	case strings.Contains(bucket, "."):
+		// Dotted bucket names do not match the wildcard certificate.
+		object = "https://s3." + region + ".amazonaws.com/" + bucket + "/" + awsEscape(key, true)
+	default:
+		object = "https://" + bucket + ".s3." + region + ".amazonaws.com/" + awsEscape(key, true)
+	}
+	s := &s3Sink{object: object, region: region, creds: creds}
+	var created struct {
+		UploadID string `xml:"UploadId"`
+	}
+	_, body, err := s.do(ctx, http.MethodPost, url.Values{"uploads": {""}}, nil, http.StatusOK)
+	if err != nil {
+		return nil, fmt.Errorf("s3: start upload: %w", err)
+	}
+	if err := xml.Unmarshal(body, &created); err != nil || created.UploadID == "" {
+		return nil, fmt.Errorf("s3: start upload: no UploadId in %q", body)
+	}
+	s.uploadID = created.UploadID
+	return s, nil
+}
+
+func (s *s3Sink) put(ctx context.Context, n int, part []byte, last bool) error {
+	if last && len(part) == 0 && n > 1 {
+		return nil // everything went out with the earlier parts
+	}
+	q := url.Values{"partNumber": {fmt.Sprint(n)}, "uploadId": {s.uploadID}}
+	resp, _, err := s.do(ctx, http.MethodPut, q, part, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	s.etags = append(s.etags, resp.Header.Get("ETag"))
+	return nil
+}
+
+func (s *s3Sink) complete(ctx context.Context) error {
+	type s3Part struct {
+		PartNumber int
+		ETag       string
+	}
+	doc := struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []s3Part `xml:"Part"`
+	}{}
+	for i, etag := range s.etags {
+		doc.Parts = append(doc.Parts, s3Part{i + 1, etag})
+	}
+	body, err := xml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	_, resp, err := s.do(ctx, http.MethodPost, url.Values{"uploadId": {s.uploadID}}, body, http.StatusOK)
+	if err != nil {
+		return fmt.Errorf("s3: complete upload: %w", err)
+	}
+	// S3 may report a failed completion in a 200 response.
+	if bytes.Contains(resp, []byte("<Error>")) {
+		return fmt.Errorf("s3: complete upload: %s", resp)
+	}
+	return nil
+}
+
+func (s *s3Sink) abort(ctx context.Context) {
+	s.do(ctx, http.MethodDelete, url.Values{"uploadId": {s.uploadID}}, nil, http.StatusNoContent)
+}
+
+func (s *s3Sink) do(ctx context.Context, method string, q url.Values, body []byte, want int) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.object+"?"+awsQuery(q), bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	signS3(req, body, s.creds, s.region, time.Now())
+	return sendUpload(req, want)
+}
+
+// signS3 adds the Signature Version 4 headers for the S3 service to req,
+// signing the host and every header already set.
+func signS3(req *http.Request, body []byte, c s3Creds, region string, now time.Time) {
+	now = now.UTC()
+	sum := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(sum[:]))
+	req.Header.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	if c.token != "" {
+		req.Header.Set("X-Amz-Security-Token", c.token)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, vals := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(strings.Fields(strings.Join(vals, ",")), " ")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonHeaders strings.Builder
+	for _, name := range names {
+		canonHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonical := strings.Join([]string{req.Method, path, awsQuery(req.URL.Query()),
+		canonHeaders.String(), signed, hex.EncodeToString(sum[:])}, "\n")
+	day := now.Format("20060102")
+	scope := day + "/" + region + "/s3/aws4_request"
+	hashed := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + now.Format("20060102T150405Z") + "\n" + scope + "\n" + hex.EncodeToString(hashed[:])
+
+	key := []byte("AWS4" + c.secretKey)
+	for _, part := range []string{day, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.accessKey, scope, signed, hex.EncodeToString(hmacSHA256(key, toSign))))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
+
+// awsQuery is the canonical query string: sorted, every key and value escaped.
+func awsQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range q[k] {
+			parts = append(parts, awsEscape(k, false)+"="+awsEscape(v, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsEscape percent-encodes everything but the unreserved characters (and
+// "/" when keepSlash is set), as Signature Version 4 expects.
+func awsEscape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// gcsSink uploads to Google Cloud Storage with a resumable upload session.
+type gcsSink struct {
+	session string
+	sent    int64
+}
+
+// newGCSSink opens a resumable upload for "bucket/object" with the OAuth
+// token in GOOGLE_OAUTH_ACCESS_TOKEN; STORAGE_EMULATOR_HOST points at an
+// emulator instead of storage.googleapis.com.
+func newGCSSink(ctx context.Context, rest string) (*gcsSink, error) {
+	bucket, object, err := splitBucket("gs", rest)
+	if err != nil {
+		return nil, err
+	}
+	token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	if token == "" {
+		return nil, errors.New("gs: set GOOGLE_OAUTH_ACCESS_TOKEN, e.g. to the output of gcloud auth print-access-token")
+	}
+	endpoint := "https://storage.googleapis.com"
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		endpoint = strings.TrimRight(host, "/")
+		if !strings.Contains(endpoint, "://") {
+			endpoint = "http://" + endpoint
+		}
+	}
+	start := endpoint + "/upload/storage/v1/b/" + url.PathEscape(bucket) + "/o?" +
+		url.Values{"uploadType": {"resumable"}, "name": {object}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, start, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-Upload-Content-Type", "application/octet-stream")
+	resp, _, err := sendUpload(req, http.StatusOK)
+	if err != nil {
+		return nil, fmt.Errorf("gs: start upload: %w", err)
+	}
+	session := resp.Header.Get("Location")
+	if session == "" {
+		return nil, errors.New("gs: start upload: no session URL in the response")
+	}
+	return &gcsSink{session: session}, nil
+}
+
+func (g *gcsSink) put(ctx context.Context, n int, part []byte, last bool) error {
+	total := "*"
+	if last {
+		total = fmt.Sprint(g.sent + int64(len(part)))
+	}
+	span := "*"
+	if len(part) > 0 {
+		span = fmt.Sprintf("%d-%d", g.sent, g.sent+int64(len(part))-1)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, g.session, bytes.NewReader(part))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Range", "bytes "+span+"/"+total)
+	want := []int{http.StatusPermanentRedirect} // 308: more to come
+	if last {
+		want = []int{http.StatusOK, http.StatusCreated}
+	}
+	resp, _, err := sendUpload(req, want...)
+	if err != nil {
+		return err
+	}
+	if !last && len(part) > 0 {
+		// The session may persist less than a chunk; parts are not kept to resend.
+		if got, want := resp.Header.Get("Range"), fmt.Sprintf("bytes=0-%d", g.sent+int64(len(part))-1); got != want {
+			return fmt.Errorf("gs: server kept %q of the upload, want %q", got, want)
+		}
+	}
+	g.sent += int64(len(part))
+	return nil
+}
+
+// complete has nothing left to do: the last chunk finalized the object.
+func (g *gcsSink) complete(context.Context) error { return nil }
+
+func (g *gcsSink) abort(ctx context.Context) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, g.session, nil)
+	if err == nil {
+		sendUpload(req, 499)
+	}
+}
+
+// azureSink uploads to Azure Blob Storage as staged blocks committed with a
+// block list.
+type azureSink struct {
+	blob   string // blob URL without a query
+	sas    string
+	blocks []string
+}
+
+// newAzureSink targets "account/container/blob" with the SAS token in
+// AZURE_STORAGE_SAS_TOKEN; AZURE_STORAGE_BLOB_ENDPOINT (e.g. Azurite's
+// http://127.0.0.1:10000/devstoreaccount1) replaces the account's endpoint.
+func newAzureSink(rest string) (*azureSink, error) {
+	account, path, _ := strings.Cut(rest, "/")
+	container, blob, _ := strings.Cut(path, "/")
+	if account == "" || container == "" || strings.Trim(blob, "/") == "" {
+		return nil, fmt.Errorf("az://%s: want az://<account>/<container>/<blob>", rest)
+	}
+	sas := strings.TrimPrefix(os.Getenv("AZURE_STORAGE_SAS_TOKEN"), "?")
+	if sas == "" {
+		return nil, errors.New("az: set AZURE_STORAGE_SAS_TOKEN to a SAS token allowing writes to the container")
+	}
+	endpoint := os.Getenv("AZURE_STORAGE_BLOB_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "https://" + account + ".blob.core.windows.net"
+	}
+	return &azureSink{
+		blob: strings.TrimRight(endpoint, "/") + "/" + url.PathEscape(container) + "/" + awsEscape(blob, true),
+		sas:  sas,
+	}, nil
+}
+
+func (a *azureSink) put(ctx context.Context, n int, part []byte, last bool) error {
+	if last && len(part) == 0 && n > 1 {
+		return nil
+	}
+	// Block IDs must all have the same length.
+	id := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("fvf-%08d", n)))
+	_, err := a.do(ctx, url.Values{"comp": {"block"}, "blockid": {id}}, part)
+	if err != nil {
+		return err
+	}
+	a.blocks = append(a.blocks, id)
+	return nil
+}
+
+func (a *azureSink) complete(ctx context.Context) error {
+	var body bytes.Buffer
+	body.WriteString(xml.Header + "<BlockList>")
+	for _, id := range a.blocks {
+		body.WriteString("<Latest>" + id + "</Latest>")
+	}
+	body.WriteString("</BlockList>")
+	if _, err := a.do(ctx, url.Values{"comp": {"blocklist"}}, body.Bytes()); err != nil {
+		return fmt.Errorf("az: commit blocks: %w", err)
+	}
+	return nil
+}
+
+// abort leaves the staged blocks, which Azure discards uncommitted after a week.
+func (a *azureSink) abort(context.Context) {}
+
+func (a *azureSink) do(ctx context.Context, q url.Values, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, a.blob+"?"+q.Encode()+"&"+a.sas, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Ms-Version", "2021-08-06")
+	resp, _, err := sendUpload(req, http.StatusCreated)
+	return resp, err
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
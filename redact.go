@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// redactMask replaces redacted values in previews and output.
+const redactMask = "[redacted]"
+
+// redactRules mask secret values by key name or by value before they are
+// shown: -redact-keys globs (e.g. *private_key*, matched case-insensitively
+// against each key) and the -redact-values regexp.
+type redactRules struct {
+	keys   []string
+	values *regexp.Regexp
+}
+
+// redaction is the active rule set; nil when no rules are configured or
+// -no-redact is given.
+var redaction *redactRules
+
+// newRedactRules parses the comma-separated key globs and the value regexp.
+func newRedactRules(keys, values string, off bool) (*redactRules, error) {
+	if off {
+		return nil, nil
+	}
+	r := &redactRules{}
+	for _, k := range strings.Split(keys, ",") {
+		if k = strings.ToLower(strings.TrimSpace(k)); k == "" {
+			continue
+		}
+		if _, err := path.Match(k, ""); err != nil {
+			return nil, fmt.Errorf("-redact-keys %q: %w", k, err)
+		}
+		r.keys = append(r.keys, k)
+	}
+	if values != "" {
+		re, err := regexp.Compile(values)
+		if err != nil {
+			return nil, fmt.Errorf("-redact-values: %w", err)
+		}
+		r.values = re
+	}
+	if len(r.keys) == 0 && r.values == nil {
+		return nil, nil
+	}
+	return r, nil
+}
+
+func (r *redactRules) keyMatches(k string) bool {
+	k = strings.ToLower(k)
+	for _, g := range r.keys {
+		if ok, _ := path.Match(g, k); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// redactValue masks every value in v under a matching key, and every string
+// matching the value regexp, in place for maps and slices. It returns v, or
+// the mask when v itself is a matching string.
+func redactValue(v interface{}) interface{} {
+	r := redaction
+	if r == nil {
+		return v
+	}
+	var walk func(v interface{}) interface{}
+	walk = func(v interface{}) interface{} {
+		switch vv := v.(type) {
+		case map[string]interface{}:
+			for k, x := range vv {
+				if r.keyMatches(k) {
+					vv[k] = redactMask
+				} else {
+					vv[k] = walk(x)
+				}
+			}
+		case []interface{}:
+			for i, x := range vv {
+				vv[i] = walk(x)
+			}
+		case string:
+			if r.values != nil && r.values.MatchString(vv) {
+				return redactMask
+			}
+		}
+		return v
+	}
+	return walk(v)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"fvf/search"
+)
+
+// reportMask stands in for a value in reports with -report-values mask.
+const reportMask = "•••"
+
+// reportEntry is one secret in a report: its KV v2 metadata, if any, and its
+// keys with masked or hashed values.
+type reportEntry struct {
+	Path string
+	Meta *search.SecretMetadata
+	Keys []string // "key=•••" or "key=sha256:…", sorted
+	Err  string
+}
+
+// reportHeader describes where a report's secrets came from.
+type reportHeader struct {
+	Addr      string
+	Namespace string
+	Query     string
+	Generated time.Time
+	// Salt is the base64 salt of hashed values; empty unless hashed.
+	Salt string
+}
+
+// reportValue renders v as -report-values asks: masked, or the first 12 hex
+// digits of its HMAC-SHA-256 under the report's key (see valueDigest), so
+// equal values can be spotted within a report without showing them, and
+// cannot be guessed from it without -report-key.
+func reportValue(v interface{}, mode string, key []byte) string {
+	if mode != "hash" {
+		return reportMask
+	}
+	d, err := valueDigest(key, v)
+	if err != nil {
+		return reportMask
+	}
+	return d[:len("hmac-sha256:")+12]
+}
+
+// newReportKey returns a fresh random salt for a hashed report, shown in its
+// summary, and the digest key combining it with secret (-report-key), like a
+// manifest's.
+func newReportKey(secret string) (salt string, key []byte, err error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+	salt = base64.StdEncoding.EncodeToString(raw)
+	key, err = manifestKey(salt, secret)
+	return salt, key, err
+}
+
+// buildReport reads the metadata and, unless mode is "none", the values of
+// paths, at most limit at a time. Failed reads are noted in the entry rather
+// than failing the report.
+func buildReport(paths []string, readMeta func(p string) (*search.SecretMetadata, error), read func(p string) (interface{}, error), limit int, mode string, key []byte) []reportEntry {
+	entries := make([]reportEntry, len(paths))
+	_ = forEachLimit(limit, len(paths), func(i int) error {
+		e := reportEntry{Path: paths[i]}
+		if readMeta != nil {
+			if md, err := readMeta(paths[i]); err == nil {
+				e.Meta = md
+			}
+		}
+		if mode != "none" {
+			val, err := read(paths[i])
+			if err != nil {
+				e.Err = err.Error()
+			} else if data, ok := val.(map[string]interface{}); ok {
+				for k, v := range data {
+					e.Keys = append(e.Keys, k+"="+reportValue(v, mode, key))
+				}
+				sort.Strings(e.Keys)
+			}
+		}
+		entries[i] = e
+		return nil
+	})
+	return entries
+}
+
+// reportColumns are a report's table cells for e: path, version, updated,
+// custom metadata and keys.
+func reportColumns(e reportEntry) []string {
+	cols := []string{e.Path, "", "", "", strings.Join(e.Keys, ", ")}
+	if e.Err != "" {
+		cols[4] = "error: " + e.Err
+	}
+	if md := e.Meta; md != nil {
+		cols[1] = fmt.Sprintf("v%d", md.CurrentVersion)
+		if !md.UpdatedTime.IsZero() {
+			cols[2] = md.UpdatedTime.UTC().Format(time.DateTime)
+		}
+		kvs := make([]string, 0, len(md.CustomMetadata))
+		for k, v := range md.CustomMetadata {
+			kvs = append(kvs, k+"="+v)
+		}
+		sort.Strings(kvs)
+		cols[3] = strings.Join(kvs, ", ")
+	}
+	return cols
+}
+
+var reportHeadings = []string{"Path", "Version", "Updated (UTC)", "Custom metadata", "Keys"}
+
+// reportSummary is the line under a report's title.
+func reportSummary(h reportHeader, n int) string {
+	parts := []string{h.Generated.UTC().Format(time.DateTime) + " UTC", "Vault " + h.Addr}
+	if h.Namespace != "" {
+		parts = append(parts, "namespace "+h.Namespace)
+	}
+	if h.Query != "" {
+		parts = append(parts, fmt.Sprintf("filter %q", h.Query))
+	}
+	parts = append(parts, fmt.Sprintf("%d secret(s)", n))
+	if h.Salt != "" {
+		parts = append(parts, "values as HMAC-SHA-256 prefixes, salt "+h.Salt)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// markdownReport renders entries as a Markdown table.
+func markdownReport(h reportHeader, entries []reportEntry) string {
+	cell := strings.NewReplacer("|", `\|`, "\n", " ")
+	var b strings.Builder
+	b.WriteString("# fvf report\n\n")
+	b.WriteString(reportSummary(h, len(entries)) + "\n\n")
+	b.WriteString("| " + strings.Join(reportHeadings, " | ") + " |\n")
+	b.WriteString(strings.Repeat("| --- ", len(reportHeadings)) + "|\n")
+	for _, e := range entries {
+		cols := reportColumns(e)
+		for i := range cols {
+			cols[i] = cell.Replace(cols[i])
+		}
+		b.WriteString("| " + strings.Join(cols, " | ") + " |\n")
+	}
+	return b.String()
+}
+
+var htmlReportTmpl = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>fvf report</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+td:first-child { font-family: monospace; }
+</style>
+</head>
+<body>
+<h1>fvf report</h1>
+<p>{{.Summary}}</p>
+<table>
+<tr>{{range .Headings}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// htmlReport renders entries as a standalone HTML page.
+func htmlReport(h reportHeader, entries []reportEntry) (string, error) {
+	rows := make([][]string, len(entries))
+	for i, e := range entries {
+		rows[i] = reportColumns(e)
+	}
+	var b strings.Builder
+	err := htmlReportTmpl.Execute(&b, map[string]interface{}{
+		"Summary":  reportSummary(h, len(entries)),
+		"Headings": reportHeadings,
+		"Rows":     rows,
+	})
+	return b.String(), err
+}
+
+// writeReport writes entries to file, as HTML for .html/.htm names and as
+// Markdown otherwise, readable by the user only.
+func writeReport(file string, h reportHeader, entries []reportEntry) error {
+	var doc string
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".html", ".htm":
+		var err error
+		if doc, err = htmlReport(h, entries); err != nil {
+			return err
+		}
+	default:
+		doc = markdownReport(h, entries)
+	}
+	return writePrivateFile(file, []byte(doc))
+}
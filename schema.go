@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// jsonSchema is the subset of JSON Schema fvf checks secrets against: type,
+// enum, required, properties, additionalProperties (a boolean or a schema),
+// items, pattern, minLength/maxLength and minimum/maximum. Other keywords are
+// ignored.
+type jsonSchema struct {
+	Type                 interface{}            `json:"type"` // a name or a list of names
+	Enum                 []interface{}          `json:"enum"`
+	Required             []string               `json:"required"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	AdditionalProperties json.RawMessage        `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	Pattern              string                 `json:"pattern"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+
+	pattern    *regexp.Regexp
+	additional *jsonSchema // nil: anything allowed
+	noExtra    bool        // additionalProperties: false
+}
+
+// loadSchema reads a JSON Schema file.
+func loadSchema(file string) (*jsonSchema, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var s jsonSchema
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if err := s.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return &s, nil
+}
+
+// compile checks the keywords fvf evaluates and prepares patterns.
+func (s *jsonSchema) compile() error {
+	switch t := s.Type.(type) {
+	case nil, string:
+	case []interface{}:
+		for _, n := range t {
+			if _, ok := n.(string); !ok {
+				return fmt.Errorf("type: want a name or a list of names")
+			}
+		}
+	default:
+		return fmt.Errorf("type: want a name or a list of names")
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("pattern: %w", err)
+		}
+		s.pattern = re
+	}
+	switch raw := strings.TrimSpace(string(s.AdditionalProperties)); {
+	case raw == "" || raw == "true":
+	case raw == "false":
+		s.noExtra = true
+	default:
+		s.additional = &jsonSchema{}
+		if err := json.Unmarshal(s.AdditionalProperties, s.additional); err != nil {
+			return fmt.Errorf("additionalProperties: %w", err)
+		}
+		if err := s.additional.compile(); err != nil {
+			return fmt.Errorf("additionalProperties: %w", err)
+		}
+	}
+	for k, p := range s.Properties {
+		if err := p.compile(); err != nil {
+			return fmt.Errorf("properties.%s: %w", k, err)
+		}
+	}
+	if s.Items != nil {
+		if err := s.Items.compile(); err != nil {
+			return fmt.Errorf("items: %w", err)
+		}
+	}
+	return nil
+}
+
+// jsonType names the JSON type of a decoded value.
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case int, int64:
+		return "integer"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// validate appends a description of every violation below at (a key path,
+// empty for the secret itself) to problems.
+func (s *jsonSchema) validate(v interface{}, at string, problems []string) []string {
+	fail := func(format string, args ...interface{}) {
+		msg := fmt.Sprintf(format, args...)
+		if at != "" {
+			msg = at + ": " + msg
+		}
+		problems = append(problems, msg)
+	}
+	if names := s.typeNames(); len(names) > 0 {
+		got, ok := jsonType(v), false
+		for _, n := range names {
+			// Every integer is a number too
+			if n == got || (n == "number" && got == "integer") {
+				ok = true
+			}
+		}
+		if !ok {
+			fail("want %s, got %s", strings.Join(names, " or "), got)
+			return problems
+		}
+	}
+	if len(s.Enum) > 0 {
+		ok := false
+		for _, e := range s.Enum {
+			if fmt.Sprint(e) == fmt.Sprint(v) && jsonType(e) == jsonType(v) {
+				ok = true
+			}
+		}
+		if !ok {
+			fail("%v is not one of %v", v, s.Enum)
+		}
+	}
+	switch v := v.(type) {
+	case string:
+		n := len([]rune(v))
+		if s.MinLength != nil && n < *s.MinLength {
+			fail("shorter than %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("longer than %d characters", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			// Never echo the value: it is usually secret
+			fail("does not match %s", s.Pattern)
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			fail("less than %v", *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			fail("greater than %v", *s.Maximum)
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, e := range v {
+				problems = s.Items.validate(e, fmt.Sprintf("%s[%d]", at, i), problems)
+			}
+		}
+	case map[string]interface{}:
+		for _, k := range s.Required {
+			if _, ok := v[k]; !ok {
+				fail("missing required key %q", k)
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sub := k
+			if at != "" {
+				sub = at + "." + k
+			}
+			if p, ok := s.Properties[k]; ok {
+				problems = p.validate(v[k], sub, problems)
+			} else if s.noExtra {
+				problems = append(problems, sub+": not allowed by the schema")
+			} else if s.additional != nil {
+				problems = s.additional.validate(v[k], sub, problems)
+			}
+		}
+	}
+	return problems
+}
+
+func (s *jsonSchema) typeNames() []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		names := make([]string, len(t))
+		for i, n := range t {
+			names[i], _ = n.(string)
+		}
+		return names
+	}
+	return nil
+}
+
+// schemaFor returns the -schemas entry with the longest prefix p is below;
+// file is "" when no schema applies.
+func schemaFor(schemas map[string]string, p string) (prefix, file string) {
+	p = strings.Trim(p, "/")
+	best := -1
+	for pre, f := range schemas {
+		t := strings.Trim(pre, "/")
+		if (p == t || strings.HasPrefix(p, t+"/")) && len(t) > best {
+			best, prefix, file = len(t), pre, f
+		}
+	}
+	return prefix, file
+}
+
+// validateSecret checks data against the schema configured for p, if any, so
+// writes fail before a malformed secret reaches Vault.
+func validateSecret(opts options, p string, data map[string]interface{}) error {
+	prefix, file := schemaFor(opts.schemas, p)
+	if file == "" {
+		return nil
+	}
+	s, err := loadSchema(file)
+	if err != nil {
+		return fmt.Errorf("schema for %s: %w", prefix, err)
+	}
+	problems := s.validate(data, "", nil)
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s does not match the schema %s (-schemas %s): %s", strings.Trim(p, "/"), file, prefix, strings.Join(problems, "; "))
+}
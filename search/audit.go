@@ -0,0 +1,75 @@
+package search
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// AuditEvent is one completed operation on a secret, taken from the JSON
+// output of a Vault audit device.
+type AuditEvent struct {
+	Time        time.Time
+	Operation   string // read, list, update, delete, ...
+	Path        string // API path, e.g. kv/data/app/db
+	DisplayName string // token display name, e.g. "oidc-alice"
+	EntityID    string
+	RemoteAddr  string
+	Error       string
+}
+
+// auditLine is the subset of an audit device entry ScanAuditLog needs.
+type auditLine struct {
+	Time time.Time `json:"time"`
+	Type string    `json:"type"`
+	Auth struct {
+		DisplayName string `json:"display_name"`
+		EntityID    string `json:"entity_id"`
+	} `json:"auth"`
+	Request struct {
+		Operation     string `json:"operation"`
+		Path          string `json:"path"`
+		RemoteAddress string `json:"remote_address"`
+	} `json:"request"`
+	Error string `json:"error"`
+}
+
+// ScanAuditLog returns the last limit response entries of a Vault audit log
+// (one JSON object per line) whose request path is one of apiPaths, newest
+// first. Lines that are not audit entries are skipped.
+func ScanAuditLog(r io.Reader, apiPaths []string, limit int) ([]AuditEvent, error) {
+	want := make(map[string]bool, len(apiPaths))
+	for _, p := range apiPaths {
+		want[p] = true
+	}
+	var out []AuditEvent
+	sc := bufio.NewScanner(r)
+	// Audit entries carry whole responses and can be large
+	sc.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for sc.Scan() {
+		var l auditLine
+		if json.Unmarshal(sc.Bytes(), &l) != nil || l.Type != "response" || !want[l.Request.Path] {
+			continue
+		}
+		out = append(out, AuditEvent{
+			Time:        l.Time,
+			Operation:   l.Request.Operation,
+			Path:        l.Request.Path,
+			DisplayName: l.Auth.DisplayName,
+			EntityID:    l.Auth.EntityID,
+			RemoteAddr:  l.Request.RemoteAddress,
+			Error:       l.Error,
+		})
+		if limit > 0 && len(out) > limit {
+			out = out[1:]
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, nil
+}
@@ -0,0 +1,30 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScanAuditLog(t *testing.T) {
+	log := strings.Join([]string{
+		`{"time":"2024-05-01T10:00:00Z","type":"request","auth":{"display_name":"alice"},"request":{"operation":"read","path":"kv/data/app/db"}}`,
+		`{"time":"2024-05-01T10:00:00Z","type":"response","auth":{"display_name":"alice","entity_id":"e1"},"request":{"operation":"read","path":"kv/data/app/db","remote_address":"10.0.0.1"}}`,
+		`not json`,
+		`{"time":"2024-05-02T10:00:00Z","type":"response","auth":{"display_name":"bob"},"request":{"operation":"read","path":"kv/data/other"}}`,
+		`{"time":"2024-05-03T10:00:00Z","type":"response","auth":{"display_name":"ci"},"request":{"operation":"update","path":"kv/data/app/db"},"error":"permission denied"}`,
+		`{"time":"2024-05-04T10:00:00Z","type":"response","auth":{"display_name":"ops"},"request":{"operation":"read","path":"kv/metadata/app/db"}}`,
+	}, "\n")
+
+	got, err := ScanAuditLog(strings.NewReader(log), []string{"kv/data/app/db", "kv/metadata/app/db"}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].DisplayName != "ops" || got[1].Operation != "update" || got[1].Error != "permission denied" {
+		t.Fatalf("want the 2 newest matches, newest first: %+v", got)
+	}
+
+	got, _ = ScanAuditLog(strings.NewReader(log), []string{"kv/data/app/db"}, 0)
+	if len(got) != 2 || got[1].EntityID != "e1" || got[1].RemoteAddr != "10.0.0.1" || got[1].Time.Day() != 1 {
+		t.Fatalf("responses only, all fields: %+v", got)
+	}
+}
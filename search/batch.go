@@ -0,0 +1,58 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// defaultListBatch is how many keys of a listing a walk takes at a time when
+// WalkOptions.ListBatch is 0.
+const defaultListBatch = 256
+
+// checkListSize applies WalkOptions.MaxListKeys to a listing of n keys, so a
+// directory with hundreds of thousands of children can be skipped instead of
+// walked.
+func checkListSize(listPath string, n int, o *WalkOptions) error {
+	if o.MaxListKeys > 0 && n > o.MaxListKeys {
+		return &kindError{kind: ErrListTooLarge, err: fmt.Errorf("%s lists %d keys, more than the limit of %d", listPath, n, o.MaxListKeys)}
+	}
+	if n > 10*batchSize(o) {
+		slog.Debug("large listing", "path", listPath, "keys", n)
+	}
+	return nil
+}
+
+func batchSize(o *WalkOptions) int {
+	if o.ListBatch > 0 {
+		return o.ListBatch
+	}
+	return defaultListBatch
+}
+
+// eachListBatch calls fn for every key of a LIST response in order, a batch
+// at a time. Only one batch of keys is converted at once, and cancellation is
+// checked between keys, so a huge directory neither doubles its footprint as
+// a second key slice nor delays Ctrl-C until its end. Items reach the caller
+// as each key is handled; nothing waits for the whole directory.
+func eachListBatch(ctx context.Context, raw []interface{}, o *WalkOptions, fn func(key string) error) error {
+	n := batchSize(o)
+	batch := make([]string, 0, min(n, len(raw)))
+	for start := 0; start < len(raw); start += n {
+		batch = batch[:0]
+		for _, k := range raw[start:min(start+n, len(raw))] {
+			if key, ok := k.(string); ok {
+				batch = append(batch, key)
+			}
+		}
+		for _, key := range batch {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// bigDir lists n leaves and one folder holding one more leaf.
+func bigDir(n int) *fakeLogical {
+	keys := make([]interface{}, 0, n+1)
+	for i := 0; i < n; i++ {
+		keys = append(keys, fmt.Sprintf("k%04d", i))
+	}
+	keys = append(keys, "sub/")
+	return &fakeLogical{list: map[string]*vault.Secret{
+		"secret":     {Data: map[string]interface{}{"keys": keys}},
+		"secret/sub": {Data: map[string]interface{}{"keys": []interface{}{"leaf"}}},
+	}}
+}
+
+func TestWalkStream_ListBatches_pkg(t *testing.T) {
+	out := make(chan FoundItem, 2000)
+	if err := WalkStream(context.Background(), bigDir(1000), "secret", WalkOptions{ListBatch: 7}, out); err != nil {
+		t.Fatal(err)
+	}
+	close(out)
+	var got []string
+	for it := range out {
+		got = append(got, it.Path)
+	}
+	if len(got) != 1001 || got[0] != "secret/k0000" || got[999] != "secret/k0999" || got[1000] != "secret/sub/leaf" {
+		t.Fatalf("got %d items, first %q last %q", len(got), got[0], got[len(got)-1])
+	}
+}
+
+func TestWalk_MaxListKeys_pkg(t *testing.T) {
+	f := bigDir(50)
+	f.list["secret"].Data["keys"] = []interface{}{"a", "big/"}
+	f.list["secret/big"] = bigDir(50).list["secret"]
+	var skipped []string
+	items, err := Walk(context.Background(), f, "secret", WalkOptions{MaxListKeys: 10, OnError: func(p string, err error) error {
+		if !errors.Is(err, ErrListTooLarge) {
+			t.Errorf("%s: %v", p, err)
+		}
+		skipped = append(skipped, p)
+		return nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Path != "secret/a" || len(skipped) != 1 || skipped[0] != "secret/big" {
+		t.Fatalf("items %v skipped %v", items, skipped)
+	}
+	// Without a handler the walk stops
+	if _, err := Walk(context.Background(), f, "secret", WalkOptions{MaxListKeys: 10}); !errors.Is(err, ErrListTooLarge) {
+		t.Fatalf("want ErrListTooLarge, got %v", err)
+	}
+}
+
+func TestWalkStream_CancelMidListing_pkg(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan FoundItem)
+	errc := make(chan error, 1)
+	go func() { errc <- WalkStream(ctx, bigDir(1000), "secret", WalkOptions{}, out) }()
+	for i := 0; i < 3; i++ {
+		<-out
+	}
+	cancel()
+	go func() {
+		for range out {
+		}
+	}()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("want context.Canceled, got %v", err)
+	}
+	close(out)
+}
@@ -0,0 +1,215 @@
+package search
+
+import (
+	"context"
+	"iter"
+	"sort"
+	"strings"
+	"sync"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// KV engine versions for SearchOptions.KVVersion.
+const (
+	KVDetect = 0 // look the version up in the mount table (KV v2 when unknown)
+	KV1      = 1
+	KV2      = 2
+)
+
+// SearchOptions configures Client.Search. The zero value walks every KV mount
+// and returns every path.
+type SearchOptions struct {
+	// Roots are the start paths, e.g. "kv/app/"; empty walks every KV mount.
+	Roots []string
+	// Filters select the returned paths; the zero value matches everything.
+	Filters Filters
+	// MaxDepth limits the recursion below each root (0 = unlimited).
+	MaxDepth int
+	// WithValues reads every secret and fills FoundItem.Value.
+	WithValues bool
+	// OnError decides whether a failing subtree aborts the search; nil aborts.
+	OnError ErrorHandler
+	// KVVersion forces KV1 or KV2 instead of detecting it per mount.
+	KVVersion int
+	// Concurrency is how many roots are walked at once (default 1).
+	Concurrency int
+}
+
+// Client searches and reads the KV secrets of one Vault server. It keeps the
+// mount table per namespace for KV version detection and is safe for
+// concurrent use.
+type Client struct {
+	vault  *vault.Client
+	mounts *MountCache
+}
+
+// NewClient wraps a configured Vault client.
+func NewClient(c *vault.Client) *Client {
+	return &Client{vault: c, mounts: NewMountCache()}
+}
+
+// Vault returns the underlying Vault client.
+func (c *Client) Vault() *vault.Client { return c.vault }
+
+// KVMounts returns the KV mount paths without trailing slash, sorted.
+func (c *Client) KVMounts(ctx context.Context) ([]string, error) {
+	mounts, err := c.mounts.Mounts(ctx, c.vault)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for p, m := range mounts {
+		if m.Type == "kv" {
+			out = append(out, strings.TrimSuffix(p, "/"))
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// IsKV2 reports whether the mount holding p is a KV v2 engine. When the mount
+// table cannot be read it assumes KV v2, the default for new KV mounts.
+func (c *Client) IsKV2(ctx context.Context, p string) bool {
+	if v, ok := c.mounts.DetectKV2(ctx, c.vault, p); ok {
+		return v
+	}
+	return true
+}
+
+func (c *Client) kv2(ctx context.Context, p string, version int) bool {
+	switch version {
+	case KV1:
+		return false
+	case KV2:
+		return true
+	}
+	return c.IsKV2(ctx, p)
+}
+
+// Read returns the secret at the logical path p, e.g. "kv/app/db".
+func (c *Client) Read(ctx context.Context, p string) (map[string]interface{}, error) {
+	if err := c.mounts.CheckKV(ctx, c.vault, p); err != nil {
+		return nil, err
+	}
+	mnt, inner := SplitMount(strings.Trim(p, "/"))
+	val, err := ReadSecret(ctx, c.vault.Logical(), mnt, inner, c.IsKV2(ctx, p))
+	if err != nil {
+		return nil, err
+	}
+	data, _ := val.(map[string]interface{})
+	return data, nil
+}
+
+// Metadata returns the KV v2 metadata of p, or nil on KV v1 mounts.
+func (c *Client) Metadata(ctx context.Context, p string) (*SecretMetadata, error) {
+	if !c.IsKV2(ctx, p) {
+		return nil, nil
+	}
+	mnt, inner := SplitMount(strings.Trim(p, "/"))
+	return ReadMetadata(ctx, c.vault.Logical(), mnt, inner)
+}
+
+// Search walks the roots and yields each matching item as it is found, in
+// walk order. A failure that stops the search is yielded last, with a zero
+// FoundItem. Breaking out of the loop cancels the walk.
+//
+//	for it, err := range client.Search(ctx, search.SearchOptions{Roots: []string{"kv/app/"}}) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(it.Path)
+//	}
+func (c *Client) Search(ctx context.Context, o SearchOptions) iter.Seq2[FoundItem, error] {
+	return func(yield func(FoundItem, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		roots := o.Roots
+		if len(roots) == 0 {
+			var err error
+			if roots, err = c.KVMounts(ctx); err != nil {
+				yield(FoundItem{}, err)
+				return
+			}
+		}
+		items := make(chan FoundItem, 256)
+		errc := make(chan error, 1)
+		go func() {
+			errc <- c.walkRoots(ctx, cancel, roots, o, items)
+			close(items)
+		}()
+		for it := range items {
+			if !yield(it, nil) {
+				cancel()
+				for range items {
+				}
+				return
+			}
+		}
+		if err := <-errc; err != nil {
+			yield(FoundItem{}, err)
+		}
+	}
+}
+
+// SearchAll collects Search into a slice sorted by path. On failure the items
+// found so far are returned with the error.
+func (c *Client) SearchAll(ctx context.Context, o SearchOptions) ([]FoundItem, error) {
+	var out []FoundItem
+	var err error
+	for it, e := range c.Search(ctx, o) {
+		if e != nil {
+			err = e
+			break
+		}
+		out = append(out, it)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, err
+}
+
+// walkRoots streams every root into out, o.Concurrency at a time. The first
+// error cancels the remaining walks and is returned.
+func (c *Client) walkRoots(ctx context.Context, cancel context.CancelFunc, roots []string, o SearchOptions, out chan<- FoundItem) error {
+	limit := o.Concurrency
+	if limit < 1 {
+		limit = 1
+	}
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, limit)
+	for _, root := range roots {
+		sem <- struct{}{}
+		if ctx.Err() != nil {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func(root string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			wo := WalkOptions{
+				KV2:        c.kv2(ctx, root, o.KVVersion),
+				MaxDepth:   o.MaxDepth,
+				Filters:    o.Filters,
+				WithValues: o.WithValues,
+				OnError:    o.OnError,
+			}
+			if err := WalkStream(ctx, c.vault.Logical(), root, wo, out); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(root)
+	}
+	wg.Wait()
+	if firstErr == nil && ctx.Err() != nil {
+		// The consumer stopped early; not a failure of the walk
+		return nil
+	}
+	return firstErr
+}
@@ -0,0 +1,142 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// newTestClient serves a kv/ v2 mount (app/web, app/db) and an old/ v1 mount
+// (x); kv/denied answers 403 and every other path 404.
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	routes := map[string]string{
+		"GET /v1/sys/mounts":         `{"data":{"kv/":{"type":"kv","options":{"version":"2"}},"old/":{"type":"kv","options":{"version":"1"}},"pki/":{"type":"pki"}}}`,
+		"LIST /v1/kv/metadata":       `{"data":{"keys":["app/"]}}`,
+		"LIST /v1/kv/metadata/app":   `{"data":{"keys":["web","db"]}}`,
+		"GET /v1/kv/data/app/web":    `{"data":{"data":{"user":"bob"}}}`,
+		"GET /v1/kv/data/app/db":     `{"data":{"data":{"pass":"s3cr3t"}}}`,
+		"GET /v1/kv/metadata/app/db": `{"data":{"current_version":3}}`,
+		"LIST /v1/old":               `{"data":{"keys":["x"]}}`,
+		"GET /v1/old/x":              `{"data":{"k":"v"}}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := r.Method
+		if r.URL.Query().Get("list") == "true" {
+			method = "LIST"
+		}
+		if strings.HasPrefix(r.URL.Path, "/v1/kv/metadata/denied") {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		body, ok := routes[method+" "+strings.TrimSuffix(r.URL.Path, "/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	cfg := vault.DefaultConfig()
+	cfg.Address = srv.URL
+	cfg.MaxRetries = 0
+	vc, err := vault.NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vc.SetToken("test")
+	return NewClient(vc)
+}
+
+func TestClient_SearchAllMounts(t *testing.T) {
+	c := newTestClient(t)
+	items, err := c.SearchAll(context.Background(), SearchOptions{WithValues: true, Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, it := range items {
+		got = append(got, it.Path)
+	}
+	if want := "kv/app/db kv/app/web old/x"; strings.Join(got, " ") != want {
+		t.Fatalf("paths = %q, want %q", got, want)
+	}
+	if v := items[1].Value.(map[string]interface{})["user"]; v != "bob" {
+		t.Fatalf("kv/app/web value = %v", items[1].Value)
+	}
+}
+
+func TestClient_SearchFiltersAndBreak(t *testing.T) {
+	c := newTestClient(t)
+	o := SearchOptions{Roots: []string{"kv/app"}, Filters: Filters{NamePart: "WE"}}
+	items, err := c.SearchAll(context.Background(), o)
+	if err != nil || len(items) != 1 || items[0].Path != "kv/app/web" {
+		t.Fatalf("items = %v, err = %v", items, err)
+	}
+
+	n := 0
+	for _, err := range c.Search(context.Background(), SearchOptions{Roots: []string{"kv/", "old/"}}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		n++
+		break
+	}
+	if n != 1 {
+		t.Fatalf("loop ran %d times after break", n)
+	}
+}
+
+func TestClient_SearchYieldsTypedError(t *testing.T) {
+	c := newTestClient(t)
+	var last error
+	for _, err := range c.Search(context.Background(), SearchOptions{Roots: []string{"kv/denied"}}) {
+		last = err
+	}
+	if !errors.Is(last, ErrPermissionDenied) {
+		t.Fatalf("last element err = %v, want ErrPermissionDenied", last)
+	}
+	skipped := 0
+	o := SearchOptions{
+		Roots:   []string{"kv/denied", "old/"},
+		OnError: func(string, error) error { skipped++; return nil },
+	}
+	items, err := c.SearchAll(context.Background(), o)
+	if err != nil || len(items) != 1 || skipped != 1 {
+		t.Fatalf("items = %v, err = %v, skipped = %d", items, err, skipped)
+	}
+}
+
+func TestClient_ReadAndMetadata(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+	data, err := c.Read(ctx, "kv/app/db")
+	if err != nil || data["pass"] != "s3cr3t" {
+		t.Fatalf("kv2 read = %v, %v", data, err)
+	}
+	if data, err := c.Read(ctx, "old/x"); err != nil || data["k"] != "v" {
+		t.Fatalf("kv1 read = %v, %v", data, err)
+	}
+	if _, err := c.Read(ctx, "pki/cert"); !errors.Is(err, ErrNotKV) {
+		t.Fatalf("pki read err = %v, want ErrNotKV", err)
+	}
+	md, err := c.Metadata(ctx, "kv/app/db")
+	if err != nil || md == nil || md.CurrentVersion != 3 {
+		t.Fatalf("metadata = %+v, %v", md, err)
+	}
+	if md, err := c.Metadata(ctx, "old/x"); md != nil || err != nil {
+		t.Fatalf("kv1 metadata = %+v, %v", md, err)
+	}
+	mounts, err := c.KVMounts(ctx)
+	if err != nil || strings.Join(mounts, ",") != "kv,old" {
+		t.Fatalf("KVMounts = %v, %v", mounts, err)
+	}
+}
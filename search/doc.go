@@ -0,0 +1,27 @@
+// Package search walks the KV secrets engines of a Vault server and finds
+// secret paths by name or regular expression.
+//
+// Other Go tools embed it through Client, which needs nothing but a
+// configured *vault.Client and keeps no package-level state:
+//
+//	vc, err := vault.NewClient(vault.DefaultConfig())
+//	if err != nil {
+//		return err
+//	}
+//	c := search.NewClient(vc)
+//	opts := search.SearchOptions{
+//		Roots:   []string{"kv/app/"},
+//		Filters: search.Filters{NamePart: "db"},
+//	}
+//	for it, err := range c.Search(ctx, opts) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(it.Path)
+//	}
+//
+// Errors wrap ErrPermissionDenied, ErrMountNotFound, ErrSealed or ErrNotKV
+// where the cause is known; test for them with errors.Is. The lower-level
+// Walk, WalkStream, ListKeys and ReadSecret take a LogicalAPI and an explicit
+// KV version for callers that manage mounts themselves.
+package search
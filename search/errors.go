@@ -0,0 +1,109 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// Error kinds returned (wrapped) by this package. Branch on them with
+// errors.Is; errors.As still reaches the underlying *vault.ResponseError.
+var (
+	// ErrPermissionDenied means the token lacks a capability on the path (HTTP 403).
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrMountNotFound means no secrets engine is mounted at the path.
+	ErrMountNotFound = errors.New("mount not found")
+	// ErrSealed means Vault is sealed and serves no secrets until unsealed.
+	ErrSealed = errors.New("vault is sealed")
+	// ErrNotKV means the path belongs to a mount that is not a KV secrets
+	// engine, or the response does not have the KV shape.
+	ErrNotKV = errors.New("not a KV mount")
+	// ErrNoData means nothing is stored at the path, e.g. no secret by that
+	// name or only a deleted KV v2 version.
+	ErrNoData = errors.New("no data")
+	// ErrCASConflict means a KV v2 check-and-set write found the secret at
+	// another version than the one given, i.e. someone else wrote it since.
+	ErrCASConflict = errors.New("check-and-set conflict")
+	// ErrListTooLarge means a directory lists more keys than
+	// WalkOptions.MaxListKeys allows.
+	ErrListTooLarge = errors.New("listing too large")
+)
+
+// kindError tags an error with its kind while keeping the original message.
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string   { return e.err.Error() }
+func (e *kindError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// classify wraps a Vault client error with its kind so callers can use
+// errors.Is; errors it cannot classify are returned unchanged.
+func classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	kind := errorKind(err)
+	if kind == nil || errors.Is(err, kind) {
+		return err
+	}
+	return &kindError{kind: kind, err: err}
+}
+
+func errorKind(err error) error {
+	var re *vault.ResponseError
+	if errors.As(err, &re) {
+		switch {
+		case re.StatusCode == http.StatusForbidden:
+			return ErrPermissionDenied
+		case re.StatusCode == http.StatusServiceUnavailable && responseMentions(re, "sealed"):
+			return ErrSealed
+		case responseMentions(re, "check-and-set"):
+			return ErrCASConflict
+		case responseMentions(re, "no handler for route"):
+			return ErrMountNotFound
+		case re.StatusCode == http.StatusMethodNotAllowed || responseMentions(re, "unsupported operation"):
+			return ErrNotKV
+		}
+		return nil
+	}
+	// Some transport layers flatten the context error into the message
+	if strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+func responseMentions(re *vault.ResponseError, s string) bool {
+	for _, e := range re.Errors {
+		if strings.Contains(strings.ToLower(e), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// unexpectedList reports a LIST response without a keys array, as returned by
+// engines other than KV.
+func unexpectedList(listPath string) error {
+	return &kindError{kind: ErrNotKV, err: fmt.Errorf("unexpected list response at %s", listPath)}
+}
+
+// checkKVMount reports ErrMountNotFound when no mount in the table covers
+// start, and ErrNotKV when the mount is not a KV engine.
+func checkKVMount(mounts map[string]*vault.MountOutput, start string) error {
+	mount, _ := SplitMount(start)
+	m, ok := mounts[mount+"/"]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrMountNotFound, mount)
+	}
+	if m.Type != "kv" {
+		return fmt.Errorf("%w: %s is a %s mount", ErrNotKV, mount, m.Type)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		err  error
+		want error
+	}{
+		{&vault.ResponseError{StatusCode: 403, Errors: []string{"permission denied"}}, ErrPermissionDenied},
+		{&vault.ResponseError{StatusCode: 503, Errors: []string{"Vault is sealed"}}, ErrSealed},
+		{&vault.ResponseError{StatusCode: 404, Errors: []string{"no handler for route \"nope/data/x\". route entry not found."}}, ErrMountNotFound},
+		{&vault.ResponseError{StatusCode: 405, Errors: []string{"1 error occurred:\n\t* unsupported operation"}}, ErrNotKV},
+		{&vault.ResponseError{StatusCode: 400, Errors: []string{"check-and-set parameter did not match the current version"}}, ErrCASConflict},
+		{fmt.Errorf("Get \"https://vault/v1/kv\": context deadline exceeded"), context.DeadlineExceeded},
+	}
+	for i, c := range cases {
+		got := classify(c.err)
+		if !errors.Is(got, c.want) {
+			t.Errorf("case %d: %v is not %v", i, got, c.want)
+		}
+		if got.Error() != c.err.Error() {
+			t.Errorf("case %d: message changed to %q", i, got.Error())
+		}
+	}
+	var re *vault.ResponseError
+	if !errors.As(classify(cases[0].err), &re) || re.StatusCode != 403 {
+		t.Fatal("classified error should still unwrap to the Vault response")
+	}
+	plain := errors.New("boom")
+	if classify(plain) != plain {
+		t.Fatal("unclassified errors should be returned unchanged")
+	}
+}
+
+func TestWalk_ErrorKinds(t *testing.T) {
+	denied := &deniedLogical{
+		fakeLogical: fakeLogical{list: map[string]*vault.Secret{
+			"secret": {Data: map[string]interface{}{"keys": []interface{}{"a/"}}},
+		}},
+		denied: map[string]bool{"secret/a": true},
+	}
+	if _, err := Walk(context.Background(), denied, "secret", WalkOptions{}); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("expected ErrPermissionDenied, got %v", err)
+	}
+
+	notKV := &fakeLogical{list: map[string]*vault.Secret{
+		"pki": {Data: map[string]interface{}{"keys": "not-a-list"}},
+	}}
+	if _, err := ListKeys(context.Background(), notKV, "pki", "", false); !errors.Is(err, ErrNotKV) {
+		t.Fatalf("expected ErrNotKV, got %v", err)
+	}
+}
+
+func TestCheckKVMount(t *testing.T) {
+	mounts := map[string]*vault.MountOutput{
+		"kv/":  {Type: "kv"},
+		"pki/": {Type: "pki"},
+	}
+	if err := checkKVMount(mounts, "kv/app"); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkKVMount(mounts, "pki/issuers"); !errors.Is(err, ErrNotKV) {
+		t.Fatalf("expected ErrNotKV, got %v", err)
+	}
+	if err := checkKVMount(mounts, "nope/x"); !errors.Is(err, ErrMountNotFound) {
+		t.Fatalf("expected ErrMountNotFound, got %v", err)
+	}
+}
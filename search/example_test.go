@@ -0,0 +1,36 @@
+package search_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"fvf/search"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+func ExampleClient_Search() {
+	vc, err := vault.NewClient(vault.DefaultConfig())
+	if err != nil {
+		log.Fatal(err)
+	}
+	c := search.NewClient(vc)
+	opts := search.SearchOptions{
+		Roots:   []string{"kv/app/"},
+		Filters: search.Filters{NamePart: "db"},
+		OnError: func(path string, err error) error {
+			if errors.Is(err, search.ErrPermissionDenied) {
+				return nil // skip subtrees the token cannot list
+			}
+			return err
+		},
+	}
+	for it, err := range c.Search(context.Background(), opts) {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(it.Path)
+	}
+}
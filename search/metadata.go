@@ -0,0 +1,164 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// SecretMetadata is the subset of a KV v2 metadata response shown in the UI.
+type SecretMetadata struct {
+	CurrentVersion int
+	CreatedTime    time.Time
+	UpdatedTime    time.Time
+	CustomMetadata map[string]string
+	// Versions are the versions Vault still keeps, newest first.
+	Versions []VersionInfo
+	// Per-secret settings; zero values mean the mount's defaults apply.
+	MaxVersions        int
+	CASRequired        bool
+	DeleteVersionAfter time.Duration
+}
+
+// VersionInfo describes one version of a KV v2 secret.
+type VersionInfo struct {
+	Version      int
+	CreatedTime  time.Time
+	DeletionTime time.Time // zero unless soft-deleted
+	Destroyed    bool
+}
+
+// Readable reports whether the version's data can still be read.
+func (v VersionInfo) Readable() bool {
+	return !v.Destroyed && v.DeletionTime.IsZero()
+}
+
+// MetadataAPIPath returns the KV v2 metadata path for mount and inner.
+func MetadataAPIPath(mount, inner string) string {
+	return path.Clean(joinNonEmpty(mount, "metadata", inner))
+}
+
+// ReadMetadata reads KV v2 metadata (versions, timestamps, custom_metadata) for a secret.
+func ReadMetadata(ctx context.Context, logical LogicalAPI, mount, inner string) (*SecretMetadata, error) {
+	p := MetadataAPIPath(mount, inner)
+	sec, err := logical.ReadWithContext(ctx, p)
+	if err != nil {
+		return nil, classify(err)
+	}
+	if sec == nil || sec.Data == nil {
+		return nil, fmt.Errorf("no metadata at %s", p)
+	}
+	md := &SecretMetadata{
+		CurrentVersion: toInt(sec.Data["current_version"]),
+		CreatedTime:    toTime(sec.Data["created_time"]),
+		UpdatedTime:    toTime(sec.Data["updated_time"]),
+		MaxVersions:    toInt(sec.Data["max_versions"]),
+	}
+	md.CASRequired, _ = sec.Data["cas_required"].(bool)
+	if d, ok := sec.Data["delete_version_after"].(string); ok {
+		md.DeleteVersionAfter, _ = time.ParseDuration(d)
+	}
+	if cm, ok := sec.Data["custom_metadata"].(map[string]interface{}); ok && len(cm) > 0 {
+		md.CustomMetadata = make(map[string]string, len(cm))
+		for k, v := range cm {
+			md.CustomMetadata[k] = fmt.Sprint(v)
+		}
+	}
+	if vs, ok := sec.Data["versions"].(map[string]interface{}); ok {
+		for k, raw := range vs {
+			n, err := strconv.Atoi(k)
+			v, _ := raw.(map[string]interface{})
+			if err != nil || v == nil {
+				continue
+			}
+			destroyed, _ := v["destroyed"].(bool)
+			md.Versions = append(md.Versions, VersionInfo{
+				Version:      n,
+				CreatedTime:  toTime(v["created_time"]),
+				DeletionTime: toTime(v["deletion_time"]),
+				Destroyed:    destroyed,
+			})
+		}
+		sort.Slice(md.Versions, func(i, j int) bool { return md.Versions[i].Version > md.Versions[j].Version })
+	}
+	return md, nil
+}
+
+func toInt(v interface{}) int {
+	switch t := v.(type) {
+	case json.Number:
+		n, _ := t.Int64()
+		return int(n)
+	case float64:
+		return int(t)
+	case int:
+		return t
+	case int64:
+		return int(t)
+	}
+	return 0
+}
+
+func toFloat(v interface{}) float64 {
+	switch t := v.(type) {
+	case json.Number:
+		f, _ := t.Float64()
+		return f
+	case float64:
+		return t
+	case int:
+		return float64(t)
+	case int64:
+		return float64(t)
+	}
+	return 0
+}
+
+func toTime(v interface{}) time.Time {
+	s, _ := v.(string)
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// WriteCustomMetadata sets the custom_metadata of the KV v2 secret at
+// mount/inner, replacing what was there.
+func WriteCustomMetadata(ctx context.Context, logical LogicalWriter, mount, inner string, cm map[string]string) error {
+	_, err := logical.WriteWithContext(ctx, MetadataAPIPath(mount, inner), map[string]interface{}{"custom_metadata": cm})
+	return classify(err)
+}
+
+// MetadataSettings are the per-secret KV v2 settings to change; nil fields
+// are left as they are.
+type MetadataSettings struct {
+	MaxVersions        *int
+	CASRequired        *bool
+	DeleteVersionAfter *time.Duration
+}
+
+// WriteMetadataSettings updates the settings of the KV v2 secret at
+// mount/inner. Vault only changes the fields sent, so custom_metadata and
+// the unset fields are kept.
+func WriteMetadataSettings(ctx context.Context, logical LogicalWriter, mount, inner string, s MetadataSettings) error {
+	body := map[string]interface{}{}
+	if s.MaxVersions != nil {
+		body["max_versions"] = *s.MaxVersions
+	}
+	if s.CASRequired != nil {
+		body["cas_required"] = *s.CASRequired
+	}
+	if s.DeleteVersionAfter != nil {
+		body["delete_version_after"] = s.DeleteVersionAfter.String()
+	}
+	if len(body) == 0 {
+		return nil
+	}
+	_, err := logical.WriteWithContext(ctx, MetadataAPIPath(mount, inner), body)
+	return classify(err)
+}
@@ -0,0 +1,68 @@
+package search
+
+import (
+	"context"
+	"sync"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// MountCache keeps the mount table per server and namespace for the session, so KV
+// version detection costs one sys/mounts round trip per namespace instead of
+// one per lookup. Failed lookups are not cached.
+type MountCache struct {
+	mu     sync.Mutex
+	mounts map[string]map[string]*vault.MountOutput
+}
+
+// NewMountCache returns an empty cache.
+func NewMountCache() *MountCache {
+	return &MountCache{mounts: make(map[string]map[string]*vault.MountOutput)}
+}
+
+// Mounts returns the mount table of the client's current namespace.
+func (mc *MountCache) Mounts(ctx context.Context, c *vault.Client) (map[string]*vault.MountOutput, error) {
+	ns := c.Address() + "|" + NormalizeNamespace(c.Namespace())
+	mc.mu.Lock()
+	m, ok := mc.mounts[ns]
+	mc.mu.Unlock()
+	if ok {
+		return m, nil
+	}
+	m, err := ListMountsWithFallback(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	mc.mu.Lock()
+	mc.mounts[ns] = m
+	mc.mu.Unlock()
+	return m, nil
+}
+
+// DetectKV2 is DetectKV2 backed by the cached mount table.
+func (mc *MountCache) DetectKV2(ctx context.Context, c *vault.Client, start string) (bool, bool) {
+	mounts, err := mc.Mounts(ctx, c)
+	if err != nil {
+		return false, false
+	}
+	return kv2FromMounts(mounts, start)
+}
+
+// CheckKV reports ErrMountNotFound or ErrNotKV when the mount table shows that
+// start is not on a KV mount. It returns nil when the table agrees or cannot be
+// read, leaving the request itself to surface the real error.
+func (mc *MountCache) CheckKV(ctx context.Context, c *vault.Client, start string) error {
+	mounts, err := mc.Mounts(ctx, c)
+	if err != nil {
+		return nil
+	}
+	return checkKVMount(mounts, start)
+}
+
+// Reset forgets every cached mount table, e.g. before a long-running process
+// walks again and should pick up new mounts.
+func (mc *MountCache) Reset() {
+	mc.mu.Lock()
+	mc.mounts = make(map[string]map[string]*vault.MountOutput)
+	mc.mu.Unlock()
+}
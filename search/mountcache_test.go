@@ -0,0 +1,51 @@
+package search
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+func TestMountCache_ListsOncePerNamespace(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/sys/mounts" {
+			http.NotFound(w, r)
+			return
+		}
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"kv/":{"type":"kv","options":{"version":"2"}},"old/":{"type":"kv","options":{"version":"1"}}}}`))
+	}))
+	defer srv.Close()
+
+	cfg := vault.DefaultConfig()
+	cfg.Address = srv.URL
+	c, err := vault.NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mc := NewMountCache()
+	ctx := context.Background()
+	if v, ok := mc.DetectKV2(ctx, c, "kv/app"); !ok || !v {
+		t.Fatalf("kv/app: v2=%v ok=%v", v, ok)
+	}
+	if v, ok := mc.DetectKV2(ctx, c, "old/app"); !ok || v {
+		t.Fatalf("old/app: v2=%v ok=%v", v, ok)
+	}
+	if _, ok := mc.DetectKV2(ctx, c, "missing/x"); ok {
+		t.Fatal("unknown mount should not be detected")
+	}
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("mount table fetched %d times, want 1", n)
+	}
+	c.SetNamespace("team-a")
+	mc.DetectKV2(ctx, c, "kv/app")
+	if n := hits.Load(); n != 2 {
+		t.Fatalf("another namespace should list its own mounts, fetched %d times", n)
+	}
+}
@@ -0,0 +1,41 @@
+package search
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// ListNamespaces lists the child namespaces of parent (Vault Enterprise).
+// parent is a namespace path such as "" (root) or "team-a/". Returned names
+// carry a trailing slash and are relative to parent.
+func ListNamespaces(ctx context.Context, c *vault.Client, parent string) ([]string, error) {
+	sec, err := c.WithNamespace(strings.TrimSuffix(parent, "/")).Logical().ListWithContext(ctx, "sys/namespaces")
+	if err != nil {
+		return nil, classify(err)
+	}
+	if sec == nil || sec.Data == nil {
+		return nil, nil
+	}
+	raw, _ := sec.Data["keys"].([]interface{})
+	out := make([]string, 0, len(raw))
+	for _, k := range raw {
+		if s, ok := k.(string); ok && s != "" {
+			out = append(out, strings.TrimSuffix(s, "/")+"/")
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// NormalizeNamespace trims surrounding slashes and returns "" for the root
+// namespace or the path with a single trailing slash otherwise.
+func NormalizeNamespace(ns string) string {
+	ns = strings.Trim(strings.TrimSpace(ns), "/")
+	if ns == "" {
+		return ""
+	}
+	return ns + "/"
+}
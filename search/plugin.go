@@ -0,0 +1,224 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// PluginProtocolVersion is passed to engine plugins in FVF_PLUGIN_PROTOCOL.
+const PluginProtocolVersion = 1
+
+// pluginRequest is one line fvf writes to a plugin's stdin. Path is the
+// logical path including the mount, e.g. "custom/app/db".
+type pluginRequest struct {
+	ID   int64  `json:"id"`
+	Op   string `json:"op"` // list or read
+	Path string `json:"path"`
+}
+
+// pluginResponse is one line a plugin writes to stdout. A response with
+// neither keys nor data means nothing exists at the path (a leaf for list).
+// Status, when set, is the HTTP status the error maps to (403 for
+// permission denied), so callers can branch on the error kinds.
+type pluginResponse struct {
+	ID     int64                  `json:"id"`
+	Keys   []string               `json:"keys,omitempty"`
+	Data   map[string]interface{} `json:"data,omitempty"`
+	Error  string                 `json:"error,omitempty"`
+	Status int                    `json:"status,omitempty"`
+}
+
+// Plugin serves LIST and READ for a secrets engine fvf does not know, through
+// an external program speaking JSON lines on stdio. It implements LogicalAPI;
+// walk its mounts with KV2 false, since paths are passed through unchanged.
+//
+// The program is started as "<bin> <mount type>" with the Vault address,
+// token and namespace in its environment, reads one request per line
+//
+//	{"id":1,"op":"list","path":"custom/app"}
+//	{"id":2,"op":"read","path":"custom/app/db"}
+//
+// and answers each with a line carrying the same id:
+//
+//	{"id":1,"keys":["db","sub/"]}
+//	{"id":2,"data":{"user":"bob"}}
+//	{"id":3,"error":"permission denied","status":403}
+//
+// Responses may arrive in any order. Concurrent requests are allowed.
+type Plugin struct {
+	name string
+	cmd  *exec.Cmd
+
+	wmu   sync.Mutex // serialises writes to stdin
+	stdin io.WriteCloser
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan pluginResponse
+	err     error // set once the plugin's stdout is closed
+	done    chan struct{}
+}
+
+// StartPlugin runs bin for mountType. env is added to the inherited
+// environment; the caller closes the plugin when done.
+func StartPlugin(bin, mountType string, env []string) (*Plugin, error) {
+	cmd := exec.Command(bin, mountType)
+	cmd.Env = append(cmd.Environ(), env...)
+	cmd.Env = append(cmd.Env, fmt.Sprintf("FVF_PLUGIN_PROTOCOL=%d", PluginProtocolVersion))
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", bin, err)
+	}
+	p := &Plugin{
+		name:    bin,
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[int64]chan pluginResponse),
+		done:    make(chan struct{}),
+	}
+	go p.readLoop(stdout)
+	return p, nil
+}
+
+// PluginEnv returns the environment a plugin needs to reach the same Vault as c.
+func PluginEnv(c *vault.Client) []string {
+	env := []string{"VAULT_ADDR=" + c.Address(), "VAULT_TOKEN=" + c.Token()}
+	if ns := c.Namespace(); ns != "" {
+		env = append(env, "VAULT_NAMESPACE="+ns)
+	}
+	return env
+}
+
+// readLoop hands each response to the request waiting for its id.
+func (p *Plugin) readLoop(r io.Reader) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var err error
+	for sc.Scan() {
+		var resp pluginResponse
+		if e := json.Unmarshal(sc.Bytes(), &resp); e != nil {
+			err = fmt.Errorf("plugin %s: bad response: %w", p.name, e)
+			break
+		}
+		p.mu.Lock()
+		ch, ok := p.pending[resp.ID]
+		delete(p.pending, resp.ID)
+		p.mu.Unlock()
+		if ok {
+			ch <- resp
+		}
+	}
+	if err == nil {
+		err = sc.Err()
+	}
+	if err == nil {
+		err = fmt.Errorf("plugin %s exited", p.name)
+	}
+	p.mu.Lock()
+	p.err = err
+	p.mu.Unlock()
+	close(p.done)
+}
+
+func (p *Plugin) call(ctx context.Context, op, path string) (pluginResponse, error) {
+	p.mu.Lock()
+	if p.err != nil {
+		p.mu.Unlock()
+		return pluginResponse{}, p.err
+	}
+	p.nextID++
+	id := p.nextID
+	ch := make(chan pluginResponse, 1)
+	p.pending[id] = ch
+	p.mu.Unlock()
+	forget := func() {
+		p.mu.Lock()
+		delete(p.pending, id)
+		p.mu.Unlock()
+	}
+
+	line, err := json.Marshal(pluginRequest{ID: id, Op: op, Path: path})
+	if err != nil {
+		forget()
+		return pluginResponse{}, err
+	}
+	p.wmu.Lock()
+	_, err = p.stdin.Write(append(line, '\n'))
+	p.wmu.Unlock()
+	if err != nil {
+		forget()
+		return pluginResponse{}, fmt.Errorf("plugin %s: %w", p.name, err)
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != "" {
+			return resp, classify(&vault.ResponseError{
+				HTTPMethod: op,
+				URL:        path,
+				StatusCode: resp.Status,
+				Errors:     []string{resp.Error},
+			})
+		}
+		return resp, nil
+	case <-p.done:
+		forget()
+		return pluginResponse{}, p.err
+	case <-ctx.Done():
+		forget()
+		return pluginResponse{}, ctx.Err()
+	}
+}
+
+// ListWithContext lists the keys below path; folders end in "/".
+func (p *Plugin) ListWithContext(ctx context.Context, path string) (*vault.Secret, error) {
+	resp, err := p.call(ctx, "list", path)
+	if err != nil || resp.Keys == nil {
+		return nil, err
+	}
+	keys := make([]interface{}, len(resp.Keys))
+	for i, k := range resp.Keys {
+		keys[i] = k
+	}
+	return &vault.Secret{Data: map[string]interface{}{"keys": keys}}, nil
+}
+
+// ReadWithContext reads the secret at path.
+func (p *Plugin) ReadWithContext(ctx context.Context, path string) (*vault.Secret, error) {
+	resp, err := p.call(ctx, "read", path)
+	if err != nil || resp.Data == nil {
+		return nil, err
+	}
+	return &vault.Secret{Data: resp.Data}, nil
+}
+
+// Close ends the plugin's input and waits for it to exit; a plugin still
+// running after five seconds is killed.
+func (p *Plugin) Close() error {
+	p.stdin.Close()
+	select {
+	case <-p.done:
+	case <-time.After(5 * time.Second):
+		p.cmd.Process.Kill()
+		<-p.done
+	}
+	if err := p.cmd.Wait(); err != nil {
+		return fmt.Errorf("plugin %s: %w", p.name, err)
+	}
+	return nil
+}
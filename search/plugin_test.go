@@ -0,0 +1,117 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestMain turns the test binary into a fake engine plugin when
+// FVF_TEST_PLUGIN is set, so StartPlugin can run os.Args[0].
+func TestMain(m *testing.M) {
+	if os.Getenv("FVF_TEST_PLUGIN") == "1" {
+		fakePlugin()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakePlugin serves custom/app/{db,web} and denies custom/locked.
+func fakePlugin() {
+	tree := map[string][]string{
+		"custom":     {"app/", "locked/"},
+		"custom/app": {"db", "web"},
+	}
+	out := json.NewEncoder(os.Stdout)
+	sc := bufio.NewScanner(os.Stdin)
+	for sc.Scan() {
+		var req pluginRequest
+		json.Unmarshal(sc.Bytes(), &req)
+		resp := pluginResponse{ID: req.ID}
+		p := strings.TrimSuffix(req.Path, "/")
+		switch {
+		case strings.HasPrefix(p, "custom/locked"):
+			resp.Error, resp.Status = "permission denied", 403
+		case req.Op == "list":
+			resp.Keys = tree[p]
+		case req.Op == "read" && strings.HasPrefix(p, "custom/app/"):
+			resp.Data = map[string]interface{}{"name": p, "type": os.Args[len(os.Args)-1], "addr": os.Getenv("VAULT_ADDR")}
+		}
+		out.Encode(resp)
+	}
+}
+
+func startFakePlugin(t *testing.T) *Plugin {
+	t.Helper()
+	t.Setenv("FVF_TEST_PLUGIN", "1")
+	p, err := StartPlugin(os.Args[0], "custom-engine", []string{"VAULT_ADDR=http://vault.test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func TestPlugin_Walk(t *testing.T) {
+	p := startFakePlugin(t)
+	skipped := 0
+	o := WalkOptions{WithValues: true, OnError: func(path string, err error) error {
+		if !errors.Is(err, ErrPermissionDenied) {
+			return err
+		}
+		skipped++
+		return nil
+	}}
+	items, err := Walk(context.Background(), p, "custom", o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Path != "custom/app/db" || items[1].Path != "custom/app/web" {
+		t.Fatalf("items = %v", items)
+	}
+	v := items[0].Value.(map[string]interface{})
+	if v["type"] != "custom-engine" || v["addr"] != "http://vault.test" {
+		t.Fatalf("plugin saw args/env %v", v)
+	}
+	if skipped != 1 {
+		t.Fatalf("skipped = %d, want the denied subtree", skipped)
+	}
+}
+
+func TestPlugin_ConcurrentReads(t *testing.T) {
+	p := startFakePlugin(t)
+	errs := make(chan error, 20)
+	for i := 0; i < cap(errs); i++ {
+		go func(i int) {
+			name := []string{"db", "web"}[i%2]
+			val, err := ReadSecret(context.Background(), p, "custom", "app/"+name, false)
+			if err == nil && val.(map[string]interface{})["name"] != "custom/app/"+name {
+				err = fmt.Errorf("read %s got %v", name, val)
+			}
+			errs <- err
+		}(i)
+	}
+	for i := 0; i < cap(errs); i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := ReadSecret(context.Background(), p, "custom", "missing", false); err == nil {
+		t.Fatal("want an error reading a missing secret")
+	}
+}
+
+func TestPlugin_ExitedFails(t *testing.T) {
+	p := startFakePlugin(t)
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.ReadWithContext(context.Background(), "custom/app/db"); err == nil {
+		t.Fatal("want an error after the plugin exited")
+	}
+}
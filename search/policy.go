@@ -0,0 +1,150 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+)
+
+// PolicyRule is one path block of an ACL policy. Start and End are the
+// zero-based lines of the block in the HCL, braces included.
+type PolicyRule struct {
+	Path         string
+	Capabilities []string
+	Start, End   int
+}
+
+// Grants reports whether the rule allows anything: it has capabilities and
+// none of them is "deny".
+func (r PolicyRule) Grants() bool {
+	for _, c := range r.Capabilities {
+		if c == "deny" {
+			return false
+		}
+	}
+	return len(r.Capabilities) > 0
+}
+
+var (
+	// policyPathRe matches the opening line of an HCL path block: path "kv/data/*" {
+	policyPathRe = regexp.MustCompile(`^\s*path\s+"([^"]+)"`)
+	// policyCapsRe matches the capabilities list of a path block.
+	policyCapsRe = regexp.MustCompile(`capabilities\s*=\s*\[([^\]]*)\]`)
+	quotedRe     = regexp.MustCompile(`"([^"]*)"`)
+)
+
+// ParsePolicyRules returns the path blocks of an ACL policy in HCL, in
+// order. It reads the path and capabilities of each block line by line,
+// which covers the policies Vault and its docs write, not HCL at large.
+func ParsePolicyRules(hcl string) []PolicyRule {
+	var rules []PolicyRule
+	lines := strings.Split(hcl, "\n")
+	for i := 0; i < len(lines); i++ {
+		m := policyPathRe.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		// Find the end of the block by counting braces
+		end, depth := i, 0
+		for j := i; j < len(lines); j++ {
+			depth += strings.Count(lines[j], "{") - strings.Count(lines[j], "}")
+			end = j
+			if depth <= 0 && strings.Contains(lines[j], "}") {
+				break
+			}
+		}
+		r := PolicyRule{Path: m[1], Start: i, End: end}
+		if c := policyCapsRe.FindStringSubmatch(strings.Join(lines[i:end+1], " ")); c != nil {
+			for _, q := range quotedRe.FindAllStringSubmatch(c[1], -1) {
+				r.Capabilities = append(r.Capabilities, q[1])
+			}
+		}
+		rules = append(rules, r)
+		i = end
+	}
+	return rules
+}
+
+// PolicyGlobMatch reports whether a policy path pattern covers p, following
+// Vault's rules: a trailing "*" is a prefix match and "+" matches one segment.
+func PolicyGlobMatch(pattern, p string) bool {
+	prefix := strings.HasSuffix(pattern, "*")
+	pattern = strings.TrimSuffix(pattern, "*")
+	ps := strings.Split(pattern, "/")
+	xs := strings.Split(p, "/")
+	for i, seg := range ps {
+		if i >= len(xs) {
+			return false
+		}
+		if i == len(ps)-1 && prefix {
+			rest := strings.Join(xs[i:], "/")
+			if seg == "+" {
+				return xs[i] != ""
+			}
+			return strings.HasPrefix(rest, seg)
+		}
+		if seg == "+" {
+			if xs[i] == "" {
+				return false
+			}
+			continue
+		}
+		if seg != xs[i] {
+			return false
+		}
+	}
+	return len(xs) == len(ps)
+}
+
+// PolicyAPIPaths lists the API paths a policy may name for a logical secret
+// path: the KV v1 path and the KV v2 data and metadata paths.
+func PolicyAPIPaths(logical string) []string {
+	mnt, inner := SplitMount(logical)
+	return []string{
+		ReadAPIPath(mnt, inner, false),
+		ReadAPIPath(mnt, inner, true),
+		MetadataAPIPath(mnt, inner),
+	}
+}
+
+// EffectiveRule returns the rule Vault applies to apiPath: of the rules
+// matching it, the one with the highest priority (see policyPriorityLess).
+func EffectiveRule(rules []PolicyRule, apiPath string) (PolicyRule, bool) {
+	var best PolicyRule
+	found := false
+	for _, r := range rules {
+		if !PolicyGlobMatch(r.Path, apiPath) {
+			continue
+		}
+		if !found || policyPriorityLess(best.Path, r.Path) {
+			best, found = r, true
+		}
+	}
+	return best, found
+}
+
+// policyPriorityLess reports whether pattern a has a lower priority than b
+// when both match a path, in the order Vault documents: a wildcard earlier,
+// a trailing "*", more "+" segments, a shorter pattern and finally a
+// lexically smaller one each lose.
+func policyPriorityLess(a, b string) bool {
+	wa, wb := strings.IndexAny(a, "+*"), strings.IndexAny(b, "+*")
+	if wa < 0 {
+		wa = len(a)
+	}
+	if wb < 0 {
+		wb = len(b)
+	}
+	if wa != wb {
+		return wa < wb
+	}
+	if sa, sb := strings.HasSuffix(a, "*"), strings.HasSuffix(b, "*"); sa != sb {
+		return sa
+	}
+	if pa, pb := strings.Count(a, "+"), strings.Count(b, "+"); pa != pb {
+		return pa > pb
+	}
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
@@ -0,0 +1,73 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPolicyGlobMatch(t *testing.T) {
+	cases := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"kv/data/app/db", "kv/data/app/db", true},
+		{"kv/data/app/*", "kv/data/app/db", true},
+		{"kv/data/app/*", "kv/data/app", false},
+		{"kv/data/ap*", "kv/data/app/db", true},
+		{"kv/data/+/db", "kv/data/app/db", true},
+		{"kv/data/+/db", "kv/data/app/x/db", false},
+		{"kv/+/app/*", "kv/metadata/app/db", true},
+		{"kv/data/app", "kv/data/app/db", false},
+	}
+	for _, c := range cases {
+		if got := PolicyGlobMatch(c.pattern, c.path); got != c.want {
+			t.Fatalf("PolicyGlobMatch(%q, %q)=%v want %v", c.pattern, c.path, got, c.want)
+		}
+	}
+}
+
+func TestParsePolicyRules(t *testing.T) {
+	hcl := `# app policy
+path "kv/data/app/*" {
+  capabilities = ["read", "list"]
+}
+path "kv/data/app/secret" {
+  capabilities = ["deny"]
+}
+path "sys/health" { capabilities = ["read"] }`
+	rules := ParsePolicyRules(hcl)
+	if len(rules) != 3 {
+		t.Fatalf("expected 3 rules, got %+v", rules)
+	}
+	if r := rules[0]; r.Path != "kv/data/app/*" || !reflect.DeepEqual(r.Capabilities, []string{"read", "list"}) || r.Start != 1 || r.End != 3 || !r.Grants() {
+		t.Fatalf("unexpected first rule %+v", r)
+	}
+	if rules[1].Grants() || rules[2].Start != 7 || rules[2].End != 7 {
+		t.Fatalf("unexpected rules %+v", rules[1:])
+	}
+}
+
+func TestEffectiveRule_VaultPriority(t *testing.T) {
+	rules := []PolicyRule{
+		{Path: "kv/*"},
+		{Path: "kv/data/+/db"},
+		{Path: "kv/data/app/*"},
+		{Path: "kv/data/app/db"},
+		{Path: "kv/data/app/d*"},
+	}
+	for _, c := range []struct{ path, want string }{
+		{"kv/data/app/db", "kv/data/app/db"},
+		{"kv/data/app/dx", "kv/data/app/d*"},
+		{"kv/data/app/x", "kv/data/app/*"},
+		{"kv/data/web/db", "kv/data/+/db"},
+		{"kv/metadata/app", "kv/*"},
+	} {
+		r, ok := EffectiveRule(rules, c.path)
+		if !ok || r.Path != c.want {
+			t.Fatalf("%s: got %q (%v), want %q", c.path, r.Path, ok, c.want)
+		}
+	}
+	if _, ok := EffectiveRule(rules, "sys/health"); ok {
+		t.Fatal("no rule should match sys/health")
+	}
+}
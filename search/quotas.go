@@ -0,0 +1,95 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MountTune is the subset of sys/mounts/<mount>/tune shown for a mount.
+type MountTune struct {
+	Description string
+	// Lease TTLs; zero means the system default applies.
+	DefaultLeaseTTL time.Duration
+	MaxLeaseTTL     time.Duration
+}
+
+// ReadMountTune reads the tuning of mount ("kv" or "kv/").
+func ReadMountTune(ctx context.Context, logical LogicalAPI, mount string) (MountTune, error) {
+	p := "sys/mounts/" + strings.Trim(mount, "/") + "/tune"
+	sec, err := logical.ReadWithContext(ctx, p)
+	if err != nil {
+		return MountTune{}, classify(err)
+	}
+	if sec == nil || sec.Data == nil {
+		return MountTune{}, fmt.Errorf("no tuning at %s", p)
+	}
+	t := MountTune{
+		DefaultLeaseTTL: time.Duration(toInt(sec.Data["default_lease_ttl"])) * time.Second,
+		MaxLeaseTTL:     time.Duration(toInt(sec.Data["max_lease_ttl"])) * time.Second,
+	}
+	t.Description, _ = sec.Data["description"].(string)
+	return t, nil
+}
+
+// RateLimitQuota is a rate-limit quota from sys/quotas/rate-limit.
+type RateLimitQuota struct {
+	Name string
+	// Path is "" for a global quota, a namespace ("ns1/"), a mount ("kv/")
+	// or a path below a mount ("kv/app").
+	Path          string
+	Rate          float64
+	Interval      time.Duration
+	BlockInterval time.Duration
+}
+
+// Applies reports whether the quota covers requests to mount, a mount path
+// with its namespace and trailing slash ("ns1/kv/"): global, namespace and
+// mount quotas cover all of it, path quotas the part below their path.
+func (q RateLimitQuota) Applies(mount string) bool {
+	return q.Path == "" || strings.HasPrefix(mount, q.Path) || strings.HasPrefix(q.Path, mount)
+}
+
+// ReadRateLimitQuotas lists the rate-limit quotas and reads each of them,
+// sorted by path and name.
+func ReadRateLimitQuotas(ctx context.Context, logical LogicalAPI) ([]RateLimitQuota, error) {
+	sec, err := logical.ListWithContext(ctx, "sys/quotas/rate-limit")
+	if err != nil {
+		return nil, classify(err)
+	}
+	if sec == nil || sec.Data == nil {
+		return nil, nil
+	}
+	keys, _ := sec.Data["keys"].([]interface{})
+	out := make([]RateLimitQuota, 0, len(keys))
+	for _, k := range keys {
+		name, ok := k.(string)
+		if !ok || name == "" {
+			continue
+		}
+		q, err := logical.ReadWithContext(ctx, "sys/quotas/rate-limit/"+name)
+		if err != nil {
+			return nil, classify(err)
+		}
+		if q == nil || q.Data == nil {
+			continue
+		}
+		rq := RateLimitQuota{
+			Name:          name,
+			Rate:          toFloat(q.Data["rate"]),
+			Interval:      time.Duration(toInt(q.Data["interval"])) * time.Second,
+			BlockInterval: time.Duration(toInt(q.Data["block_interval"])) * time.Second,
+		}
+		rq.Path, _ = q.Data["path"].(string)
+		out = append(out, rq)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Path != out[j].Path {
+			return out[i].Path < out[j].Path
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
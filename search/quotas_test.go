@@ -0,0 +1,66 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+func TestReadMountTune(t *testing.T) {
+	f := &fakeLogical{read: map[string]*vault.Secret{
+		"sys/mounts/kv/tune": {Data: map[string]interface{}{
+			"default_lease_ttl": json.Number("3600"),
+			"max_lease_ttl":     json.Number("86400"),
+			"description":       "app secrets",
+		}},
+	}}
+	got, err := ReadMountTune(context.Background(), f, "kv/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := MountTune{Description: "app secrets", DefaultLeaseTTL: time.Hour, MaxLeaseTTL: 24 * time.Hour}
+	if got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if _, err := ReadMountTune(context.Background(), f, "other"); err == nil {
+		t.Fatal("expected an error for a mount without tuning")
+	}
+}
+
+func TestReadRateLimitQuotas_AppliesToMount(t *testing.T) {
+	quota := func(path string, rate string) *vault.Secret {
+		return &vault.Secret{Data: map[string]interface{}{
+			"path": path, "rate": json.Number(rate), "interval": json.Number("1"),
+		}}
+	}
+	f := &fakeLogical{
+		list: map[string]*vault.Secret{
+			"sys/quotas/rate-limit": {Data: map[string]interface{}{"keys": []interface{}{"global", "kv-app", "other", "team"}}},
+		},
+		read: map[string]*vault.Secret{
+			"sys/quotas/rate-limit/global": quota("", "1000"),
+			"sys/quotas/rate-limit/kv-app": quota("ns1/kv/app", "10.5"),
+			"sys/quotas/rate-limit/other":  quota("ns1/pki/", "50"),
+			"sys/quotas/rate-limit/team":   quota("ns1/", "200"),
+		},
+	}
+	qs, err := ReadRateLimitQuotas(context.Background(), f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var applies []string
+	for _, q := range qs {
+		if q.Applies("ns1/kv/") {
+			applies = append(applies, q.Name)
+		}
+	}
+	if len(applies) != 3 || applies[0] != "global" || applies[1] != "team" || applies[2] != "kv-app" {
+		t.Fatalf("unexpected applicable quotas: %v", applies)
+	}
+	if qs[2].Rate != 10.5 || qs[2].Interval != time.Second {
+		t.Fatalf("unexpected quota: %+v", qs[2])
+	}
+}
@@ -0,0 +1,51 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RetryPolicy bounds single requests such as value reads: each attempt gets
+// Timeout (0 leaves only the caller's context), and an attempt that ran out
+// of its own time is repeated up to Retries times. Other errors, and attempts
+// cut short because the caller's context ended, are returned as they are.
+type RetryPolicy struct {
+	Timeout time.Duration
+	Retries int
+}
+
+// Do runs fn under the policy, passing each attempt its own context.
+func (r RetryPolicy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	for attempt := 0; ; attempt++ {
+		timedOut, err := r.attempt(ctx, fn)
+		if err == nil || !timedOut || attempt >= r.Retries {
+			return err
+		}
+	}
+}
+
+// attempt runs fn once and reports whether it failed because its own
+// deadline passed while ctx was still live; the contexts say so directly,
+// whatever the transport made of the error.
+func (r RetryPolicy) attempt(ctx context.Context, fn func(ctx context.Context) error) (bool, error) {
+	if r.Timeout <= 0 {
+		return false, fn(ctx)
+	}
+	actx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+	err := fn(actx)
+	timedOut := err != nil && errors.Is(actx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+	return timedOut, err
+}
+
+// ReadSecretRetry is ReadSecret under the retry policy r.
+func ReadSecretRetry(ctx context.Context, logical LogicalAPI, mount, inner string, kv2 bool, r RetryPolicy) (interface{}, error) {
+	var val interface{}
+	err := r.Do(ctx, func(ctx context.Context) error {
+		var err error
+		val, err = ReadSecret(ctx, logical, mount, inner, kv2)
+		return err
+	})
+	return val, err
+}
@@ -0,0 +1,53 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_RetriesOnlyOwnTimeouts(t *testing.T) {
+	slow := func(calls *int) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			*calls++
+			<-ctx.Done()
+			// Transports often flatten the context error into a new one
+			return errors.New("request failed")
+		}
+	}
+
+	calls := 0
+	err := RetryPolicy{Timeout: 5 * time.Millisecond, Retries: 2}.Do(context.Background(), slow(&calls))
+	if err == nil || calls != 3 {
+		t.Fatalf("expected 3 attempts and an error, got %d (%v)", calls, err)
+	}
+
+	calls = 0
+	if err := (RetryPolicy{Timeout: 5 * time.Millisecond, Retries: 2}).Do(context.Background(), func(context.Context) error {
+		calls++
+		return ErrPermissionDenied
+	}); !errors.Is(err, ErrPermissionDenied) || calls != 1 {
+		t.Fatalf("other errors must not be retried: %d (%v)", calls, err)
+	}
+
+	// The caller's own deadline ends the retries
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	calls = 0
+	if err := (RetryPolicy{Timeout: time.Second, Retries: 3}).Do(ctx, slow(&calls)); err == nil || calls != 1 {
+		t.Fatalf("expected one attempt after the caller's deadline, got %d (%v)", calls, err)
+	}
+
+	calls = 0
+	if err := (RetryPolicy{Timeout: 5 * time.Millisecond, Retries: 3}).Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 2 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}); err != nil || calls != 2 {
+		t.Fatalf("expected success on the retry, got %d (%v)", calls, err)
+	}
+}
@@ -0,0 +1,61 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// LogicalVersionReader reads with query parameters (satisfied by *vault.Logical).
+type LogicalVersionReader interface {
+	ReadWithDataWithContext(ctx context.Context, path string, data map[string][]string) (*vault.Secret, error)
+}
+
+// ReadSecretAtVersion reads the data of one version of the KV v2 secret at
+// mount/inner. Deleted and destroyed versions have no data and fail.
+func ReadSecretAtVersion(ctx context.Context, logical LogicalVersionReader, mount, inner string, version int) (map[string]interface{}, error) {
+	p := ReadAPIPath(mount, inner, true)
+	sec, err := logical.ReadWithDataWithContext(ctx, p, map[string][]string{"version": {strconv.Itoa(version)}})
+	if err != nil {
+		return nil, classify(err)
+	}
+	if sec == nil {
+		return nil, fmt.Errorf("%s has no version %d", p, version)
+	}
+	data, ok := sec.Data["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("version %d of %s is deleted or destroyed", version, p)
+	}
+	return data, nil
+}
+
+// LogicalReadWriter can read versions and write; *vault.Logical is one.
+type LogicalReadWriter interface {
+	LogicalAPI
+	LogicalVersionReader
+	LogicalWriter
+}
+
+// Rollback writes the data of version as the new current version of the KV v2
+// secret at mount/inner, like vault kv rollback. The write uses check-and-set
+// against the current version read beforehand, so a concurrent write makes it
+// fail rather than being replaced. It returns the version written.
+func Rollback(ctx context.Context, logical LogicalReadWriter, mount, inner string, version int) (int, error) {
+	md, err := ReadMetadata(ctx, logical, mount, inner)
+	if err != nil {
+		return 0, err
+	}
+	if version < 1 || version > md.CurrentVersion {
+		return 0, fmt.Errorf("version %d out of range (current is %d)", version, md.CurrentVersion)
+	}
+	if version == md.CurrentVersion {
+		return 0, fmt.Errorf("version %d is already the current version", version)
+	}
+	data, err := ReadSecretAtVersion(ctx, logical, mount, inner, version)
+	if err != nil {
+		return 0, err
+	}
+	return WriteSecretCAS(ctx, logical, mount, inner, data, md.CurrentVersion)
+}
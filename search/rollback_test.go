@@ -0,0 +1,73 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+func TestRollback(t *testing.T) {
+	var written map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/v1/kv/metadata/app/db":
+			w.Write([]byte(`{"data":{"current_version":3,"versions":{
+				"1":{"created_time":"2024-01-01T00:00:00Z","deletion_time":"","destroyed":true},
+				"2":{"created_time":"2024-02-01T00:00:00Z","deletion_time":"","destroyed":false},
+				"3":{"created_time":"2024-03-01T00:00:00Z","deletion_time":"","destroyed":false}}}}`))
+		case r.URL.Path == "/v1/kv/data/app/db" && r.Method == http.MethodGet:
+			switch r.URL.Query().Get("version") {
+			case "2":
+				w.Write([]byte(`{"data":{"data":{"pass":"old"},"metadata":{"version":2}}}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"data":{"data":null,"metadata":{"version":1,"destroyed":true}}}`))
+			}
+		case r.URL.Path == "/v1/kv/data/app/db":
+			json.NewDecoder(r.Body).Decode(&written)
+			w.Write([]byte(`{"data":{"version":4}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	cfg := vault.DefaultConfig()
+	cfg.Address = srv.URL
+	cfg.MaxRetries = 0
+	vc, err := vault.NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	md, err := ReadMetadata(ctx, vc.Logical(), "kv", "app/db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(md.Versions) != 3 || md.Versions[0].Version != 3 || md.Versions[2].Readable() {
+		t.Fatalf("versions %+v", md.Versions)
+	}
+
+	v, err := Rollback(ctx, vc.Logical(), "kv", "app/db", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 4 {
+		t.Fatalf("rollback wrote version %d, want 4", v)
+	}
+	data, _ := written["data"].(map[string]interface{})
+	opts, _ := written["options"].(map[string]interface{})
+	if data["pass"] != "old" || opts["cas"] != float64(3) {
+		t.Fatalf("rollback wrote %v", written)
+	}
+	for _, bad := range []int{1, 3, 9} {
+		if _, err := Rollback(ctx, vc.Logical(), "kv", "app/db", bad); err == nil {
+			t.Errorf("rollback to v%d should fail", bad)
+		}
+	}
+}
@@ -2,8 +2,10 @@ package search
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path"
 	"regexp"
@@ -18,11 +20,112 @@ import (
 type FoundItem struct {
 	Path  string      `json:"path"`
 	Value interface{} `json:"value,omitempty"`
+	// Size (bytes of the value's JSON encoding) and Keys (top-level keys) are
+	// set by value walks, to spot oversized secrets.
+	Size int `json:"size,omitempty"`
+	Keys int `json:"keys,omitempty"`
+}
+
+// valueItem returns the item for a secret read during a value walk.
+func valueItem(p string, val interface{}) FoundItem {
+	it := FoundItem{Path: p, Value: val}
+	if b, err := json.Marshal(val); err == nil {
+		it.Size = len(b)
+	}
+	if m, ok := val.(map[string]interface{}); ok {
+		it.Keys = len(m)
+	}
+	return it
+}
+
+// WalkOptions configures a walk. Each walk carries its own options, so
+// concurrent walks may use different filters.
+type WalkOptions struct {
+    // KV2 selects the KV v2 API paths (metadata/ and data/).
+    KV2 bool
+    // MaxDepth limits how deep leaves may be below the start path; 0 means unlimited.
+    MaxDepth int
+    // Filters selects which leaves are reported.
+    Filters Filters
+    // WithValues reads each leaf's value during the walk.
+    WithValues bool
+    // OnError handles per-path failures (see ErrorHandler); nil aborts on the first error.
+    OnError ErrorHandler
+    // ListBatch is how many keys of a listing are handled at a time; 0 means
+    // defaultListBatch.
+    ListBatch int
+    // MaxListKeys refuses directories listing more keys than this with
+    // ErrListTooLarge (passed to OnError); 0 means no limit.
+    MaxListKeys int
+    // Read bounds each value read when WithValues is set; the zero value
+    // reads under the walk's context alone.
+    Read RetryPolicy
+}
+
+// Filters selects leaves by name and/or full path. With neither set every
+// leaf matches; with both set a leaf matches either (OR semantics).
+type Filters struct {
+    // NamePart is a case-insensitive substring of the last path segment.
+    NamePart string
+    // Matcher is matched against the full logical path.
+    Matcher *regexp.Regexp
+}
+
+// Match reports whether the leaf at logicalPath, named baseName, passes the filters.
+func (f Filters) Match(baseName, logicalPath string) bool {
+    nameProvided := f.NamePart != ""
+    regexProvided := f.Matcher != nil
+    switch {
+    case !nameProvided && !regexProvided:
+        return true // no filters -> match all
+    case nameProvided && !regexProvided:
+        return f.nameMatch(baseName)
+    case !nameProvided && regexProvided:
+        return f.Matcher.MatchString(logicalPath)
+    default: // both provided -> OR semantics
+        return f.nameMatch(baseName) || f.Matcher.MatchString(logicalPath)
+    }
+}
+
+func (f Filters) nameMatch(base string) bool {
+    if f.NamePart == "" {
+        return false
+    }
+    return strings.Contains(strings.ToLower(base), strings.ToLower(f.NamePart))
+}
+
+// legacyOptions builds WalkOptions for the positional-argument walkers, which
+// still read the deprecated CurrentNamePart.
+func legacyOptions(kv2 bool, maxDepth int, matcher *regexp.Regexp, withValues bool, onErr ErrorHandler) WalkOptions {
+    return WalkOptions{
+        KV2:        kv2,
+        MaxDepth:   maxDepth,
+        Filters:    Filters{NamePart: CurrentNamePart, Matcher: matcher},
+        WithValues: withValues,
+        OnError:    onErr,
+    }
+}
+
+// WalkStream recursively walks the given start path and sends matching items
+// to outCh as they are found. It respects context cancellation and returns the
+// error that stopped the walk, if any.
+func WalkStream(ctx context.Context, logical LogicalAPI, start string, o WalkOptions, outCh chan<- FoundItem) error {
+    mount, inner := SplitMount(start)
+    began := time.Now()
+    ctx, span := startWalkSpan(ctx, start, o.KV2)
+    slog.Debug("walk started", "path", start, "kv2", o.KV2)
+    err := recurseStream(ctx, logical, mount, inner, 0, &o, outCh)
+    slog.Debug("walk finished", "path", start, "elapsed", time.Since(began), "err", err)
+    endSpan(span, err)
+    return err
 }
 
 // WalkVaultStream recursively walks the given start path and sends matching items to outCh as they are found.
 // It respects context cancellation. When finished (or on error), it closes doneCh by sending the first error (nil on success)
 // via errCh if provided.
+//
+// Deprecated: use WalkStream, which takes the name filter in WalkOptions
+// instead of reading CurrentNamePart.
 func WalkVaultStream(
     ctx context.Context,
     logical LogicalAPI,
@@ -33,86 +136,114 @@ func WalkVaultStream(
     withValues bool,
     outCh chan<- FoundItem,
 ) error {
-    mount, inner := SplitMount(start)
-    return recurseStream(ctx, logical, mount, inner, kv2, 0, maxDepth, matcher, withValues, outCh)
+    return WalkStream(ctx, logical, start, legacyOptions(kv2, maxDepth, matcher, withValues, nil), outCh)
 }
 
-func recurseStream(
+// ErrorHandler decides what happens when listing or reading a subtree fails.
+// Returning nil skips the failing path and continues the walk; returning an
+// error aborts it. Context cancellation always aborts without calling it.
+type ErrorHandler func(path string, err error) error
+
+// WalkVaultStreamWithErrors is WalkVaultStream with per-path error handling.
+// A nil onErr aborts on the first error, like WalkVaultStream.
+//
+// Deprecated: use WalkStream with WalkOptions.OnError.
+func WalkVaultStreamWithErrors(
     ctx context.Context,
     logical LogicalAPI,
-    mount, inner string,
+    start string,
     kv2 bool,
-    depth, maxDepth int,
+    maxDepth int,
     matcher *regexp.Regexp,
     withValues bool,
     outCh chan<- FoundItem,
+    onErr ErrorHandler,
 ) error {
-    if maxDepth > 0 && depth > maxDepth {
+    return WalkStream(ctx, logical, start, legacyOptions(kv2, maxDepth, matcher, withValues, onErr), outCh)
+}
+
+// handleWalkError applies onErr to a failure at p.
+func handleWalkError(ctx context.Context, onErr ErrorHandler, p string, err error) error {
+    if onErr == nil || ctx.Err() != nil {
+        return err
+    }
+    if herr := onErr(p, err); herr != nil {
+        return herr
+    }
+    slog.Warn("skipping path after error", "path", p, "err", err)
+    return nil
+}
+
+func recurseStream(
+    ctx context.Context,
+    logical LogicalAPI,
+    mount, inner string,
+    depth int,
+    o *WalkOptions,
+    outCh chan<- FoundItem,
+) error {
+    if o.MaxDepth > 0 && depth > o.MaxDepth {
         return nil
     }
 
-    listPath := ListAPIPath(mount, inner, kv2)
-    sec, err := logical.ListWithContext(ctx, listPath)
+    logicalPath := path.Clean(joinNonEmpty(mount, inner))
+    listPath := ListAPIPath(mount, inner, o.KV2)
+    sec, err := listTraced(ctx, logical, listPath)
     if err != nil {
-        return err
+        return handleWalkError(ctx, o.OnError, logicalPath, classify(err))
     }
     if sec == nil || sec.Data == nil {
-        return handleLeafStream(ctx, logical, mount, inner, kv2, matcher, withValues, outCh)
+        if err := handleLeafStream(ctx, logical, mount, inner, o, outCh); err != nil {
+            return handleWalkError(ctx, o.OnError, logicalPath, err)
+        }
+        return nil
     }
 
     rawKeys, ok := sec.Data["keys"].([]interface{})
     if !ok {
-        return fmt.Errorf("unexpected list response at %s", listPath)
+        return handleWalkError(ctx, o.OnError, logicalPath, unexpectedList(listPath))
     }
-    for _, k := range rawKeys {
-        select {
-        case <-ctx.Done():
-            return ctx.Err()
-        default:
-        }
-        key, _ := k.(string)
+    if err := checkListSize(listPath, len(rawKeys), o); err != nil {
+        return handleWalkError(ctx, o.OnError, logicalPath, err)
+    }
+    return eachListBatch(ctx, rawKeys, o, func(key string) error {
         if strings.HasSuffix(key, "/") {
             nextDepth := depth + 1
-            if maxDepth > 0 && nextDepth >= maxDepth {
-                continue
+            if o.MaxDepth > 0 && nextDepth >= o.MaxDepth {
+                return nil
             }
             nextInner := joinNonEmpty(strings.TrimSuffix(inner, "/"), strings.TrimSuffix(key, "/"))
-            if err := recurseStream(ctx, logical, mount, nextInner, kv2, nextDepth, maxDepth, matcher, withValues, outCh); err != nil {
-                return err
-            }
-        } else {
-            if maxDepth > 0 && (depth+1) > maxDepth {
-                continue
-            }
-            leafInner := joinNonEmpty(inner, key)
-            if err := handleLeafStream(ctx, logical, mount, leafInner, kv2, matcher, withValues, outCh); err != nil {
-                return err
-            }
+            return recurseStream(ctx, logical, mount, nextInner, nextDepth, o, outCh)
         }
-    }
-    return nil
+        if o.MaxDepth > 0 && (depth+1) > o.MaxDepth {
+            return nil
+        }
+        leafInner := joinNonEmpty(inner, key)
+        if err := handleLeafStream(ctx, logical, mount, leafInner, o, outCh); err != nil {
+            return handleWalkError(ctx, o.OnError, path.Clean(joinNonEmpty(mount, leafInner)), err)
+        }
+        return nil
+    })
 }
 
 func handleLeafStream(
     ctx context.Context,
     logical LogicalAPI,
     mount, inner string,
-    kv2 bool,
-    matcher *regexp.Regexp,
-    withValues bool,
+    o *WalkOptions,
     outCh chan<- FoundItem,
 ) error {
     logicalPath := path.Clean(joinNonEmpty(mount, inner))
     base := path.Base(logicalPath)
-    matches := NameOrRegexMatch(base, logicalPath, matcher)
+    matches := o.Filters.Match(base, logicalPath)
 
-    if withValues {
-        val, err := ReadSecret(ctx, logical, mount, inner, kv2)
+    if o.WithValues {
+        val, err := ReadSecretRetry(ctx, logical, mount, inner, o.KV2, o.Read)
         if err != nil {
             return err
         }
         if matches {
-            outCh <- FoundItem{Path: logicalPath, Value: val}
+            outCh <- valueItem(logicalPath, val)
         }
         return nil
     }
@@ -130,12 +261,13 @@ func ListMountsWithFallback(ctx context.Context, c *vault.Client) (map[string]*v
 	if err == nil {
 		return mounts, nil
 	}
-	var respErr *vault.ResponseError
-	if !errors.As(err, &respErr) || respErr.StatusCode != 403 {
+	err = classify(err)
+	if !errors.Is(err, ErrPermissionDenied) {
 		return nil, err
 	}
 
 	// Fallback: internal UI endpoint
+	slog.Info("listing mounts denied, falling back to sys/internal/ui/mounts", "err", err)
 	sec, ierr := c.Logical().ReadWithContext(ctx, "sys/internal/ui/mounts")
 	if ierr != nil {
 		return nil, err // return original error for context
@@ -205,35 +337,22 @@ type LogicalAPI interface {
 
 // CurrentNamePart is the case-insensitive substring used to match the last path segment.
 // Set via CLI before walking.
+//
+// Deprecated: set Filters.NamePart in WalkOptions instead. Only the
+// deprecated positional walkers and NameOrRegexMatch read it.
 var CurrentNamePart string
 
 // SetNamePart sets the -name filter value.
+//
+// Deprecated: set Filters.NamePart in WalkOptions instead.
 func SetNamePart(s string) { CurrentNamePart = s }
 
 // NameOrRegexMatch returns true if, based on provided filters, the base name or the full path matches.
 // If neither filter is provided, match all. If both provided, OR semantics.
+//
+// Deprecated: use Filters.Match.
 func NameOrRegexMatch(baseName, logicalPath string, matcher *regexp.Regexp) bool {
-	nameProvided := CurrentNamePart != ""
-	regexProvided := matcher != nil
-	switch {
-	case !nameProvided && !regexProvided:
-		return true // no filters -> match all
-	case nameProvided && !regexProvided:
-		return nameMatch(baseName)
-	case !nameProvided && regexProvided:
-		return matcher.MatchString(logicalPath)
-	default: // both provided -> OR semantics
-		return nameMatch(baseName) || matcher.MatchString(logicalPath)
-	}
-}
-
-func nameMatch(base string) bool {
-	if CurrentNamePart == "" {
-		return false
-	}
-	b := strings.ToLower(base)
-	q := strings.ToLower(CurrentNamePart)
-	return strings.Contains(b, q)
+	return Filters{NamePart: CurrentNamePart, Matcher: matcher}.Match(baseName, logicalPath)
 }
 
 // SplitMount splits the provided path into mount and inner parts.
@@ -277,15 +396,40 @@ func ReadAPIPath(mount, inner string, kv2 bool) string {
 	return path.Clean(joinNonEmpty(mount, inner))
 }
 
+// ListKeys lists one level below mount/inner, sorted; folders keep their
+// trailing slash. A path that is not a folder yields no keys.
+func ListKeys(ctx context.Context, logical LogicalAPI, mount, inner string, kv2 bool) ([]string, error) {
+	listPath := ListAPIPath(mount, inner, kv2)
+	sec, err := listTraced(ctx, logical, listPath)
+	if err != nil {
+		return nil, classify(err)
+	}
+	if sec == nil || sec.Data == nil {
+		return nil, nil
+	}
+	rawKeys, ok := sec.Data["keys"].([]interface{})
+	if !ok {
+		return nil, unexpectedList(listPath)
+	}
+	keys := make([]string, 0, len(rawKeys))
+	for _, k := range rawKeys {
+		if key, ok := k.(string); ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
 // ReadSecret reads a secret and returns its data payload depending on kv version
 func ReadSecret(ctx context.Context, logical LogicalAPI, mount, inner string, kv2 bool) (interface{}, error) {
 	readPath := ReadAPIPath(mount, inner, kv2)
-	sec, err := logical.ReadWithContext(ctx, readPath)
+	sec, err := readTraced(ctx, logical, readPath)
 	if err != nil {
-		return nil, err
+		return nil, classify(err)
 	}
 	if sec == nil {
-		return nil, fmt.Errorf("no data at %s", readPath)
+		return nil, fmt.Errorf("%w at %s", ErrNoData, readPath)
 	}
 	if kv2 {
 		// In some cases an empty secret may have a nil or missing data field.
@@ -301,7 +445,30 @@ func ReadSecret(ctx context.Context, logical LogicalAPI, mount, inner string, kv
 	return sec.Data, nil
 }
 
+// Walk recursively walks the given start path and returns matching items
+// sorted by path. When the walk stops early (e.g. the context is cancelled)
+// the items found so far are returned along with the error.
+func Walk(ctx context.Context, logical LogicalAPI, start string, o WalkOptions) ([]FoundItem, error) {
+	mount, inner := SplitMount(start)
+	began := time.Now()
+	ctx, span := startWalkSpan(ctx, start, o.KV2)
+	slog.Debug("walk started", "path", start, "kv2", o.KV2)
+	var out []FoundItem
+	err := recurse(ctx, logical, mount, inner, 0, &o, &out)
+	endSpan(span, err)
+	if err != nil {
+		slog.Debug("walk failed", "path", start, "items", len(out), "elapsed", time.Since(began), "err", err)
+	} else {
+		slog.Debug("walk finished", "path", start, "items", len(out), "elapsed", time.Since(began))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, err
+}
+
 // WalkVault recursively walks the given start path and returns matching items
+//
+// Deprecated: use Walk, which takes the name filter in WalkOptions instead of
+// reading CurrentNamePart.
 func WalkVault(
 	ctx context.Context,
 	logical LogicalAPI,
@@ -311,12 +478,10 @@ func WalkVault(
 	matcher *regexp.Regexp,
 	withValues bool,
 ) ([]FoundItem, error) {
-	mount, inner := SplitMount(start)
-	var out []FoundItem
-	if err := recurse(ctx, logical, mount, inner, kv2, 0, maxDepth, matcher, withValues, &out); err != nil {
+	out, err := Walk(ctx, logical, start, legacyOptions(kv2, maxDepth, matcher, withValues, nil))
+	if err != nil {
 		return nil, err
 	}
-	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
 	return out, nil
 }
 
@@ -324,102 +489,98 @@ func recurse(
 	ctx context.Context,
 	logical LogicalAPI,
 	mount, inner string,
-	kv2 bool,
-	depth, maxDepth int,
-	matcher *regexp.Regexp,
-	withValues bool,
+	depth int,
+	o *WalkOptions,
 	out *[]FoundItem,
 ) error {
-	if maxDepth > 0 && depth > maxDepth {
+	if o.MaxDepth > 0 && depth > o.MaxDepth {
 		return nil
 	}
 
-	listPath := ListAPIPath(mount, inner, kv2)
-	sec, err := logical.ListWithContext(ctx, listPath)
+	logicalPath := path.Clean(joinNonEmpty(mount, inner))
+	listPath := ListAPIPath(mount, inner, o.KV2)
+	sec, err := listTraced(ctx, logical, listPath)
 	if err != nil {
-		return err
+		return handleWalkError(ctx, o.OnError, logicalPath, classify(err))
 	}
 	if sec == nil || sec.Data == nil {
 		// treat as leaf
-		return handleLeaf(ctx, logical, mount, inner, kv2, matcher, withValues, out)
+		if err := handleLeaf(ctx, logical, mount, inner, o, out); err != nil {
+			return handleWalkError(ctx, o.OnError, logicalPath, err)
+		}
+		return nil
 	}
 
 	rawKeys, ok := sec.Data["keys"].([]interface{})
 	if !ok {
-		return fmt.Errorf("unexpected list response at %s", listPath)
+		return handleWalkError(ctx, o.OnError, logicalPath, unexpectedList(listPath))
 	}
-	for _, k := range rawKeys {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-		key, _ := k.(string)
+	if err := checkListSize(listPath, len(rawKeys), o); err != nil {
+		return handleWalkError(ctx, o.OnError, logicalPath, err)
+	}
+	return eachListBatch(ctx, rawKeys, o, func(key string) error {
 		if strings.HasSuffix(key, "/") {
 			// recurse into subpath only if doing so can yield leaves within maxDepth
 			nextDepth := depth + 1
-			if maxDepth > 0 && nextDepth >= maxDepth {
-				continue
+			if o.MaxDepth > 0 && nextDepth >= o.MaxDepth {
+				return nil
 			}
 			nextInner := joinNonEmpty(strings.TrimSuffix(inner, "/"), strings.TrimSuffix(key, "/"))
-			if err := recurse(ctx, logical, mount, nextInner, kv2, nextDepth, maxDepth, matcher, withValues, out); err != nil {
-				return err
-			}
-		} else {
-			// leaf candidate at depth+1
-			if maxDepth > 0 && (depth+1) > maxDepth {
-				continue
-			}
-			leafInner := joinNonEmpty(inner, key)
-			if err := handleLeaf(ctx, logical, mount, leafInner, kv2, matcher, withValues, out); err != nil {
-				return err
-			}
+			return recurse(ctx, logical, mount, nextInner, nextDepth, o, out)
 		}
-	}
-	return nil
+		// leaf candidate at depth+1
+		if o.MaxDepth > 0 && (depth+1) > o.MaxDepth {
+			return nil
+		}
+		leafInner := joinNonEmpty(inner, key)
+		if err := handleLeaf(ctx, logical, mount, leafInner, o, out); err != nil {
+			return handleWalkError(ctx, o.OnError, path.Clean(joinNonEmpty(mount, leafInner)), err)
+		}
+		return nil
+	})
 }
 
 func handleLeaf(
 	ctx context.Context,
 	logical LogicalAPI,
 	mount, inner string,
-	kv2 bool,
-	matcher *regexp.Regexp,
-	withValues bool,
+	o *WalkOptions,
 	out *[]FoundItem,
 ) error {
 	logicalPath := path.Clean(joinNonEmpty(mount, inner))
 	base := path.Base(logicalPath)
-	if !NameOrRegexMatch(base, logicalPath, matcher) {
-		if !withValues {
-			return nil
-		}
+	matches := o.Filters.Match(base, logicalPath)
+	if !matches && !o.WithValues {
+		return nil
 	}
 
-	if withValues {
-		val, err := ReadSecret(ctx, logical, mount, inner, kv2)
+	if o.WithValues {
+		val, err := ReadSecretRetry(ctx, logical, mount, inner, o.KV2, o.Read)
 		if err != nil {
 			return err
 		}
-		if NameOrRegexMatch(base, logicalPath, matcher) {
-			*out = append(*out, FoundItem{Path: logicalPath, Value: val})
+		if matches {
+			*out = append(*out, valueItem(logicalPath, val))
 		}
 		return nil
 	}
 
-	if NameOrRegexMatch(base, logicalPath, matcher) {
-		*out = append(*out, FoundItem{Path: logicalPath})
-	}
+	*out = append(*out, FoundItem{Path: logicalPath})
 	return nil
 }
 
 // DetectKV2 tries to determine whether the mount for the start path is KV v2.
 func DetectKV2(ctx context.Context, c *vault.Client, start string) (bool, bool) {
-	mount, _ := SplitMount(start)
 	mounts, err := ListMountsWithFallback(ctx, c)
 	if err != nil {
 		return false, false
 	}
+	return kv2FromMounts(mounts, start)
+}
+
+// kv2FromMounts reports the KV version of start's mount in the mount table.
+func kv2FromMounts(mounts map[string]*vault.MountOutput, start string) (bool, bool) {
+	mount, _ := SplitMount(start)
 	m, ok := mounts[mount+"/"]
 	if !ok {
 		return false, false
@@ -438,6 +599,11 @@ func DetectKV2(ctx context.Context, c *vault.Client, start string) (bool, bool)
 // - VAULT_ADDR must be set; if not, return an error instead of defaulting to 127.0.0.1:8200
 // - If VAULT_TOKEN is not set, attempt to read from ~/.vault-token
 func NewVaultClient() (*vault.Client, error) {
+    return NewVaultClientWithTransport(TransportOptions{})
+}
+
+// NewVaultClientWithTransport is NewVaultClient with HTTP transport tuning.
+func NewVaultClientWithTransport(transport TransportOptions) (*vault.Client, error) {
     // Enforce explicit address to avoid accidental localhost default
     addr := strings.TrimSpace(os.Getenv("VAULT_ADDR"))
     if addr == "" {
@@ -450,6 +616,9 @@ func NewVaultClient() (*vault.Client, error) {
         return nil, err
     }
     cfg.Address = addr
+    if err := applyTransportOptions(cfg, transport); err != nil {
+        return nil, err
+    }
 
     c, err := vault.NewClient(cfg)
     if err != nil {
@@ -487,8 +656,15 @@ func NewVaultClient() (*vault.Client, error) {
     return c, nil
 }
 
-// CheckConnection verifies the Vault server is reachable by calling the health endpoint.
+// CheckConnection verifies the Vault server is reachable by calling the health
+// endpoint. A sealed server is reported as ErrSealed.
 func CheckConnection(ctx context.Context, c *vault.Client) error {
-	_, err := c.Sys().HealthWithContext(ctx)
-	return err
+	h, err := c.Sys().HealthWithContext(ctx)
+	if err != nil {
+		return classify(err)
+	}
+	if h != nil && h.Sealed {
+		return fmt.Errorf("%w at %s", ErrSealed, c.Address())
+	}
+	return nil
 }
@@ -2,6 +2,7 @@ package search
 
 import (
 	"context"
+	"encoding/json"
 	"reflect"
 	"regexp"
 	"sort"
@@ -195,3 +196,211 @@ func TestWalkVault_KV2_pkg(t *testing.T) {
 		t.Fatalf("expected 2 items with values, got %#v", items)
 	}
 }
+
+func TestNormalizeNamespace_pkg(t *testing.T) {
+	cases := map[string]string{"": "", "/": "", "team-a": "team-a/", "/team-a/b/": "team-a/b/"}
+	for in, want := range cases {
+		if got := NormalizeNamespace(in); got != want {
+			t.Fatalf("NormalizeNamespace(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestReadMetadata_pkg(t *testing.T) {
+	f := &fakeLogical{
+		read: map[string]*vault.Secret{
+			"kv/metadata/app/db": {Data: map[string]interface{}{
+				"current_version": json.Number("3"),
+				"created_time":    "2024-01-02T03:04:05.123456Z",
+				"updated_time":    "2024-02-03T04:05:06Z",
+				"custom_metadata": map[string]interface{}{"owner": "team-a"},
+			}},
+		},
+	}
+	md, err := ReadMetadata(context.Background(), f, "kv", "app/db")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if md.CurrentVersion != 3 || md.CreatedTime.Year() != 2024 || md.UpdatedTime.Month() != 2 || md.CustomMetadata["owner"] != "team-a" {
+		t.Fatalf("unexpected metadata: %+v", md)
+	}
+	if _, err := ReadMetadata(context.Background(), f, "kv", "missing"); err == nil {
+		t.Fatal("expected error for missing metadata")
+	}
+}
+
+// fakeWriter records writes and deletes for the write helpers.
+type fakeWriter struct {
+	writes  map[string]map[string]interface{}
+	deletes []string
+	reply   *vault.Secret
+}
+
+func (f *fakeWriter) WriteWithContext(_ context.Context, p string, data map[string]interface{}) (*vault.Secret, error) {
+	if f.writes == nil {
+		f.writes = make(map[string]map[string]interface{})
+	}
+	f.writes[p] = data
+	return f.reply, nil
+}
+
+func (f *fakeWriter) DeleteWithContext(_ context.Context, p string) (*vault.Secret, error) {
+	f.deletes = append(f.deletes, p)
+	return nil, nil
+}
+
+func TestWriteAndDeleteSecret_pkg(t *testing.T) {
+	f := &fakeWriter{}
+	data := map[string]interface{}{"k": "v"}
+	if err := WriteSecret(context.Background(), f, "kv", "app/db", true, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteSecret(context.Background(), f, "old", "app/db", false, data); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.writes["kv/data/app/db"]; !reflect.DeepEqual(got, map[string]interface{}{"data": data}) {
+		t.Fatalf("unexpected kv2 write: %#v", got)
+	}
+	if got := f.writes["old/app/db"]; !reflect.DeepEqual(got, data) {
+		t.Fatalf("unexpected kv1 write: %#v", got)
+	}
+	_ = DeleteSecret(context.Background(), f, "kv", "app/db", true)
+	_ = DeleteSecret(context.Background(), f, "old", "app/db", false)
+	if !reflect.DeepEqual(f.deletes, []string{"kv/metadata/app/db", "old/app/db"}) {
+		t.Fatalf("unexpected deletes: %v", f.deletes)
+	}
+}
+
+// deniedLogical fails listing the given paths, like a 403 on a subtree.
+type deniedLogical struct {
+	fakeLogical
+	denied map[string]bool
+}
+
+func (f *deniedLogical) ListWithContext(ctx context.Context, p string) (*vault.Secret, error) {
+	if f.denied[p] {
+		return nil, &vault.ResponseError{HTTPMethod: "LIST", StatusCode: 403, Errors: []string{"permission denied"}}
+	}
+	return f.fakeLogical.ListWithContext(ctx, p)
+}
+
+func TestWalkStreamWithErrors_SkipsFailingSubtrees_pkg(t *testing.T) {
+	f := &deniedLogical{
+		fakeLogical: fakeLogical{
+			list: map[string]*vault.Secret{
+				"secret":   {Data: map[string]interface{}{"keys": []interface{}{"a/", "b/"}}},
+				"secret/b": {Data: map[string]interface{}{"keys": []interface{}{"c"}}},
+			},
+			read: map[string]*vault.Secret{
+				"secret/b/c": {Data: map[string]interface{}{"k": "v"}},
+			},
+		},
+		denied: map[string]bool{"secret/a": true},
+	}
+	SetNamePart("")
+	run := func(onErr ErrorHandler) ([]string, error) {
+		ch := make(chan FoundItem, 8)
+		err := WalkVaultStreamWithErrors(context.Background(), f, "secret", false, 0, nil, false, ch, onErr)
+		close(ch)
+		var got []string
+		for it := range ch {
+			got = append(got, it.Path)
+		}
+		return got, err
+	}
+
+	// Without a handler the first error aborts the walk
+	if _, err := run(nil); err == nil {
+		t.Fatal("expected error without handler")
+	}
+
+	var failed []string
+	got, err := run(func(p string, err error) error {
+		failed = append(failed, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"secret/b/c"}) || !reflect.DeepEqual(failed, []string{"secret/a"}) {
+		t.Fatalf("got items %v failures %v", got, failed)
+	}
+}
+
+func TestCapabilitiesSelf_SingleRequest_pkg(t *testing.T) {
+	f := &fakeWriter{reply: &vault.Secret{Data: map[string]interface{}{
+		"kv/data/a":    []interface{}{"read", "update"},
+		"kv/data/b":    []interface{}{"deny"},
+		"capabilities": []interface{}{"deny"},
+	}}}
+	got, err := CapabilitiesSelf(context.Background(), f, []string{"kv/data/a", "kv/data/b", "kv/data/c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{"kv/data/a": {"read", "update"}, "kv/data/b": {"deny"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if len(f.writes) != 1 || !reflect.DeepEqual(f.writes["sys/capabilities-self"]["paths"], []string{"kv/data/a", "kv/data/b", "kv/data/c"}) {
+		t.Fatalf("writes=%v", f.writes)
+	}
+}
+
+func TestFilters_Match(t *testing.T) {
+	re := regexp.MustCompile(`^secret/app/`)
+	cases := []struct {
+		f    Filters
+		want bool
+	}{
+		{Filters{}, true},
+		{Filters{NamePart: "CONF"}, true},
+		{Filters{NamePart: "x"}, false},
+		{Filters{Matcher: re}, true},
+		{Filters{NamePart: "x", Matcher: re}, true},
+		{Filters{NamePart: "x", Matcher: regexp.MustCompile(`^other/`)}, false},
+	}
+	for i, c := range cases {
+		if got := c.f.Match("config", "secret/app/config"); got != c.want {
+			t.Errorf("case %d: got %v want %v", i, got, c.want)
+		}
+	}
+}
+
+func TestWalk_ConcurrentFiltersIndependent(t *testing.T) {
+	f := &fakeLogical{
+		list: map[string]*vault.Secret{
+			"secret": {Data: map[string]interface{}{"keys": []interface{}{"db", "api", "cfg"}}},
+		},
+	}
+	SetNamePart("ignored")
+	defer SetNamePart("")
+	names := []string{"db", "api", "cfg"}
+	results := make([][]FoundItem, len(names))
+	errs := make([]error, len(names))
+	done := make(chan int)
+	for i, n := range names {
+		go func(i int, n string) {
+			results[i], errs[i] = Walk(context.Background(), f, "secret", WalkOptions{Filters: Filters{NamePart: n}})
+			done <- i
+		}(i, n)
+	}
+	for range names {
+		<-done
+	}
+	for i, n := range names {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		want := []FoundItem{{Path: "secret/" + n}}
+		if !reflect.DeepEqual(results[i], want) {
+			t.Fatalf("filter %q: got %#v want %#v", n, results[i], want)
+		}
+	}
+}
+
+func TestValueItem_SizeAndKeys_pkg(t *testing.T) {
+	it := valueItem("kv/a", map[string]interface{}{"user": "u", "pass": "p"})
+	if it.Keys != 2 || it.Size != len(`{"pass":"p","user":"u"}`) {
+		t.Fatalf("got keys=%d size=%d", it.Keys, it.Size)
+	}
+}
@@ -0,0 +1,48 @@
+package search
+
+import (
+	"context"
+
+	vault "github.com/hashicorp/vault/api"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer records walks and Vault calls as spans. It uses the global provider,
+// which is a no-op until the program installs an exporter.
+var tracer = otel.Tracer("fvf/search")
+
+// startWalkSpan opens the span covering one walk below start.
+func startWalkSpan(ctx context.Context, start string, kv2 bool) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "walk", trace.WithAttributes(
+		attribute.String("vault.path", start),
+		attribute.Bool("vault.kv2", kv2),
+	))
+}
+
+// endSpan records err, if any, and ends the span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// listTraced is logical.ListWithContext inside a "vault.list" span.
+func listTraced(ctx context.Context, logical LogicalAPI, p string) (*vault.Secret, error) {
+	ctx, span := tracer.Start(ctx, "vault.list", trace.WithAttributes(attribute.String("vault.path", p)))
+	sec, err := logical.ListWithContext(ctx, p)
+	endSpan(span, err)
+	return sec, err
+}
+
+// readTraced is logical.ReadWithContext inside a "vault.read" span.
+func readTraced(ctx context.Context, logical LogicalAPI, p string) (*vault.Secret, error) {
+	ctx, span := tracer.Start(ctx, "vault.read", trace.WithAttributes(attribute.String("vault.path", p)))
+	sec, err := logical.ReadWithContext(ctx, p)
+	endSpan(span, err)
+	return sec, err
+}
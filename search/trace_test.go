@@ -0,0 +1,46 @@
+package search
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	vault "github.com/hashicorp/vault/api"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWalk_Spans(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+
+	f := &fakeLogical{
+		list: map[string]*vault.Secret{
+			"secret": {Data: map[string]interface{}{"keys": []interface{}{"a"}}},
+		},
+		read: map[string]*vault.Secret{
+			"secret/a": {Data: map[string]interface{}{"k": "v"}},
+		},
+	}
+	if _, err := Walk(context.Background(), f, "secret", WalkOptions{WithValues: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	var walk sdktrace.ReadOnlySpan
+	for _, s := range rec.Ended() {
+		names = append(names, s.Name())
+		if s.Name() == "walk" {
+			walk = s
+		}
+	}
+	if !reflect.DeepEqual(names, []string{"vault.list", "vault.read", "walk"}) {
+		t.Fatalf("spans %v", names)
+	}
+	for _, s := range rec.Ended()[:2] {
+		if s.Parent().SpanID() != walk.SpanContext().SpanID() {
+			t.Fatalf("%s is not a child of the walk span", s.Name())
+		}
+	}
+}
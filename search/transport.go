@@ -0,0 +1,47 @@
+package search
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// TransportOptions tunes the HTTP transport of the Vault client. The zero
+// value keeps the client defaults (pooled connections, keep-alives, HTTP/2).
+type TransportOptions struct {
+	// MaxIdleConnsPerHost bounds the idle connections kept for reuse (0 = default).
+	MaxIdleConnsPerHost int
+	// DisableKeepAlives opens a new connection per request.
+	DisableKeepAlives bool
+	// DisableHTTP2 forces HTTP/1.1, e.g. behind load balancers with broken h2.
+	DisableHTTP2 bool
+}
+
+// applyTransportOptions adjusts the client's transport in place.
+func applyTransportOptions(cfg *vault.Config, o TransportOptions) error {
+	if cfg.HttpClient == nil {
+		return nil
+	}
+	tr, ok := cfg.HttpClient.Transport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("unexpected HTTP transport %T", cfg.HttpClient.Transport)
+	}
+	if o.MaxIdleConnsPerHost > 0 {
+		tr.MaxIdleConnsPerHost = o.MaxIdleConnsPerHost
+		if tr.MaxIdleConns != 0 && tr.MaxIdleConns < o.MaxIdleConnsPerHost {
+			tr.MaxIdleConns = o.MaxIdleConnsPerHost
+		}
+	}
+	tr.DisableKeepAlives = o.DisableKeepAlives
+	if o.DisableHTTP2 {
+		// A non-nil empty map stops net/http from negotiating h2
+		tr.ForceAttemptHTTP2 = false
+		tr.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		if tr.TLSClientConfig != nil {
+			tr.TLSClientConfig.NextProtos = []string{"http/1.1"}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package search
+
+import (
+	"net/http"
+	"testing"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+func TestApplyTransportOptions(t *testing.T) {
+	cfg := vault.DefaultConfig()
+	if err := applyTransportOptions(cfg, TransportOptions{MaxIdleConnsPerHost: 200, DisableKeepAlives: true, DisableHTTP2: true}); err != nil {
+		t.Fatal(err)
+	}
+	tr := cfg.HttpClient.Transport.(*http.Transport)
+	if tr.MaxIdleConnsPerHost != 200 || tr.MaxIdleConns < 200 {
+		t.Fatalf("idle conns: per host %d, total %d", tr.MaxIdleConnsPerHost, tr.MaxIdleConns)
+	}
+	if !tr.DisableKeepAlives {
+		t.Fatal("keep-alives should be disabled")
+	}
+	if tr.TLSNextProto == nil || len(tr.TLSNextProto) != 0 || tr.ForceAttemptHTTP2 {
+		t.Fatal("HTTP/2 should be disabled")
+	}
+
+	// The zero value keeps the defaults
+	cfg = vault.DefaultConfig()
+	before := cfg.HttpClient.Transport.(*http.Transport).MaxIdleConnsPerHost
+	if err := applyTransportOptions(cfg, TransportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	tr = cfg.HttpClient.Transport.(*http.Transport)
+	if tr.MaxIdleConnsPerHost != before || tr.DisableKeepAlives || len(tr.TLSNextProto) == 0 {
+		t.Fatalf("zero options changed the transport: %+v", tr)
+	}
+}
@@ -0,0 +1,34 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// WrapSecret re-reads the secret at mount/inner with response wrapping and
+// returns the wrap info. The single-use token unwraps (vault unwrap) to the
+// read response within ttl; the plaintext never leaves Vault otherwise.
+func WrapSecret(ctx context.Context, c *vault.Client, mount, inner string, kv2 bool, ttl time.Duration) (*vault.SecretWrapInfo, error) {
+	wc, err := c.Clone()
+	if err != nil {
+		return nil, err
+	}
+	wc.SetToken(c.Token())
+	wc.SetNamespace(c.Namespace())
+	wrapTTL := strconv.Itoa(int(ttl.Seconds())) + "s"
+	wc.SetWrappingLookupFunc(func(operation, path string) string { return wrapTTL })
+
+	readPath := ReadAPIPath(mount, inner, kv2)
+	sec, err := readTraced(ctx, wc.Logical(), readPath)
+	if err != nil {
+		return nil, classify(err)
+	}
+	if sec == nil || sec.WrapInfo == nil || sec.WrapInfo.Token == "" {
+		return nil, fmt.Errorf("no wrapped response for %s", readPath)
+	}
+	return sec.WrapInfo, nil
+}
@@ -0,0 +1,164 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// LogicalWriter is the write side of the Vault logical API (satisfied by *vault.Logical).
+type LogicalWriter interface {
+	WriteWithContext(ctx context.Context, path string, data map[string]interface{}) (*vault.Secret, error)
+	DeleteWithContext(ctx context.Context, path string) (*vault.Secret, error)
+}
+
+// WriteSecret writes data as the secret at mount/inner, wrapping it for KV v2.
+func WriteSecret(ctx context.Context, logical LogicalWriter, mount, inner string, kv2 bool, data map[string]interface{}) error {
+	body := data
+	if kv2 {
+		body = map[string]interface{}{"data": data}
+	}
+	_, err := logical.WriteWithContext(ctx, ReadAPIPath(mount, inner, kv2), body)
+	return classify(err)
+}
+
+// DeleteSecret removes the secret at mount/inner. On KV v2 the metadata is
+// deleted, which removes all versions.
+func DeleteSecret(ctx context.Context, logical LogicalWriter, mount, inner string, kv2 bool) error {
+	p := path.Clean(joinNonEmpty(mount, inner))
+	if kv2 {
+		p = MetadataAPIPath(mount, inner)
+	}
+	_, err := logical.DeleteWithContext(ctx, p)
+	return classify(err)
+}
+
+// CapabilitiesSelf returns the token's capabilities on each API path with a
+// single sys/capabilities-self request. Paths missing from the response map to nil.
+func CapabilitiesSelf(ctx context.Context, logical LogicalWriter, paths []string) (map[string][]string, error) {
+	sec, err := logical.WriteWithContext(ctx, "sys/capabilities-self", map[string]interface{}{"paths": paths})
+	if err != nil {
+		return nil, classify(err)
+	}
+	out := make(map[string][]string, len(paths))
+	if sec == nil || sec.Data == nil {
+		return out, nil
+	}
+	for _, p := range paths {
+		raw, ok := sec.Data[p].([]interface{})
+		if !ok {
+			continue
+		}
+		caps := make([]string, 0, len(raw))
+		for _, c := range raw {
+			if s, ok := c.(string); ok {
+				caps = append(caps, s)
+			}
+		}
+		out[p] = caps
+	}
+	return out, nil
+}
+
+// WriteSecretCAS writes data as a new version of the KV v2 secret at
+// mount/inner with check-and-set: the write only succeeds while the current
+// version is cas (0: only when the secret does not exist yet; negative: no
+// check). It returns the version written.
+func WriteSecretCAS(ctx context.Context, logical LogicalWriter, mount, inner string, data map[string]interface{}, cas int) (int, error) {
+	body := map[string]interface{}{"data": data}
+	if cas >= 0 {
+		body["options"] = map[string]interface{}{"cas": cas}
+	}
+	sec, err := logical.WriteWithContext(ctx, ReadAPIPath(mount, inner, true), body)
+	if err != nil {
+		return 0, classify(err)
+	}
+	if sec == nil || sec.Data == nil {
+		return 0, nil
+	}
+	return toInt(sec.Data["version"]), nil
+}
+
+// ReadSecretVersion reads the KV v2 secret at mount/inner along with the
+// version the data belongs to, for a later WriteSecretCAS.
+func ReadSecretVersion(ctx context.Context, logical LogicalAPI, mount, inner string) (map[string]interface{}, int, error) {
+	readPath := ReadAPIPath(mount, inner, true)
+	sec, err := readTraced(ctx, logical, readPath)
+	if err != nil {
+		return nil, 0, classify(err)
+	}
+	if sec == nil {
+		return nil, 0, fmt.Errorf("%w at %s", ErrNoData, readPath)
+	}
+	version := 0
+	if md, ok := sec.Data["metadata"].(map[string]interface{}); ok {
+		version = toInt(md["version"])
+	}
+	data, _ := sec.Data["data"].(map[string]interface{})
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	return data, version, nil
+}
+
+// ReadSecretCurrent reads the KV v2 secret at mount/inner for a
+// check-and-set write. Unlike ReadSecretVersion, a missing secret is not an
+// error: it reads as empty at version 0, and a deleted current version as
+// empty at that version.
+func ReadSecretCurrent(ctx context.Context, logical LogicalAPI, mount, inner string) (map[string]interface{}, int, error) {
+	md, err := readTraced(ctx, logical, MetadataAPIPath(mount, inner))
+	if err != nil {
+		return nil, 0, classify(err)
+	}
+	if md == nil || md.Data == nil {
+		return map[string]interface{}{}, 0, nil
+	}
+	current := toInt(md.Data["current_version"])
+	sec, err := readTraced(ctx, logical, ReadAPIPath(mount, inner, true))
+	if err != nil {
+		return nil, 0, classify(err)
+	}
+	data := map[string]interface{}{}
+	if sec != nil {
+		if d, ok := sec.Data["data"].(map[string]interface{}); ok {
+			data = d
+		}
+		// The data may be newer than the metadata read just before
+		if m, ok := sec.Data["metadata"].(map[string]interface{}); ok && toInt(m["version"]) > 0 {
+			current = toInt(m["version"])
+		}
+	}
+	return data, current, nil
+}
+
+// SecretExists reports whether a secret is stored at mount/inner. On KV v2 a
+// secret whose current version is deleted or destroyed does not exist.
+func SecretExists(ctx context.Context, logical LogicalAPI, mount, inner string, kv2 bool) (bool, error) {
+	sec, err := readTraced(ctx, logical, ReadAPIPath(mount, inner, kv2))
+	if err != nil {
+		return false, classify(err)
+	}
+	if sec == nil || sec.Data == nil {
+		return false, nil
+	}
+	if kv2 {
+		return sec.Data["data"] != nil, nil
+	}
+	return true, nil
+}
+
+// DeleteVersions soft-deletes versions of the KV v2 secret at mount/inner;
+// they can be undeleted until destroyed.
+func DeleteVersions(ctx context.Context, logical LogicalWriter, mount, inner string, versions []int) error {
+	_, err := logical.WriteWithContext(ctx, path.Clean(joinNonEmpty(mount, "delete", inner)), map[string]interface{}{"versions": versions})
+	return classify(err)
+}
+
+// DestroyVersions permanently removes the data of versions of the KV v2
+// secret at mount/inner; the version numbers stay in the metadata.
+func DestroyVersions(ctx context.Context, logical LogicalWriter, mount, inner string, versions []int) error {
+	_, err := logical.WriteWithContext(ctx, path.Clean(joinNonEmpty(mount, "destroy", inner)), map[string]interface{}{"versions": versions})
+	return classify(err)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"fvf/ui"
+)
+
+// loadSession reads a -session file; a missing file starts a new session.
+func loadSession(file string) (*ui.Session, error) {
+	b, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s ui.Session
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("session %s: %w", file, err)
+	}
+	return &s, nil
+}
+
+// sessionSaver returns the UI's SaveSession hook for -session. A failed save
+// is logged; it must not turn a normal exit into an error.
+func sessionSaver(file string) func(ui.Session) {
+	return func(s ui.Session) {
+		b, err := json.MarshalIndent(s, "", "  ")
+		if err == nil {
+			err = writePrivateFile(file, b)
+		}
+		if err != nil {
+			slog.Error("saving session failed", "file", file, "err", err)
+		}
+	}
+}
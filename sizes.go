@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"fvf/search"
+)
+
+// sortItems orders items by path, or largest first by value size or key
+// count (ties by path). Sizes are only known after a value walk.
+func sortItems(items []search.FoundItem, by string) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		switch {
+		case by == "size" && a.Size != b.Size:
+			return a.Size > b.Size
+		case by == "keys" && a.Keys != b.Keys:
+			return a.Keys > b.Keys
+		}
+		return a.Path < b.Path
+	})
+}
+
+// humanSize formats n bytes with binary units, e.g. 2.0 MiB.
+func humanSize(n int) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	f, unit := float64(n)/1024, "KiB"
+	for _, u := range []string{"MiB", "GiB"} {
+		if f < 1024 {
+			break
+		}
+		f, unit = f/1024, u
+	}
+	return fmt.Sprintf("%.1f %s", f, unit)
+}
+
+// printSizeTable writes one row per secret with its key count and value
+// size: an aligned table with readable sizes, or CSV with sizes in bytes.
+func printSizeTable(w io.Writer, items []search.FoundItem, asCSV bool) error {
+	if asCSV {
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"path", "keys", "bytes"})
+		for _, it := range items {
+			cw.Write([]string{it.Path, strconv.Itoa(it.Keys), strconv.Itoa(it.Size)})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "KEYS\tSIZE\t PATH")
+	for _, it := range items {
+		fmt.Fprintf(tw, "%d\t%s\t %s\n", it.Keys, humanSize(it.Size), it.Path)
+	}
+	return tw.Flush()
+}
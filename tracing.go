@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// stopTracing flushes and shuts down the exporter started by setupTracing; it
+// is safe to call more than once.
+var stopTracing = func() {}
+
+// setupTracing exports the walk and Vault call spans over OTLP/HTTP to
+// endpoint, e.g. http://localhost:4318. An empty endpoint disables tracing.
+// Headers, TLS and timeouts follow the usual OTEL_EXPORTER_OTLP_* variables.
+func setupTracing(endpoint string) error {
+	if endpoint == "" {
+		return nil
+	}
+	exp, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return fmt.Errorf("-otlp-endpoint: %w", err)
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", "fvf"),
+			attribute.String("service.version", version),
+		)),
+	)
+	otel.SetTracerProvider(tp)
+	stopTracing = func() {
+		stopTracing = func() {}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		tp.Shutdown(ctx)
+	}
+	return nil
+}
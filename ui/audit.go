@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"fvf/search"
+)
+
+// AuditFetcher returns the recent audit events for the API paths backing a
+// secret, newest first.
+type AuditFetcher func(apiPaths []string) ([]search.AuditEvent, error)
+
+// auditWho names the client of an event: display name, then entity ID.
+func auditWho(e search.AuditEvent) string {
+	switch {
+	case e.DisplayName != "" && e.EntityID != "":
+		return fmt.Sprintf("%s (entity %s)", e.DisplayName, e.EntityID)
+	case e.DisplayName != "":
+		return e.DisplayName
+	case e.EntityID != "":
+		return "entity " + e.EntityID
+	}
+	return "unknown"
+}
+
+// auditLines renders one line per event: time, operation, client, address
+// and the error, if any.
+func auditLines(events []search.AuditEvent) []string {
+	lines := make([]string, len(events))
+	for i, e := range events {
+		line := fmt.Sprintf("%s  %-6s  %s", e.Time.Local().Format(time.DateTime), e.Operation, auditWho(e))
+		if e.RemoteAddr != "" {
+			line += "  from " + e.RemoteAddr
+		}
+		if e.Error != "" {
+			line += "  error: " + e.Error
+		}
+		lines[i] = line
+	}
+	return lines
+}
+
+// lastRead summarises the newest successful read, e.g. "last read by alice at 2024-05-01 10:00:00".
+func lastRead(events []search.AuditEvent) string {
+	for _, e := range events {
+		if e.Operation == "read" && e.Error == "" {
+			return "last read by " + auditWho(e) + " at " + e.Time.Local().Format(time.DateTime)
+		}
+	}
+	return "no successful read in the log"
+}
+
+// openAuditPanel lists the recent operations on the current secret from the
+// audit source (Ctrl-A).
+func (st *UIState) openAuditPanel() {
+	if st.audit == nil || st.Cursor < 0 || st.Cursor >= len(st.Filtered) {
+		return
+	}
+	secret := st.Filtered[st.Cursor].Path
+	events, err := st.audit(search.PolicyAPIPaths(secret))
+	if err != nil {
+		st.showToast("audit: "+err.Error(), true)
+		return
+	}
+	if len(events) == 0 {
+		st.showToast("no audit entries for "+secret, false)
+		return
+	}
+	st.openPanel(&Panel{
+		Title: fmt.Sprintf("Audit for %s: %s (Esc: close)", secret, lastRead(events)),
+		Lines: auditLines(events),
+	})
+}
@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"fvf/search"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestAuditPanel(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	var asked []string
+	st := &UIState{PreviewCache: map[string]string{}, PreviewErr: map[string]error{}}
+	st.Items = []search.FoundItem{{Path: "kv/app/db"}}
+	st.ApplyFilter()
+	st.audit = func(apiPaths []string) ([]search.AuditEvent, error) {
+		asked = apiPaths
+		return []search.AuditEvent{
+			{Time: at.Add(time.Hour), Operation: "update", DisplayName: "ci", Error: "permission denied"},
+			{Time: at, Operation: "read", DisplayName: "alice", EntityID: "e1", RemoteAddr: "10.0.0.1"},
+		}, nil
+	}
+
+	HandleKey(s, tcell.NewEventKey(tcell.KeyCtrlA, 0, tcell.ModNone), &st.Items, &st.Filtered, &st.Query, &st.Cursor, &st.Offset, st.PreviewCache, nil, st, st.ApplyFilter, nil)
+	if st.Panel == nil {
+		t.Fatal("Ctrl-A should open the audit panel")
+	}
+	if !reflect.DeepEqual(asked, []string{"kv/app/db", "kv/data/app/db", "kv/metadata/app/db"}) {
+		t.Fatalf("asked for %v", asked)
+	}
+	if !strings.Contains(st.Panel.Title, "last read by alice (entity e1) at 2024-05-01 10:00:00") {
+		t.Fatalf("title %q", st.Panel.Title)
+	}
+	want := []string{
+		"2024-05-01 11:00:00  update  ci  error: permission denied",
+		"2024-05-01 10:00:00  read    alice (entity e1)  from 10.0.0.1",
+	}
+	if !reflect.DeepEqual(st.Panel.Lines, want) {
+		t.Fatalf("lines %q", st.Panel.Lines)
+	}
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// backgroundResults carries work finished off the event loop back to it:
+// results are queued by the worker goroutine and applied to UIState only by
+// applyBackground, which the event loop runs on every interrupt.
+type backgroundResults struct {
+	mu      sync.Mutex
+	pending []func()
+}
+
+// background runs work in a goroutine (e.g. the Vault writes of a copy or
+// move) and, once it returns, applies the func it returned on the event loop
+// and redraws.
+func (st *UIState) background(s tcell.Screen, work func() func()) {
+	if st.results == nil {
+		st.results = &backgroundResults{}
+	}
+	r := st.results
+	go func() {
+		apply := work()
+		r.mu.Lock()
+		r.pending = append(r.pending, apply)
+		r.mu.Unlock()
+		s.PostEvent(tcell.NewEventInterrupt(nil))
+	}()
+}
+
+// applyBackground applies the results of finished background work.
+func (st *UIState) applyBackground() {
+	if st.results == nil {
+		return
+	}
+	st.results.mu.Lock()
+	pending := st.results.pending
+	st.results.pending = nil
+	st.results.mu.Unlock()
+	for _, apply := range pending {
+		if apply != nil {
+			apply()
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package ui
+
+import "strings"
+
+// BatchFetcher reads the given secrets and renders them as one document.
+type BatchFetcher func(paths []string) (string, error)
+
+// selectionOutput is what Enter prints while secrets are multi-selected: their
+// paths, one per line, when Enter prints paths; otherwise all of them fetched
+// at once as one document. ok is false when the fetch failed (shown as a
+// toast) and nothing should be printed.
+func (st *UIState) selectionOutput(paths []string, printPath bool) (out string, ok bool) {
+	if printPath {
+		return strings.Join(paths, "\n"), true
+	}
+	if st.fetchBatch == nil {
+		st.showToast("fetching several secrets at once is not available here", true)
+		return "", false
+	}
+	if err := st.guard.takeN(len(paths)); err != nil {
+		st.showToast(err.Error(), true)
+		return "", false
+	}
+	out, err := st.fetchBatch(paths)
+	if err != nil {
+		st.showToast(err.Error(), true)
+		return "", false
+	}
+	return strings.TrimSuffix(out, "\n"), true
+}
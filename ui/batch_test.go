@@ -0,0 +1,23 @@
+package ui
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSelectionOutput(t *testing.T) {
+	st := &UIState{}
+	paths := []string{"kv/a", "kv/b"}
+	if out, ok := st.selectionOutput(paths, true); !ok || out != "kv/a\nkv/b" {
+		t.Fatalf("paths: %q %v", out, ok)
+	}
+	var got []string
+	st.fetchBatch = func(p []string) (string, error) { got = p; return "{}\n", nil }
+	if out, ok := st.selectionOutput(paths, false); !ok || out != "{}" || len(got) != 2 {
+		t.Fatalf("values: %q %v %v", out, ok, got)
+	}
+	st.fetchBatch = func([]string) (string, error) { return "", errors.New("kv/b: denied") }
+	if _, ok := st.selectionOutput(paths, false); ok || st.Toast == nil {
+		t.Fatal("a failed fetch should print nothing and show a toast")
+	}
+}
@@ -0,0 +1,296 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Binding runs an external command when its key is pressed, like fzf's
+// --bind 'ctrl-o:execute(ssh {host})'.
+type Binding struct {
+	// Key is the key name as written, e.g. "ctrl-o", "alt-s" or "f5".
+	Key string
+	// Silent runs the command without giving it the terminal (execute-silent);
+	// otherwise the screen is suspended until it exits (execute).
+	Silent bool
+	// Command is run with sh -c after placeholder expansion.
+	Command string
+
+	key tcell.Key
+	r   rune // for alt-<rune>
+}
+
+// ParseBindings parses a comma-separated list of key:action(command) entries,
+// where action is execute or execute-silent. Commas inside the parentheses
+// belong to the command.
+func ParseBindings(spec string) ([]Binding, error) {
+	var out []Binding
+	for rest := strings.TrimSpace(spec); rest != ""; {
+		keyName, after, ok := strings.Cut(rest, ":")
+		if !ok {
+			return nil, fmt.Errorf("binding %q: want key:action(command)", rest)
+		}
+		b := Binding{Key: strings.ToLower(strings.TrimSpace(keyName))}
+		if err := b.parseKey(); err != nil {
+			return nil, err
+		}
+		open := strings.IndexByte(after, '(')
+		if open < 0 {
+			return nil, fmt.Errorf("binding %s: want action(command)", b.Key)
+		}
+		switch action := strings.TrimSpace(after[:open]); action {
+		case "execute":
+		case "execute-silent":
+			b.Silent = true
+		default:
+			return nil, fmt.Errorf("binding %s: unknown action %q (want execute or execute-silent)", b.Key, action)
+		}
+		end := matchingParen(after, open)
+		if end < 0 {
+			return nil, fmt.Errorf("binding %s: unbalanced parentheses", b.Key)
+		}
+		b.Command = strings.TrimSpace(after[open+1 : end])
+		if b.Command == "" {
+			return nil, fmt.Errorf("binding %s: empty command", b.Key)
+		}
+		out = append(out, b)
+		rest = strings.TrimSpace(after[end+1:])
+		if rest != "" {
+			if rest[0] != ',' {
+				return nil, fmt.Errorf("binding %s: unexpected %q after the command", b.Key, rest)
+			}
+			rest = strings.TrimSpace(rest[1:])
+		}
+	}
+	return out, nil
+}
+
+// matchingParen returns the index of the parenthesis closing the one at open.
+func matchingParen(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (b *Binding) parseKey() error {
+	switch {
+	case strings.HasPrefix(b.Key, "ctrl-") && len(b.Key) == len("ctrl-")+1:
+		c := b.Key[len(b.Key)-1]
+		// ctrl-h, ctrl-i and ctrl-m are Backspace, Tab and Enter to the terminal
+		if c < 'a' || c > 'z' || c == 'h' || c == 'i' || c == 'm' {
+			break
+		}
+		b.key = tcell.KeyCtrlA + tcell.Key(c-'a')
+		return nil
+	case strings.HasPrefix(b.Key, "alt-"):
+		r := []rune(b.Key[len("alt-"):])
+		if len(r) != 1 {
+			break
+		}
+		b.key, b.r = tcell.KeyRune, r[0]
+		return nil
+	case strings.HasPrefix(b.Key, "f"):
+		n, err := strconv.Atoi(b.Key[1:])
+		if err != nil || n < 1 || n > 12 {
+			break
+		}
+		b.key = tcell.KeyF1 + tcell.Key(n-1)
+		return nil
+	}
+	return fmt.Errorf("binding: unsupported key %q (want ctrl-a..ctrl-z except h/i/m, alt-<char> or f1..f12)", b.Key)
+}
+
+// matches reports whether ev is the binding's key.
+func (b Binding) matches(ev *tcell.EventKey) bool {
+	if b.key == tcell.KeyRune {
+		return ev.Key() == tcell.KeyRune && ev.Modifiers()&tcell.ModAlt != 0 && ev.Rune() == b.r
+	}
+	return ev.Key() == b.key
+}
+
+// bindingFor returns the user binding for ev; user bindings win over the
+// built-in keys.
+func (st *UIState) bindingFor(ev *tcell.EventKey) (Binding, bool) {
+	for _, b := range st.bindings {
+		if b.matches(ev) {
+			return b, true
+		}
+	}
+	return Binding{}, false
+}
+
+var placeholderRe = regexp.MustCompile(`\{([^{}\s]*)\}`)
+
+// expandPlaceholders replaces {} and {path} with the secret path, {mount}
+// with its mount, {name} with its last segment and any other {field} with
+// that field of the secret's value. Every substitution is shell-quoted;
+// ${VAR} is left to the shell. fields is called only when a value field is
+// referenced.
+func expandPlaceholders(cmd, secretPath string, fields func() (map[string]string, error)) (string, error) {
+	var (
+		out  strings.Builder
+		kv   map[string]string
+		last int
+	)
+	for _, m := range placeholderRe.FindAllStringSubmatchIndex(cmd, -1) {
+		if m[0] > 0 && cmd[m[0]-1] == '$' {
+			continue
+		}
+		out.WriteString(cmd[last:m[0]])
+		last = m[1]
+		switch name := cmd[m[2]:m[3]]; name {
+		case "", "path":
+			out.WriteString(shellQuote(secretPath))
+		case "mount":
+			mount, _, _ := strings.Cut(secretPath, "/")
+			out.WriteString(shellQuote(mount))
+		case "name":
+			out.WriteString(shellQuote(path.Base(secretPath)))
+		default:
+			if kv == nil {
+				var err error
+				if kv, err = fields(); err != nil {
+					return "", err
+				}
+			}
+			v, ok := kv[name]
+			if !ok {
+				return "", fmt.Errorf("%s has no field %q", secretPath, name)
+			}
+			out.WriteString(shellQuote(v))
+		}
+	}
+	out.WriteString(cmd[last:])
+	return out.String(), nil
+}
+
+// shellQuote wraps s in single quotes for sh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// previewFields splits a fetched preview (JSON or key: value lines) into fields.
+func previewFields(text string) map[string]string {
+	if isLikelyJSON(text) {
+		var m map[string]interface{}
+		if json.Unmarshal([]byte(text), &m) == nil {
+			return toKVFromMap(m)
+		}
+	}
+	return toKVFromLines(text)
+}
+
+// errNotRead marks a {field} placeholder whose secret has not been read yet.
+var errNotRead = errors.New("value not read yet")
+
+// runBinding expands b for the current secret and runs it. A {field}
+// placeholder of a secret not read yet reads it first in the background,
+// through the read guard, and runs the command once the value arrives.
+// Failures are shown as a toast.
+func (st *UIState) runBinding(s tcell.Screen, b Binding, fetcher ValueFetcher) {
+	if st.Cursor < 0 || st.Cursor >= len(st.Filtered) {
+		return
+	}
+	p := st.Filtered[st.Cursor].Path
+	cmdline, err := expandPlaceholders(b.Command, p, func() (map[string]string, error) {
+		if err := st.PreviewErr[p]; err != nil {
+			// The cached preview is an error placeholder, not the secret
+			return nil, err
+		}
+		if v, ok := st.PreviewCache[p]; ok {
+			return previewFields(v), nil
+		}
+		return nil, errNotRead
+	})
+	if errors.Is(err, errNotRead) {
+		read := st.guardedFetch(fetcher)
+		if read == nil {
+			st.showToast(b.Key+": values are not available", true)
+			return
+		}
+		st.background(s, func() func() {
+			v, err := read(context.Background(), p)
+			return func() {
+				if err != nil {
+					st.showToast(b.Key+": "+err.Error(), true)
+					return
+				}
+				st.cachePreview(p, v, nil)
+				cmdline, err := expandPlaceholders(b.Command, p, func() (map[string]string, error) { return previewFields(v), nil })
+				if err != nil {
+					st.showToast(b.Key+": "+err.Error(), true)
+					return
+				}
+				st.execBinding(s, b, p, cmdline)
+			}
+		})
+		return
+	}
+	if err != nil {
+		st.showToast(b.Key+": "+err.Error(), true)
+		return
+	}
+	st.execBinding(s, b, p, cmdline)
+}
+
+// execBinding runs the expanded command of b for path p. execute hands the
+// terminal to the command and restores the UI afterwards; execute-silent runs
+// it in a goroutine while the UI stays responsive.
+func (st *UIState) execBinding(s tcell.Screen, b Binding, p, cmdline string) {
+	cmd := exec.Command("sh", "-c", cmdline)
+	cmd.Env = append(os.Environ(), "FVF_PATH="+p)
+	if b.Silent {
+		st.background(s, func() func() {
+			var stderr bytes.Buffer
+			cmd.Stderr = &stderr
+			err := cmd.Run()
+			return func() {
+				if err == nil {
+					return
+				}
+				msg := strings.TrimSpace(stderr.String())
+				if msg == "" {
+					msg = err.Error()
+				}
+				st.showToast(b.Key+": "+msg, true)
+			}
+		})
+		return
+	}
+	if err := s.Suspend(); err != nil {
+		st.showToast(b.Key+": "+err.Error(), true)
+		return
+	}
+	out, release := commandOutput()
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, out, os.Stderr
+	err := cmd.Run()
+	release()
+	if rerr := s.Resume(); rerr != nil && err == nil {
+		err = rerr
+	}
+	s.Sync()
+	if err != nil {
+		st.showToast(b.Key+": "+err.Error(), true)
+	}
+}
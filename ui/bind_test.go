@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fvf/search"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestParseBindings(t *testing.T) {
+	bs, err := ParseBindings("ctrl-o:execute(psql -h {host} -c 'select 1, 2'), alt-s:execute-silent(echo (x) {path}),f5:execute(true)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bs) != 3 {
+		t.Fatalf("bindings %+v", bs)
+	}
+	if bs[0].Command != "psql -h {host} -c 'select 1, 2'" || bs[0].Silent || bs[0].key != tcell.KeyCtrlO {
+		t.Fatalf("ctrl-o %+v", bs[0])
+	}
+	if bs[1].Command != "echo (x) {path}" || !bs[1].Silent || bs[1].r != 's' {
+		t.Fatalf("alt-s %+v", bs[1])
+	}
+	if bs[2].key != tcell.KeyF5 {
+		t.Fatalf("f5 %+v", bs[2])
+	}
+
+	for _, bad := range []string{"ctrl-o", "ctrl-o:run(x)", "ctrl-o:execute(x", "ctrl-m:execute(x)", "f13:execute(x)", "ctrl-o:execute()", "ctrl-o:execute(x) y"} {
+		if _, err := ParseBindings(bad); err == nil {
+			t.Errorf("%q: want an error", bad)
+		}
+	}
+}
+
+func TestExpandPlaceholders(t *testing.T) {
+	calls := 0
+	fields := func() (map[string]string, error) {
+		calls++
+		return map[string]string{"host": "db.internal", "user": "o'neil"}, nil
+	}
+	got, err := expandPlaceholders("psql -h {host} -U {user} # {} {mount} {name} ${HOME}", "kv/app/db", fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `psql -h 'db.internal' -U 'o'\''neil' # 'kv/app/db' 'kv' 'db' ${HOME}`
+	if got != want || calls != 1 {
+		t.Fatalf("got %q (%d fetches)\nwant %q", got, calls, want)
+	}
+	if _, err := expandPlaceholders("echo {port}", "kv/app/db", fields); err == nil || !strings.Contains(err.Error(), `"port"`) {
+		t.Fatalf("missing field err = %v", err)
+	}
+	calls = 0
+	if _, err := expandPlaceholders("echo {path}", "kv/app/db", fields); err != nil || calls != 0 {
+		t.Fatalf("path only should not fetch values (err %v, %d fetches)", err, calls)
+	}
+}
+
+func TestHandleKey_BindingRunsCommand(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	out := filepath.Join(t.TempDir(), "out")
+	bs, err := ParseBindings("ctrl-o:execute-silent(printf '%s %s' {path} {user} > " + out + ")")
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := &UIState{PreviewCache: map[string]string{}, PreviewErr: map[string]error{}, bindings: bs}
+	st.Items = []search.FoundItem{{Path: "kv/app/db"}}
+	st.ApplyFilter()
+	fetcher := func(p string) (string, error) { return "user: bob\npass: s3cr3t", nil }
+
+	HandleKey(s, tcell.NewEventKey(tcell.KeyCtrlO, 0, tcell.ModNone), &st.Items, &st.Filtered, &st.Query, &st.Cursor, &st.Offset, st.PreviewCache, fetcher, st, st.ApplyFilter, nil)
+	if st.Panel != nil {
+		t.Fatal("a bound ctrl-o should not open the mount switcher")
+	}
+	// The value is read in the background first, then the command runs
+	waitBackground(t, st)
+	waitBackground(t, st)
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "kv/app/db bob" {
+		t.Fatalf("command wrote %q", b)
+	}
+
+	st.bindings[0].Command = "exit 3"
+	HandleKey(s, tcell.NewEventKey(tcell.KeyCtrlO, 0, tcell.ModNone), &st.Items, &st.Filtered, &st.Query, &st.Cursor, &st.Offset, st.PreviewCache, fetcher, st, st.ApplyFilter, nil)
+	waitBackground(t, st)
+	if st.Toast == nil || !st.Toast.Err {
+		t.Fatal("a failing command should show an error toast")
+	}
+
+	// A failed read cached as an error placeholder is not parsed as fields
+	st.Toast = nil
+	st.bindings[0].Command = "printf %s {user} > " + out
+	st.cachePreview("kv/app/db", "(error fetching values) permission denied", errors.New("permission denied"))
+	HandleKey(s, tcell.NewEventKey(tcell.KeyCtrlO, 0, tcell.ModNone), &st.Items, &st.Filtered, &st.Query, &st.Cursor, &st.Offset, st.PreviewCache, fetcher, st, st.ApplyFilter, nil)
+	if st.Toast == nil || !strings.Contains(st.Toast.Text, "permission denied") {
+		t.Fatalf("expected the read error, got %+v", st.Toast)
+	}
+}
+
+func TestRunBinding_ReadsThroughGuard(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	bs, err := ParseBindings("ctrl-o:execute-silent(true {user})")
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := &UIState{PreviewCache: map[string]string{}, PreviewErr: map[string]error{}, bindings: bs, guard: newReadGuard(0, 1)}
+	st.Items = []search.FoundItem{{Path: "kv/app/db"}}
+	st.ApplyFilter()
+	st.guard.take(false)
+	reads := 0
+	fetcher := func(p string) (string, error) { reads++; return "user: bob", nil }
+
+	st.runBinding(s, bs[0], fetcher)
+	waitBackground(t, st)
+	if reads != 0 || st.Toast == nil || !strings.Contains(st.Toast.Text, "read limit") {
+		t.Fatalf("expected the read budget to hold the read back, reads=%d toast=%+v", reads, st.Toast)
+	}
+}
@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"container/list"
+)
+
+// previewLRU bounds a preview cache by entry count and value bytes, evicting
+// the least recently shown secrets first. Zero limits mean unbounded.
+type previewLRU struct {
+	maxEntries int
+	maxBytes   int
+	bytes      int
+	order      *list.List // front = most recently used
+	elems      map[string]*list.Element
+}
+
+type lruEntry struct {
+	path string
+	val  string
+}
+
+func newPreviewLRU(maxEntries, maxBytes int) *previewLRU {
+	return &previewLRU{maxEntries: maxEntries, maxBytes: maxBytes, order: list.New(), elems: make(map[string]*list.Element)}
+}
+
+// touch marks path as most recently used.
+func (l *previewLRU) touch(path string) {
+	if e, ok := l.elems[path]; ok {
+		l.order.MoveToFront(e)
+	}
+}
+
+// put records the cached value of path and returns the entries to evict,
+// oldest first. The entry just added is never evicted.
+func (l *previewLRU) put(path, val string) []lruEntry {
+	l.remove(path)
+	l.elems[path] = l.order.PushFront(lruEntry{path: path, val: val})
+	l.bytes += len(val)
+	var out []lruEntry
+	for l.order.Len() > 1 && ((l.maxEntries > 0 && l.order.Len() > l.maxEntries) || (l.maxBytes > 0 && l.bytes > l.maxBytes)) {
+		ent := l.order.Back().Value.(lruEntry)
+		l.remove(ent.path)
+		out = append(out, ent)
+	}
+	return out
+}
+
+func (l *previewLRU) remove(path string) {
+	if e, ok := l.elems[path]; ok {
+		l.bytes -= len(e.Value.(lruEntry).val)
+		l.order.Remove(e)
+		delete(l.elems, path)
+	}
+}
+
+// cachePreview stores a fetched value (or fetch error) for path and drops
+// the entries the LRU evicts. Evicted values are only released, not wiped:
+// the same string may still be on screen or waiting to be printed.
+func (st *UIState) cachePreview(path, val string, err error) {
+	st.PreviewCache[path] = val
+	if err != nil {
+		st.PreviewErr[path] = err
+	} else {
+		delete(st.PreviewErr, path)
+	}
+	if st.previewLRU == nil {
+		st.previewLRU = newPreviewLRU(st.PreviewCacheEntries, st.PreviewCacheBytes)
+	}
+	for _, ent := range st.previewLRU.put(path, val) {
+		cur, ok := st.PreviewCache[ent.path]
+		if !ok || cur != ent.val {
+			// Replaced or dropped outside the LRU; not ours to evict
+			continue
+		}
+		delete(st.PreviewCache, ent.path)
+		delete(st.PreviewErr, ent.path)
+	}
+}
+
+// touchPreview marks path as just shown so it is evicted last.
+func (st *UIState) touchPreview(path string) {
+	if st.previewLRU != nil {
+		st.previewLRU.touch(path)
+	}
+}
@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCachePreview_EvictsLeastRecentlyShown(t *testing.T) {
+	st := &UIState{PreviewCache: map[string]string{}, PreviewErr: map[string]error{}, PreviewCacheEntries: 2}
+	st.cachePreview("kv/a", "a: 1", nil)
+	st.cachePreview("kv/b", "b: 2", errors.New("boom"))
+	st.touchPreview("kv/a")
+	evicted := st.PreviewCache["kv/b"]
+	st.cachePreview("kv/c", "c: 3", nil)
+
+	if _, ok := st.PreviewCache["kv/b"]; ok {
+		t.Fatal("least recently shown entry should be evicted")
+	}
+	if _, ok := st.PreviewErr["kv/b"]; ok {
+		t.Fatal("evicted entry should drop its error too")
+	}
+	if st.PreviewCache["kv/a"] != "a: 1" || st.PreviewCache["kv/c"] != "c: 3" {
+		t.Fatalf("cache=%v", st.PreviewCache)
+	}
+	// A copy still held elsewhere (e.g. a pending print) is left intact
+	if evicted != "b: 2" {
+		t.Fatalf("evicted value was modified: %q", evicted)
+	}
+}
+
+func TestCachePreview_ByteBudget(t *testing.T) {
+	st := &UIState{PreviewCache: map[string]string{}, PreviewErr: map[string]error{}, PreviewCacheBytes: 10}
+	st.cachePreview("kv/a", "123456", nil)
+	st.cachePreview("kv/b", "123456", nil)
+	if _, ok := st.PreviewCache["kv/a"]; ok {
+		t.Fatal("byte budget exceeded without eviction")
+	}
+	// A single value over budget stays cached: it is the one on screen
+	st.cachePreview("kv/big", strings.Repeat("x", 64), nil)
+	if len(st.PreviewCache) != 1 || st.PreviewCache["kv/big"] == "" {
+		t.Fatalf("cache=%v", st.PreviewCache)
+	}
+}
+
+func TestCachePreview_LeavesForeignValuesAlone(t *testing.T) {
+	st := &UIState{PreviewCache: map[string]string{}, PreviewErr: map[string]error{}, PreviewCacheEntries: 1}
+	st.cachePreview("kv/a", "a: 1", nil)
+	// Replaced outside the LRU (e.g. a literal): must not be dropped
+	st.PreviewCache["kv/a"] = "literal"
+	st.cachePreview("kv/b", "b: 2", nil)
+	if st.PreviewCache["kv/a"] != "literal" {
+		t.Fatalf("foreign value touched: %q", st.PreviewCache["kv/a"])
+	}
+}
@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// CapabilityFetcher returns the token's capabilities (sys/capabilities-self)
+// on the API paths backing the given logical secret paths, keyed by logical
+// path, in a single request.
+type CapabilityFetcher func(paths []string) (map[string][]string, error)
+
+// capsBatchSize bounds the paths sent in one capabilities request.
+const capsBatchSize = 32
+
+// capabilityOrder fixes the badge order regardless of how Vault lists them.
+var capabilityOrder = []string{"root", "sudo", "read", "list", "create", "update", "patch", "delete", "deny"}
+
+// capabilityBadges renders capabilities as "[read] [update]" in a stable order.
+// Unknown capabilities are appended in their original order.
+func capabilityBadges(caps []string) string {
+	if len(caps) == 0 {
+		return ""
+	}
+	have := make(map[string]bool, len(caps))
+	for _, c := range caps {
+		have[c] = true
+	}
+	var out []string
+	for _, c := range capabilityOrder {
+		if have[c] {
+			out = append(out, "["+c+"]")
+			delete(have, c)
+		}
+	}
+	for _, c := range caps {
+		if have[c] {
+			out = append(out, "["+c+"]")
+			delete(have, c)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// previewCapabilities returns the badge string for path. On a cache miss path
+// and the uncached items from the top of the visible list are looked up in
+// one background request, so scrolling rarely waits on Vault; the badges are
+// empty until it returns. A failed request is retried after lookupRetry, and
+// renewing the token clears the cache.
+func (st *UIState) previewCapabilities(s tcell.Screen, path string) string {
+	if st.capabilities == nil {
+		return ""
+	}
+	if caps, ok := st.CapsCache[path]; ok {
+		return capabilityBadges(caps)
+	}
+	now := time.Now()
+	if !st.capsLookups.due(path, now) {
+		return ""
+	}
+	batch := []string{path}
+	for i := st.Offset; i < len(st.Filtered) && len(batch) < capsBatchSize; i++ {
+		p := st.Filtered[i].Path
+		if _, ok := st.CapsCache[p]; !ok && p != path && st.capsLookups.due(p, now) {
+			batch = append(batch, p)
+		}
+	}
+	seq := st.capsLookups.start(batch)
+	fetch := st.capabilities
+	st.background(s, func() func() {
+		got, err := fetch(batch)
+		if err != nil {
+			slog.Warn("capabilities lookup failed", "paths", len(batch), "err", err)
+		}
+		return func() {
+			for _, p := range batch {
+				if !st.capsLookups.finish(p, seq, err, time.Now()) || err != nil {
+					continue
+				}
+				if st.CapsCache == nil {
+					st.CapsCache = make(map[string][]string)
+				}
+				st.CapsCache[p] = got[p]
+			}
+		}
+	})
+	return ""
+}
@@ -0,0 +1,112 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"fvf/search"
+)
+
+func TestCapabilityBadges_StableOrder(t *testing.T) {
+	if got := capabilityBadges(nil); got != "" {
+		t.Fatalf("expected empty badges, got %q", got)
+	}
+	got := capabilityBadges([]string{"delete", "custom", "update", "read", "read"})
+	if got != "[read] [update] [delete] [custom]" {
+		t.Fatalf("unexpected badges: %q", got)
+	}
+}
+
+func TestRenderAll_ShowsCapabilityBadgesCached(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	s.SetSize(100, 12)
+	calls := 0
+	st := &UIState{
+		Items:        []search.FoundItem{{Path: "kv/app"}},
+		PreviewCache: map[string]string{"kv/app": "user: bob"},
+		PreviewErr:   make(map[string]error),
+		capabilities: func(paths []string) (map[string][]string, error) {
+			calls++
+			out := make(map[string][]string)
+			for _, p := range paths {
+				out[p] = []string{"update", "read"}
+			}
+			return out, nil
+		},
+	}
+	st.ApplyFilter()
+	RenderAll(s, true, nil, nil, nil, st)
+	RenderAll(s, true, nil, nil, nil, st)
+	waitBackground(t, st)
+	RenderAll(s, true, nil, nil, nil, st)
+	if ln := readLine(s, 2, 100); !strings.Contains(ln, "kv/app  [read] [update]") {
+		t.Fatalf("expected badges after the path, got %q", ln)
+	}
+	if calls != 1 {
+		t.Fatalf("expected capabilities to be cached, got %d calls", calls)
+	}
+}
+
+func TestPreviewCapabilities_BatchesVisibleItemsAndResetsOnRenew(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	var mu sync.Mutex
+	var requests [][]string
+	fail := false
+	st := &UIState{
+		capabilities: func(paths []string) (map[string][]string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			requests = append(requests, paths)
+			if fail {
+				return nil, errors.New("permission denied")
+			}
+			return map[string][]string{"kv/b": {"read"}}, nil
+		},
+		renew: func() (time.Duration, error) { return time.Hour, nil },
+	}
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(requests)
+	}
+	st.Items = []search.FoundItem{{Path: "kv/a"}, {Path: "kv/b"}, {Path: "kv/c"}}
+	st.ApplyFilter()
+	st.Cursor = 1
+	if got := st.previewCapabilities(s, "kv/b"); got != "" {
+		t.Fatalf("expected no badges while loading, got %q", got)
+	}
+	st.previewCapabilities(s, "kv/a")
+	waitBackground(t, st)
+	if got := st.previewCapabilities(s, "kv/b"); got != "[read]" {
+		t.Fatalf("badges=%q", got)
+	}
+	st.previewCapabilities(s, "kv/a")
+	st.previewCapabilities(s, "kv/c")
+	if count() != 1 || strings.Join(requests[0], ",") != "kv/b,kv/a,kv/c" {
+		t.Fatalf("expected one batched request, got %v", requests)
+	}
+	st.renewToken()
+	mu.Lock()
+	fail = true
+	mu.Unlock()
+	st.previewCapabilities(s, "kv/b")
+	waitBackground(t, st)
+	if count() != 2 {
+		t.Fatalf("renewal should invalidate cached capabilities, got %d requests", count())
+	}
+	st.previewCapabilities(s, "kv/b")
+	if count() != 2 {
+		t.Fatalf("a failed lookup should not be retried at once, got %d requests", count())
+	}
+	st.capsLookups.failed["kv/b"] = time.Now().Add(-lookupRetry)
+	st.previewCapabilities(s, "kv/b")
+	waitBackground(t, st)
+	if count() != 3 {
+		t.Fatalf("expected the failed lookup to be retried, got %d requests", count())
+	}
+}
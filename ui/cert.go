@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// certExpiredMark tags preview lines that drawSection renders in red.
+const certExpiredMark = "⚠ EXPIRED"
+
+// CertSummary is the decoded view of a PEM certificate shown in the preview.
+type CertSummary struct {
+	Subject   string
+	Issuer    string
+	SANs      []string
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// ParseCertificates decodes every PEM "CERTIFICATE" block found in s.
+// Blocks that fail to parse are skipped; keys and other PEM types are ignored.
+func ParseCertificates(s string) []CertSummary {
+	if !strings.Contains(s, "-----BEGIN CERTIFICATE-----") {
+		return nil
+	}
+	var out []CertSummary
+	rest := []byte(s)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		sans := append([]string{}, c.DNSNames...)
+		for _, ip := range c.IPAddresses {
+			sans = append(sans, ip.String())
+		}
+		sans = append(sans, c.EmailAddresses...)
+		for _, u := range c.URIs {
+			sans = append(sans, u.String())
+		}
+		out = append(out, CertSummary{
+			Subject:   c.Subject.String(),
+			Issuer:    c.Issuer.String(),
+			SANs:      sans,
+			NotBefore: c.NotBefore,
+			NotAfter:  c.NotAfter,
+		})
+	}
+	return out
+}
+
+// certSummaryLines renders the summary rows for one certificate (without key prefix).
+func certSummaryLines(c CertSummary, now time.Time) []string {
+	lines := []string{
+		"subject:   " + c.Subject,
+		"issuer:    " + c.Issuer,
+	}
+	if len(c.SANs) > 0 {
+		lines = append(lines, "SANs:      "+strings.Join(c.SANs, ", "))
+	}
+	lines = append(lines, "notBefore: "+c.NotBefore.UTC().Format(time.RFC3339))
+	after := "notAfter:  " + c.NotAfter.UTC().Format(time.RFC3339)
+	if now.After(c.NotAfter) {
+		after += "  " + certExpiredMark
+	}
+	return append(lines, after)
+}
+
+// withCertSummaries injects decoded certificate details above the raw value of
+// table lines whose key holds PEM certificates. raw must hold unmasked values so
+// details stay visible while the PEM body itself is masked.
+func withCertSummaries(lines []string, raw map[string]string, now time.Time) []string {
+	out := make([]string, 0, len(lines))
+	for _, ln := range lines {
+		key := keyOfLine(ln, false)
+		certs := []CertSummary(nil)
+		if key != "" {
+			certs = ParseCertificates(raw[key])
+		}
+		idx := strings.Index(ln, ": ")
+		if len(certs) == 0 || idx < 0 {
+			out = append(out, ln)
+			continue
+		}
+		prefix := ln[:idx+2]
+		pad := strings.Repeat(" ", len(prefix))
+		for i, c := range certs {
+			head := "x509"
+			if len(certs) > 1 {
+				head = fmt.Sprintf("x509 [%d/%d]", i+1, len(certs))
+			}
+			if i == 0 {
+				out = append(out, prefix+head)
+			} else {
+				out = append(out, pad+head)
+			}
+			for _, sl := range certSummaryLines(c, now) {
+				out = append(out, pad+"  "+sl)
+			}
+		}
+		out = append(out, pad+ln[idx+2:])
+	}
+	return out
+}
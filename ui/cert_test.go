@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testCertPEM(t *testing.T, cn string, notAfter time.Time) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{cn, "www." + cn},
+		NotBefore:    notAfter.Add(-24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("cert: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestParseCertificates(t *testing.T) {
+	pemText := testCertPEM(t, "example.com", time.Now().Add(time.Hour))
+	certs := ParseCertificates(pemText)
+	if len(certs) != 1 {
+		t.Fatalf("expected 1 cert, got %d", len(certs))
+	}
+	if certs[0].Subject != "CN=example.com" || len(certs[0].SANs) != 2 {
+		t.Fatalf("unexpected summary: %#v", certs[0])
+	}
+	if got := ParseCertificates("not a cert"); got != nil {
+		t.Fatalf("expected nil for non-PEM, got %#v", got)
+	}
+}
+
+func TestWithCertSummaries_ExpiredAndMasked(t *testing.T) {
+	raw := map[string]string{"cert": testCertPEM(t, "old.example", time.Now().Add(-time.Hour)), "user": "x"}
+	lines := renderKVTable(maskKV(raw, true))
+	out := withCertSummaries(lines, raw, time.Now())
+	joined := strings.Join(out, "\n")
+	if !strings.HasPrefix(out[0], "cert: x509") {
+		t.Fatalf("expected summary header on the key line, got %q", out[0])
+	}
+	if !strings.Contains(joined, "subject:   CN=old.example") || !strings.Contains(joined, certExpiredMark) {
+		t.Fatalf("expected subject and expired warning, got:\n%s", joined)
+	}
+	if strings.Contains(joined, "BEGIN CERTIFICATE") {
+		t.Fatalf("masked PEM body must not be shown:\n%s", joined)
+	}
+	if keyOfLine(out[len(out)-1], false) != "user" {
+		t.Fatalf("other keys must be preserved, got %q", out[len(out)-1])
+	}
+}
@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// copyFlash is how long a [copy] button shows [OK] after copying.
+const copyFlash = 1200 * time.Millisecond
+
+// clipboardCopy writes text to the clipboard; replaced in tests.
+var clipboardCopy = copyToClipboard
+
+// scheduleFlashClear posts a redraw once a copy flash has expired.
+func scheduleFlashClear(s tcell.Screen) {
+	go func() {
+		time.Sleep(copyFlash + 100*time.Millisecond)
+		s.PostEvent(tcell.NewEventInterrupt(nil))
+	}()
+}
+
+// copyKey copies one value and flashes that key's [copy] button.
+func (st *UIState) copyKey(s tcell.Screen, key, val string) {
+	_ = clipboardCopy(val)
+	if st.PerKeyFlash == nil {
+		st.PerKeyFlash = make(map[string]time.Time)
+	}
+	st.PerKeyFlash[key] = time.Now().Add(copyFlash)
+	scheduleFlashClear(s)
+}
+
+// copySecret copies the whole previewed secret and flashes the header [copy] button.
+func (st *UIState) copySecret(s tcell.Screen) {
+	if st.CurrentFetchedVal == "" {
+		return
+	}
+	_ = clipboardCopy(st.CurrentFetchedVal)
+	st.CopyFlashUntil = time.Now().Add(copyFlash)
+	scheduleFlashClear(s)
+}
+
+// copyFocusedKey copies the value of the key under the preview key cursor
+// (Alt-y), using the same value its [copy] button would. Without a focused key
+// the whole secret is copied.
+func (st *UIState) copyFocusedKey(s tcell.Screen) {
+	if st.FocusKey == "" {
+		st.copySecret(s)
+		return
+	}
+	for _, b := range st.PerLineCopyBtns {
+		if b.Key == st.FocusKey {
+			st.copyKey(s, b.Key, b.Val)
+			return
+		}
+	}
+	// Key scrolled out of view: fall back to the raw value
+	if st.Cursor >= 0 && st.Cursor < len(st.Filtered) {
+		if v, ok := toKVFromLines(st.PreviewCache[st.Filtered[st.Cursor].Path])[st.FocusKey]; ok {
+			st.copyKey(s, st.FocusKey, v)
+		}
+	}
+}
+
+// confirmPrint asks before out, the selected secret's value, is printed to
+// the terminal (-confirm-print), so it does not end up in a shared session's
+// scrollback or tmux log by accident. "c" copies it instead and keeps the UI
+// open; anything but "y" prints nothing.
+func (st *UIState) confirmPrint(out string) {
+	st.openPrompt("print secret to terminal? (y: print, c: copy only, N: cancel)", "", nil, func(in string) {
+		switch strings.ToLower(in) {
+		case "y", "yes":
+			st.pendingPrint = &out
+		case "c", "copy":
+			if err := clipboardCopy(out); err != nil {
+				st.showToast("copy failed: "+err.Error(), true)
+				return
+			}
+			st.showToast("copied to the clipboard, not printed", false)
+		default:
+			st.showToast("not printed", false)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"fvf/search"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestKeyboardCopy_FlashesLikeMouse(t *testing.T) {
+	var copied []string
+	saved := clipboardCopy
+	clipboardCopy = func(s string) error { copied = append(copied, s); return nil }
+	defer func() { clipboardCopy = saved }()
+
+	s := newSimScreen(t)
+	defer s.Fini()
+	s.SetSize(100, 14)
+	st := &UIState{
+		PreviewCache: map[string]string{"kv/app": "pass: secret\nuser: bob"},
+		PreviewErr:   map[string]error{},
+		PerKeyFlash:  map[string]time.Time{},
+	}
+	st.Items = []search.FoundItem{{Path: "kv/app"}}
+	st.ApplyFilter()
+	RenderAll(s, true, nil, nil, nil, st)
+
+	key := func(k tcell.Key, r rune, m tcell.ModMask) {
+		HandleKey(s, tcell.NewEventKey(k, r, m), &st.Items, &st.Filtered, &st.Query, &st.Cursor, &st.Offset, st.PreviewCache, nil, st, st.ApplyFilter, nil)
+	}
+	key(tcell.KeyDown, 0, tcell.ModShift) // focus "pass"
+	key(tcell.KeyRune, 'y', tcell.ModAlt)
+	if len(copied) != 1 || copied[0] != "secret" || !time.Now().Before(st.PerKeyFlash["pass"]) {
+		t.Fatalf("expected focused key copied with flash, copied=%v flash=%v", copied, st.PerKeyFlash)
+	}
+	if st.Query != "" {
+		t.Fatalf("Alt-y must not type into the query, got %q", st.Query)
+	}
+
+	RenderAll(s, true, nil, nil, nil, st)
+	key(tcell.KeyRune, 'Y', tcell.ModAlt)
+	if len(copied) != 2 || copied[1] != st.CurrentFetchedVal || !time.Now().Before(st.CopyFlashUntil) {
+		t.Fatalf("expected whole secret copied with header flash, copied=%v", copied)
+	}
+}
+
+func TestConfirmPrint(t *testing.T) {
+	var copied []string
+	saved := clipboardCopy
+	clipboardCopy = func(s string) error { copied = append(copied, s); return nil }
+	defer func() { clipboardCopy = saved }()
+
+	// Not closed here: confirming the print finalizes the screen
+	s := newSimScreen(t)
+	st := &UIState{ConfirmPrint: true, PreviewCache: map[string]string{"kv/app": `{"pass":"secret"}`}, PreviewErr: map[string]error{}}
+	st.Items = []search.FoundItem{{Path: "kv/app"}}
+	st.ApplyFilter()
+	fetch := func(string) (string, error) { return `{"pass":"secret"}`, nil }
+	press := func(k tcell.Key, r rune) (bool, bool) {
+		return HandleKey(s, tcell.NewEventKey(k, r, tcell.ModNone), &st.Items, &st.Filtered, &st.Query, &st.Cursor, &st.Offset, st.PreviewCache, fetch, st, st.ApplyFilter, nil)
+	}
+
+	if _, quit := press(tcell.KeyEnter, 0); quit || st.Prompt == nil {
+		t.Fatal("Enter should ask before printing")
+	}
+	press(tcell.KeyRune, 'c')
+	if _, quit := press(tcell.KeyEnter, 0); quit {
+		t.Fatal("copy only must keep the UI open")
+	}
+	if len(copied) != 1 || st.pendingPrint != nil {
+		t.Fatalf("copy only: copied %q, pending %v", copied, st.pendingPrint)
+	}
+
+	press(tcell.KeyEnter, 0)
+	press(tcell.KeyRune, 'y')
+	if _, quit := press(tcell.KeyEnter, 0); !quit {
+		t.Fatal("y should print and quit")
+	}
+}
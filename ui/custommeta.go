@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// CustomMetadataWriter replaces the KV v2 custom_metadata of the secret at path.
+type CustomMetadataWriter func(path string, cm map[string]string) error
+
+// openMetaEditor lists the custom_metadata of the current secret (Ctrl-D).
+// Enter on a key edits it as key=value (an empty value removes the key);
+// Enter on the last line adds a key.
+func (st *UIState) openMetaEditor() {
+	if st.writeMeta == nil && st.ReadOnly {
+		st.showToast(readOnlyToast, true)
+		return
+	}
+	if st.metadata == nil || st.writeMeta == nil || st.Cursor < 0 || st.Cursor >= len(st.Filtered) {
+		return
+	}
+	secret := st.Filtered[st.Cursor].Path
+	md, err := st.metadata(secret)
+	if err != nil {
+		st.showToast("metadata: "+err.Error(), true)
+		return
+	}
+	if md == nil {
+		st.showToast(secret+" has no custom_metadata (KV v1?)", true)
+		return
+	}
+	keys := make([]string, 0, len(md.CustomMetadata))
+	for k := range md.CustomMetadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys)+1)
+	for _, k := range keys {
+		lines = append(lines, k+"="+md.CustomMetadata[k])
+	}
+	lines = append(lines, "+ add key")
+	st.openPanel(&Panel{
+		Title: fmt.Sprintf("custom_metadata of %s (Enter: edit, Esc: close)", secret),
+		Lines: lines,
+		Submit: func(p *Panel) {
+			initial, old := "", ""
+			if p.Cursor < len(keys) {
+				old = keys[p.Cursor]
+				initial = lines[p.Cursor]
+			}
+			st.openPrompt("key=value (empty value removes)", initial, nil, func(in string) {
+				st.editCustomMetadata(secret, md.CustomMetadata, old, in)
+			})
+		},
+	})
+}
+
+// editCustomMetadata applies one key=value edit to cm and writes the result.
+// old is the key being edited ("" when adding), so renaming a key drops it.
+func (st *UIState) editCustomMetadata(secret string, cm map[string]string, old, in string) {
+	if in == "" {
+		return
+	}
+	k, v, ok := strings.Cut(in, "=")
+	k = strings.TrimSpace(k)
+	if !ok || k == "" {
+		st.showToast("want key=value", true)
+		return
+	}
+	next := make(map[string]string, len(cm)+1)
+	for ck, cv := range cm {
+		if ck != old {
+			next[ck] = cv
+		}
+	}
+	if v = strings.TrimSpace(v); v != "" {
+		next[k] = v
+	} else {
+		delete(next, k)
+	}
+	if err := st.writeMeta(secret, next); err != nil {
+		slog.Warn("custom_metadata write failed", "path", secret, "err", err)
+		st.showToast("metadata: "+err.Error(), true)
+		return
+	}
+	// The preview header shows the metadata; fetch it again
+	delete(st.MetaCache, secret)
+	st.metaLookups.forget(secret)
+	st.showToast(fmt.Sprintf("%s: custom_metadata saved", secret), false)
+}
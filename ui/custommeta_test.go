@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"testing"
+
+	"fvf/search"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestMetaEditor(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	st := &UIState{PreviewCache: map[string]string{}, PreviewErr: map[string]error{}}
+	st.Items = []search.FoundItem{{Path: "kv/app/db"}}
+	st.ApplyFilter()
+	st.MetaCache = map[string]*search.SecretMetadata{"kv/app/db": {}}
+	st.metadata = func(string) (*search.SecretMetadata, error) {
+		return &search.SecretMetadata{CurrentVersion: 1, CustomMetadata: map[string]string{"owner": "payments", "tier": "1"}}, nil
+	}
+	var written map[string]string
+	st.writeMeta = func(p string, cm map[string]string) error {
+		written = cm
+		return nil
+	}
+	key := func(k tcell.Key, r rune) {
+		HandleKey(s, tcell.NewEventKey(k, r, tcell.ModNone), &st.Items, &st.Filtered, &st.Query, &st.Cursor, &st.Offset, st.PreviewCache, nil, st, st.ApplyFilter, nil)
+	}
+	typeText := func(text string) {
+		for _, r := range text {
+			key(tcell.KeyRune, r)
+		}
+	}
+
+	key(tcell.KeyCtrlD, 0)
+	if st.Panel == nil || len(st.Panel.Lines) != 3 || st.Panel.Lines[0] != "owner=payments" {
+		t.Fatalf("editor panel %+v", st.Panel)
+	}
+	// Edit owner: the prompt starts with the current key=value
+	key(tcell.KeyEnter, 0)
+	if st.Prompt == nil || st.Prompt.Input != "owner=payments" {
+		t.Fatalf("prompt %+v", st.Prompt)
+	}
+	st.Prompt.Input = "owner=billing"
+	key(tcell.KeyEnter, 0)
+	if written["owner"] != "billing" || written["tier"] != "1" {
+		t.Fatalf("written %v", written)
+	}
+	if _, ok := st.MetaCache["kv/app/db"]; ok {
+		t.Fatal("metadata cache not dropped after a write")
+	}
+
+	// Remove tier with an empty value
+	key(tcell.KeyCtrlD, 0)
+	key(tcell.KeyDown, 0)
+	key(tcell.KeyEnter, 0)
+	st.Prompt.Input = "tier="
+	key(tcell.KeyEnter, 0)
+	if _, ok := written["tier"]; ok || len(written) != 1 {
+		t.Fatalf("written %v", written)
+	}
+
+	// Add a key from the last line
+	key(tcell.KeyCtrlD, 0)
+	key(tcell.KeyEnd, 0)
+	key(tcell.KeyEnter, 0)
+	typeText("team=core")
+	key(tcell.KeyEnter, 0)
+	if written["team"] != "core" || len(written) != 3 {
+		t.Fatalf("written %v", written)
+	}
+}
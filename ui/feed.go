@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"sync"
+
+	"fvf/search"
+)
+
+// itemFeed moves streamed items from walker goroutines to the UI event loop.
+// Each call to reset starts a new generation; items still arriving from a
+// superseded walk are dropped so a restarted stream never mixes results.
+type itemFeed struct {
+	mu      sync.Mutex
+	gen     int
+	pending []search.FoundItem
+	wake    func()
+}
+
+func newItemFeed(wake func()) *itemFeed {
+	if wake == nil {
+		wake = func() {}
+	}
+	return &itemFeed{wake: wake}
+}
+
+// follow drains ch in the background on behalf of the current generation.
+// The channel is always drained to completion so the walker never blocks.
+func (f *itemFeed) follow(ch <-chan search.FoundItem) {
+	if ch == nil {
+		return
+	}
+	f.mu.Lock()
+	gen := f.gen
+	f.mu.Unlock()
+	go func() {
+		for it := range ch {
+			f.mu.Lock()
+			if f.gen == gen {
+				f.pending = append(f.pending, it)
+			}
+			f.mu.Unlock()
+			f.wake()
+		}
+		f.wake()
+	}()
+}
+
+// reset discards pending items and invalidates all running followers.
+func (f *itemFeed) reset() {
+	f.mu.Lock()
+	f.gen++
+	f.pending = nil
+	f.mu.Unlock()
+}
+
+// drain returns the items received since the last call.
+func (f *itemFeed) drain() []search.FoundItem {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := f.pending
+	f.pending = nil
+	return out
+}
@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"fvf/search"
+)
+
+func waitDrain(f *itemFeed, want int) []search.FoundItem {
+	var got []search.FoundItem
+	deadline := time.Now().Add(time.Second)
+	for len(got) < want && time.Now().Before(deadline) {
+		got = append(got, f.drain()...)
+		time.Sleep(time.Millisecond)
+	}
+	return got
+}
+
+func TestItemFeed_FollowAndDrain(t *testing.T) {
+	f := newItemFeed(nil)
+	ch := make(chan search.FoundItem, 2)
+	ch <- search.FoundItem{Path: "kv/a"}
+	ch <- search.FoundItem{Path: "kv/b"}
+	close(ch)
+	f.follow(ch)
+	got := waitDrain(f, 2)
+	if len(got) != 2 || got[0].Path != "kv/a" || got[1].Path != "kv/b" {
+		t.Fatalf("unexpected drained items: %#v", got)
+	}
+}
+
+func TestItemFeed_ResetDropsStaleGeneration(t *testing.T) {
+	f := newItemFeed(nil)
+	old := make(chan search.FoundItem)
+	f.follow(old)
+	f.reset()
+
+	fresh := make(chan search.FoundItem, 1)
+	fresh <- search.FoundItem{Path: "kv/new"}
+	close(fresh)
+	f.follow(fresh)
+
+	// The old walker is still sending; its item must not reach the UI.
+	old <- search.FoundItem{Path: "kv/old"}
+	close(old)
+
+	got := waitDrain(f, 1)
+	time.Sleep(10 * time.Millisecond)
+	got = append(got, f.drain()...)
+	if len(got) != 1 || got[0].Path != "kv/new" {
+		t.Fatalf("expected only the fresh item, got %#v", got)
+	}
+}
@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fvf/search"
+)
+
+const (
+	// filterDebounce delays re-filtering while typing into a large result set.
+	filterDebounce = 80 * time.Millisecond
+	// filterDebounceMin is the item count from which filtering is debounced.
+	filterDebounceMin = 20000
+)
+
+// jumpSuffix is a trailing ":N" in the query, which moves the cursor to row
+// N of the list instead of filtering; a bare ":" is ignored while N is typed.
+var jumpSuffix = regexp.MustCompile(`:(\d*)$`)
+
+// splitJump separates the ":N" jump suffix from the filter text of q; row
+// is 0 when q has none or no number yet.
+func splitJump(q string) (filter string, row int) {
+	m := jumpSuffix.FindStringSubmatchIndex(q)
+	if m == nil {
+		return q, 0
+	}
+	row, _ = strconv.Atoi(q[m[2]:m[3]])
+	return q[:m[0]], row
+}
+
+// normalizeQuery returns the form of q that paths are matched against.
+func normalizeQuery(q string) string {
+	filter, _ := splitJump(q)
+	return strings.ToLower(strings.TrimSpace(filter))
+}
+
+// filterText is the query without its jump suffix, as highlighted in the list.
+func (st *UIState) filterText() string {
+	filter, _ := splitJump(st.Query)
+	return strings.TrimSpace(filter)
+}
+
+// appliedQuery is the query Filtered currently reflects: while a debounced
+// filter is pending it is the previous query, not the one being typed.
+func (st *UIState) appliedQuery() string {
+	if !st.filterAt.IsZero() {
+		return st.filteredFor
+	}
+	return normalizeQuery(st.Query)
+}
+
+// canNarrow reports whether Filtered can be refined in place for lq: the query
+// was extended and no item was added to or removed from Items behind our back.
+func (st *UIState) canNarrow(lq string) bool {
+	return st.filteredValid && lq != st.filteredFor && strings.HasPrefix(lq, st.filteredFor) && st.filteredItems == len(st.Items)
+}
+
+// mergeSorted inserts the path-sorted items of add into the path-sorted list,
+// working backwards in place so a batch costs one pass instead of a full sort.
+func mergeSorted(list, add []search.FoundItem) []search.FoundItem {
+	n := len(list)
+	for range add {
+		list = append(list, search.FoundItem{})
+	}
+	i, j := n-1, len(add)-1
+	for k := len(list) - 1; j >= 0; k-- {
+		if i >= 0 && list[i].Path > add[j].Path {
+			list[k] = list[i]
+			i--
+		} else {
+			list[k] = add[j]
+			j--
+		}
+	}
+	return list
+}
+
+// scheduleFilter applies the query after filterDebounce without further
+// keystrokes; wake must get the event loop to call flushFilter.
+func (st *UIState) scheduleFilter(wake func()) {
+	st.filterAt = time.Now().Add(filterDebounce)
+	if st.filterTimer != nil {
+		st.filterTimer.Stop()
+	}
+	st.filterTimer = time.AfterFunc(filterDebounce, wake)
+}
+
+// flushFilter applies a debounced filter once its deadline has passed.
+func (st *UIState) flushFilter(now time.Time) {
+	if st.filterAt.IsZero() || now.Before(st.filterAt) {
+		return
+	}
+	st.ApplyFilter()
+}
+
+// sortFiltered orders Filtered by path for a stable list.
+func (st *UIState) sortFiltered() {
+	sort.Slice(st.Filtered, func(i, j int) bool { return st.Filtered[i].Path < st.Filtered[j].Path })
+}
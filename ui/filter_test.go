@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"fvf/search"
+)
+
+func itemPaths(items []search.FoundItem) []string {
+	out := make([]string, len(items))
+	for i, it := range items {
+		out[i] = it.Path
+	}
+	return out
+}
+
+func TestMergeSorted(t *testing.T) {
+	list := []search.FoundItem{{Path: "b"}, {Path: "d"}, {Path: "f"}}
+	got := mergeSorted(list, []search.FoundItem{{Path: "a"}, {Path: "e"}, {Path: "g"}})
+	if want := []string{"a", "b", "d", "e", "f", "g"}; !reflect.DeepEqual(itemPaths(got), want) {
+		t.Fatalf("got %v want %v", itemPaths(got), want)
+	}
+}
+
+func TestApplyFilter_NarrowsAndMatchesFullScan(t *testing.T) {
+	st := &UIState{}
+	st.AddItems([]search.FoundItem{{Path: "kv/web/db"}, {Path: "kv/app/db"}, {Path: "kv/app/api"}, {Path: "kv/app/dbx"}})
+	if want := []string{"kv/app/api", "kv/app/db", "kv/app/dbx", "kv/web/db"}; !reflect.DeepEqual(itemPaths(st.Filtered), want) {
+		t.Fatalf("streamed items not in sorted order: %v", itemPaths(st.Filtered))
+	}
+	for _, q := range []string{"a", "ap", "app/d", "app/db"} {
+		st.Query = q
+		st.ApplyFilter()
+		narrowed := itemPaths(st.Filtered)
+		full := &UIState{Items: st.Items, Query: q}
+		full.ApplyFilter()
+		if !reflect.DeepEqual(narrowed, itemPaths(full.Filtered)) {
+			t.Fatalf("query %q: narrowed %v, full scan %v", q, narrowed, itemPaths(full.Filtered))
+		}
+	}
+
+	// Items removed behind the filter's back force a full rescan
+	st.Items = st.Items[:1]
+	st.Query = "app/dbx"
+	st.ApplyFilter()
+	if len(st.Filtered) != 0 {
+		t.Fatalf("stale items survived: %v", itemPaths(st.Filtered))
+	}
+}
+
+func TestScheduleFilter_AppliesAfterDebounce(t *testing.T) {
+	st := &UIState{}
+	st.AddItems([]search.FoundItem{{Path: "kv/a"}, {Path: "kv/b"}})
+	st.ApplyFilter()
+
+	woke := make(chan struct{}, 1)
+	st.Query = "kv/b"
+	st.scheduleFilter(func() { woke <- struct{}{} })
+	st.flushFilter(time.Now())
+	if len(st.Filtered) != 2 {
+		t.Fatal("filter applied before the debounce deadline")
+	}
+	// Items streamed meanwhile follow the applied query, not the one being typed
+	st.AddItems([]search.FoundItem{{Path: "kv/c"}})
+	if len(st.Filtered) != 3 {
+		t.Fatalf("filtered=%v", itemPaths(st.Filtered))
+	}
+	select {
+	case <-woke:
+	case <-time.After(2 * time.Second):
+		t.Fatal("debounce timer never fired")
+	}
+	st.flushFilter(time.Now())
+	if want := []string{"kv/b"}; !reflect.DeepEqual(itemPaths(st.Filtered), want) {
+		t.Fatalf("got %v want %v", itemPaths(st.Filtered), want)
+	}
+}
+
+func TestAddItems_ManyBatchesStaySorted(t *testing.T) {
+	st := &UIState{Query: "svc"}
+	st.ApplyFilter()
+	for b := 0; b < 50; b++ {
+		var batch []search.FoundItem
+		for i := 0; i < 40; i++ {
+			// Interleave paths across batches so every merge inserts in the middle
+			batch = append(batch, search.FoundItem{Path: fmt.Sprintf("kv/%03d/svc%02d", (i*37+b)%1000, b)})
+		}
+		batch = append(batch, search.FoundItem{Path: fmt.Sprintf("kv/other/%d", b)})
+		st.AddItems(batch)
+	}
+	got := itemPaths(st.Filtered)
+	if len(got) != 50*40 || !sort.StringsAreSorted(got) {
+		t.Fatalf("filtered has %d items, sorted=%v", len(got), sort.StringsAreSorted(got))
+	}
+}
+
+func TestApplyFilter_JumpSuffix(t *testing.T) {
+	st := &UIState{}
+	st.AddItems([]search.FoundItem{{Path: "kv/app/db"}, {Path: "kv/app/api"}, {Path: "kv/web/db"}, {Path: "kv/x/db"}})
+	for _, tc := range []struct {
+		query   string
+		matches int
+		cursor  int
+	}{
+		{"db", 3, 0},
+		{"db:", 3, 0},
+		{"db:2", 3, 1},
+		{"db:99", 3, 2},
+		{":4", 4, 3},
+	} {
+		st.Query = tc.query
+		st.ApplyFilter()
+		if len(st.Filtered) != tc.matches || st.Cursor != tc.cursor {
+			t.Fatalf("%q: %d matches, cursor %d; want %d, %d", tc.query, len(st.Filtered), st.Cursor, tc.matches, tc.cursor)
+		}
+	}
+	st.Query = "db:2"
+	if got := st.filterText(); got != "db" {
+		t.Fatalf("filterText %q", got)
+	}
+}
+
+func TestDrawLeftList_LineNumbers(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	var items []search.FoundItem
+	for i := 0; i < 12; i++ {
+		items = append(items, search.FoundItem{Path: fmt.Sprintf("kv/s%02d", i)})
+	}
+	drawLeftList(s, 0, 30, 80, items, "", 0, 8, 4, nil, nil, true)
+	if got := readLine(s, 0, 30); !strings.HasPrefix(got, " 9 kv/s08") {
+		t.Fatalf("first row %q", got)
+	}
+	if got := readLine(s, 3, 30); !strings.HasPrefix(got, "12 kv/s11") {
+		t.Fatalf("last row %q", got)
+	}
+}
@@ -0,0 +1,177 @@
+package ui
+
+import (
+	"fmt"
+
+	"fvf/search"
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// listRow is one line of the grouped result list: a mount header or an item.
+type listRow struct {
+	item  int    // index into Filtered; for a header, its mount's first item
+	mount string // set on header rows
+	count int    // items below a header
+}
+
+// groupRows lays Filtered out under one header per mount. Filtered is sorted
+// by path, so each mount's items are contiguous; collapsed mounts show only
+// their header.
+func groupRows(filtered []search.FoundItem, collapsed map[string]bool) []listRow {
+	var rows []listRow
+	for i := 0; i < len(filtered); {
+		mnt, _ := search.SplitMount(filtered[i].Path)
+		j := i + 1
+		for j < len(filtered) {
+			if m, _ := search.SplitMount(filtered[j].Path); m != mnt {
+				break
+			}
+			j++
+		}
+		rows = append(rows, listRow{item: i, mount: mnt, count: j - i})
+		if !collapsed[mnt] {
+			for k := i; k < j; k++ {
+				rows = append(rows, listRow{item: k})
+			}
+		}
+		i = j
+	}
+	return rows
+}
+
+// selectable reports whether the cursor can rest on row: items, and the
+// headers of collapsed mounts (standing in for their hidden items).
+func (r listRow) selectable(collapsed map[string]bool) bool {
+	return r.mount == "" || collapsed[r.mount]
+}
+
+// cursorRow returns the row showing the item at cursor: its own row or, when
+// its mount is collapsed, the mount header.
+func cursorRow(rows []listRow, cursor int, collapsed map[string]bool) int {
+	for i, r := range rows {
+		if r.item > cursor {
+			break
+		}
+		if r.mount != "" && collapsed[r.mount] && cursor < r.item+r.count {
+			return i
+		}
+		if r.mount == "" && r.item == cursor {
+			return i
+		}
+	}
+	return 0
+}
+
+// moveCursor moves the cursor by delta list lines, skipping the headers of
+// expanded mounts in the grouped view.
+func (st *UIState) moveCursor(delta int) {
+	if !st.Grouped {
+		st.Cursor = max(0, min(st.Cursor+delta, len(st.Filtered)-1))
+		return
+	}
+	rows := groupRows(st.Filtered, st.Collapsed)
+	if len(rows) == 0 {
+		return
+	}
+	r := cursorRow(rows, st.Cursor, st.Collapsed)
+	step := 1
+	if delta < 0 {
+		step, delta = -1, -delta
+	}
+	for ; delta > 0; delta-- {
+		next := r + step
+		for next >= 0 && next < len(rows) && !rows[next].selectable(st.Collapsed) {
+			next += step
+		}
+		if next < 0 || next >= len(rows) {
+			break
+		}
+		r = next
+	}
+	st.Cursor = rows[r].item
+}
+
+// toggleGrouped switches between the flat and the grouped list (Alt-g).
+func (st *UIState) toggleGrouped() {
+	st.Grouped = !st.Grouped
+	// Offset counts rows, which differ between the two views
+	st.Offset = 0
+}
+
+// toggleCollapsed collapses or expands the mount of the selected item
+// (Alt-c); the cursor moves to the mount's first item.
+func (st *UIState) toggleCollapsed() {
+	if !st.Grouped || st.Cursor < 0 || st.Cursor >= len(st.Filtered) {
+		return
+	}
+	mnt, _ := search.SplitMount(st.Filtered[st.Cursor].Path)
+	if st.Collapsed == nil {
+		st.Collapsed = make(map[string]bool)
+	}
+	st.Collapsed[mnt] = !st.Collapsed[mnt]
+	for st.Cursor > 0 {
+		if m, _ := search.SplitMount(st.Filtered[st.Cursor-1].Path); m != mnt {
+			break
+		}
+		st.Cursor--
+	}
+}
+
+// onCollapsedHeader reports whether the cursor rests on a collapsed mount.
+func (st *UIState) onCollapsedHeader() bool {
+	if !st.Grouped || st.Cursor < 0 || st.Cursor >= len(st.Filtered) {
+		return false
+	}
+	mnt, _ := search.SplitMount(st.Filtered[st.Cursor].Path)
+	return st.Collapsed[mnt]
+}
+
+// headerLine is the text of a mount header row.
+func headerLine(r listRow, collapsed bool) string {
+	arrow := "▾"
+	if collapsed {
+		arrow = "▸"
+	}
+	return fmt.Sprintf("%s %s/ (%d)", arrow, r.mount, r.count)
+}
+
+// drawGroupedList renders rows from offset like drawLeftList, with bold mount
+// headers and the items indented below them.
+func drawGroupedList(s tcell.Screen, contentTop, leftW, w int, filtered []search.FoundItem, rows []listRow, collapsed map[string]bool, q string, cursorRow, offset, maxRows int, selected map[string]bool, roots []string) {
+	avail := leftW
+	if avail <= 0 {
+		avail = w
+	}
+	for i := 0; i < maxRows && i+offset < len(rows); i++ {
+		r := rows[i+offset]
+		if r.mount != "" {
+			line := headerLine(r, collapsed[r.mount])
+			if runewidth.StringWidth(line) > avail {
+				line = runewidth.Truncate(line, avail, "…")
+			}
+			style := tcell.StyleDefault.Bold(true)
+			if i+offset == cursorRow {
+				style = style.Reverse(true)
+			}
+			putLineWithHighlights(s, 0, contentTop+i, line, "", style, style)
+			continue
+		}
+		it := filtered[r.item]
+		p := RelativePath(it.Path, roots)
+		line := "  " + p
+		if selected[it.Path] {
+			line = "● " + p
+		}
+		if runewidth.StringWidth(line) > avail {
+			line = runewidth.Truncate(line, avail, "…")
+		}
+		if i+offset == cursorRow {
+			base := tcell.StyleDefault.Reverse(true)
+			putLineWithHighlights(s, 0, contentTop+i, line, q, base, base.Bold(true))
+		} else {
+			base := tcell.StyleDefault.Foreground(tcell.ColorDarkGray)
+			putLineWithHighlights(s, 0, contentTop+i, line, q, base, tcell.StyleDefault.Foreground(tcell.ColorWhite))
+		}
+	}
+}
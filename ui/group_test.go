@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"testing"
+
+	"fvf/search"
+)
+
+func groupedState() *UIState {
+	return &UIState{
+		Grouped: true,
+		Filtered: []search.FoundItem{
+			{Path: "kv/a"}, {Path: "kv/b"},
+			{Path: "other/x"},
+			{Path: "secret/y"}, {Path: "secret/z"},
+		},
+	}
+}
+
+func TestGroupRows(t *testing.T) {
+	st := groupedState()
+	rows := groupRows(st.Filtered, map[string]bool{"other": true})
+	// kv header + 2 items, collapsed other header, secret header + 2 items
+	if len(rows) != 7 {
+		t.Fatalf("got %d rows: %+v", len(rows), rows)
+	}
+	if rows[0].mount != "kv" || rows[0].count != 2 || rows[3].mount != "other" || rows[3].item != 2 {
+		t.Fatalf("headers %+v", rows)
+	}
+	if got := cursorRow(rows, 2, map[string]bool{"other": true}); got != 3 {
+		t.Fatalf("cursor on a collapsed item should show on its header, got row %d", got)
+	}
+}
+
+func TestMoveCursor_Grouped(t *testing.T) {
+	st := groupedState()
+	st.Cursor = 1
+	st.moveCursor(1)
+	if st.Cursor != 2 {
+		t.Fatalf("down should skip the other/ header, cursor %d", st.Cursor)
+	}
+	st.toggleCollapsed()
+	if !st.onCollapsedHeader() {
+		t.Fatal("other/ should be collapsed")
+	}
+	st.moveCursor(1)
+	if st.Cursor != 3 {
+		t.Fatalf("down from a collapsed header, cursor %d", st.Cursor)
+	}
+	st.moveCursor(-1)
+	if st.Cursor != 2 || !st.onCollapsedHeader() {
+		t.Fatalf("up should land on the collapsed header, cursor %d", st.Cursor)
+	}
+	st.Cursor = 4
+	st.toggleCollapsed()
+	if st.Cursor != 3 {
+		t.Fatalf("collapsing moves to the mount's first item, cursor %d", st.Cursor)
+	}
+	st.moveCursor(-10)
+	if st.Cursor != 0 {
+		t.Fatalf("page up, cursor %d", st.Cursor)
+	}
+}
@@ -0,0 +1,202 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// errValueTooLarge and errReadLimit mark preview reads held back by the
+// read guard; Alt-v reads the value under the cursor anyway.
+var (
+	errValueTooLarge = errors.New("value too large")
+	errReadLimit     = errors.New("read limit reached")
+)
+
+// guardError explains why the read guard held a value back.
+type guardError struct {
+	kind   error
+	detail string
+}
+
+func (e *guardError) Error() string {
+	return fmt.Sprintf("%v: %s; press Alt-v to force", e.kind, e.detail)
+}
+
+func (e *guardError) Unwrap() error { return e.kind }
+
+// isGuarded reports whether err comes from the read guard.
+func isGuarded(err error) bool {
+	return errors.Is(err, errValueTooLarge) || errors.Is(err, errReadLimit)
+}
+
+// readGuard protects the terminal and the audit log from preview reads:
+// values over maxBytes are not shown and at most perMinute values are read
+// in any minute. Zero disables a limit. The size is only known once the value
+// is read, so a value over maxBytes has still been read (and audited); it is
+// just not put on screen. Reads cancelled before they finish are refunded.
+type readGuard struct {
+	maxBytes  int
+	perMinute int
+	now       func() time.Time
+
+	mu    sync.Mutex
+	reads []time.Time // within the last minute, oldest first
+}
+
+func newReadGuard(maxBytes, perMinute int) *readGuard {
+	if maxBytes <= 0 && perMinute <= 0 {
+		return nil
+	}
+	return &readGuard{maxBytes: maxBytes, perMinute: perMinute, now: time.Now}
+}
+
+// prune drops reads older than a minute; callers hold mu.
+func (g *readGuard) prune(now time.Time) {
+	i := 0
+	for i < len(g.reads) && now.Sub(g.reads[i]) >= time.Minute {
+		i++
+	}
+	g.reads = g.reads[i:]
+}
+
+// available reports whether a read would be allowed now.
+func (g *readGuard) available() bool {
+	if g == nil || g.perMinute <= 0 {
+		return true
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.prune(g.now())
+	return len(g.reads) < g.perMinute
+}
+
+// limitsReads reports whether a per-minute budget is set.
+func (g *readGuard) limitsReads() bool {
+	return g != nil && g.perMinute > 0
+}
+
+// take records a read, failing when the per-minute budget is spent unless
+// force is set. It returns the time recorded, for refund.
+func (g *readGuard) take(force bool) (time.Time, error) {
+	if g == nil || g.perMinute <= 0 {
+		return time.Time{}, nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	g.prune(now)
+	if !force && len(g.reads) >= g.perMinute {
+		return time.Time{}, &guardError{kind: errReadLimit, detail: fmt.Sprintf("%d values per minute", g.perMinute)}
+	}
+	g.reads = append(g.reads, now)
+	return now, nil
+}
+
+// takeN records n reads at once for a batch read outside the preview,
+// failing without recording any when fewer than n are left this minute.
+func (g *readGuard) takeN(n int) error {
+	if g == nil || g.perMinute <= 0 {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	g.prune(now)
+	if left := g.perMinute - len(g.reads); n > left {
+		return fmt.Errorf("%w: %d value(s) to read, %d of %d per minute left", errReadLimit, n, left, g.perMinute)
+	}
+	for i := 0; i < n; i++ {
+		g.reads = append(g.reads, now)
+	}
+	return nil
+}
+
+// refund gives back the read taken at at, e.g. one cancelled because the
+// cursor moved away before Vault answered.
+func (g *readGuard) refund(at time.Time) {
+	if g == nil || g.perMinute <= 0 {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i, t := range g.reads {
+		if t.Equal(at) {
+			g.reads = append(g.reads[:i], g.reads[i+1:]...)
+			return
+		}
+	}
+}
+
+// check fails for values over the size limit.
+func (g *readGuard) check(v string) error {
+	if g == nil || g.maxBytes <= 0 || len(v) <= g.maxBytes {
+		return nil
+	}
+	return &guardError{kind: errValueTooLarge, detail: fmt.Sprintf("%d KiB, limit %d KiB", (len(v)+1023)/1024, g.maxBytes/1024)}
+}
+
+// wrap returns fetch with the guard applied.
+func (g *readGuard) wrap(fetch ContextFetcher) ContextFetcher {
+	if g == nil {
+		return fetch
+	}
+	return func(ctx context.Context, path string) (string, error) {
+		at, err := g.take(false)
+		if err != nil {
+			return "", err
+		}
+		v, err := fetch(ctx, path)
+		if ctx.Err() != nil {
+			// Abandoned by the prefetcher: the value is dropped unseen
+			g.refund(at)
+		}
+		if err != nil {
+			return v, err
+		}
+		if err := g.check(v); err != nil {
+			return "", err
+		}
+		return v, nil
+	}
+}
+
+// guardedFetch returns the context-aware fetcher, or fetcher when none is
+// set, with the read guard applied; nil when values cannot be read.
+func (st *UIState) guardedFetch(fetcher ValueFetcher) ContextFetcher {
+	fetch := st.fetchCtx
+	if fetch == nil {
+		if fetcher == nil {
+			return nil
+		}
+		fetch = func(_ context.Context, path string) (string, error) { return fetcher(path) }
+	}
+	return st.guard.wrap(fetch)
+}
+
+// forceRead reads the value under the cursor past the guard (Alt-v) and
+// caches it, replacing a held-back or failed read.
+func (st *UIState) forceRead(fetcher ValueFetcher) {
+	if fetcher == nil || st.Cursor < 0 || st.Cursor >= len(st.Filtered) {
+		return
+	}
+	path := st.Filtered[st.Cursor].Path
+	if _, ok := st.PreviewCache[path]; ok && st.PreviewErr[path] == nil {
+		return
+	}
+	st.guard.take(true)
+	var v string
+	var err error
+	if st.fetchCtx != nil {
+		v, err = st.fetchCtx(context.Background(), path)
+	} else {
+		v, err = fetcher(path)
+	}
+	if err != nil {
+		st.cachePreview(path, fmt.Sprintf("(error fetching values) %v", err), err)
+		return
+	}
+	st.cachePreview(path, v, nil)
+}
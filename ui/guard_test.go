@@ -0,0 +1,153 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"fvf/search"
+)
+
+func TestReadGuard_LimitsSizeAndRate(t *testing.T) {
+	if newReadGuard(0, 0) != nil {
+		t.Fatal("no limits should mean no guard")
+	}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	g := newReadGuard(1024, 2)
+	g.now = func() time.Time { return now }
+	fetch := g.wrap(func(_ context.Context, p string) (string, error) {
+		if p == "kv/big" {
+			return strings.Repeat("x", 2000), nil
+		}
+		return "ok", nil
+	})
+
+	if _, err := fetch(context.Background(), "kv/big"); !errors.Is(err, errValueTooLarge) || !strings.Contains(err.Error(), "2 KiB, limit 1 KiB; press Alt-v") {
+		t.Fatalf("expected a size hold, got %v", err)
+	}
+	if v, err := fetch(context.Background(), "kv/a"); err != nil || v != "ok" {
+		t.Fatalf("second read: %q, %v", v, err)
+	}
+	if _, err := fetch(context.Background(), "kv/b"); !errors.Is(err, errReadLimit) {
+		t.Fatalf("expected a rate hold after 2 reads, got %v", err)
+	}
+	if g.available() {
+		t.Fatal("budget should be spent")
+	}
+	now = now.Add(time.Minute)
+	if !g.available() {
+		t.Fatal("budget should refill after a minute")
+	}
+}
+
+func TestForceRead_ReplacesHeldBackValue(t *testing.T) {
+	st := &UIState{PreviewCache: map[string]string{}, PreviewErr: map[string]error{}}
+	st.Items = []search.FoundItem{{Path: "kv/big"}}
+	st.ApplyFilter()
+	st.guard = newReadGuard(4, 0)
+	big := "0123456789"
+	fetcher := func(string) (string, error) { return big, nil }
+
+	wake := make(chan struct{}, 1)
+	st.fetchAroundCursor(fetcher, func() { wake <- struct{}{} })
+	select {
+	case <-wake:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for prefetch")
+	}
+	st.fetchAroundCursor(fetcher, nil)
+	if !isGuarded(st.PreviewErr["kv/big"]) || !strings.HasPrefix(st.PreviewCache["kv/big"], "(value too large") {
+		t.Fatalf("expected a held-back preview, got %q (%v)", st.PreviewCache["kv/big"], st.PreviewErr["kv/big"])
+	}
+
+	st.forceRead(fetcher)
+	if st.PreviewCache["kv/big"] != big || st.PreviewErr["kv/big"] != nil {
+		t.Fatalf("Alt-v should show the value, got %q (%v)", st.PreviewCache["kv/big"], st.PreviewErr["kv/big"])
+	}
+}
+
+func TestReadGuard_RefundsCancelledReads(t *testing.T) {
+	g := newReadGuard(0, 1)
+	fetch := g.wrap(func(ctx context.Context, _ string) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := fetch(ctx, "kv/a"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancellation, got %v", err)
+	}
+	if !g.available() {
+		t.Fatal("a cancelled read should not use up the budget")
+	}
+}
+
+func TestFetchAroundCursor_NoNeighboursUnderReadBudget(t *testing.T) {
+	st := &UIState{PreviewCache: map[string]string{}, PreviewErr: map[string]error{}}
+	st.Items = []search.FoundItem{{Path: "kv/a"}, {Path: "kv/b"}, {Path: "kv/c"}}
+	st.ApplyFilter()
+	st.guard = newReadGuard(0, 10)
+	read := make(chan string, 8)
+	fetcher := func(p string) (string, error) { read <- p; return "v", nil }
+
+	st.fetchAroundCursor(fetcher, nil)
+	select {
+	case p := <-read:
+		if p != "kv/a" {
+			t.Fatalf("read %s first", p)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the item under the cursor was not read")
+	}
+	select {
+	case p := <-read:
+		t.Fatalf("neighbour %s read under a per-minute budget", p)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestBatchReads_UseTheReadBudget(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	st := &UIState{guard: newReadGuard(0, 3)}
+	st.guard.now = func() time.Time { return now }
+	calls := 0
+	st.fetchBatch = func([]string) (string, error) { calls++; return "{}\n", nil }
+
+	if _, ok := st.selectionOutput([]string{"kv/a", "kv/b"}, false); !ok || calls != 1 {
+		t.Fatalf("a selection within the budget should be read, ok=%v calls=%d", ok, calls)
+	}
+	if _, ok := st.selectionOutput([]string{"kv/a", "kv/b"}, false); ok || calls != 1 {
+		t.Fatal("a selection over the remaining budget should not be read")
+	}
+	if st.Toast == nil || !strings.Contains(st.Toast.Text, "2 value(s) to read, 1 of 3 per minute left") {
+		t.Fatalf("toast %+v", st.Toast)
+	}
+
+	// The report reads every value unless it was told it reads none
+	var reported bool
+	st.report = func(string, string, []string) error { reported = true; return nil }
+	st.AddItems([]search.FoundItem{{Path: "kv/a"}, {Path: "kv/b"}})
+	st.ApplyFilter()
+	st.reportReads = true
+	st.openReport()
+	st.Prompt.Submit("out.md")
+	if reported || !strings.Contains(st.Toast.Text, "report: read limit reached") {
+		t.Fatalf("report over the budget: reported=%v toast=%+v", reported, st.Toast)
+	}
+	st.reportReads = false
+	st.openReport()
+	st.Prompt.Submit("out.md")
+	if !reported {
+		t.Fatal("a report without values needs no reads")
+	}
+	now = now.Add(time.Minute)
+	reported = false
+	st.reportReads = true
+	st.openReport()
+	st.Prompt.Submit("out.md")
+	if !reported || st.guard.takeN(2) == nil {
+		t.Fatalf("report within a fresh budget: reported=%v", reported)
+	}
+}
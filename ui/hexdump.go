@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// hexdumpLimit caps the bytes dumped per value so huge keystores stay browsable.
+const hexdumpLimit = 4096
+
+// decodeBinaryBase64 decodes v when it is base64 (standard or URL alphabet,
+// padded or not, whitespace ignored) of a binary payload. Short values and
+// base64 of plain text are left alone.
+func decodeBinaryBase64(v string) ([]byte, bool) {
+	c := compactBase64(v)
+	if len(c) < 16 {
+		return nil, false
+	}
+	var b []byte
+	var err error
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if b, err = enc.DecodeString(c); err == nil {
+			break
+		}
+	}
+	if err != nil || len(b) == 0 {
+		return nil, false
+	}
+	if utf8.Valid(b) && !strings.ContainsFunc(string(b), func(r rune) bool {
+		return r < 0x20 && r != '\n' && r != '\r' && r != '\t'
+	}) {
+		return nil, false
+	}
+	return b, true
+}
+
+// hexdumpValue renders b as "offset  hex bytes  |ascii|" lines under a size header.
+func hexdumpValue(b []byte) string {
+	head := fmt.Sprintf("base64 → %d bytes", len(b))
+	more := ""
+	if len(b) > hexdumpLimit {
+		more = fmt.Sprintf("\n… %d more bytes", len(b)-hexdumpLimit)
+		b = b[:hexdumpLimit]
+	}
+	return head + "\n" + strings.TrimRight(hex.Dump(b), "\n") + more
+}
+
+// withHexdumps replaces base64 binary values in kv with their hexdump.
+// Masked values ("***") never decode, so hidden secrets stay hidden.
+func withHexdumps(kv map[string]string) map[string]string {
+	var out map[string]string
+	for k, v := range kv {
+		b, ok := decodeBinaryBase64(v)
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]string, len(kv))
+			for dk, dv := range kv {
+				out[dk] = dv
+			}
+		}
+		out[k] = hexdumpValue(b)
+	}
+	if out == nil {
+		return kv
+	}
+	return out
+}
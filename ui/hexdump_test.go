@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeBinaryBase64(t *testing.T) {
+	bin := []byte{0xfe, 0xed, 0xfe, 0xed, 0x00, 0x00, 0x00, 0x02, 0x00, 0x01, 0x02, 0x03, 0x04, 0x05}
+	if b, ok := decodeBinaryBase64(base64.StdEncoding.EncodeToString(bin)); !ok || len(b) != len(bin) {
+		t.Fatalf("expected padded std base64 binary to decode")
+	}
+	if _, ok := decodeBinaryBase64(base64.RawURLEncoding.EncodeToString(bin)); !ok {
+		t.Fatalf("expected raw URL base64 binary to decode")
+	}
+	if _, ok := decodeBinaryBase64(base64.StdEncoding.EncodeToString([]byte("just some readable text"))); ok {
+		t.Fatalf("base64 of text is not a binary payload")
+	}
+	for _, v := range []string{"***", "short", "not base64 at all!!"} {
+		if _, ok := decodeBinaryBase64(v); ok {
+			t.Fatalf("%q should not decode", v)
+		}
+	}
+}
+
+func TestPreviewTableLines_HexViewOnlyForRevealedBinary(t *testing.T) {
+	bin := make([]byte, 20)
+	for i := range bin {
+		bin[i] = byte(i)
+	}
+	raw := map[string]string{"keystore": base64.StdEncoding.EncodeToString(bin), "user": "bob"}
+
+	lines := previewTableLines(raw, true, previewView{Hex: true}, time.Unix(0, 0))
+	joined := strings.Join(lines, "\n")
+	if !strings.Contains(joined, "keystore: base64 → 20 bytes") || !strings.Contains(joined, "00000000  00 01 02 03") || !strings.Contains(joined, "00000010  10 11 12 13") {
+		t.Fatalf("expected hexdump for keystore:\n%s", joined)
+	}
+	for _, ln := range lines[1:3] {
+		if keyOfLine(ln, false) != "" {
+			t.Fatalf("hexdump rows must be continuation lines, got %q", ln)
+		}
+	}
+
+	masked := strings.Join(previewTableLines(raw, false, previewView{Hex: true}, time.Unix(0, 0)), "\n")
+	if strings.Contains(masked, "00000000") {
+		t.Fatalf("masked values must not be dumped:\n%s", masked)
+	}
+	off := strings.Join(previewTableLines(raw, true, previewView{}, time.Unix(0, 0)), "\n")
+	if strings.Contains(off, "00000000") {
+		t.Fatalf("hexdump only in hex view:\n%s", off)
+	}
+}
@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"strings"
+)
+
+// pathIndex is a trigram index over the lowercased item paths. A query of
+// three or more bytes only verifies the items listed under its rarest
+// trigram instead of scanning every path.
+type pathIndex struct {
+	lower    []string
+	postings map[uint32][]int32
+}
+
+func newPathIndex() *pathIndex {
+	return &pathIndex{postings: make(map[uint32][]int32)}
+}
+
+func trigramKey(s string, i int) uint32 {
+	return uint32(s[i])<<16 | uint32(s[i+1])<<8 | uint32(s[i+2])
+}
+
+// add indexes the next item; its position must equal the number of items added so far.
+func (x *pathIndex) add(path string) {
+	lp := strings.ToLower(path)
+	id := int32(len(x.lower))
+	x.lower = append(x.lower, lp)
+	for i := 0; i+3 <= len(lp); i++ {
+		k := trigramKey(lp, i)
+		ps := x.postings[k]
+		// Repeated trigrams within one path are posted once
+		if n := len(ps); n > 0 && ps[n-1] == id {
+			continue
+		}
+		x.postings[k] = append(ps, id)
+	}
+}
+
+// lookup returns the indexes of the items whose path contains lq (already
+// lowercased), in ascending order. ok is false for queries shorter than a trigram.
+func (x *pathIndex) lookup(lq string) (ids []int32, ok bool) {
+	if len(lq) < 3 {
+		return nil, false
+	}
+	var best []int32
+	for i := 0; i+3 <= len(lq); i++ {
+		ps, found := x.postings[trigramKey(lq, i)]
+		if !found {
+			return nil, true
+		}
+		if best == nil || len(ps) < len(best) {
+			best = ps
+		}
+	}
+	for _, id := range best {
+		if strings.Contains(x.lower[id], lq) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, true
+}
+
+// pathIndexFor returns the index over Items, rebuilding it when Items changed
+// other than by AddItems.
+func (st *UIState) pathIndexFor() *pathIndex {
+	if st.index == nil || len(st.index.lower) != len(st.Items) {
+		st.index = newPathIndex()
+		for _, it := range st.Items {
+			st.index.add(it.Path)
+		}
+	}
+	return st.index
+}
+
+// indexedMatches fills Filtered from the trigram index; false when lq is too short.
+func (st *UIState) indexedMatches(lq string) bool {
+	ids, ok := st.pathIndexFor().lookup(lq)
+	if !ok {
+		return false
+	}
+	st.Filtered = st.Filtered[:0]
+	for _, id := range ids {
+		st.Filtered = append(st.Filtered, st.Items[id])
+	}
+	st.sortFiltered()
+	return true
+}
+
+// invalidateIndex drops the path index after Items was rewritten in place.
+func (st *UIState) invalidateIndex() {
+	st.index = nil
+}
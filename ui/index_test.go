@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"fvf/search"
+)
+
+func TestPathIndex_Lookup(t *testing.T) {
+	x := newPathIndex()
+	for _, p := range []string{"kv/App/DB", "kv/web/db", "kv/app/api", "kv/aaaa"} {
+		x.add(p)
+	}
+	cases := []struct {
+		q    string
+		want []int32
+		ok   bool
+	}{
+		{"app", []int32{0, 2}, true},
+		{"/db", []int32{0, 1}, true},
+		{"aaa", []int32{3}, true},
+		{"zzz", nil, true},
+		{"ap", nil, false},
+	}
+	for _, c := range cases {
+		got, ok := x.lookup(c.q)
+		if ok != c.ok || !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("lookup(%q)=%v,%v want %v,%v", c.q, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestApplyFilter_IndexMatchesScanWhileStreaming(t *testing.T) {
+	st := &UIState{}
+	var batch []search.FoundItem
+	for i := 0; i < 500; i++ {
+		batch = append(batch, search.FoundItem{Path: fmt.Sprintf("kv/team%d/svc%d", i%7, i)})
+	}
+	st.AddItems(batch[:200])
+	st.Query = "team3/"
+	st.ApplyFilter() // builds the index
+	st.AddItems(batch[200:])
+	st.Query = "svc4"
+	st.ApplyFilter()
+	if st.index == nil || len(st.index.lower) != len(st.Items) {
+		t.Fatal("index should follow streamed items")
+	}
+	full := &UIState{Items: st.Items, Query: "svc4"}
+	full.ApplyFilter()
+	if !reflect.DeepEqual(itemPaths(st.Filtered), itemPaths(full.Filtered)) {
+		t.Fatalf("index %d matches, scan %d", len(st.Filtered), len(full.Filtered))
+	}
+}
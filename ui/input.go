@@ -3,7 +3,8 @@ package ui
 import (
 	"encoding/json"
 	"fmt"
-	"time"
+	"log/slog"
+	"strings"
 
 	"fvf/search"
 
@@ -25,25 +26,147 @@ func HandleKey(
 	applyFilter func(),
 	activity chan<- struct{},
 ) (shouldRedraw bool, shouldQuit bool) {
+	if uiState.Locked {
+		// Any key unlocks; the key itself is swallowed
+		uiState.Locked = false
+		uiState.touch()
+		notifyActivity(activity)
+		return true, false
+	}
+	uiState.touch()
+	if uiState.Prompt != nil {
+		handlePromptKey(ev, uiState)
+		notifyActivity(activity)
+		if out := uiState.pendingPrint; out != nil {
+			// Confirmed -confirm-print prompt: print after leaving the UI
+			s.Fini()
+			fmt.Println(*out)
+			return false, true
+		}
+		return true, false
+	}
+	if uiState.Panel != nil {
+		handlePanelKey(ev, uiState)
+		notifyActivity(activity)
+		return true, false
+	}
 	if ev.Key() == tcell.KeyEscape || ev.Key() == tcell.KeyCtrlC {
 		return false, true
 	}
+	if b, ok := uiState.bindingFor(ev); ok {
+		// User-defined action (-bind)
+		uiState.runBinding(s, b, fetcher)
+		notifyActivity(activity)
+		return true, false
+	}
 	shouldRedraw = true
 	switch ev.Key() {
+	case tcell.KeyCtrlO:
+		// Mount switcher: choose which mounts the walk includes
+		uiState.openMountPanel(uiState.mounts, nil)
+	case tcell.KeyCtrlN:
+		// Namespace switcher (Vault Enterprise)
+		uiState.openNamespacePanel(uiState.namespaces, uiState.setNS)
+	case tcell.KeyCtrlSpace:
+		// Multi-select: toggle the item under the cursor
+		uiState.toggleSelected()
+	case tcell.KeyCtrlK:
+		// Copy or move the selection (or the current item) to another prefix
+		uiState.openTransfer(s, uiState.copier)
+	case tcell.KeyCtrlX:
+		// Hexdump view for base64-encoded binary values
+		uiState.HexView = !uiState.HexView
+	case tcell.KeyCtrlE:
+		// List the subtrees the walk skipped because of errors
+		uiState.openWalkErrors()
+	case tcell.KeyCtrlP:
+		// Drill into the policies listed in the preview
+		uiState.openPolicyPanel(uiState.policyReader)
+	case tcell.KeyCtrlA:
+		// Recent operations on the secret from the audit log
+		uiState.openAuditPanel()
+	case tcell.KeyCtrlW:
+		// Wrap the secret into a single-use token to hand over
+		uiState.shareSecret()
+	case tcell.KeyCtrlV:
+		// KV v2 version history of the secret, with rollback
+		uiState.openVersions()
+	case tcell.KeyCtrlD:
+		// Edit the secret's custom_metadata
+		uiState.openMetaEditor()
+	case tcell.KeyCtrlL:
+		// Cycle the policies section: half, quarter, hidden
+		uiState.cyclePolicyPane()
+	case tcell.KeyCtrlT:
+		// Renew the session token; the status bar TTL updates right away
+		uiState.renewToken()
+	case tcell.KeyCtrlG:
+		// Change the search root: prompt for a path, then restart the walk there
+		if uiState.restartWalk != nil {
+			restart := uiState.restartWalk
+			suggestions := append(uiState.rootSuggestions(), uiState.aliases...)
+			uiState.openPrompt("root", strings.Join(uiState.Roots, ","), suggestions, func(in string) {
+				var roots []string
+				for _, p := range strings.Split(in, ",") {
+					if p = strings.TrimSpace(p); p == "" {
+						continue
+					}
+					if uiState.expandRoot != nil {
+						expanded, err := uiState.expandRoot(p)
+						if err != nil {
+							uiState.showToast(err.Error(), true)
+							return
+						}
+						p = expanded
+					}
+					roots = append(roots, p)
+				}
+				restart(roots)
+			})
+		}
 	case tcell.KeyEnter:
 		if len(*filtered) == 0 {
 			return false, true
 		}
+		if uiState.onCollapsedHeader() {
+			// Enter on a collapsed mount expands it instead of printing
+			uiState.toggleCollapsed()
+			break
+		}
+		printPath := uiState.PrintPath != (ev.Modifiers()&tcell.ModAlt != 0)
+		if len(uiState.Selected) > 0 {
+			// Multi-selection: all selected secrets (or paths) in one document
+			out, ok := uiState.selectionOutput(uiState.selectedPaths(), printPath)
+			if !ok {
+				break
+			}
+			if uiState.ConfirmPrint && !printPath {
+				uiState.confirmPrint(out)
+				break
+			}
+			s.Fini()
+			fmt.Println(out)
+			return false, true
+		}
 		it := (*filtered)[*cursor]
+		// Alt-Enter (or -print path) prints only the path, for use in pipelines
+		if printPath {
+			s.Fini()
+			fmt.Println(it.Path)
+			return false, true
+		}
 		out := ""
 		if fetcher != nil {
-			if v, ok := previewCache[it.Path]; ok {
+			if v, ok := previewCache[it.Path]; ok && !isGuarded(uiState.PreviewErr[it.Path]) {
 				out = v
 			} else {
+				// Enter always reads, but the read counts against the budget
+				uiState.guard.take(true)
 				if v, err := fetcher(it.Path); err == nil {
 					previewCache[it.Path] = v
 					out = v
 				} else {
+					slog.Warn("value fetch failed", "path", it.Path, "err", err)
 					out = fmt.Sprintf("(error fetching values) %v", err)
 				}
 			}
@@ -52,64 +175,84 @@ func HandleKey(
 			out = string(b)
 		}
 		// Match printed output to current preview mode
-		if uiState.JSONPreview {
-			if isLikelyJSON(out) {
-				// keep
-			} else {
-				kv := toKVFromLines(out)
-				if len(kv) > 0 {
-					if b, err := json.MarshalIndent(kv, "", "  "); err == nil {
-						out = string(b)
-					}
-				}
-			}
-		} else {
-			// Ensure table output in table mode
-			if isLikelyJSON(out) {
-				lines := toLinesFromJSONText(out)
-				out = joinLines(lines)
-			}
-		}
+		out = inPreviewFormat(out, uiState.JSONPreview)
 		if out == "" {
 			out = "{}"
 		}
+		if uiState.ConfirmPrint {
+			uiState.confirmPrint(out)
+			break
+		}
 		// finalize
 		s.Fini()
 		fmt.Println(out)
 		return false, true
 	case tcell.KeyUp:
+		if ev.Modifiers()&tcell.ModShift != 0 {
+			uiState.moveKeyFocus(-1)
+			break
+		}
+		if uiState.Grouped {
+			uiState.moveCursor(-1)
+			uiState.resetReveal()
+			break
+		}
 		if *cursor > 0 {
 			*cursor--
-			uiState.RevealAll = false
+			uiState.resetReveal()
 		}
 	case tcell.KeyDown:
+		if ev.Modifiers()&tcell.ModShift != 0 {
+			uiState.moveKeyFocus(1)
+			break
+		}
+		if uiState.Grouped {
+			uiState.moveCursor(1)
+			uiState.resetReveal()
+			break
+		}
 		if *cursor < len(*filtered)-1 {
 			*cursor++
-			uiState.RevealAll = false
+			uiState.resetReveal()
 		}
 	case tcell.KeyPgUp:
+		if uiState.Grouped {
+			uiState.moveCursor(-10)
+			uiState.resetReveal()
+			break
+		}
 		*cursor -= 10
 		if *cursor < 0 {
 			*cursor = 0
 		}
-		uiState.RevealAll = false
+		uiState.resetReveal()
 	case tcell.KeyPgDn:
+		if uiState.Grouped {
+			uiState.moveCursor(10)
+			uiState.resetReveal()
+			break
+		}
 		*cursor += 10
 		if *cursor >= len(*filtered) {
 			*cursor = len(*filtered) - 1
 		}
-		uiState.RevealAll = false
+		uiState.resetReveal()
 	case tcell.KeyHome:
 		*cursor = 0
-		uiState.RevealAll = false
+		uiState.resetReveal()
 	case tcell.KeyEnd:
+		if uiState.Grouped {
+			uiState.moveCursor(len(*filtered) + 1)
+			uiState.resetReveal()
+			break
+		}
 		*cursor = len(*filtered) - 1
-		uiState.RevealAll = false
+		uiState.resetReveal()
 	case tcell.KeyBackspace, tcell.KeyBackspace2:
 		if len(*query) > 0 {
 			*query = (*query)[:len(*query)-1]
 			applyFilter()
-			uiState.RevealAll = false
+			uiState.resetReveal()
 		}
 	case tcell.KeyLeft:
 		// Toggle mouse enablement with Left Arrow
@@ -120,12 +263,55 @@ func HandleKey(
 			s.DisableMouse()
 		}
 	case tcell.KeyRight:
+		if ev.Modifiers()&tcell.ModShift != 0 {
+			// Shift-Right reveals only the key under the preview key cursor
+			uiState.toggleRevealFocused()
+			break
+		}
 		// Toggle reveal all secret values with Right Arrow
 		uiState.RevealAll = !uiState.RevealAll
 	case tcell.KeyTAB:
 		uiState.PreviewWrap = !uiState.PreviewWrap
 	case tcell.KeyRune:
 		r := ev.Rune()
+		if r == '|' && ev.Modifiers()&tcell.ModAlt == 0 {
+			// Pipe the selected secret into a command
+			uiState.openPipePrompt(s, fetcher)
+			break
+		}
+		if ev.Modifiers()&tcell.ModAlt != 0 {
+			// Alt-1..9: switch/open query tabs; Alt-w closes; Alt-r renames; Alt-y/Y copy;
+			// Alt-g/c group by mount and collapse the current mount; Alt-p relative paths;
+			// Alt-m mount details; Alt-n line numbers; Alt-e report; Alt-v forces a held-back read
+			switch {
+			case r >= '1' && r <= '9':
+				uiState.switchTab(int(r - '1'))
+			case r == 'w':
+				uiState.closeTab()
+			case r == 'r':
+				uiState.renameTab()
+			case r == 'y':
+				// Copy the focused key (or the whole secret) with the same [OK] flash as a click
+				uiState.copyFocusedKey(s)
+			case r == 'Y':
+				uiState.copySecret(s)
+			case r == 'g':
+				uiState.toggleGrouped()
+			case r == 'c':
+				uiState.toggleCollapsed()
+			case r == 'p':
+				uiState.RelativePaths = !uiState.RelativePaths
+			case r == 'm':
+				uiState.openMountDetails()
+			case r == 'n':
+				uiState.LineNumbers = !uiState.LineNumbers
+			case r == 'e':
+				uiState.openReport()
+			case r == 'v':
+				uiState.forceRead(fetcher)
+			}
+			break
+		}
 		// Some terminals send Tab as a rune instead of KeyTAB.
 		if r == '\t' {
 			uiState.PreviewWrap = !uiState.PreviewWrap
@@ -134,16 +320,21 @@ func HandleKey(
 		if r != 0 {
 			*query += string(r)
 			applyFilter()
-			uiState.RevealAll = false
+			uiState.resetReveal()
 		}
 	}
+	notifyActivity(activity)
+	return shouldRedraw, false
+}
+
+// notifyActivity signals user interaction without blocking.
+func notifyActivity(activity chan<- struct{}) {
 	if activity != nil {
 		select {
 		case activity <- struct{}{}:
 		default:
 		}
 	}
-	return shouldRedraw, false
 }
 
 // HandleMouse processes a mouse event, mutating state and returning whether to redraw.
@@ -159,18 +350,14 @@ func HandleMouse(
 	revealBtnX, revealBtnY, revealBtnW int,
 	activity chan<- struct{},
 ) (shouldRedraw bool) {
-	if !uiState.MouseEnabled {
+	if !uiState.MouseEnabled || uiState.Panel != nil || uiState.Locked {
 		return false
 	}
+	uiState.touch()
 	mx, my := ev.Position()
 	btn := ev.Buttons()
 
-	if activity != nil {
-		select {
-		case activity <- struct{}{}:
-		default:
-		}
-	}
+	notifyActivity(activity)
 
 	// Map click position to left list rows
 	w, h := s.Size()
@@ -182,6 +369,14 @@ func HandleMouse(
 	leftW := computeLeftWidth(w)
 
 	// Wheel scroll
+	if btn&tcell.WheelUp != 0 && uiState.Grouped {
+		uiState.moveCursor(-1)
+		return true
+	}
+	if btn&tcell.WheelDown != 0 && uiState.Grouped {
+		uiState.moveCursor(1)
+		return true
+	}
 	if btn&tcell.WheelUp != 0 {
 		if *cursor > 0 {
 			*cursor--
@@ -199,13 +394,7 @@ func HandleMouse(
 	if btn&tcell.Button1 != 0 {
 		for _, b := range uiState.PerLineCopyBtns {
 			if my == b.Y && mx >= b.X && mx < b.X+b.W {
-				_ = copyToClipboard(b.Val)
-				uiState.PerKeyFlash[b.Key] = time.Now().Add(1200 * time.Millisecond)
-				// schedule a delayed redraw to clear the flash
-				go func() {
-					time.Sleep(1300 * time.Millisecond)
-					s.PostEvent(tcell.NewEventInterrupt(nil))
-				}()
+				uiState.copyKey(s, b.Key, b.Val)
 				return true
 			}
 		}
@@ -225,12 +414,7 @@ func HandleMouse(
 		}
 		if copyBtnW > 0 && my == copyBtnY && mx >= copyBtnX && mx < copyBtnX+copyBtnW {
 			if uiState.CurrentFetchedVal != "" {
-				_ = copyToClipboard(uiState.CurrentFetchedVal)
-				uiState.CopyFlashUntil = time.Now().Add(1200 * time.Millisecond)
-				go func() {
-					time.Sleep(1300 * time.Millisecond)
-					s.PostEvent(tcell.NewEventInterrupt(nil))
-				}()
+				uiState.copySecret(s)
 				return true
 			}
 		}
@@ -240,10 +424,23 @@ func HandleMouse(
 	if btn&tcell.Button1 != 0 {
 		if mx >= 0 && mx < leftW && my >= contentTop && my < contentTop+maxRows {
 			row := my - contentTop
+			if uiState.Grouped {
+				// Clicking a mount header collapses or expands it
+				rows := groupRows(*filtered, uiState.Collapsed)
+				if i := *offset + row; i < len(rows) {
+					*cursor = rows[i].item
+					if rows[i].mount != "" {
+						uiState.toggleCollapsed()
+					}
+					uiState.resetReveal()
+					return true
+				}
+				return false
+			}
 			newCursor := *offset + row
 			if newCursor >= 0 && newCursor < len(*filtered) {
 				*cursor = newCursor
-				uiState.RevealAll = false
+				uiState.resetReveal()
 				return true
 			}
 		}
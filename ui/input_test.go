@@ -1,6 +1,8 @@
 package ui
 
 import (
+	"io"
+	"os"
 	"testing"
 	"github.com/gdamore/tcell/v2"
 	"fvf/search"
@@ -88,3 +90,45 @@ func TestHandleMouse_WheelScroll(t *testing.T) {
 	redraw = HandleMouse(s, ev, &filtered, &cursor, &offset, uiState, -1, -1, 0, -1, -1, 0, -1, -1, 0, nil)
 	if !redraw || cursor != 1 { t.Fatalf("wheel down should move cursor to 1; cursor=%d", cursor) }
 }
+
+// captureStdout runs fn with os.Stdout redirected and returns what it printed.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	saved := os.Stdout
+	os.Stdout = w
+	fn()
+	os.Stdout = saved
+	w.Close()
+	b, _ := io.ReadAll(r)
+	return string(b)
+}
+
+func TestHandleKey_AltEnterPrintsPath(t *testing.T) {
+	for _, tc := range []struct {
+		printPath bool
+		mod       tcell.ModMask
+		want      string
+	}{
+		{false, tcell.ModAlt, "kv/app/db\n"},
+		{false, tcell.ModNone, "user: bob\n"},
+		{true, tcell.ModNone, "kv/app/db\n"},
+		{true, tcell.ModAlt, "user: bob\n"},
+	} {
+		s := newSimScreen(t)
+		st := &UIState{PrintPath: tc.printPath, PreviewCache: map[string]string{"kv/app/db": "user: bob"}, PreviewErr: map[string]error{}}
+		st.Items = []search.FoundItem{{Path: "kv/app/db"}}
+		st.ApplyFilter()
+		fetcher := func(string) (string, error) { return "user: bob", nil }
+		var quit bool
+		out := captureStdout(t, func() {
+			_, quit = HandleKey(s, tcell.NewEventKey(tcell.KeyEnter, 0, tc.mod), &st.Items, &st.Filtered, &st.Query, &st.Cursor, &st.Offset, st.PreviewCache, fetcher, st, st.ApplyFilter, nil)
+		})
+		if !quit || out != tc.want {
+			t.Fatalf("printPath=%v mod=%v: quit=%v out=%q want %q", tc.printPath, tc.mod, quit, out, tc.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"fmt"
+
+	"fvf/search"
+)
+
+// KVVersionFunc reports the KV version reads of path use and the version its
+// mount shows (0 when unknown or not a KV mount), for the preview badge.
+type KVVersionFunc func(path string) (used, detected int)
+
+// kvBadge renders "[kv2]", or "[kv1 ≠ mount kv2]" when -kv1 or -force-kv2
+// makes reads disagree with the mount.
+func kvBadge(used, detected int) string {
+	switch {
+	case used == 0:
+		return ""
+	case detected != 0 && detected != used:
+		return fmt.Sprintf("[kv%d ≠ mount kv%d]", used, detected)
+	}
+	return fmt.Sprintf("[kv%d]", used)
+}
+
+// previewKVBadge returns the KV version badge for path, looked up once per
+// mount and namespace.
+func (st *UIState) previewKVBadge(path string) string {
+	if st.kvVersion == nil {
+		return ""
+	}
+	mnt, _ := search.SplitMount(path)
+	key := st.Namespace + "|" + mnt
+	if b, ok := st.kvBadges[key]; ok {
+		return b
+	}
+	if st.kvBadges == nil {
+		st.kvBadges = make(map[string]string)
+	}
+	b := kvBadge(st.kvVersion(path))
+	st.kvBadges[key] = b
+	return b
+}
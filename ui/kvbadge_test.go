@@ -0,0 +1,27 @@
+package ui
+
+import "testing"
+
+func TestPreviewKVBadge(t *testing.T) {
+	calls := 0
+	st := &UIState{kvVersion: func(p string) (int, int) {
+		calls++
+		if p == "legacy/a" {
+			return 2, 1
+		}
+		return 2, 2
+	}}
+	if got := st.previewKVBadge("kv/a"); got != "[kv2]" {
+		t.Fatalf("got %q", got)
+	}
+	st.previewKVBadge("kv/b")
+	if calls != 1 {
+		t.Fatalf("badge should be looked up once per mount, got %d calls", calls)
+	}
+	if got := st.previewKVBadge("legacy/a"); got != "[kv2 ≠ mount kv1]" {
+		t.Fatalf("got %q", got)
+	}
+	if kvBadge(0, 2) != "" || kvBadge(1, 0) != "[kv1]" {
+		t.Fatal("unknown versions should not claim a mismatch")
+	}
+}
@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// lockMessage is shown in place of the list and preview while locked.
+const lockMessage = "fvf is locked after inactivity — press any key"
+
+// checkLock locks the UI once no input arrived for LockAfter.
+func (st *UIState) checkLock(now time.Time) {
+	if st.LockAfter > 0 && !st.LastInput.IsZero() && now.Sub(st.LastInput) >= st.LockAfter {
+		st.Locked = true
+	}
+}
+
+// touch records user input for the lock timer.
+func (st *UIState) touch() {
+	st.LastInput = time.Now()
+}
+
+// drawLockScreen blanks the content area and centers the lock message.
+func drawLockScreen(s tcell.Screen, w, h int) {
+	y := h / 2
+	x := (w - len([]rune(lockMessage))) / 2
+	if x < 0 {
+		x = 0
+	}
+	putLine(s, x, y, lockMessage)
+}
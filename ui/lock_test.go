@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"fvf/search"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestLockScreen_HidesContentUntilKey(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	s.SetSize(80, 10)
+	st := &UIState{
+		PreviewCache: map[string]string{},
+		PreviewErr:   map[string]error{},
+		LockAfter:    time.Minute,
+		LastInput:    time.Now().Add(-2 * time.Minute),
+		Query:        "app",
+	}
+	st.Items = []search.FoundItem{{Path: "kv/app"}}
+	st.ApplyFilter()
+
+	RenderAll(s, false, nil, nil, nil, st)
+	if !st.Locked {
+		t.Fatal("expected UI to lock after inactivity")
+	}
+	for y := 0; y < 9; y++ {
+		if ln := readLine(s, y, 80); strings.Contains(ln, "kv/app") {
+			t.Fatalf("content visible while locked on row %d: %q", y, ln)
+		}
+	}
+	if ln := readLine(s, 5, 80); !strings.Contains(ln, "press any key") {
+		t.Fatalf("expected lock message, got %q", ln)
+	}
+
+	HandleKey(s, tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone), &st.Items, &st.Filtered, &st.Query, &st.Cursor, &st.Offset, st.PreviewCache, nil, st, st.ApplyFilter, nil)
+	if st.Locked || st.Query != "app" {
+		t.Fatalf("unlock key should be swallowed: locked=%v query=%q", st.Locked, st.Query)
+	}
+	RenderAll(s, false, nil, nil, nil, st)
+	if ln := readLine(s, 2, 80); !strings.Contains(ln, "kv/app") {
+		t.Fatalf("expected content after unlock, got %q", ln)
+	}
+}
@@ -0,0 +1,77 @@
+package ui
+
+import "time"
+
+// lookupRetry is how long a failed metadata or capabilities lookup is kept
+// before the preview asks Vault again.
+const lookupRetry = 30 * time.Second
+
+// lookups tracks the preview header's background lookups by path: the ones in
+// flight, so a path is requested once however often the preview is drawn,
+// and the ones that failed recently, so a failing path is retried after
+// lookupRetry rather than on every frame. A result is applied only while its
+// lookup is still current; forget and reset drop lookups in flight, e.g.
+// after a write or a namespace switch.
+type lookups struct {
+	seq     int
+	pending map[string]int
+	failed  map[string]time.Time
+}
+
+// due reports whether path needs a lookup: none is in flight and the last
+// one did not fail within lookupRetry.
+func (l *lookups) due(path string, now time.Time) bool {
+	if _, ok := l.pending[path]; ok {
+		return false
+	}
+	at, ok := l.failed[path]
+	return !ok || now.Sub(at) >= lookupRetry
+}
+
+// loading reports whether a lookup of path is in flight.
+func (l *lookups) loading(path string) bool {
+	_, ok := l.pending[path]
+	return ok
+}
+
+// start marks paths as in flight and returns the token finish expects.
+func (l *lookups) start(paths []string) int {
+	if l.pending == nil {
+		l.pending = make(map[string]int)
+	}
+	l.seq++
+	for _, p := range paths {
+		l.pending[p] = l.seq
+	}
+	return l.seq
+}
+
+// finish ends the lookup of path started as seq, remembering a failure, and
+// reports whether its result is still current.
+func (l *lookups) finish(path string, seq int, err error, now time.Time) bool {
+	if s, ok := l.pending[path]; !ok || s != seq {
+		return false
+	}
+	delete(l.pending, path)
+	if err == nil {
+		delete(l.failed, path)
+		return true
+	}
+	if l.failed == nil {
+		l.failed = make(map[string]time.Time)
+	}
+	l.failed[path] = now
+	return true
+}
+
+// forget drops what is known about path so the next preview looks it up again.
+func (l *lookups) forget(path string) {
+	delete(l.pending, path)
+	delete(l.failed, path)
+}
+
+// reset forgets every path.
+func (l *lookups) reset() {
+	l.pending = nil
+	l.failed = nil
+}
@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"fvf/search"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// MetadataFetcher returns KV v2 metadata for a path, or (nil, nil) when the
+// path has none (e.g. KV v1 mounts).
+type MetadataFetcher func(path string) (*search.SecretMetadata, error)
+
+// historyEntries caps the versions listed in the preview's history line.
+const historyEntries = 8
+
+// metadataLines formats metadata for the preview header: a version/timestamp
+// line, a version history line when more than one version is kept and, when
+// present, a custom_metadata line.
+func metadataLines(md *search.SecretMetadata) []string {
+	if md == nil {
+		return nil
+	}
+	parts := []string{fmt.Sprintf("v%d", md.CurrentVersion)}
+	if !md.CreatedTime.IsZero() {
+		parts = append(parts, "created "+md.CreatedTime.Local().Format(time.DateTime))
+	}
+	if !md.UpdatedTime.IsZero() {
+		parts = append(parts, "updated "+md.UpdatedTime.Local().Format(time.DateTime))
+	}
+	lines := []string{strings.Join(parts, "  ")}
+	if h := historyLine(md.Versions); h != "" {
+		lines = append(lines, h)
+	}
+	if len(md.CustomMetadata) > 0 {
+		keys := make([]string, 0, len(md.CustomMetadata))
+		for k := range md.CustomMetadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		kvs := make([]string, 0, len(keys))
+		for _, k := range keys {
+			kvs = append(kvs, k+"="+md.CustomMetadata[k])
+		}
+		lines = append(lines, "meta: "+strings.Join(kvs, ", "))
+	}
+	return lines
+}
+
+// previewMetadata returns the header lines for path. Metadata is read in the
+// background on first use, with a placeholder line until it arrives; a
+// failed read shows no metadata and is retried after lookupRetry.
+func (st *UIState) previewMetadata(s tcell.Screen, path string) []string {
+	if st.metadata == nil {
+		return nil
+	}
+	if md, ok := st.MetaCache[path]; ok {
+		return metadataLines(md)
+	}
+	if st.metaLookups.due(path, time.Now()) {
+		seq := st.metaLookups.start([]string{path})
+		fetch := st.metadata
+		st.background(s, func() func() {
+			md, err := fetch(path)
+			if err != nil {
+				slog.Warn("metadata fetch failed", "path", path, "err", err)
+			}
+			return func() {
+				if !st.metaLookups.finish(path, seq, err, time.Now()) || err != nil {
+					return
+				}
+				if st.MetaCache == nil {
+					st.MetaCache = make(map[string]*search.SecretMetadata)
+				}
+				st.MetaCache[path] = md
+			}
+		})
+	}
+	if st.metaLookups.loading(path) {
+		return []string{"(loading metadata…)"}
+	}
+	return nil
+}
+
+// historyLine renders versions (newest first) as a compact timeline, e.g.
+// "history: v5 2024-06-01 · v4 2024-03-10 deleted · v3 2024-01-02 destroyed
+// (every ~51d)", so the change cadence shows without opening the versions
+// panel. It is empty for secrets with a single version.
+func historyLine(versions []search.VersionInfo) string {
+	if len(versions) < 2 {
+		return ""
+	}
+	shown := versions
+	if len(shown) > historyEntries {
+		shown = shown[:historyEntries]
+	}
+	entries := make([]string, 0, len(shown))
+	for _, v := range shown {
+		e := fmt.Sprintf("v%d", v.Version)
+		if !v.CreatedTime.IsZero() {
+			e += " " + v.CreatedTime.Local().Format(time.DateOnly)
+		}
+		switch {
+		case v.Destroyed:
+			e += " destroyed"
+		case !v.DeletionTime.IsZero():
+			e += " deleted"
+		}
+		entries = append(entries, e)
+	}
+	line := "history: " + strings.Join(entries, " · ")
+	if more := len(versions) - len(shown); more > 0 {
+		line += fmt.Sprintf(" · +%d older", more)
+	}
+	newest, oldest := versions[0].CreatedTime, versions[len(versions)-1].CreatedTime
+	if !newest.IsZero() && !oldest.IsZero() && newest.After(oldest) {
+		gap := newest.Sub(oldest) / time.Duration(len(versions)-1)
+		line += " (every ~" + cadence(gap) + ")"
+	}
+	return line
+}
+
+// cadence formats the average time between versions in its largest whole
+// unit: days, hours or minutes.
+func cadence(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	}
+	return "<1m"
+}
@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fvf/search"
+)
+
+func TestMetadataLines(t *testing.T) {
+	if metadataLines(nil) != nil {
+		t.Fatal("expected no lines without metadata")
+	}
+	md := &search.SecretMetadata{
+		CurrentVersion: 4,
+		CreatedTime:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		CustomMetadata: map[string]string{"team": "a", "owner": "bob"},
+	}
+	lines := metadataLines(md)
+	if len(lines) != 2 {
+		t.Fatalf("expected version and custom metadata lines, got %#v", lines)
+	}
+	if !strings.HasPrefix(lines[0], "v4  created ") || strings.Contains(lines[0], "updated") {
+		t.Fatalf("unexpected header line: %q", lines[0])
+	}
+	if lines[1] != "meta: owner=bob, team=a" {
+		t.Fatalf("unexpected custom metadata line: %q", lines[1])
+	}
+}
+
+func TestMetadataLines_History(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC) }
+	md := &search.SecretMetadata{
+		CurrentVersion: 3,
+		Versions: []search.VersionInfo{
+			{Version: 3, CreatedTime: day(21)},
+			{Version: 2, CreatedTime: day(11), DeletionTime: day(12)},
+			{Version: 1, CreatedTime: day(1), Destroyed: true},
+		},
+	}
+	lines := metadataLines(md)
+	if len(lines) != 2 {
+		t.Fatalf("expected version and history lines, got %#v", lines)
+	}
+	want := "history: v3 2024-01-21 · v2 2024-01-11 deleted · v1 2024-01-01 destroyed (every ~10d)"
+	if lines[1] != want {
+		t.Fatalf("history line:\n got %q\nwant %q", lines[1], want)
+	}
+
+	md.Versions = md.Versions[:1]
+	if got := metadataLines(md); len(got) != 1 {
+		t.Fatalf("expected no history line for a single version, got %#v", got)
+	}
+}
+
+func TestHistoryLine_CapsEntries(t *testing.T) {
+	var vs []search.VersionInfo
+	for n := 12; n >= 1; n-- {
+		vs = append(vs, search.VersionInfo{Version: n})
+	}
+	got := historyLine(vs)
+	if !strings.HasSuffix(got, "v5 · +4 older") {
+		t.Fatalf("unexpected capped history: %q", got)
+	}
+}
+
+func TestPreviewMetadata_FetchesInBackgroundAndRetriesFailures(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	var calls atomic.Int32
+	st := &UIState{metadata: func(p string) (*search.SecretMetadata, error) {
+		calls.Add(1)
+		if p == "kv/bad" {
+			return nil, errors.New("permission denied")
+		}
+		return &search.SecretMetadata{CurrentVersion: 2}, nil
+	}}
+	for _, p := range []string{"kv/a", "kv/bad"} {
+		if got := st.previewMetadata(s, p); len(got) != 1 || got[0] != "(loading metadata…)" {
+			t.Fatalf("%s: expected a placeholder while loading, got %#v", p, got)
+		}
+		st.previewMetadata(s, p)
+		waitBackground(t, st)
+	}
+	for i := 0; i < 2; i++ {
+		if got := st.previewMetadata(s, "kv/a"); len(got) != 1 || got[0] != "v2" {
+			t.Fatalf("unexpected lines: %#v", got)
+		}
+		if got := st.previewMetadata(s, "kv/bad"); got != nil {
+			t.Fatalf("expected no lines on error, got %#v", got)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("expected one fetch per path, got %d", n)
+	}
+	// A failure is not kept for good
+	st.metaLookups.failed["kv/bad"] = time.Now().Add(-lookupRetry)
+	st.previewMetadata(s, "kv/bad")
+	waitBackground(t, st)
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("expected the failed path to be retried, got %d fetches", n)
+	}
+}
+
+func TestRenderAll_ShowsMetadataUnderPath(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	s.SetSize(100, 12)
+	st := &UIState{
+		Items:        []search.FoundItem{{Path: "kv/app"}},
+		PreviewCache: map[string]string{"kv/app": "user: bob"},
+		PreviewErr:   make(map[string]error),
+		metadata: func(string) (*search.SecretMetadata, error) {
+			return &search.SecretMetadata{CurrentVersion: 7}, nil
+		},
+	}
+	st.ApplyFilter()
+	RenderAll(s, true, nil, nil, nil, st)
+	waitBackground(t, st)
+	RenderAll(s, true, nil, nil, nil, st)
+	found := false
+	for y := 2; y < 6; y++ {
+		if strings.Contains(readLine(s, y, 100), "v7") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("expected metadata version line in the preview header")
+	}
+}
@@ -0,0 +1,189 @@
+package ui
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"fvf/search"
+)
+
+// MountInfo describes a secrets engine mount offered in the mount switcher.
+type MountInfo struct {
+	Path    string // e.g. "kv/"
+	Type    string // engine type, e.g. "kv"
+	Version string // KV version ("1" or "2"), empty when unknown
+	// Secrets is the number of indexed secrets below the mount, -1 when no
+	// index (fvf index) walked the whole mount.
+	Secrets int
+}
+
+// MountLister returns the mounts the user can choose to walk.
+type MountLister func() ([]MountInfo, error)
+
+// MountDetails describes the mount of a secret for the mount panel (Alt-m).
+// Parts the token may not read carry an error instead.
+type MountDetails struct {
+	MountInfo
+	Description string
+	// Lease TTLs from the mount's tuning; zero means the system default.
+	DefaultLeaseTTL time.Duration
+	MaxLeaseTTL     time.Duration
+	TuneErr         error
+	// IndexUpdated is when the index counting Secrets was written.
+	IndexUpdated time.Time
+	// Quotas are the rate-limit quotas covering the mount.
+	Quotas   []search.RateLimitQuota
+	QuotaErr error
+}
+
+// MountInspector returns the details of the mount holding path.
+type MountInspector func(path string) (*MountDetails, error)
+
+// countByMount tallies streamed items per mount path ("kv/").
+func countByMount(items []search.FoundItem) map[string]int {
+	out := make(map[string]int)
+	for _, it := range items {
+		mnt, _ := search.SplitMount(it.Path)
+		out[mnt+"/"]++
+	}
+	return out
+}
+
+// openMountPanel shows the mount switcher. Secret counts come from the index
+// and, for mounts it does not cover, from the items streamed so far. Mounts
+// that are part of the current roots (or all mounts when walking everything)
+// start checked; Enter restarts the walk with the checked mounts.
+func (st *UIState) openMountPanel(lister MountLister, onCancel func()) {
+	if lister == nil || st.restartWalk == nil {
+		return
+	}
+	mounts, err := lister()
+	if err != nil {
+		slog.Warn("listing mounts failed", "err", err)
+		st.openPanel(&Panel{Title: "Mounts", Lines: []string{fmt.Sprintf("(error listing mounts) %v", err)}, Cancel: onCancel})
+		return
+	}
+	counts := countByMount(st.Items)
+	selected := make(map[string]bool)
+	for _, r := range st.Roots {
+		mnt, _ := search.SplitMount(r)
+		selected[mnt+"/"] = true
+	}
+
+	maxP := 0
+	for _, m := range mounts {
+		if len(m.Path) > maxP {
+			maxP = len(m.Path)
+		}
+	}
+	p := &Panel{
+		Title:  "Mounts (Space: toggle, Enter: walk selected, Esc: cancel)",
+		Multi:  true,
+		Cancel: onCancel,
+	}
+	p.Checked = make(map[int]bool)
+	for i, m := range mounts {
+		ver := "v?"
+		if m.Version != "" {
+			ver = "v" + m.Version
+		}
+		count := "-"
+		if m.Secrets >= 0 {
+			count = fmt.Sprintf("%d", m.Secrets)
+		} else if n, ok := counts[m.Path]; ok {
+			count = fmt.Sprintf("%d", n)
+		}
+		p.Lines = append(p.Lines, fmt.Sprintf("%-*s  %-4s %-3s  %s secrets", maxP, m.Path, m.Type, ver, count))
+		p.Checked[i] = len(st.Roots) == 0 || selected[m.Path]
+	}
+	restart := st.restartWalk
+	p.Submit = func(p *Panel) {
+		idx := p.checkedIndexes()
+		if len(idx) == len(mounts) {
+			// Everything selected: walk all mounts (also picks up mounts created later)
+			restart(nil)
+			return
+		}
+		roots := make([]string, 0, len(idx))
+		for _, i := range idx {
+			roots = append(roots, mounts[i].Path)
+		}
+		if len(roots) == 0 {
+			if onCancel != nil {
+				onCancel()
+			}
+			return
+		}
+		restart(roots)
+	}
+	st.openPanel(p)
+}
+
+// leaseTTL formats a mount lease TTL; zero falls back to the system default.
+func leaseTTL(d time.Duration) string {
+	if d == 0 {
+		return "system default"
+	}
+	return d.String()
+}
+
+// mountDetailLines renders the mount panel: engine, secret count, lease TTLs
+// and one line per applicable rate-limit quota.
+func mountDetailLines(d *MountDetails) []string {
+	engine := d.Type
+	if d.Version != "" {
+		engine += " v" + d.Version
+	}
+	if engine == "" {
+		engine = "unknown"
+	}
+	lines := []string{"engine       " + engine}
+	if d.Description != "" {
+		lines = append(lines, "description  "+d.Description)
+	}
+	if d.Secrets < 0 {
+		lines = append(lines, "secrets      unknown (mount not indexed; run fvf index)")
+	} else {
+		lines = append(lines, fmt.Sprintf("secrets      %d (index of %s)", d.Secrets, d.IndexUpdated.Local().Format(time.DateTime)))
+	}
+	if d.TuneErr != nil {
+		lines = append(lines, "lease TTLs   "+d.TuneErr.Error())
+	} else {
+		lines = append(lines, "default TTL  "+leaseTTL(d.DefaultLeaseTTL), "max TTL      "+leaseTTL(d.MaxLeaseTTL))
+	}
+	switch {
+	case d.QuotaErr != nil:
+		lines = append(lines, "quotas       "+d.QuotaErr.Error())
+	case len(d.Quotas) == 0:
+		lines = append(lines, "quotas       none")
+	}
+	for _, q := range d.Quotas {
+		scope := q.Path
+		if scope == "" {
+			scope = "global"
+		}
+		line := fmt.Sprintf("quota        %s: %g req per %s on %s", q.Name, q.Rate, q.Interval, scope)
+		if q.BlockInterval > 0 {
+			line += fmt.Sprintf(", blocks %s when exceeded", q.BlockInterval)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// openMountDetails shows the details of the current item's mount (Alt-m).
+func (st *UIState) openMountDetails() {
+	if st.mountInfo == nil || st.Cursor < 0 || st.Cursor >= len(st.Filtered) {
+		return
+	}
+	d, err := st.mountInfo(st.Filtered[st.Cursor].Path)
+	if err != nil {
+		st.showToast("mount: "+err.Error(), true)
+		return
+	}
+	st.openPanel(&Panel{
+		Title: fmt.Sprintf("Mount %s (Esc: close)", d.Path),
+		Lines: mountDetailLines(d),
+	})
+}
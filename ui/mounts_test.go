@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"fvf/search"
+)
+
+func TestMountDetailLines(t *testing.T) {
+	d := &MountDetails{
+		MountInfo:       MountInfo{Path: "kv/", Type: "kv", Version: "2", Secrets: -1},
+		DefaultLeaseTTL: time.Hour,
+		Quotas:          []search.RateLimitQuota{{Name: "global", Rate: 100, Interval: time.Second}},
+	}
+	got := strings.Join(mountDetailLines(d), "\n")
+	for _, want := range []string{
+		"engine       kv v2",
+		"secrets      unknown (mount not indexed; run fvf index)",
+		"default TTL  1h0m0s",
+		"max TTL      system default",
+		"quota        global: 100 req per 1s on global",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("missing %q in:\n%s", want, got)
+		}
+	}
+
+	d.Quotas, d.QuotaErr = nil, errors.New("permission denied")
+	if got := mountDetailLines(d); got[len(got)-1] != "quotas       permission denied" {
+		t.Fatalf("unexpected quota line: %q", got[len(got)-1])
+	}
+}
+
+func TestOpenMountDetails(t *testing.T) {
+	var asked string
+	st := &UIState{
+		Filtered: []search.FoundItem{{Path: "kv/app/db"}},
+		mountInfo: func(p string) (*MountDetails, error) {
+			asked = p
+			return &MountDetails{MountInfo: MountInfo{Path: "kv/", Type: "kv", Secrets: 3}}, nil
+		},
+	}
+	st.openMountDetails()
+	if asked != "kv/app/db" || st.Panel == nil || !strings.HasPrefix(st.Panel.Title, "Mount kv/") {
+		t.Fatalf("asked %q, panel %+v", asked, st.Panel)
+	}
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// NamespaceLister returns the child namespaces (e.g. "team-a/") of the given
+// namespace path ("" for the root namespace).
+type NamespaceLister func(current string) ([]string, error)
+
+// NamespaceSetter switches the Vault client to the given namespace path.
+type NamespaceSetter func(ns string)
+
+// parentNamespace returns the parent of a namespace path like "a/b/" ("a/").
+func parentNamespace(ns string) string {
+	ns = strings.TrimSuffix(ns, "/")
+	if i := strings.LastIndex(ns, "/"); i >= 0 {
+		return ns[:i+1]
+	}
+	return ""
+}
+
+// openNamespacePanel lists the parent, current and child namespaces. Selecting
+// an entry switches the client namespace and restarts the walk across all mounts.
+func (st *UIState) openNamespacePanel(lister NamespaceLister, setter NamespaceSetter) {
+	if lister == nil || setter == nil || st.restartWalk == nil {
+		return
+	}
+	cur := st.Namespace
+	children, err := lister(cur)
+	if err != nil {
+		slog.Warn("listing namespaces failed", "err", err)
+		st.openPanel(&Panel{Title: "Namespaces", Lines: []string{fmt.Sprintf("(error listing namespaces) %v", err)}})
+		return
+	}
+	label := func(ns string) string {
+		if ns == "" {
+			return "(root)"
+		}
+		return ns
+	}
+	var targets, lines []string
+	if cur != "" {
+		targets = append(targets, parentNamespace(cur))
+		lines = append(lines, ".. "+label(parentNamespace(cur)))
+	}
+	targets = append(targets, cur)
+	lines = append(lines, ". "+label(cur)+" (current)")
+	for _, c := range children {
+		ns := cur + strings.TrimSuffix(c, "/") + "/"
+		targets = append(targets, ns)
+		lines = append(lines, ns)
+	}
+
+	restart := st.restartWalk
+	st.openPanel(&Panel{
+		Title:  "Namespaces (Enter: switch, Esc: cancel) — current: " + label(cur),
+		Lines:  lines,
+		Cursor: len(lines) - len(children) - 1,
+		Submit: func(p *Panel) {
+			if p.Cursor < 0 || p.Cursor >= len(targets) || targets[p.Cursor] == cur {
+				return
+			}
+			st.Namespace = targets[p.Cursor]
+			setter(st.Namespace)
+			// Cached previews belong to the previous namespace
+			for k := range st.PreviewCache {
+				delete(st.PreviewCache, k)
+			}
+			for k := range st.PreviewErr {
+				delete(st.PreviewErr, k)
+			}
+			st.previewLRU = nil
+			for _, t := range st.Tabs {
+				if t.PreviewCache != nil {
+					t.PreviewCache = make(map[string]string)
+					t.PreviewErr = make(map[string]error)
+					t.lru = nil
+				}
+			}
+			st.MetaCache = nil
+			st.metaLookups.reset()
+			st.CapsCache = nil
+			st.capsLookups.reset()
+			restart(nil)
+		},
+	})
+}
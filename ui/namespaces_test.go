@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestParentNamespace(t *testing.T) {
+	cases := map[string]string{"": "", "a/": "", "a/b/": "a/", "a/b/c/": "a/b/"}
+	for in, want := range cases {
+		if got := parentNamespace(in); got != want {
+			t.Fatalf("parentNamespace(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestOpenNamespacePanel_SwitchClearsCacheAndRestarts(t *testing.T) {
+	var setTo string
+	restarted := false
+	st := &UIState{
+		Namespace:    "team/",
+		Roots:        []string{"kv/"},
+		PreviewCache: map[string]string{"kv/a": "x: 1"},
+		PreviewErr:   map[string]error{},
+		restartWalk:  func(roots []string) { restarted = roots == nil },
+	}
+	lister := func(cur string) ([]string, error) {
+		if cur != "team/" {
+			t.Fatalf("lister called with %q", cur)
+		}
+		return []string{"dev/", "prod/"}, nil
+	}
+	st.openNamespacePanel(lister, func(ns string) { setTo = ns })
+	p := st.Panel
+	if p == nil || len(p.Lines) != 4 {
+		t.Fatalf("expected parent, current and two children, got %#v", p)
+	}
+	if p.Cursor != 1 {
+		t.Fatalf("cursor should start on the current namespace, got %d", p.Cursor)
+	}
+	handlePanelKey(tcell.NewEventKey(tcell.KeyDown, 0, 0), st)
+	handlePanelKey(tcell.NewEventKey(tcell.KeyEnter, 0, 0), st)
+	if setTo != "team/dev/" || st.Namespace != "team/dev/" {
+		t.Fatalf("expected switch to team/dev/, got setter=%q state=%q", setTo, st.Namespace)
+	}
+	if !restarted || len(st.PreviewCache) != 0 {
+		t.Fatalf("expected restart across all mounts and cleared cache; restarted=%v cache=%v", restarted, st.PreviewCache)
+	}
+}
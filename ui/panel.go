@@ -0,0 +1,139 @@
+package ui
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// Panel is a modal list drawn over the results and preview panes.
+// When Multi is set, Space toggles the entry under the cursor in Checked.
+// Enter calls Submit (if any) and closes the panel unless Submit reopens one.
+type Panel struct {
+	Title   string
+	Lines   []string
+	Cursor  int
+	Offset  int
+	Multi   bool
+	Checked map[int]bool
+	Submit  func(p *Panel)
+	Cancel  func()
+
+	// Highlight marks lines drawn emphasized (e.g. matching policy rules).
+	Highlight map[int]bool
+}
+
+// openPanel replaces any active panel.
+func (st *UIState) openPanel(p *Panel) {
+	if p.Multi && p.Checked == nil {
+		p.Checked = make(map[int]bool)
+	}
+	st.Panel = p
+}
+
+// handlePanelKey navigates or acts on the active panel.
+func handlePanelKey(ev *tcell.EventKey, st *UIState) {
+	p := st.Panel
+	if p == nil {
+		return
+	}
+	last := len(p.Lines) - 1
+	switch ev.Key() {
+	case tcell.KeyEscape, tcell.KeyCtrlC:
+		st.Panel = nil
+		if p.Cancel != nil {
+			p.Cancel()
+		}
+	case tcell.KeyEnter:
+		st.Panel = nil
+		if p.Submit != nil {
+			p.Submit(p)
+		}
+	case tcell.KeyUp:
+		if p.Cursor > 0 {
+			p.Cursor--
+		}
+	case tcell.KeyDown:
+		if p.Cursor < last {
+			p.Cursor++
+		}
+	case tcell.KeyPgUp:
+		p.Cursor -= 10
+		if p.Cursor < 0 {
+			p.Cursor = 0
+		}
+	case tcell.KeyPgDn:
+		p.Cursor += 10
+		if p.Cursor > last {
+			p.Cursor = last
+		}
+	case tcell.KeyHome:
+		p.Cursor = 0
+	case tcell.KeyEnd:
+		p.Cursor = last
+	case tcell.KeyRune:
+		if ev.Rune() == ' ' && p.Multi && p.Cursor >= 0 && p.Cursor <= last {
+			p.Checked[p.Cursor] = !p.Checked[p.Cursor]
+		}
+	}
+	if p.Cursor < 0 {
+		p.Cursor = 0
+	}
+}
+
+// checkedIndexes returns the toggled entries in display order.
+func (p *Panel) checkedIndexes() []int {
+	out := make([]int, 0, len(p.Checked))
+	for i := range p.Lines {
+		if p.Checked[i] {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// drawPanel renders the panel into the given rectangle.
+func drawPanel(s tcell.Screen, x, y, w, h int, p *Panel) {
+	if p == nil || w <= 0 || h <= 0 {
+		return
+	}
+	title := p.Title
+	if runewidth.StringWidth(title) > w {
+		title = runewidth.Truncate(title, w, "…")
+	}
+	putLineWithHighlights(s, x, y, title, "", tcell.StyleDefault.Bold(true), tcell.StyleDefault)
+	if h > 1 {
+		putLine(s, x, y+1, makeSeparator(w))
+	}
+	rows := h - 2
+	if rows <= 0 {
+		return
+	}
+	if p.Cursor < p.Offset {
+		p.Offset = p.Cursor
+	}
+	if p.Cursor >= p.Offset+rows {
+		p.Offset = p.Cursor - rows + 1
+	}
+	for i := 0; i < rows && i+p.Offset < len(p.Lines); i++ {
+		idx := i + p.Offset
+		line := p.Lines[idx]
+		if p.Multi {
+			mark := "[ ] "
+			if p.Checked[idx] {
+				mark = "[x] "
+			}
+			line = mark + line
+		}
+		if runewidth.StringWidth(line) > w {
+			line = runewidth.Truncate(line, w, "…")
+		}
+		st := tcell.StyleDefault
+		if p.Highlight[idx] {
+			st = st.Foreground(tcell.ColorYellow)
+		}
+		if idx == p.Cursor {
+			st = st.Reverse(true)
+		}
+		putLineWithHighlights(s, x, y+2+i, line, "", st, st)
+	}
+}
@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"fvf/search"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestPanel_NavigateToggleSubmit(t *testing.T) {
+	st := &UIState{}
+	var got []int
+	st.openPanel(&Panel{
+		Lines:  []string{"a", "b", "c"},
+		Multi:  true,
+		Submit: func(p *Panel) { got = p.checkedIndexes() },
+	})
+	handlePanelKey(tcell.NewEventKey(tcell.KeyDown, 0, 0), st)
+	handlePanelKey(tcell.NewEventKey(tcell.KeyRune, ' ', 0), st)
+	handlePanelKey(tcell.NewEventKey(tcell.KeyEnd, 0, 0), st)
+	handlePanelKey(tcell.NewEventKey(tcell.KeyRune, ' ', 0), st)
+	handlePanelKey(tcell.NewEventKey(tcell.KeyDown, 0, 0), st)
+	if st.Panel.Cursor != 2 {
+		t.Fatalf("cursor should stop at last line, got %d", st.Panel.Cursor)
+	}
+	handlePanelKey(tcell.NewEventKey(tcell.KeyEnter, 0, 0), st)
+	if st.Panel != nil {
+		t.Fatal("expected panel closed after Enter")
+	}
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("unexpected checked indexes: %v", got)
+	}
+}
+
+func TestOpenMountPanel_PreselectsRootsAndRestarts(t *testing.T) {
+	var restarted []string
+	calls := 0
+	st := &UIState{
+		Items:       []search.FoundItem{{Path: "secret/app/a"}, {Path: "secret/app/b"}},
+		Roots:       []string{"secret/app/"},
+		restartWalk: func(roots []string) { restarted = roots; calls++ },
+	}
+	lister := func() ([]MountInfo, error) {
+		return []MountInfo{{Path: "kv/", Type: "kv", Version: "2", Secrets: 5}, {Path: "secret/", Type: "kv", Version: "1", Secrets: -1}}, nil
+	}
+	st.openMountPanel(lister, nil)
+	p := st.Panel
+	if p == nil || p.Checked[0] || !p.Checked[1] {
+		t.Fatalf("expected only secret/ preselected, got %#v", p)
+	}
+	if !strings.Contains(p.Lines[0], "v2") || !strings.Contains(p.Lines[0], "5 secrets") {
+		t.Fatalf("expected version and indexed count columns, got %q", p.Lines[0])
+	}
+	if !strings.Contains(p.Lines[1], "2 secrets") {
+		t.Fatalf("expected the streamed count without an index, got %q", p.Lines[1])
+	}
+	// Select kv/ as well: all mounts selected -> walk everything (nil roots)
+	handlePanelKey(tcell.NewEventKey(tcell.KeyRune, ' ', 0), st)
+	handlePanelKey(tcell.NewEventKey(tcell.KeyEnter, 0, 0), st)
+	if calls != 1 || restarted != nil {
+		t.Fatalf("expected restart with all mounts, got calls=%d roots=%v", calls, restarted)
+	}
+}
+
+func TestRenderAll_DrawsPanelOverPanes(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	s.SetSize(60, 10)
+	st := &UIState{PreviewCache: map[string]string{}, PreviewErr: map[string]error{}}
+	st.openPanel(&Panel{Title: "Mounts", Lines: []string{"kv/  kv v2"}})
+	RenderAll(s, false, nil, nil, nil, st)
+	if ln := readLine(s, 2, 60); !strings.HasPrefix(ln, "Mounts") {
+		t.Fatalf("expected panel title on first content row, got %q", ln)
+	}
+	if ln := readLine(s, 4, 60); !strings.Contains(ln, "kv/  kv v2") {
+		t.Fatalf("expected panel line, got %q", ln)
+	}
+}
@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// pipeWait keeps the command's output on the terminal until the user is done
+// reading it; replaced in tests.
+var pipeWait = func() {
+	fmt.Fprint(os.Stderr, "\n[fvf: press Enter to return]")
+	bufio.NewReader(os.Stdin).ReadString('\n')
+}
+
+// openPipePrompt asks for a command and pipes the selected secret into it
+// ('|'), pre-filled with the previous command.
+func (st *UIState) openPipePrompt(s tcell.Screen, fetcher ValueFetcher) {
+	if st.Cursor < 0 || st.Cursor >= len(st.Filtered) {
+		return
+	}
+	p := st.Filtered[st.Cursor].Path
+	st.openPrompt("pipe "+p+" to", st.lastPipe, nil, func(cmdline string) {
+		if cmdline == "" {
+			return
+		}
+		st.lastPipe = cmdline
+		st.pipeSecret(s, p, cmdline, fetcher)
+	})
+}
+
+// pipeSecret runs cmdline through sh -c with the value of secretPath on
+// stdin, in the format the preview shows (table or JSON). The screen is
+// suspended so the command owns the terminal for its output.
+func (st *UIState) pipeSecret(s tcell.Screen, secretPath, cmdline string, fetcher ValueFetcher) {
+	if err := st.PreviewErr[secretPath]; err != nil {
+		// The cached preview is an error placeholder, not the secret
+		st.showToast("pipe: "+err.Error(), true)
+		return
+	}
+	val, ok := st.PreviewCache[secretPath]
+	if !ok {
+		var err error
+		switch {
+		case fetcher != nil:
+			// Like Enter, an explicit pipe reads past the budget but uses it up
+			st.guard.take(true)
+			if val, err = fetcher(secretPath); err == nil {
+				st.cachePreview(secretPath, val, nil)
+			}
+		case st.Cursor < len(st.Filtered) && st.Filtered[st.Cursor].Value != nil:
+			b, _ := json.Marshal(st.Filtered[st.Cursor].Value)
+			val = string(b)
+		default:
+			err = fmt.Errorf("values are not available")
+		}
+		if err != nil {
+			st.showToast("pipe: "+err.Error(), true)
+			return
+		}
+	}
+	val = inPreviewFormat(val, st.JSONPreview)
+	if !strings.HasSuffix(val, "\n") {
+		val += "\n"
+	}
+
+	if err := s.Suspend(); err != nil {
+		st.showToast("pipe: "+err.Error(), true)
+		return
+	}
+	cmd := exec.Command("sh", "-c", cmdline)
+	cmd.Env = append(os.Environ(), "FVF_PATH="+secretPath)
+	cmd.Stdin = strings.NewReader(val)
+	out, release := commandOutput()
+	defer release()
+	cmd.Stdout, cmd.Stderr = out, os.Stderr
+	err := cmd.Run()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\nfvf: %s: %v", cmdline, err)
+	}
+	pipeWait()
+	if rerr := s.Resume(); rerr != nil && err == nil {
+		err = rerr
+	}
+	s.Sync()
+	if err != nil {
+		st.showToast("pipe: "+err.Error(), true)
+	}
+}
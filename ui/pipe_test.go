@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fvf/search"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestPipePrompt_PipesPreviewFormat(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	waited := 0
+	old := pipeWait
+	pipeWait = func() { waited++ }
+	defer func() { pipeWait = old }()
+
+	out := filepath.Join(t.TempDir(), "out")
+	st := &UIState{PreviewCache: map[string]string{}, PreviewErr: map[string]error{}}
+	st.Items = []search.FoundItem{{Path: "kv/app/db"}}
+	st.ApplyFilter()
+	fetcher := func(p string) (string, error) { return `{"user":"bob"}`, nil }
+	key := func(ev *tcell.EventKey) {
+		HandleKey(s, ev, &st.Items, &st.Filtered, &st.Query, &st.Cursor, &st.Offset, st.PreviewCache, fetcher, st, st.ApplyFilter, nil)
+	}
+
+	key(tcell.NewEventKey(tcell.KeyRune, '|', tcell.ModNone))
+	if st.Prompt == nil || st.Query != "" {
+		t.Fatalf("'|' should open a prompt, not filter (query %q)", st.Query)
+	}
+	for _, r := range "cat > " + out {
+		key(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone))
+	}
+	key(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "user: bob\n" {
+		t.Fatalf("table mode piped %q", b)
+	}
+	if waited != 1 || st.Toast != nil {
+		t.Fatalf("waited %d, toast %+v", waited, st.Toast)
+	}
+
+	// JSON preview pipes JSON; the previous command is offered again
+	st.JSONPreview = true
+	key(tcell.NewEventKey(tcell.KeyRune, '|', tcell.ModNone))
+	if st.Prompt == nil || st.Prompt.Input != "cat > "+out {
+		t.Fatalf("prompt %+v", st.Prompt)
+	}
+	key(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+	if b, _ := os.ReadFile(out); string(b) != "{\"user\":\"bob\"}\n" {
+		t.Fatalf("JSON mode piped %q", b)
+	}
+
+	// A failed read is refused rather than piping its error placeholder
+	os.Remove(out)
+	st.cachePreview("kv/app/db", "(error fetching values) permission denied", errors.New("permission denied"))
+	st.pipeSecret(s, "kv/app/db", "cat > "+out, fetcher)
+	if _, err := os.Stat(out); err == nil || st.Toast == nil || !st.Toast.Err {
+		t.Fatalf("expected the read error as a toast and no command run, got %+v", st.Toast)
+	}
+}
@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fvf/search"
+)
+
+// PolicyReader returns the HCL rules of an ACL policy (sys/policies/acl/<name>).
+type PolicyReader func(name string) (string, error)
+
+// matchingPolicyLines returns the line indexes of every path block in hcl
+// whose pattern covers one of paths, braces included.
+func matchingPolicyLines(hcl string, paths []string) map[int]bool {
+	out := make(map[int]bool)
+	for _, r := range search.ParsePolicyRules(hcl) {
+		for _, p := range paths {
+			if search.PolicyGlobMatch(r.Path, p) {
+				for j := r.Start; j <= r.End; j++ {
+					out[j] = true
+				}
+				break
+			}
+		}
+	}
+	return out
+}
+
+// openPolicyPanel lists the policies shown in the preview; policies with rules
+// covering the current secret are highlighted. Enter opens the policy's HCL in
+// a scrollable viewer with the matching rules highlighted.
+func (st *UIState) openPolicyPanel(reader PolicyReader) {
+	if reader == nil || len(st.PreviewPolicies) == 0 || st.Cursor < 0 || st.Cursor >= len(st.Filtered) {
+		return
+	}
+	secret := st.Filtered[st.Cursor].Path
+	paths := search.PolicyAPIPaths(secret)
+	names := append([]string{}, st.PreviewPolicies...)
+	rules := make(map[string]string, len(names))
+	highlight := make(map[int]bool)
+	for i, n := range names {
+		hcl, err := reader(n)
+		if err != nil {
+			rules[n] = fmt.Sprintf("# error reading policy %q: %v", n, err)
+			continue
+		}
+		rules[n] = hcl
+		if len(matchingPolicyLines(hcl, paths)) > 0 {
+			highlight[i] = true
+		}
+	}
+	list := &Panel{
+		Title:     "Policies for " + secret + " (rules covering it highlighted; Enter: view, Esc: close)",
+		Lines:     names,
+		Highlight: highlight,
+	}
+	list.Submit = func(p *Panel) {
+		if p.Cursor < 0 || p.Cursor >= len(names) {
+			return
+		}
+		name := names[p.Cursor]
+		hcl := rules[name]
+		match := matchingPolicyLines(hcl, paths)
+		first := 0
+		for i := range strings.Split(hcl, "\n") {
+			if match[i] {
+				first = i
+				break
+			}
+		}
+		st.openPanel(&Panel{
+			Title:     "Policy " + name + " (Esc: back)",
+			Lines:     strings.Split(hcl, "\n"),
+			Cursor:    first,
+			Highlight: match,
+			Cancel:    func() { st.openPanel(list) },
+		})
+	}
+	st.openPanel(list)
+}
+
+// cyclePolicyPane steps the policies section through half, quarter and hidden.
+func (st *UIState) cyclePolicyPane() {
+	switch {
+	case st.PolicyPane < 0:
+		st.PolicyPane = 50
+	case st.PolicyPane == 0 || st.PolicyPane > 25:
+		st.PolicyPane = 25
+	default:
+		st.PolicyPane = -1
+	}
+}
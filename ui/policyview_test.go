@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"fvf/search"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const testPolicyHCL = `# app policy
+path "kv/data/app/*" {
+  capabilities = ["read"]
+}
+
+path "kv/data/other/*" {
+  capabilities = ["read", "list"]
+}
+path "sys/health" { capabilities = ["read"] }`
+
+func TestMatchingPolicyLines_WholeBlock(t *testing.T) {
+	got := matchingPolicyLines(testPolicyHCL, search.PolicyAPIPaths("kv/app/db"))
+	if len(got) != 3 || !got[1] || !got[2] || !got[3] {
+		t.Fatalf("expected lines 1-3 highlighted, got %v", got)
+	}
+	got = matchingPolicyLines(testPolicyHCL, []string{"sys/health"})
+	if len(got) != 1 || !got[8] {
+		t.Fatalf("expected one-line block highlighted, got %v", got)
+	}
+}
+
+func TestHandleKey_CtrlPOpensPolicyViewer(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	st := &UIState{PreviewCache: map[string]string{}, PreviewErr: map[string]error{}}
+	st.Items = []search.FoundItem{{Path: "kv/app/db"}}
+	st.ApplyFilter()
+	st.PreviewPolicies = []string{"default", "app"}
+	st.policyReader = func(name string) (string, error) {
+		if name == "app" {
+			return testPolicyHCL, nil
+		}
+		return `path "auth/token/lookup-self" { capabilities = ["read"] }`, nil
+	}
+	key := func(k tcell.Key) {
+		HandleKey(s, tcell.NewEventKey(k, 0, tcell.ModNone), &st.Items, &st.Filtered, &st.Query, &st.Cursor, &st.Offset, st.PreviewCache, nil, st, st.ApplyFilter, nil)
+	}
+	key(tcell.KeyCtrlP)
+	if st.Panel == nil || st.Panel.Highlight[0] || !st.Panel.Highlight[1] {
+		t.Fatalf("expected policy list with 'app' highlighted, got %+v", st.Panel)
+	}
+	key(tcell.KeyDown)
+	key(tcell.KeyEnter)
+	if st.Panel == nil || !strings.HasPrefix(st.Panel.Title, "Policy app") || st.Panel.Cursor != 1 || !st.Panel.Highlight[2] {
+		t.Fatalf("expected HCL viewer on the first matching rule, got %+v", st.Panel)
+	}
+	key(tcell.KeyEscape)
+	if st.Panel == nil || st.Panel.Lines[0] != "default" {
+		t.Fatalf("Esc in the viewer should return to the policy list, got %+v", st.Panel)
+	}
+}
+
+func TestSplitPreviewAndCycle(t *testing.T) {
+	if s, p := splitPreview(7, 0); s != 3 || p != 4 {
+		t.Fatalf("default split should match the old half split, got %d/%d", s, p)
+	}
+	if s, p := splitPreview(20, 25); s != 15 || p != 5 {
+		t.Fatalf("quarter split got %d/%d", s, p)
+	}
+	if s, p := splitPreview(20, -1); s != 20 || p != 0 {
+		t.Fatalf("hidden split got %d/%d", s, p)
+	}
+	st := &UIState{}
+	var seen []int
+	for i := 0; i < 4; i++ {
+		st.cyclePolicyPane()
+		seen = append(seen, st.PolicyPane)
+	}
+	if seen[0] != 25 || seen[1] != -1 || seen[2] != 50 || seen[3] != 25 {
+		t.Fatalf("unexpected cycle: %v", seen)
+	}
+}
+
+func TestDrawPreview_HiddenPoliciesUseFullHeight(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	s.SetSize(40, 12)
+	items := []search.FoundItem{{Path: "kv/app", Value: map[string]interface{}{"user": "bob"}}}
+	drawPreviewWith(s, 0, 0, 40, 12, items, 0, true, false, "", []string{"default"}, false, true, previewView{PolicyPane: -1})
+	for y := 0; y < 12; y++ {
+		if strings.Contains(readLine(s, y, 40), "User Policies") {
+			t.Fatalf("policies section should be hidden, found on row %d", y)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+const (
+	// prefetchRadius is how many items above and below the cursor are fetched ahead.
+	prefetchRadius = 3
+	// prefetchWorkers bounds the concurrent background reads.
+	prefetchWorkers = 2
+)
+
+// ContextFetcher is a ValueFetcher whose read is abandoned when ctx is
+// cancelled, e.g. once the cursor has moved away from path.
+type ContextFetcher func(ctx context.Context, path string) (string, error)
+
+type prefetchResult struct {
+	val string
+	err error
+}
+
+// prefetcher reads preview values in the background on a small worker pool.
+// Only the most recent wish list is worked on, so scrolling quickly never
+// queues reads for items long left behind, and reads in flight for paths
+// that dropped out of the wish list are cancelled instead of filling the
+// cache. Results are handed to the event loop through drain; reset cancels
+// the reads still in flight and drops their results.
+type prefetcher struct {
+	mu       sync.Mutex
+	fetch    ContextFetcher
+	workers  int
+	running  int
+	gen      int
+	queue    []string
+	inflight map[string]context.CancelFunc
+	done     map[string]prefetchResult
+	wake     func()
+}
+
+func newPrefetcher(fetch ContextFetcher, workers int, wake func()) *prefetcher {
+	if wake == nil {
+		wake = func() {}
+	}
+	return &prefetcher{
+		fetch:    fetch,
+		workers:  workers,
+		inflight: make(map[string]context.CancelFunc),
+		done:     make(map[string]prefetchResult),
+		wake:     wake,
+	}
+}
+
+// want replaces the queue with paths (nearest first), skipping reads already
+// in flight or finished, cancels the reads in flight for paths no longer
+// wanted and starts workers up to the pool size.
+func (p *prefetcher) want(paths []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	wanted := make(map[string]bool, len(paths))
+	p.queue = p.queue[:0]
+	for _, path := range paths {
+		wanted[path] = true
+		if _, ok := p.done[path]; ok || p.inflight[path] != nil {
+			continue
+		}
+		p.queue = append(p.queue, path)
+	}
+	for path, cancel := range p.inflight {
+		if !wanted[path] {
+			cancel()
+		}
+	}
+	for p.running < p.workers && len(p.queue) > 0 {
+		p.running++
+		go p.work()
+	}
+}
+
+func (p *prefetcher) work() {
+	for {
+		p.mu.Lock()
+		if len(p.queue) == 0 {
+			p.running--
+			p.mu.Unlock()
+			return
+		}
+		path, gen := p.queue[0], p.gen
+		p.queue = p.queue[1:]
+		ctx, cancel := context.WithCancel(context.Background())
+		p.inflight[path] = cancel
+		p.mu.Unlock()
+
+		v, err := p.fetch(ctx, path)
+		// A cancelled read is dropped; the path is queued again if still wanted
+		abandoned := ctx.Err() != nil
+		cancel()
+		if err != nil && !abandoned {
+			slog.Warn("preview fetch failed", "path", path, "err", err)
+		}
+
+		p.mu.Lock()
+		delete(p.inflight, path)
+		if p.gen == gen && !abandoned {
+			p.done[path] = prefetchResult{val: v, err: err}
+		}
+		p.mu.Unlock()
+		p.wake()
+	}
+}
+
+// drain returns the reads finished since the last call.
+func (p *prefetcher) drain() map[string]prefetchResult {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := p.done
+	p.done = make(map[string]prefetchResult)
+	return out
+}
+
+// reset forgets the queue and finished reads and cancels the reads in
+// flight, e.g. after a namespace switch.
+func (p *prefetcher) reset() {
+	p.mu.Lock()
+	p.gen++
+	for _, cancel := range p.inflight {
+		cancel()
+	}
+	p.queue = p.queue[:0]
+	p.done = make(map[string]prefetchResult)
+	p.mu.Unlock()
+}
+
+// fetchAroundCursor merges finished background reads into the preview cache
+// and queues the uncached item under the cursor followed by its neighbours,
+// nearest first. Reads go through the context-aware fetcher when the caller
+// set one, so those the cursor has moved away from are cancelled, and through
+// the read guard, whose per-minute holds are retried once it has room again.
+// Under a per-minute budget only the item under the cursor is read, so the
+// budget and the audit log only see values the user actually looked at.
+func (st *UIState) fetchAroundCursor(fetcher ValueFetcher, wake func()) {
+	if fetcher == nil {
+		return
+	}
+	if st.prefetch == nil {
+		st.prefetch = newPrefetcher(st.guardedFetch(fetcher), prefetchWorkers, wake)
+	}
+	for path, r := range st.prefetch.drain() {
+		if _, ok := st.PreviewCache[path]; ok {
+			continue
+		}
+		if isGuarded(r.err) {
+			st.cachePreview(path, fmt.Sprintf("(%v)", r.err), r.err)
+			continue
+		}
+		if r.err != nil {
+			st.cachePreview(path, fmt.Sprintf("(error fetching values) %v", r.err), r.err)
+			continue
+		}
+		st.cachePreview(path, r.val, nil)
+	}
+	uncached := func(path string) bool {
+		if _, ok := st.PreviewCache[path]; !ok {
+			return true
+		}
+		if errors.Is(st.PreviewErr[path], errReadLimit) && st.guard.available() {
+			delete(st.PreviewCache, path)
+			delete(st.PreviewErr, path)
+			return true
+		}
+		return false
+	}
+	var paths []string
+	if st.Cursor >= 0 && st.Cursor < len(st.Filtered) {
+		if uncached(st.Filtered[st.Cursor].Path) {
+			paths = append(paths, st.Filtered[st.Cursor].Path)
+		}
+	}
+	radius := prefetchRadius
+	if st.guard.limitsReads() {
+		radius = 0
+	}
+	for d := 1; d <= radius; d++ {
+		for _, i := range []int{st.Cursor + d, st.Cursor - d} {
+			if i < 0 || i >= len(st.Filtered) {
+				continue
+			}
+			if uncached(st.Filtered[i].Path) {
+				paths = append(paths, st.Filtered[i].Path)
+			}
+		}
+	}
+	st.prefetch.want(paths)
+}
@@ -0,0 +1,155 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"fvf/search"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestFetchAroundCursor_FetchesInBackground(t *testing.T) {
+	st := &UIState{PreviewCache: map[string]string{}, PreviewErr: map[string]error{}}
+	for _, p := range []string{"kv/0", "kv/1", "kv/2", "kv/3", "kv/4", "kv/5", "kv/6", "kv/7", "kv/8", "kv/9"} {
+		st.Items = append(st.Items, search.FoundItem{Path: p})
+	}
+	st.ApplyFilter()
+	st.Cursor = 5
+	st.PreviewCache["kv/6"] = "cached"
+
+	var mu sync.Mutex
+	fetched := map[string]int{}
+	fetcher := func(p string) (string, error) {
+		mu.Lock()
+		fetched[p]++
+		mu.Unlock()
+		if p == "kv/3" {
+			return "", errors.New("permission denied")
+		}
+		return "v=" + p, nil
+	}
+	wake := make(chan struct{}, 16)
+	st.fetchAroundCursor(fetcher, func() { wake <- struct{}{} })
+	for i := 0; i < 6; i++ {
+		select {
+		case <-wake:
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for prefetch")
+		}
+	}
+	st.fetchAroundCursor(fetcher, nil)
+
+	for _, p := range []string{"kv/2", "kv/4", "kv/5", "kv/7", "kv/8"} {
+		if st.PreviewCache[p] != "v="+p {
+			t.Fatalf("cache[%s]=%q", p, st.PreviewCache[p])
+		}
+	}
+	if st.PreviewErr["kv/3"] == nil {
+		t.Fatal("fetch errors should be cached like foreground fetches")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if fetched["kv/6"] != 0 || fetched["kv/1"] != 0 || fetched["kv/9"] != 0 {
+		t.Fatalf("fetched outside the window or already cached: %v", fetched)
+	}
+	for p, n := range fetched {
+		if n != 1 {
+			t.Fatalf("%s fetched %d times", p, n)
+		}
+	}
+}
+
+func TestFetchAroundCursor_CancelsReadsLeftBehind(t *testing.T) {
+	st := &UIState{PreviewCache: map[string]string{}, PreviewErr: map[string]error{}}
+	for i := 0; i < 20; i++ {
+		st.Items = append(st.Items, search.FoundItem{Path: fmt.Sprintf("kv/%02d", i)})
+	}
+	st.ApplyFilter()
+
+	started, cancelled := make(chan struct{}), make(chan string, 20)
+	st.fetchCtx = func(ctx context.Context, p string) (string, error) {
+		if p == "kv/00" {
+			// A slow path: only returns once the read is abandoned
+			close(started)
+			<-ctx.Done()
+			cancelled <- p
+			return "", ctx.Err()
+		}
+		return "v=" + p, nil
+	}
+	fetcher := func(string) (string, error) {
+		t.Fatal("plain fetcher used although FetchContext is set")
+		return "", nil
+	}
+	wake := make(chan struct{}, 64)
+	st.fetchAroundCursor(fetcher, func() { wake <- struct{}{} })
+	<-started
+
+	// Moving far away cancels the slow read instead of waiting for it
+	st.Cursor = 15
+	st.fetchAroundCursor(fetcher, func() { wake <- struct{}{} })
+	select {
+	case p := <-cancelled:
+		if p != "kv/00" {
+			t.Fatalf("cancelled %s", p)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("read left behind was not cancelled")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for st.PreviewCache["kv/15"] == "" {
+		if time.Now().After(deadline) {
+			t.Fatal("read under the new cursor never finished")
+		}
+		<-wake
+		st.fetchAroundCursor(fetcher, nil)
+	}
+	if _, ok := st.PreviewCache["kv/00"]; ok || st.PreviewErr["kv/00"] != nil {
+		t.Fatal("a cancelled read must not fill the cache")
+	}
+}
+
+func TestRenderAll_ShowsLoadingUntilBackgroundFetchFinishes(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	st := &UIState{PreviewCache: map[string]string{}, PreviewErr: map[string]error{}, RevealAll: true}
+	st.Items = []search.FoundItem{{Path: "kv/app"}}
+	st.ApplyFilter()
+
+	release := make(chan struct{})
+	fetcher := func(p string) (string, error) {
+		<-release
+		return "user: alice", nil
+	}
+	RenderAll(s, true, fetcher, nil, nil, st)
+	if !screenContains(s, "(loading…)") {
+		t.Fatal("expected loading placeholder while the read is in flight")
+	}
+
+	close(release)
+	deadline := time.Now().Add(2 * time.Second)
+	for !screenContains(s, "alice") {
+		if time.Now().After(deadline) {
+			t.Fatal("value never replaced the placeholder")
+		}
+		time.Sleep(10 * time.Millisecond)
+		RenderAll(s, true, fetcher, nil, nil, st)
+	}
+}
+
+// screenContains reports whether any screen row contains sub.
+func screenContains(s tcell.Screen, sub string) bool {
+	w, h := s.Size()
+	for y := 0; y < h; y++ {
+		if strings.Contains(readLine(s, y, w), sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// PromptState is a single-line input shown in place of the query prompt.
+// Submit receives the trimmed input on Enter; Esc closes the prompt without calling it.
+type PromptState struct {
+	Label       string
+	Input       string
+	Suggestions []string
+	Submit      func(input string)
+}
+
+// openPrompt replaces any active prompt with a new one.
+func (st *UIState) openPrompt(label, initial string, suggestions []string, submit func(string)) {
+	st.Prompt = &PromptState{Label: label, Input: initial, Suggestions: suggestions, Submit: submit}
+}
+
+// handlePromptKey edits the active prompt in place.
+func handlePromptKey(ev *tcell.EventKey, st *UIState) {
+	p := st.Prompt
+	if p == nil {
+		return
+	}
+	switch ev.Key() {
+	case tcell.KeyEscape, tcell.KeyCtrlC:
+		st.Prompt = nil
+	case tcell.KeyEnter:
+		st.Prompt = nil
+		if p.Submit != nil {
+			p.Submit(strings.TrimSpace(p.Input))
+		}
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if r := []rune(p.Input); len(r) > 0 {
+			p.Input = string(r[:len(r)-1])
+		}
+	case tcell.KeyTAB:
+		// Complete to the first suggestion extending the current input
+		if m := p.matching(); len(m) > 0 {
+			p.Input = m[0]
+		}
+	case tcell.KeyRune:
+		p.Input += string(ev.Rune())
+	}
+}
+
+// matching returns suggestions that start with the current input.
+func (p *PromptState) matching() []string {
+	out := make([]string, 0, len(p.Suggestions))
+	for _, sg := range p.Suggestions {
+		if strings.HasPrefix(sg, p.Input) && sg != p.Input {
+			out = append(out, sg)
+		}
+	}
+	return out
+}
+
+// line renders the prompt as shown on the first screen row.
+func (p *PromptState) line() string {
+	return p.Label + "> " + p.Input
+}
+
+// hint renders matching suggestions for the second screen row.
+func (p *PromptState) hint() string {
+	m := p.matching()
+	if len(m) == 0 {
+		return "(Enter: apply, Esc: cancel)"
+	}
+	return "Tab: " + strings.Join(m, "  ")
+}
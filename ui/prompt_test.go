@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"testing"
+
+	"fvf/search"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestPrompt_EditCompleteAndSubmit(t *testing.T) {
+	st := &UIState{}
+	var submitted string
+	st.openPrompt("root", "", []string{"kv/", "secret/"}, func(in string) { submitted = in })
+
+	handlePromptKey(tcell.NewEventKey(tcell.KeyRune, 's', 0), st)
+	handlePromptKey(tcell.NewEventKey(tcell.KeyTAB, 0, 0), st)
+	if st.Prompt.Input != "secret/" {
+		t.Fatalf("expected Tab to complete to secret/, got %q", st.Prompt.Input)
+	}
+	handlePromptKey(tcell.NewEventKey(tcell.KeyRune, 'x', 0), st)
+	handlePromptKey(tcell.NewEventKey(tcell.KeyBackspace2, 0, 0), st)
+	handlePromptKey(tcell.NewEventKey(tcell.KeyEnter, 0, 0), st)
+	if st.Prompt != nil {
+		t.Fatal("expected prompt to close on Enter")
+	}
+	if submitted != "secret/" {
+		t.Fatalf("submitted %q, want secret/", submitted)
+	}
+}
+
+func TestPrompt_EscapeCancels(t *testing.T) {
+	st := &UIState{}
+	called := false
+	st.openPrompt("root", "kv/", nil, func(string) { called = true })
+	handlePromptKey(tcell.NewEventKey(tcell.KeyEscape, 0, 0), st)
+	if st.Prompt != nil || called {
+		t.Fatalf("expected Esc to close without submit; prompt=%v called=%v", st.Prompt, called)
+	}
+}
+
+func TestHandleKey_CtrlGRestartsAtNewRoot(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+
+	var gotRoots []string
+	st := &UIState{
+		Items:       []search.FoundItem{{Path: "kv/a"}, {Path: "secret/b"}},
+		restartWalk: func(roots []string) { gotRoots = roots },
+	}
+	_, quit := HandleKey(s, tcell.NewEventKey(tcell.KeyCtrlG, 0, tcell.ModCtrl), &st.Items, &st.Filtered, &st.Query, &st.Cursor, &st.Offset, map[string]string{}, nil, st, func() {}, nil)
+	if quit || st.Prompt == nil {
+		t.Fatal("expected Ctrl-G to open the root prompt")
+	}
+	if len(st.Prompt.Suggestions) != 2 {
+		t.Fatalf("expected mount suggestions, got %v", st.Prompt.Suggestions)
+	}
+	for _, r := range "kv/app/" {
+		HandleKey(s, tcell.NewEventKey(tcell.KeyRune, r, 0), &st.Items, &st.Filtered, &st.Query, &st.Cursor, &st.Offset, map[string]string{}, nil, st, func() {}, nil)
+	}
+	HandleKey(s, tcell.NewEventKey(tcell.KeyEnter, 0, 0), &st.Items, &st.Filtered, &st.Query, &st.Cursor, &st.Offset, map[string]string{}, nil, st, func() {}, nil)
+	if len(gotRoots) != 1 || gotRoots[0] != "kv/app/" {
+		t.Fatalf("expected restart at kv/app/, got %v", gotRoots)
+	}
+	if st.Query != "" {
+		t.Fatalf("prompt input must not leak into the query, got %q", st.Query)
+	}
+}
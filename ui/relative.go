@@ -0,0 +1,32 @@
+package ui
+
+import "strings"
+
+// RelativePath strips the longest of roots that p lies below, so kv/app/config/db
+// under the root kv/app/ shows as config/db. Paths outside every root, and a
+// root that is itself the secret, are returned unchanged.
+func RelativePath(p string, roots []string) string {
+	best := ""
+	for _, r := range roots {
+		r = strings.Trim(strings.TrimSpace(r), "/")
+		if r == "" {
+			continue
+		}
+		if strings.HasPrefix(p, r+"/") && len(r) > len(best) && len(p) > len(r)+1 {
+			best = r
+		}
+	}
+	if best == "" {
+		return p
+	}
+	return p[len(best)+1:]
+}
+
+// displayRoots are the roots list paths are shown relative to; nil while
+// full paths are shown.
+func (st *UIState) displayRoots() []string {
+	if !st.RelativePaths {
+		return nil
+	}
+	return st.Roots
+}
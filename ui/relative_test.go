@@ -0,0 +1,21 @@
+package ui
+
+import "testing"
+
+func TestRelativePath(t *testing.T) {
+	roots := []string{"kv/", "kv/app/", " secret/team "}
+	for p, want := range map[string]string{
+		"kv/app/config/db": "config/db", // longest root wins
+		"kv/other":         "other",
+		"secret/team/x":    "x",
+		"secret/teamx/y":   "secret/teamx/y",
+		"kv/app":           "app",
+	} {
+		if got := RelativePath(p, roots); got != want {
+			t.Errorf("RelativePath(%q) = %q, want %q", p, got, want)
+		}
+	}
+	if got := RelativePath("kv/app/db", nil); got != "kv/app/db" {
+		t.Errorf("no roots: %q", got)
+	}
+}
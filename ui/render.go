@@ -25,8 +25,29 @@ func RenderAll(
 
 	s.Clear()
 	w, h := s.Size()
+	status = uiState.statusBar(status)
+	uiState.LiveRefresh = false
+	defer func() {
+		// Toasts replace the status bar until they expire
+		if t := uiState.activeToast(time.Now()); t != nil {
+			drawToast(s, 0, h-1, w, t)
+			uiState.LiveRefresh = true
+			s.Show()
+		}
+	}()
+
+	uiState.checkLock(time.Now())
+	if uiState.Locked {
+		drawLockScreen(s, w, h)
+		drawStatusBar(s, 0, h-1, w, status)
+		s.Show()
+		return
+	}
 
 	prompt := "> " + uiState.Query
+	if uiState.Prompt != nil {
+		prompt = uiState.Prompt.line()
+	}
 	putLine(s, 0, 0, prompt)
 
 	wrapState := "off"
@@ -37,7 +58,27 @@ func RenderAll(
 	if uiState.MouseEnabled {
 		mouseState = "on"
 	}
-	help := fmt.Sprintf("%d/%d  (Up/Down: move, Enter: select, Tab: wrap[%s], Left: mouse[%s], Right: reveal/hide, Esc: quit)", len(uiState.Filtered), len(uiState.Items), wrapState, mouseState)
+	keys := fmt.Sprintf("(Up/Down: move, Enter: select, Alt-Enter: path, Alt-1..9: tabs, Tab: wrap[%s], Left: mouse[%s], Right: reveal/hide, Shift-Up/Down+Shift-Right: reveal key, Alt-g/c: group/collapse, Alt-p: relative paths, Alt-n: line numbers, Alt-e: report, Alt-v: force read, :N: go to row N, Ctrl-Space: select, Ctrl-K: copy/move, Alt-y/Y: copy key/secret, Ctrl-X: hex, Ctrl-E: errors, Ctrl-P: policies, Ctrl-A: audit, Ctrl-W: share, Ctrl-V: versions, Ctrl-D: metadata, |: pipe, Ctrl-L: policy pane, Ctrl-T: renew, Ctrl-G: root, Ctrl-O: mounts, Ctrl-N: namespace, Esc: quit)", wrapState, mouseState)
+	counts := fmt.Sprintf("%d/%d", len(uiState.Filtered), len(uiState.Items))
+	if len(uiState.Roots) > 0 {
+		counts += " [" + strings.Join(uiState.Roots, ",") + "]"
+	}
+	if uiState.Namespace != "" {
+		counts += " ns:" + uiState.Namespace
+	}
+	if n := len(uiState.Selected); n > 0 {
+		counts += fmt.Sprintf(" (%d selected)", n)
+	}
+	if badge := uiState.walkErrorBadge(); badge != "" {
+		counts += " " + badge
+	}
+	if tabs := uiState.tabBar(); tabs != "" {
+		counts = tabs + "  " + counts
+	}
+	help := counts + "  " + keys
+	if uiState.Prompt != nil {
+		help = uiState.Prompt.hint()
+	}
 	putLine(s, 0, 1, help)
 
 	contentTop := 2
@@ -49,36 +90,57 @@ func RenderAll(
 		return
 	}
 
+	if uiState.Panel != nil {
+		drawPanel(s, 0, contentTop, w, maxRows, uiState.Panel)
+		drawStatusBar(s, 0, h-1, w, status)
+		s.Show()
+		return
+	}
+
 	leftW := computeLeftWidth(w)
 	rightX := leftW
 
 	drawVerticalSeparator(s, rightX, h)
 
-	if uiState.Cursor < uiState.Offset {
-		uiState.Offset = uiState.Cursor
-	}
-	if uiState.Cursor >= uiState.Offset+maxRows {
-		uiState.Offset = uiState.Cursor - maxRows + 1
+	if uiState.Grouped {
+		rows := groupRows(uiState.Filtered, uiState.Collapsed)
+		cr := cursorRow(rows, uiState.Cursor, uiState.Collapsed)
+		if cr < uiState.Offset {
+			uiState.Offset = cr
+			if cr > 0 && rows[cr-1].mount != "" {
+				// Keep the mount header of the first item in view
+				uiState.Offset--
+			}
+		}
+		if cr >= uiState.Offset+maxRows {
+			uiState.Offset = cr - maxRows + 1
+		}
+		drawGroupedList(s, contentTop, leftW, w, uiState.Filtered, rows, uiState.Collapsed, uiState.filterText(), cr, uiState.Offset, maxRows, uiState.Selected, uiState.displayRoots())
+	} else {
+		if uiState.Cursor < uiState.Offset {
+			uiState.Offset = uiState.Cursor
+		}
+		if uiState.Cursor >= uiState.Offset+maxRows {
+			uiState.Offset = uiState.Cursor - maxRows + 1
+		}
+		drawLeftList(s, contentTop, leftW, w, uiState.Filtered, uiState.filterText(), uiState.Cursor, uiState.Offset, maxRows, uiState.Selected, uiState.displayRoots(), uiState.LineNumbers)
 	}
-	drawLeftList(s, contentTop, leftW, w, uiState.Filtered, strings.TrimSpace(uiState.Query), uiState.Cursor, uiState.Offset, maxRows)
 
 	if rightX+1 < w && maxRows > 0 {
 		var val string
 		var policies []string
+		loading := false
+		if printValues {
+			// Values are read in the background; a finished read posts an interrupt
+			uiState.fetchAroundCursor(fetcher, func() { s.PostEvent(tcell.NewEventInterrupt(nil)) })
+		}
 		if len(uiState.Filtered) > 0 && uiState.Cursor >= 0 && uiState.Cursor < len(uiState.Filtered) {
 			p := uiState.Filtered[uiState.Cursor].Path
 			if cached, ok := uiState.PreviewCache[p]; ok {
 				val = cached
+				uiState.touchPreview(p)
 			} else if fetcher != nil && printValues {
-				if v, err := fetcher(p); err == nil {
-					val = v
-					uiState.PreviewCache[p] = v
-				} else {
-					msg := fmt.Sprintf("(error fetching values) %v", err)
-					uiState.PreviewCache[p] = msg
-					uiState.PreviewErr[p] = err
-					val = msg
-				}
+				loading = true
 			}
 
 			// Fetch policies if policy fetcher is available
@@ -88,13 +150,21 @@ func RenderAll(
 				}
 			}
 		}
-		drawPreview(s, rightX+1, contentTop, w-(rightX+1), maxRows, uiState.Filtered, uiState.Cursor, printValues, uiState.JSONPreview, val, policies, uiState.PreviewWrap, uiState.RevealAll)
+		uiState.PreviewPolicies = policies
+		view := previewView{Revealed: uiState.RevealedKeys, FocusKey: uiState.FocusKey, Hex: uiState.HexView, PolicyPane: uiState.PolicyPane, Loading: loading}
+		if len(uiState.Filtered) > 0 && uiState.Cursor >= 0 && uiState.Cursor < len(uiState.Filtered) {
+			cur := uiState.Filtered[uiState.Cursor].Path
+			view.Meta = uiState.previewMetadata(s, cur)
+			view.Badges = strings.TrimSpace(uiState.previewKVBadge(cur) + " " + uiState.previewCapabilities(s, cur))
+		}
+		drawPreviewWith(s, rightX+1, contentTop, w-(rightX+1), maxRows, uiState.Filtered, uiState.Cursor, printValues, uiState.JSONPreview, val, policies, uiState.PreviewWrap, uiState.RevealAll, view)
 
 		// Remember current fetched value for header copy button
 		uiState.CurrentFetchedVal = val
 
 		// Draw per-secret copy buttons (right-aligned) when values are shown
 		uiState.PerLineCopyBtns = uiState.PerLineCopyBtns[:0]
+		uiState.PreviewKeys = uiState.PreviewKeys[:0]
 		if printValues {
 			var kv map[string]string
 			if uiState.JSONPreview && isLikelyJSON(val) {
@@ -107,7 +177,18 @@ func RenderAll(
 			if kv == nil {
 				kv = toKVFromLines(val)
 			}
+			// Table mode adds derived TOTP rows; their copy button copies the bare code
+			var totpCodes map[string]string
+			rawKV := kv
+			if !uiState.JSONPreview {
+				kv, totpCodes = addTOTPRows(kv, time.Now())
+				uiState.LiveRefresh = len(totpCodes) > 0
+			}
 			if len(kv) > 0 {
+				for k := range kv {
+					uiState.PreviewKeys = append(uiState.PreviewKeys, k)
+				}
+				sortStrings(uiState.PreviewKeys)
 				// If JSON preview is active, ensure header copy uses JSON text
 				if uiState.JSONPreview {
 					if isLikelyJSON(val) {
@@ -119,13 +200,13 @@ func RenderAll(
 					}
 				}
 				// Recompute layout similar to drawPreview's top section
-				headerHeight := 1
+				headerHeight := previewHeaderHeight(maxRows, view.Meta)
 				separatorHeight := 1
 				availableHeight := maxRows - headerHeight - separatorHeight
 				if availableHeight < 0 {
 					availableHeight = 0
 				}
-				secretsHeight := availableHeight / 2
+				secretsHeight, _ := splitPreview(availableHeight, view.PolicyPane)
 				secretsY := contentTop + headerHeight + separatorHeight
 
 				// Determine the visual line indices for each key depending on preview mode
@@ -150,7 +231,7 @@ func RenderAll(
 				}
 				// Fallback to table lines (non-JSON preview)
 				if len(visualLines) == 0 {
-					visualLines = renderKVTable(kv)
+					visualLines = previewTableLines(rawKV, uiState.RevealAll, view, time.Now())
 					// Apply the same wrapping used by drawPreview for table mode
 					if uiState.PreviewWrap && len(visualLines) > 1 {
 						head := visualLines[:1]
@@ -181,25 +262,7 @@ func RenderAll(
 				}
 				for i := 0; i < searchLimit; i++ {
 					ln := visualLines[i]
-					var key string
-					if uiState.JSONPreview {
-						// Extract key from JSON line pattern: optional spaces + "key":
-						// Simple heuristic: find first '"', then next '"', and ensure following ':' exists
-						if p1 := strings.Index(ln, "\""); p1 != -1 {
-							if p2 := strings.Index(ln[p1+1:], "\""); p2 != -1 {
-								candidate := ln[p1+1 : p1+1+p2]
-								rest := ln[p1+1+p2+1:]
-								if strings.Contains(rest, ":") {
-									key = candidate
-								}
-							}
-						}
-					} else {
-						// Table mode: take left side before ':' and trim spaces (handles padding)
-						if idx := strings.Index(ln, ":"); idx != -1 {
-							key = strings.TrimSpace(ln[:idx])
-						}
-					}
+					key := keyOfLine(ln, uiState.JSONPreview)
 					if key == "" {
 						continue
 					}
@@ -207,6 +270,9 @@ func RenderAll(
 					if !ok {
 						continue
 					}
+					if code, ok := totpCodes[key]; ok {
+						valToCopy = code
+					}
 					y := secretsY + i
 
 					lbl := baseLabel
@@ -0,0 +1,26 @@
+package ui
+
+import (
+	"log/slog"
+	"time"
+)
+
+// TokenRenewer renews the session token (auth/token/renew-self) and returns the new TTL.
+type TokenRenewer func() (time.Duration, error)
+
+// renewToken runs the renewer bound to Ctrl-T and reports the outcome as a toast.
+func (st *UIState) renewToken() {
+	if st.renew == nil {
+		return
+	}
+	ttl, err := st.renew()
+	if err != nil {
+		slog.Warn("token renew failed", "err", err)
+		st.showToast("token renew failed: "+err.Error(), true)
+		return
+	}
+	// Capabilities may change with the renewed token's policies
+	st.CapsCache = nil
+	st.capsLookups.reset()
+	st.showToast("token renewed, TTL "+ttl.Round(time.Second).String(), false)
+}
@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestHandleKey_CtrlTRenewsAndToasts(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	calls := 0
+	st := &UIState{PreviewCache: map[string]string{}, PreviewErr: map[string]error{}}
+	st.renew = func() (time.Duration, error) {
+		calls++
+		return 90 * time.Minute, nil
+	}
+	ev := tcell.NewEventKey(tcell.KeyCtrlT, 0, tcell.ModCtrl)
+	HandleKey(s, ev, &st.Items, &st.Filtered, &st.Query, &st.Cursor, &st.Offset, st.PreviewCache, nil, st, st.ApplyFilter, nil)
+	if calls != 1 || st.Toast == nil || st.Toast.Err || !strings.Contains(st.Toast.Text, "1h30m0s") {
+		t.Fatalf("expected success toast after renew, calls=%d toast=%+v", calls, st.Toast)
+	}
+
+	st.renew = func() (time.Duration, error) { return 0, errors.New("lease is not renewable") }
+	HandleKey(s, ev, &st.Items, &st.Filtered, &st.Query, &st.Cursor, &st.Offset, st.PreviewCache, nil, st, st.ApplyFilter, nil)
+	if st.Toast == nil || !st.Toast.Err || !strings.Contains(st.Toast.Text, "not renewable") {
+		t.Fatalf("expected error toast, got %+v", st.Toast)
+	}
+}
+
+func TestRenderAll_ToastOverStatusBarUntilExpired(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	s.SetSize(60, 10)
+	st := &UIState{PreviewCache: map[string]string{}, PreviewErr: map[string]error{}}
+	status := func() (string, string, string) { return "TTL: 1h", "addr", "fvf" }
+
+	st.showToast("token renewed", false)
+	RenderAll(s, false, nil, nil, status, st)
+	if ln := readLine(s, 9, 60); !strings.Contains(ln, "token renewed") || !st.LiveRefresh {
+		t.Fatalf("expected toast on status row with live refresh, got %q live=%v", ln, st.LiveRefresh)
+	}
+
+	st.Toast.Until = time.Now().Add(-time.Second)
+	RenderAll(s, false, nil, nil, status, st)
+	if ln := readLine(s, 9, 60); !strings.Contains(ln, "TTL: 1h") || st.Toast != nil || st.LiveRefresh {
+		t.Fatalf("expected status bar back after toast expiry, got %q", ln)
+	}
+}
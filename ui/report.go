@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+)
+
+// ReportWriter writes a report on paths to file; query is the filter the
+// paths were found with, for the report's header.
+type ReportWriter func(file, query string, paths []string) error
+
+// defaultReportFile is offered by the report prompt; a .html name writes HTML.
+const defaultReportFile = "fvf-report.md"
+
+// reportPaths are the secrets a report covers: the multi-selection, or every
+// result of the current filter when nothing is selected.
+func (st *UIState) reportPaths() []string {
+	if len(st.Selected) > 0 {
+		return st.selectedPaths()
+	}
+	out := make([]string, 0, len(st.Filtered))
+	for _, it := range st.Filtered {
+		out = append(out, it.Path)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// openReport asks for a file name and writes a report on the results (Alt-e).
+func (st *UIState) openReport() {
+	if st.report == nil {
+		return
+	}
+	paths := st.reportPaths()
+	if len(paths) == 0 {
+		st.showToast("nothing to report", true)
+		return
+	}
+	reads := 0
+	if st.reportReads {
+		reads = len(paths)
+	}
+	query := st.filterText()
+	st.openPrompt(fmt.Sprintf("report on %d secret(s) to (.md or .html)", len(paths)), defaultReportFile, nil, func(file string) {
+		if file == "" {
+			return
+		}
+		if err := st.guard.takeN(reads); err != nil {
+			st.showToast("report: "+err.Error(), true)
+			return
+		}
+		if err := st.report(file, query, paths); err != nil {
+			slog.Warn("report failed", "file", file, "err", err)
+			st.showToast("report failed: "+err.Error(), true)
+			return
+		}
+		st.showToast(fmt.Sprintf("wrote a report on %d secret(s) to %s", len(paths), file), false)
+	})
+}
@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"fvf/search"
+)
+
+func TestOpenReport_WritesFilteredResults(t *testing.T) {
+	var gotFile, gotQuery string
+	var gotPaths []string
+	st := &UIState{
+		Query: "app:2",
+		report: func(file, query string, paths []string) error {
+			gotFile, gotQuery, gotPaths = file, query, paths
+			return nil
+		},
+	}
+	st.AddItems([]search.FoundItem{{Path: "kv/web/app"}, {Path: "kv/app/db"}, {Path: "kv/other"}})
+	st.ApplyFilter()
+
+	st.openReport()
+	if st.Prompt == nil || st.Prompt.Input != defaultReportFile {
+		t.Fatalf("expected the file prompt, got %+v", st.Prompt)
+	}
+	st.Prompt.Submit("out.html")
+	if gotFile != "out.html" || gotQuery != "app" || !reflect.DeepEqual(gotPaths, []string{"kv/app/db", "kv/web/app"}) {
+		t.Fatalf("report(%q, %q, %v)", gotFile, gotQuery, gotPaths)
+	}
+	if st.Toast == nil || !strings.Contains(st.Toast.Text, "2 secret(s)") {
+		t.Fatalf("expected a success toast, got %+v", st.Toast)
+	}
+
+	// A selection narrows the report to the selected secrets
+	st.Selected = map[string]bool{"kv/web/app": true}
+	if got := st.reportPaths(); !reflect.DeepEqual(got, []string{"kv/web/app"}) {
+		t.Fatalf("selected report paths %v", got)
+	}
+}
@@ -0,0 +1,134 @@
+package ui
+
+import (
+	"strings"
+)
+
+// previewView carries per-frame preview options beyond the basic drawPreview inputs.
+type previewView struct {
+	// Revealed keys are shown in clear text even when RevealAll is off.
+	Revealed map[string]bool
+	// FocusKey is the secret key under the preview key cursor (highlighted).
+	FocusKey string
+	// Meta lines (KV v2 version/timestamps/custom_metadata) are shown under the path.
+	Meta []string
+	// Badges (e.g. "[read] [update]") are shown after the path.
+	Badges string
+	// Hex shows base64 binary values as hexdumps (table view only).
+	Hex bool
+	// PolicyPane is the policies share of the body in percent (see splitPreview).
+	PolicyPane int
+	// Loading shows a placeholder while the value is read in the background.
+	Loading bool
+}
+
+// maskKVExcept masks all values like maskKV except for keys in keep.
+func maskKVExcept(kv map[string]string, mask bool, keep map[string]bool) map[string]string {
+	if !mask || kv == nil || len(keep) == 0 {
+		return maskKV(kv, mask)
+	}
+	out := make(map[string]string, len(kv))
+	for k, v := range kv {
+		if keep[k] {
+			out[k] = v
+		} else {
+			out[k] = "***"
+		}
+	}
+	return out
+}
+
+// maskJSONExcept masks string values like maskJSONStrings, leaving top-level keys in keep untouched.
+func maskJSONExcept(v interface{}, mask bool, keep map[string]bool) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !mask || !ok || len(keep) == 0 {
+		return maskJSONStrings(v, mask)
+	}
+	out := make(map[string]interface{}, len(m))
+	for k, val := range m {
+		if keep[k] {
+			out[k] = val
+		} else {
+			out[k] = maskJSONStrings(val, true)
+		}
+	}
+	return out
+}
+
+// keyOfLine extracts the secret key rendered on a preview line, or "" for
+// continuation lines and non key/value lines.
+func keyOfLine(ln string, jsonMode bool) string {
+	if jsonMode {
+		// Pattern: optional spaces + "key":
+		if p1 := strings.Index(ln, "\""); p1 != -1 {
+			if p2 := strings.Index(ln[p1+1:], "\""); p2 != -1 {
+				candidate := ln[p1+1 : p1+1+p2]
+				rest := ln[p1+1+p2+1:]
+				if strings.HasPrefix(strings.TrimSpace(rest), ":") {
+					return candidate
+				}
+			}
+		}
+		return ""
+	}
+	// Table mode: left side before ':' with padding trimmed; continuation lines start with spaces
+	if strings.HasPrefix(ln, " ") {
+		return ""
+	}
+	if idx := strings.Index(ln, ":"); idx != -1 {
+		return strings.TrimSpace(ln[:idx])
+	}
+	return ""
+}
+
+// moveKeyFocus moves the preview key cursor by delta over the current secret's keys.
+func (st *UIState) moveKeyFocus(delta int) {
+	if len(st.PreviewKeys) == 0 {
+		st.FocusKey = ""
+		return
+	}
+	idx := -1
+	for i, k := range st.PreviewKeys {
+		if k == st.FocusKey {
+			idx = i
+			break
+		}
+	}
+	switch {
+	case idx == -1 && delta > 0:
+		idx = 0
+	case idx == -1:
+		idx = len(st.PreviewKeys) - 1
+	default:
+		idx += delta
+	}
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(st.PreviewKeys) {
+		idx = len(st.PreviewKeys) - 1
+	}
+	st.FocusKey = st.PreviewKeys[idx]
+}
+
+// toggleRevealFocused reveals or re-masks the key under the preview key cursor.
+func (st *UIState) toggleRevealFocused() {
+	if st.FocusKey == "" {
+		return
+	}
+	if st.RevealedKeys == nil {
+		st.RevealedKeys = make(map[string]bool)
+	}
+	if st.RevealedKeys[st.FocusKey] {
+		delete(st.RevealedKeys, st.FocusKey)
+	} else {
+		st.RevealedKeys[st.FocusKey] = true
+	}
+}
+
+// resetReveal re-masks everything; called whenever the selected secret changes.
+func (st *UIState) resetReveal() {
+	st.RevealAll = false
+	st.RevealedKeys = nil
+	st.FocusKey = ""
+}
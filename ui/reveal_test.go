@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"fvf/search"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestMaskKVExcept(t *testing.T) {
+	kv := map[string]string{"user": "alice", "password": "s3cr3t"}
+	out := maskKVExcept(kv, true, map[string]bool{"user": true})
+	if out["user"] != "alice" || out["password"] != "***" {
+		t.Fatalf("unexpected masking: %#v", out)
+	}
+	if got := maskKVExcept(kv, false, nil); got["password"] != "s3cr3t" {
+		t.Fatalf("mask=false must keep values, got %#v", got)
+	}
+}
+
+func TestKeyOfLine(t *testing.T) {
+	cases := []struct {
+		ln   string
+		json bool
+		want string
+	}{
+		{"user    : alice", false, "user"},
+		{"          continuation: x", false, ""},
+		{`  "password": "***",`, true, "password"},
+		{`  "x"`, true, ""},
+	}
+	for i, c := range cases {
+		if got := keyOfLine(c.ln, c.json); got != c.want {
+			t.Fatalf("case %d: keyOfLine(%q) = %q, want %q", i, c.ln, got, c.want)
+		}
+	}
+}
+
+func TestMoveKeyFocusAndToggle(t *testing.T) {
+	st := &UIState{PreviewKeys: []string{"a", "b", "c"}}
+	st.moveKeyFocus(1)
+	if st.FocusKey != "a" {
+		t.Fatalf("first Shift-Down should focus first key, got %q", st.FocusKey)
+	}
+	st.moveKeyFocus(5)
+	if st.FocusKey != "c" {
+		t.Fatalf("focus should clamp at last key, got %q", st.FocusKey)
+	}
+	st.toggleRevealFocused()
+	if !st.RevealedKeys["c"] {
+		t.Fatal("expected c revealed")
+	}
+	st.resetReveal()
+	if st.FocusKey != "" || st.RevealedKeys != nil || st.RevealAll {
+		t.Fatal("resetReveal must clear per-key state")
+	}
+}
+
+func TestDrawPreviewWith_RevealsSingleKey(t *testing.T) {
+	s := tcell.NewSimulationScreen("UTF-8")
+	if err := s.Init(); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	defer s.Fini()
+
+	filtered := []search.FoundItem{{Path: "secret/foo"}}
+	view := previewView{Revealed: map[string]bool{"user": true}, FocusKey: "user"}
+	drawPreviewWith(s, 0, 0, 40, 8, filtered, 0, true, false, "password: s3cr3t\nuser: alice", nil, false, false, view)
+
+	pw := readLine(s, 2, 40)
+	user := readLine(s, 3, 40)
+	if !strings.Contains(pw, "***") || strings.Contains(pw, "s3cr3t") {
+		t.Fatalf("password should stay masked, got %q", pw)
+	}
+	if !strings.Contains(user, "alice") {
+		t.Fatalf("user should be revealed, got %q", user)
+	}
+	if _, _, st, _ := s.GetContent(0, 3); st != tcell.StyleDefault.Reverse(true) {
+		t.Fatal("focused key line should be highlighted")
+	}
+}
@@ -0,0 +1,91 @@
+package ui
+
+import "sort"
+
+// Session is the part of an interactive session that -session keeps between
+// runs: every tab's name, query and roots, the active tab, the namespace, the
+// multi-selection and the list view toggles. Values and caches are not kept.
+type Session struct {
+	Tabs          []SessionTab `json:"tabs"`
+	TabIndex      int          `json:"tab_index,omitempty"`
+	Namespace     string       `json:"namespace,omitempty"`
+	Selected      []string     `json:"selected,omitempty"`
+	Grouped       bool         `json:"grouped,omitempty"`
+	RelativePaths bool         `json:"relative_paths,omitempty"`
+}
+
+// SessionTab is one saved query tab.
+type SessionTab struct {
+	Name  string   `json:"name,omitempty"`
+	Query string   `json:"query,omitempty"`
+	Roots []string `json:"roots,omitempty"`
+}
+
+// ActiveRoots returns the roots of the tab that was active, which the first
+// walk of a restored session starts from.
+func (s Session) ActiveRoots() []string {
+	if s.TabIndex < 0 || s.TabIndex >= len(s.Tabs) {
+		return nil
+	}
+	return s.Tabs[s.TabIndex].Roots
+}
+
+// session captures the current state, the live query standing in for the
+// active tab's saved one.
+func (st *UIState) session() Session {
+	s := Session{Namespace: st.Namespace, Grouped: st.Grouped, RelativePaths: st.RelativePaths}
+	if len(st.Tabs) == 0 {
+		s.Tabs = []SessionTab{{Query: st.Query, Roots: st.Roots}}
+	}
+	for i, t := range st.Tabs {
+		tab := SessionTab{Name: t.Name, Query: t.Query, Roots: t.Roots}
+		if i == st.TabIndex {
+			tab.Query, tab.Roots = st.Query, st.Roots
+		}
+		s.Tabs = append(s.Tabs, tab)
+	}
+	s.TabIndex = st.TabIndex
+	for p, on := range st.Selected {
+		if on {
+			s.Selected = append(s.Selected, p)
+		}
+	}
+	sort.Strings(s.Selected)
+	return s
+}
+
+// restoreSession applies a saved session before the first frame. The walk
+// is already streaming from the caller's roots, so the active tab takes
+// those; the other tabs walk their own roots when switched to.
+func (st *UIState) restoreSession(s Session) {
+	if len(s.Tabs) > 1 {
+		st.Tabs = make([]*Tab, len(s.Tabs))
+		for i, t := range s.Tabs {
+			st.Tabs[i] = &Tab{
+				Name:         t.Name,
+				Query:        t.Query,
+				Roots:        t.Roots,
+				PreviewCache: make(map[string]string),
+				PreviewErr:   make(map[string]error),
+			}
+		}
+		st.TabIndex = 0
+		if s.TabIndex > 0 && s.TabIndex < len(s.Tabs) {
+			st.TabIndex = s.TabIndex
+		}
+		active := st.Tabs[st.TabIndex]
+		active.Roots = st.Roots
+		active.PreviewCache, active.PreviewErr = st.PreviewCache, st.PreviewErr
+	}
+	if i := st.TabIndex; i >= 0 && i < len(s.Tabs) {
+		st.Query = s.Tabs[i].Query
+	}
+	if len(s.Selected) > 0 {
+		st.Selected = make(map[string]bool, len(s.Selected))
+		for _, p := range s.Selected {
+			st.Selected[p] = true
+		}
+	}
+	st.Grouped = st.Grouped || s.Grouped
+	st.RelativePaths = st.RelativePaths || s.RelativePaths
+}
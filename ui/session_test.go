@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSessionRoundTrip(t *testing.T) {
+	st := &UIState{Roots: []string{"kv/app"}, Query: "db", Namespace: "team/"}
+	st.ensureTabs()
+	st.switchTab(1) // new tab on the same roots
+	st.Query = "api"
+	st.Tabs[1].Name = "api keys"
+	st.Selected = map[string]bool{"kv/app/b": true, "kv/app/a": true, "kv/app/c": false}
+	st.Grouped = true
+
+	s := st.session()
+	want := Session{
+		Tabs: []SessionTab{
+			{Query: "db", Roots: []string{"kv/app"}},
+			{Name: "api keys", Query: "api", Roots: []string{"kv/app"}},
+		},
+		TabIndex:  1,
+		Namespace: "team/",
+		Selected:  []string{"kv/app/a", "kv/app/b"},
+		Grouped:   true,
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Fatalf("session\n got %+v\nwant %+v", s, want)
+	}
+
+	restored := &UIState{Roots: s.ActiveRoots(), PreviewCache: map[string]string{}}
+	restored.restoreSession(s)
+	if restored.Query != "api" || restored.TabIndex != 1 || len(restored.Tabs) != 2 || restored.Tabs[1].Name != "api keys" {
+		t.Fatalf("restored tabs: query %q index %d tabs %d", restored.Query, restored.TabIndex, len(restored.Tabs))
+	}
+	if !restored.Selected["kv/app/a"] || !restored.Selected["kv/app/b"] || !restored.Grouped {
+		t.Fatalf("restored selection %v grouped %v", restored.Selected, restored.Grouped)
+	}
+	// Switching back to the first tab brings its query back
+	restored.switchTab(0)
+	if restored.Query != "db" {
+		t.Fatalf("first tab query %q", restored.Query)
+	}
+}
@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// SecretSharer re-reads a secret with response wrapping and returns the
+// single-use wrapping token and its TTL.
+type SecretSharer func(path string) (token string, ttl time.Duration, err error)
+
+// shareSecret wraps the current secret (Ctrl-W), copies the wrapping token to
+// the clipboard and shows it with the unwrap command, so the value can be
+// handed over without pasting plaintext.
+func (st *UIState) shareSecret() {
+	if st.share == nil || st.Cursor < 0 || st.Cursor >= len(st.Filtered) {
+		return
+	}
+	secret := st.Filtered[st.Cursor].Path
+	token, ttl, err := st.share(secret)
+	if err != nil {
+		slog.Warn("wrap failed", "path", secret, "err", err)
+		st.showToast("wrap failed: "+err.Error(), true)
+		return
+	}
+	copied := "token copied"
+	if err := clipboardCopy(token); err != nil {
+		copied = "copy failed"
+	}
+	st.openPanel(&Panel{
+		Title: fmt.Sprintf("Wrapped %s: single use, expires in %s (%s; Esc: close)", secret, ttl.Round(time.Second), copied),
+		Lines: []string{token, "", "Unwrap with: vault unwrap " + token},
+	})
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"fvf/search"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestShareSecret(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	var copied []string
+	saved := clipboardCopy
+	clipboardCopy = func(s string) error { copied = append(copied, s); return nil }
+	defer func() { clipboardCopy = saved }()
+
+	st := &UIState{PreviewCache: map[string]string{}, PreviewErr: map[string]error{}}
+	st.Items = []search.FoundItem{{Path: "kv/app/db"}}
+	st.ApplyFilter()
+	var asked string
+	st.share = func(p string) (string, time.Duration, error) {
+		asked = p
+		return "hvs.wrapped", time.Hour, nil
+	}
+	ctrlW := tcell.NewEventKey(tcell.KeyCtrlW, 0, tcell.ModNone)
+	HandleKey(s, ctrlW, &st.Items, &st.Filtered, &st.Query, &st.Cursor, &st.Offset, st.PreviewCache, nil, st, st.ApplyFilter, nil)
+	if asked != "kv/app/db" || st.Panel == nil {
+		t.Fatalf("asked %q, panel %+v", asked, st.Panel)
+	}
+	if st.Panel.Lines[0] != "hvs.wrapped" || !strings.Contains(st.Panel.Title, "expires in 1h0m0s") {
+		t.Fatalf("panel %+v", st.Panel)
+	}
+	if len(copied) != 1 || copied[0] != "hvs.wrapped" {
+		t.Fatalf("copied %q", copied)
+	}
+
+	st.Panel = nil
+	st.share = func(string) (string, time.Duration, error) { return "", 0, errors.New("permission denied") }
+	HandleKey(s, ctrlW, &st.Items, &st.Filtered, &st.Query, &st.Cursor, &st.Offset, st.PreviewCache, nil, st, st.ApplyFilter, nil)
+	if st.Panel != nil || st.Toast == nil || !st.Toast.Err {
+		t.Fatalf("a failed wrap should only show an error toast (panel %+v)", st.Panel)
+	}
+}
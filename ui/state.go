@@ -23,6 +23,8 @@ type UIState struct {
 	// Preview/cache
 	PreviewCache map[string]string
 	PreviewErr   map[string]error
+	MetaCache    map[string]*search.SecretMetadata
+	CapsCache    map[string][]string
 
 	// Buttons and flash
 	PerLineCopyBtns []PerLineCopyBtn
@@ -41,24 +43,155 @@ type UIState struct {
 	PrintValues  bool
 	JSONPreview  bool
 	RevealAll    bool
+	PrintPath    bool // Enter prints the selected path; Alt-Enter prints the value
+	HexView      bool // base64 binary values shown as hexdumps
+
+	// Grouped shows the list under one header per mount (Alt-g); Offset then
+	// counts list rows, headers included. Collapsed mounts show only their
+	// header (Alt-c).
+	Grouped   bool
+	Collapsed map[string]bool
+
+	// RelativePaths shows list paths relative to the search roots (Alt-p).
+	RelativePaths bool
+	// LineNumbers numbers the rows of the flat list (Alt-n), for pointing
+	// at "number 17" and jumping there with ":17".
+	LineNumbers bool
+
+	// ConfirmPrint asks before Enter prints a secret to the terminal and
+	// offers copying it instead; pendingPrint is the confirmed output.
+	ConfirmPrint bool
+	pendingPrint *string
+
+	// ReadOnly explains the write actions left unwired by -read-only
+	// (Ctrl-K, Ctrl-D, rollback) instead of ignoring their keys.
+	ReadOnly bool
+
+	// Per-key reveal: keys of the selected secret, the key under the preview
+	// key cursor, and keys revealed individually while RevealAll is off.
+	PreviewKeys  []string
+	FocusKey     string
+	RevealedKeys map[string]bool
+
+	// PreviewPolicies are the policy names listed under the current preview (Ctrl-P).
+	PreviewPolicies []string
+	// PolicyPane is the policies share of the preview in percent (0 = half, <0 = hidden).
+	PolicyPane int
+
+	// LiveRefresh is set by RenderAll while the frame needs per-second redraws
+	// (a ticking TOTP code or a toast that has to disappear).
+	LiveRefresh bool
+	Toast       *Toast
+
+	// Search root and the active input prompt (if any)
+	Roots     []string
+	Namespace string
+	Prompt    *PromptState
+	Panel     *Panel
+
+	// Query tabs (Alt-1..9); the active tab's state lives in the fields above.
+	Tabs     []*Tab
+	TabIndex int
+
+	// Lock screen: after LockAfter without input the content is hidden until a key is pressed.
+	LockAfter time.Duration
+	LastInput time.Time
+	Locked    bool
+
+	// Selected holds multi-selected paths (Ctrl-Space) for bulk actions.
+	Selected map[string]bool
+
+	// Preview cache budget (0 = unbounded); least recently shown values are evicted and zeroed.
+	PreviewCacheEntries int
+	PreviewCacheBytes   int
+
+	// WalkErrors are the subtrees the current walk skipped (Ctrl-E lists them).
+	WalkErrors []WalkError
+
+	// restartWalk cancels the running walk and streams from new roots; nil when unsupported.
+	restartWalk  func(roots []string)
+	mounts       MountLister
+	mountInfo    MountInspector
+	kvVersion    KVVersionFunc
+	kvBadges     map[string]string // by namespace and mount
+	namespaces   NamespaceLister
+	setNS        NamespaceSetter
+	metadata     MetadataFetcher
+	capabilities CapabilityFetcher
+	renew        TokenRenewer
+	copier       SecretCopier
+	fetchCtx     ContextFetcher
+	fetchBatch   BatchFetcher
+	report       ReportWriter
+	reportReads  bool // the report reads values, within the read guard's budget
+	aliases      []string
+	expandRoot   func(root string) (string, error)
+	policyReader PolicyReader
+	audit        AuditFetcher
+	bindings     []Binding
+	share        SecretSharer
+	rollback     SecretRollbacker
+	writeMeta    CustomMetadataWriter
+	lastPipe     string // previous '|' command, offered again
+	prefetch     *prefetcher
+	guard        *readGuard
+	previewLRU   *previewLRU
+	results      *backgroundResults
+	metaLookups  lookups // metadata reads in flight or recently failed
+	capsLookups  lookups // capabilities lookups in flight or recently failed
+	transferring bool // a copy or move is checking or writing in the background
+
+	// Filter bookkeeping: Filtered holds the matches of filteredFor among the
+	// first filteredItems Items; filterAt is the deadline of a debounced filter.
+	filteredFor   string
+	filteredItems int
+	filteredValid bool
+	filterAt      time.Time
+	filterTimer   *time.Timer
+	index         *pathIndex
+
+	// Configurable status bar; statusSegments nil means the fixed StatusProvider is used.
+	statusSegments StatusSegments
+	statusLayout   StatusLayout
 }
 
 // ApplyFilter filters Items into Filtered based on Query and normalizes Cursor/Offset.
+// Extending the previous query narrows Filtered in place instead of rescanning Items.
 func (st *UIState) ApplyFilter() {
-    q := st.Query
-    if q == "" {
+    lq := normalizeQuery(st.Query)
+    switch {
+    case st.canNarrow(lq):
+        kept := st.Filtered[:0]
+        for _, it := range st.Filtered {
+            if strings.Contains(strings.ToLower(it.Path), lq) {
+                kept = append(kept, it)
+            }
+        }
+        st.Filtered = kept
+    case lq == "":
         st.Filtered = append(st.Filtered[:0], st.Items...)
-    } else {
-        lq := strings.ToLower(strings.TrimSpace(q))
+        st.sortFiltered()
+    case st.indexedMatches(lq):
+        // Trigram index lookup, sorted by indexedMatches
+    default:
         st.Filtered = st.Filtered[:0]
         for _, it := range st.Items {
             if strings.Contains(strings.ToLower(it.Path), lq) {
                 st.Filtered = append(st.Filtered, it)
             }
         }
+        // Sort filtered list by path for stable order
+        st.sortFiltered()
+    }
+    st.filteredFor, st.filteredItems, st.filteredValid = lq, len(st.Items), true
+    st.filterAt = time.Time{}
+    if st.filterTimer != nil {
+        st.filterTimer.Stop()
+    }
+    if _, row := splitJump(st.Query); row > 0 {
+        // "db:17" jumps to row 17 of the matches for "db"
+        st.Cursor = row - 1
     }
-    // Sort filtered list by path for stable order
-    sort.Slice(st.Filtered, func(i, j int) bool { return st.Filtered[i].Path < st.Filtered[j].Path })
 
     if st.Cursor >= len(st.Filtered) {
         st.Cursor = len(st.Filtered) - 1
@@ -68,6 +201,45 @@ func (st *UIState) ApplyFilter() {
     }
     st.Offset = 0
 }
+
+// AddItems appends streamed items and inserts those matching the applied query
+// into Filtered at their sorted position.
+func (st *UIState) AddItems(batch []search.FoundItem) {
+    q := st.appliedQuery()
+    var add []search.FoundItem
+    for _, it := range batch {
+        if st.index != nil && len(st.index.lower) == len(st.Items) {
+            st.index.add(it.Path)
+        }
+        st.Items = append(st.Items, it)
+        if q == "" || strings.Contains(strings.ToLower(it.Path), q) {
+            add = append(add, it)
+        }
+    }
+    if len(add) > 0 {
+        sort.Slice(add, func(i, j int) bool { return add[i].Path < add[j].Path })
+        st.Filtered = mergeSorted(st.Filtered, add)
+    }
+    if st.filteredValid && q == st.filteredFor {
+        st.filteredItems = len(st.Items)
+    }
+}
+
+// rootSuggestions lists mount roots seen so far, for completing the root prompt.
+func (st *UIState) rootSuggestions() []string {
+    seen := make(map[string]bool)
+    out := make([]string, 0, 8)
+    for _, it := range st.Items {
+        mnt, _ := search.SplitMount(it.Path)
+        if mnt != "" && !seen[mnt] {
+            seen[mnt] = true
+            out = append(out, mnt+"/")
+        }
+    }
+    sort.Strings(out)
+    return out
+}
+
 // ButtonBounds represents a clickable rectangular region.
 type ButtonBounds struct {
 	X int
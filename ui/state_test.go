@@ -37,3 +37,14 @@ func TestUIState_ApplyFilter_WithQuery(t *testing.T) {
 		t.Fatalf("expected sorted order, got %v", st.Filtered)
 	}
 }
+
+func TestUIState_AddItems_MergesMatchingSorted(t *testing.T) {
+	st := &UIState{Query: "app"}
+	st.AddItems([]search.FoundItem{{Path: "kv/zapp"}, {Path: "kv/other"}, {Path: "kv/app"}})
+	if len(st.Items) != 3 {
+		t.Fatalf("expected 3 items, got %d", len(st.Items))
+	}
+	if len(st.Filtered) != 2 || st.Filtered[0].Path != "kv/app" || st.Filtered[1].Path != "kv/zapp" {
+		t.Fatalf("unexpected filtered: %v", st.Filtered)
+	}
+}
@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// StatusSegments supplies status bar segments computed outside the UI, keyed by
+// segment name (e.g. "ttl", "idle", "addr", "version"). Empty values are skipped.
+type StatusSegments func() map[string]string
+
+// StatusLayout lists the segment names shown in the left, middle and right parts of the status bar.
+type StatusLayout [3][]string
+
+// DefaultStatusLayout reproduces the classic "TTL | Idle ... addr ... version" bar.
+const DefaultStatusLayout = "ttl,idle|addr|version"
+
+// statusSegmentNames are the segments a layout may reference. The UI fills
+// counts, filter, roots, namespace and errors itself; the rest come from StatusSegments.
+var statusSegmentNames = map[string]bool{
+	"ttl": true, "idle": true, "addr": true, "version": true, "match": true, "identity": true,
+	"counts": true, "filter": true, "roots": true, "namespace": true, "errors": true,
+}
+
+// ParseStatusLayout parses "left|middle|right" where each part is a
+// comma-separated list of segment names, e.g. "ttl,idle|addr|counts,version".
+// Parts may be empty or omitted.
+func ParseStatusLayout(spec string) (StatusLayout, error) {
+	var layout StatusLayout
+	parts := strings.Split(spec, "|")
+	if len(parts) > 3 {
+		return layout, fmt.Errorf("status bar layout %q has more than 3 parts (left|middle|right)", spec)
+	}
+	for i, part := range parts {
+		for _, name := range strings.Split(part, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if !statusSegmentNames[name] {
+				known := make([]string, 0, len(statusSegmentNames))
+				for k := range statusSegmentNames {
+					known = append(known, k)
+				}
+				sort.Strings(known)
+				return layout, fmt.Errorf("unknown status bar segment %q (known: %s)", name, strings.Join(known, ", "))
+			}
+			layout[i] = append(layout[i], name)
+		}
+	}
+	return layout, nil
+}
+
+// uiStatusSegments returns the segments derived from UI state.
+func (st *UIState) uiStatusSegments() map[string]string {
+	seg := map[string]string{
+		"counts": fmt.Sprintf("%d/%d", len(st.Filtered), len(st.Items)),
+	}
+	if q := strings.TrimSpace(st.Query); q != "" {
+		seg["filter"] = "filter: " + q
+	}
+	if len(st.Roots) > 0 {
+		seg["roots"] = "[" + strings.Join(st.Roots, ",") + "]"
+	}
+	if st.Namespace != "" {
+		seg["namespace"] = "ns: " + st.Namespace
+	}
+	if badge := st.walkErrorBadge(); badge != "" {
+		seg["errors"] = badge
+	}
+	return seg
+}
+
+// statusBar returns the provider used for the bottom bar: the configured layout
+// when segments are wired, otherwise the fixed fallback provider.
+func (st *UIState) statusBar(fallback StatusProvider) StatusProvider {
+	if st.statusSegments == nil {
+		return fallback
+	}
+	return func() (string, string, string) {
+		seg := st.uiStatusSegments()
+		for k, v := range st.statusSegments() {
+			seg[k] = v
+		}
+		var out [3]string
+		for i, names := range st.statusLayout {
+			vals := make([]string, 0, len(names))
+			for _, n := range names {
+				if v := seg[n]; v != "" {
+					vals = append(vals, v)
+				}
+			}
+			out[i] = strings.Join(vals, " | ")
+		}
+		return out[0], out[1], out[2]
+	}
+}
@@ -0,0 +1,130 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxTabs is the number of tabs reachable with Alt-1..Alt-9.
+const maxTabs = 9
+
+// Tab holds the per-tab search state that is swapped in and out of UIState.
+type Tab struct {
+	Name         string
+	Query        string
+	Cursor       int
+	Offset       int
+	Roots        []string
+	PreviewCache map[string]string
+	PreviewErr   map[string]error
+
+	lru *previewLRU
+}
+
+// label returns the tab bar text for the tab at index i.
+func (t *Tab) label(i int) string {
+	name := t.Name
+	if name == "" {
+		name = t.Query
+	}
+	if name == "" {
+		name = "*"
+	}
+	return fmt.Sprintf("%d:%s", i+1, name)
+}
+
+// ensureTabs lazily creates the tab list with the current state as tab 1.
+func (st *UIState) ensureTabs() {
+	if len(st.Tabs) == 0 {
+		st.Tabs = []*Tab{{}}
+		st.TabIndex = 0
+	}
+}
+
+// saveTab stores the live search state into the active tab.
+func (st *UIState) saveTab() {
+	st.ensureTabs()
+	t := st.Tabs[st.TabIndex]
+	t.Query, t.Cursor, t.Offset = st.Query, st.Cursor, st.Offset
+	t.Roots = st.Roots
+	t.PreviewCache, t.PreviewErr, t.lru = st.PreviewCache, st.PreviewErr, st.previewLRU
+}
+
+// switchTab activates tab n (0-based). Selecting the slot right after the last
+// tab opens a new tab with an empty query on the current roots. A tab whose
+// roots differ from the running walk restarts the walk.
+func (st *UIState) switchTab(n int) {
+	st.ensureTabs()
+	if n < 0 || n >= maxTabs || n > len(st.Tabs) || n == st.TabIndex {
+		return
+	}
+	st.saveTab()
+	if n == len(st.Tabs) {
+		st.Tabs = append(st.Tabs, &Tab{
+			Roots:        st.Roots,
+			PreviewCache: make(map[string]string),
+			PreviewErr:   make(map[string]error),
+		})
+	}
+	st.loadTab(n)
+}
+
+// closeTab drops the active tab and activates its left neighbour. The last tab stays open.
+func (st *UIState) closeTab() {
+	st.ensureTabs()
+	if len(st.Tabs) < 2 {
+		return
+	}
+	i := st.TabIndex
+	st.Tabs = append(st.Tabs[:i], st.Tabs[i+1:]...)
+	if i > 0 {
+		i--
+	}
+	st.TabIndex = -1
+	st.loadTab(i)
+}
+
+// loadTab makes tab n the live state.
+func (st *UIState) loadTab(n int) {
+	t := st.Tabs[n]
+	st.TabIndex = n
+	sameRoots := strings.Join(t.Roots, ",") == strings.Join(st.Roots, ",")
+	st.PreviewCache, st.PreviewErr, st.previewLRU = t.PreviewCache, t.PreviewErr, t.lru
+	st.resetReveal()
+	if !sameRoots && st.restartWalk != nil {
+		// restartWalk clears cursor/offset; the query applies to the new stream
+		st.Query = t.Query
+		st.restartWalk(t.Roots)
+		return
+	}
+	st.Query = t.Query
+	st.Cursor = t.Cursor
+	st.ApplyFilter() // clamps the cursor and resets the offset
+	st.Offset = t.Offset
+}
+
+// renameTab prompts for a name for the active tab (empty falls back to the query).
+func (st *UIState) renameTab() {
+	st.ensureTabs()
+	t := st.Tabs[st.TabIndex]
+	st.openPrompt("tab name", t.Name, nil, func(in string) { t.Name = in })
+}
+
+// tabBar renders the tab list, marking the active tab with brackets. It is
+// empty while only one tab exists.
+func (st *UIState) tabBar() string {
+	if len(st.Tabs) < 2 {
+		return ""
+	}
+	parts := make([]string, len(st.Tabs))
+	for i, t := range st.Tabs {
+		if i == st.TabIndex {
+			q := *t
+			q.Query = st.Query
+			parts[i] = "[" + q.label(i) + "]"
+		} else {
+			parts[i] = t.label(i)
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"testing"
+
+	"fvf/search"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func newTabState() *UIState {
+	st := &UIState{PreviewCache: map[string]string{"kv/a": "x"}, PreviewErr: map[string]error{}}
+	st.Items = []search.FoundItem{{Path: "kv/a"}, {Path: "kv/b"}, {Path: "kv/db1"}, {Path: "kv/db2"}}
+	st.ApplyFilter()
+	return st
+}
+
+func TestTabs_SwitchKeepsQueryCursorAndCache(t *testing.T) {
+	st := newTabState()
+	st.Query = "db"
+	st.ApplyFilter()
+	st.Cursor = 1
+
+	st.switchTab(1) // opens tab 2
+	if len(st.Tabs) != 2 || st.TabIndex != 1 || st.Query != "" || len(st.Filtered) != 4 {
+		t.Fatalf("expected fresh second tab, got tabs=%d idx=%d query=%q filtered=%d", len(st.Tabs), st.TabIndex, st.Query, len(st.Filtered))
+	}
+	if _, ok := st.PreviewCache["kv/a"]; ok {
+		t.Fatal("new tab should start with its own preview cache")
+	}
+	st.Query = "b"
+	st.ApplyFilter()
+
+	st.switchTab(0)
+	if st.Query != "db" || st.Cursor != 1 || len(st.Filtered) != 2 || st.PreviewCache["kv/a"] != "x" {
+		t.Fatalf("tab 1 state not restored: query=%q cursor=%d filtered=%d", st.Query, st.Cursor, len(st.Filtered))
+	}
+	if got := st.tabBar(); got != "[1:db] 2:b" {
+		t.Fatalf("unexpected tab bar: %q", got)
+	}
+
+	st.switchTab(5) // beyond next free slot: ignored
+	if st.TabIndex != 0 || len(st.Tabs) != 2 {
+		t.Fatalf("expected switch to a far slot to be ignored")
+	}
+}
+
+func TestTabs_CloseAndRestartOnRootChange(t *testing.T) {
+	st := newTabState()
+	var restarted []string
+	calls := 0
+	st.restartWalk = func(roots []string) {
+		calls++
+		restarted = roots
+		st.Roots = roots
+	}
+	st.switchTab(1)
+	st.Roots = []string{"kv/other/"}
+	st.switchTab(0)
+	if calls != 1 || restarted != nil {
+		t.Fatalf("tab 1 has the original (empty) roots; expected restart(nil), got %v", restarted)
+	}
+	st.switchTab(1)
+	if len(restarted) != 1 || restarted[0] != "kv/other/" {
+		t.Fatalf("expected walk restart with tab roots, got %v", restarted)
+	}
+
+	st.closeTab()
+	if len(st.Tabs) != 1 || st.TabIndex != 0 || st.tabBar() != "" {
+		t.Fatalf("expected single tab after close, got %d idx=%d", len(st.Tabs), st.TabIndex)
+	}
+	st.closeTab()
+	if len(st.Tabs) != 1 {
+		t.Fatal("the last tab must stay open")
+	}
+}
+
+func TestHandleKey_AltDigitSwitchesTabsWithoutTyping(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	st := newTabState()
+	HandleKey(s, tcell.NewEventKey(tcell.KeyRune, '2', tcell.ModAlt), &st.Items, &st.Filtered, &st.Query, &st.Cursor, &st.Offset, st.PreviewCache, nil, st, st.ApplyFilter, nil)
+	if st.Query != "" || st.TabIndex != 1 {
+		t.Fatalf("Alt-2 should open tab 2 without typing, query=%q idx=%d", st.Query, st.TabIndex)
+	}
+}
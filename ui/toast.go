@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// toastDuration is how long a toast stays over the status bar.
+const toastDuration = 3 * time.Second
+
+// Toast is a short-lived message drawn over the status bar.
+type Toast struct {
+	Text  string
+	Err   bool
+	Until time.Time
+}
+
+// showToast displays text over the status bar for toastDuration.
+func (st *UIState) showToast(text string, isErr bool) {
+	st.Toast = &Toast{Text: text, Err: isErr, Until: time.Now().Add(toastDuration)}
+}
+
+// activeToast returns the current toast, dropping it once expired.
+func (st *UIState) activeToast(now time.Time) *Toast {
+	if st.Toast != nil && !now.Before(st.Toast.Until) {
+		st.Toast = nil
+	}
+	return st.Toast
+}
+
+// drawToast paints t across the status bar row: green for info, red for errors.
+func drawToast(s tcell.Screen, x, y, w int, t *Toast) {
+	if t == nil || w <= 0 {
+		return
+	}
+	bg := tcell.ColorGreen
+	if t.Err {
+		bg = tcell.ColorRed
+	}
+	st := tcell.StyleDefault.Background(bg).Foreground(tcell.ColorWhite).Bold(true)
+	text := runewidth.Truncate(" "+t.Text+" ", w, "…")
+	for cx := 0; cx < w; cx++ {
+		s.SetContent(x+cx, y, ' ', nil, st)
+	}
+	cx := x
+	for _, r := range text {
+		s.SetContent(cx, y, r, nil, st)
+		cx += runewidth.RuneWidth(r)
+	}
+}
@@ -0,0 +1,146 @@
+package ui
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// totpSuffix names the derived preview row holding the current code for a seed key.
+const totpSuffix = " (totp)"
+
+// totpSpec holds the parameters of a time-based one-time password (RFC 6238).
+type totpSpec struct {
+	Secret []byte
+	Digits int
+	Period int
+	Algo   string // SHA1, SHA256 or SHA512
+}
+
+// parseTOTP recognizes otpauth://totp URIs in any key and bare base32 seeds in
+// keys whose name suggests a second factor (totp, otp, 2fa, mfa).
+func parseTOTP(key, value string) (totpSpec, bool) {
+	v := strings.TrimSpace(value)
+	if strings.HasPrefix(strings.ToLower(v), "otpauth://") {
+		u, err := url.Parse(v)
+		if err != nil || !strings.EqualFold(u.Host, "totp") {
+			return totpSpec{}, false
+		}
+		q := u.Query()
+		secret, ok := decodeBase32Seed(q.Get("secret"))
+		if !ok {
+			return totpSpec{}, false
+		}
+		spec := totpSpec{Secret: secret, Digits: 6, Period: 30, Algo: "SHA1"}
+		if d, err := strconv.Atoi(q.Get("digits")); err == nil && d >= 6 && d <= 10 {
+			spec.Digits = d
+		}
+		if p, err := strconv.Atoi(q.Get("period")); err == nil && p > 0 {
+			spec.Period = p
+		}
+		if a := strings.ToUpper(q.Get("algorithm")); a == "SHA256" || a == "SHA512" {
+			spec.Algo = a
+		}
+		return spec, true
+	}
+	lk := strings.ToLower(key)
+	if !strings.Contains(lk, "otp") && !strings.Contains(lk, "2fa") && !strings.Contains(lk, "mfa") {
+		return totpSpec{}, false
+	}
+	secret, ok := decodeBase32Seed(v)
+	if !ok || len(secret) < 10 {
+		return totpSpec{}, false
+	}
+	return totpSpec{Secret: secret, Digits: 6, Period: 30, Algo: "SHA1"}, true
+}
+
+// decodeBase32Seed decodes a base32 seed as commonly shared (spaces, lowercase, no padding).
+func decodeBase32Seed(s string) ([]byte, bool) {
+	s = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(s), " ", ""))
+	s = strings.TrimRight(s, "=")
+	if s == "" {
+		return nil, false
+	}
+	b, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+// totpCode returns the code valid at t and the seconds left in its period.
+func totpCode(spec totpSpec, t time.Time) (string, int) {
+	unix := t.Unix()
+	counter := uint64(unix / int64(spec.Period))
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	var h func() hash.Hash
+	switch spec.Algo {
+	case "SHA256":
+		h = sha256.New
+	case "SHA512":
+		h = sha512.New
+	default:
+		h = sha1.New
+	}
+	mac := hmac.New(h, spec.Secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	off := sum[len(sum)-1] & 0x0f
+	// 10^10 (10 digits) does not fit in a uint32
+	bin := uint64(binary.BigEndian.Uint32(sum[off:off+4]) & 0x7fffffff)
+	mod := uint64(1)
+	for i := 0; i < spec.Digits; i++ {
+		mod *= 10
+	}
+	code := fmt.Sprintf("%0*d", spec.Digits, bin%mod)
+	left := spec.Period - int(unix%int64(spec.Period))
+	return code, left
+}
+
+// addTOTPRows derives "<key> (totp)" rows for seed values in kv. display holds
+// the code with its countdown for rendering; codes holds the bare code for copying.
+// kv itself is left untouched; the returned display map includes all original keys.
+func addTOTPRows(kv map[string]string, now time.Time) (display map[string]string, codes map[string]string) {
+	display = kv
+	for k, v := range kv {
+		spec, ok := parseTOTP(k, v)
+		if !ok {
+			continue
+		}
+		if codes == nil {
+			codes = make(map[string]string)
+			display = make(map[string]string, len(kv)+1)
+			for dk, dv := range kv {
+				display[dk] = dv
+			}
+		}
+		code, left := totpCode(spec, now)
+		codes[k+totpSuffix] = code
+		display[k+totpSuffix] = fmt.Sprintf("%s (%ds)", code, left)
+	}
+	return display, codes
+}
+
+// previewTableLines renders raw secret data as the preview's key/value table:
+// TOTP rows are derived, masking honours RevealAll and per-key reveals, binary
+// base64 values become hexdumps in hex view, and certificate details are
+// injected from the unmasked values.
+func previewTableLines(raw map[string]string, reveal bool, view previewView, now time.Time) []string {
+	display, _ := addTOTPRows(raw, now)
+	masked := maskKVExcept(display, !reveal, view.Revealed)
+	if view.Hex {
+		masked = withHexdumps(masked)
+	}
+	return withCertSummaries(renderKVTable(masked), raw, now)
+}
@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B test vectors (8 digits)
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	cases := []struct {
+		algo   string
+		secret string
+		at     int64
+		want   string
+	}{
+		{"SHA1", "12345678901234567890", 59, "94287082"},
+		{"SHA1", "12345678901234567890", 2000000000, "69279037"},
+		{"SHA1", "12345678901234567890", 1111111109, "07081804"},
+		{"SHA256", "12345678901234567890123456789012", 59, "46119246"},
+		{"SHA512", "1234567890123456789012345678901234567890123456789012345678901234", 59, "90693936"},
+	}
+	for _, c := range cases {
+		spec := totpSpec{Secret: []byte(c.secret), Digits: 8, Period: 30, Algo: c.algo}
+		got, left := totpCode(spec, time.Unix(c.at, 0))
+		if got != c.want {
+			t.Fatalf("%s@%d: got %s want %s", c.algo, c.at, got, c.want)
+		}
+		if want := 30 - int(c.at%30); left != want {
+			t.Fatalf("%s@%d: left=%d want %d", c.algo, c.at, left, want)
+		}
+	}
+
+	// Ten digits: the whole truncated value, which is larger than 10^10 mod 2^32
+	spec := totpSpec{Secret: []byte("12345678901234567890"), Digits: 10, Period: 30, Algo: "SHA1"}
+	if got, _ := totpCode(spec, time.Unix(2000000000, 0)); got != "2069279037" {
+		t.Fatalf("10 digits: got %s want 2069279037", got)
+	}
+}
+
+func TestParseTOTP_URIAndSeed(t *testing.T) {
+	const seed = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" // base32("12345678901234567890")
+
+	spec, ok := parseTOTP("anything", "otpauth://totp/ACME:alice?secret="+seed+"&digits=8&period=60&algorithm=SHA256")
+	if !ok || spec.Digits != 8 || spec.Period != 60 || spec.Algo != "SHA256" || string(spec.Secret) != "12345678901234567890" {
+		t.Fatalf("unexpected uri spec: %+v ok=%v", spec, ok)
+	}
+	if _, ok := parseTOTP("x", "otpauth://hotp/ACME?secret="+seed); ok {
+		t.Fatalf("hotp URIs are not time based")
+	}
+	if spec, ok := parseTOTP("totp_seed", strings.ToLower(seed[:16])+" "+seed[16:]); !ok || spec.Digits != 6 {
+		t.Fatalf("expected bare seed under totp-like key to parse: %+v ok=%v", spec, ok)
+	}
+	if _, ok := parseTOTP("password", seed); ok {
+		t.Fatalf("bare base32 under an unrelated key must not be treated as TOTP")
+	}
+	if _, ok := parseTOTP("mfa", "not base32!"); ok {
+		t.Fatalf("invalid base32 should not parse")
+	}
+}
+
+func TestPreviewTableLines_TOTPRowMaskedUntilRevealed(t *testing.T) {
+	raw := map[string]string{
+		"user": "alice",
+		"otp":  "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+	}
+	now := time.Unix(59, 0)
+	display, codes := addTOTPRows(raw, now)
+	if codes["otp (totp)"] != "287082" {
+		t.Fatalf("code=%q", codes["otp (totp)"])
+	}
+	if display["otp (totp)"] != "287082 (1s)" {
+		t.Fatalf("display=%q", display["otp (totp)"])
+	}
+	if _, ok := raw["otp (totp)"]; ok {
+		t.Fatalf("addTOTPRows must not modify its input")
+	}
+
+	masked := strings.Join(previewTableLines(raw, false, previewView{}, now), "\n")
+	if strings.Contains(masked, "287082") || !strings.Contains(masked, "otp (totp)") {
+		t.Fatalf("TOTP row should be present but masked:\n%s", masked)
+	}
+	shown := strings.Join(previewTableLines(raw, false, previewView{Revealed: map[string]bool{"otp (totp)": true}}, now), "\n")
+	if !strings.Contains(shown, "287082 (1s)") {
+		t.Fatalf("revealed TOTP row should show code and countdown:\n%s", shown)
+	}
+}
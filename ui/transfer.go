@@ -0,0 +1,233 @@
+package ui
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"fvf/search"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// TransferStep is one secret of a copy or move: Action is copy, overwrite or
+// skip as decided by -on-conflict, Err is set once a write failed.
+type TransferStep struct {
+	Src, Dst string
+	Action   string
+	Err      error
+}
+
+// TransferPlan is a checked copy or move. Run writes it (check-and-set on
+// KV v2, deleting each source for a move) and returns the steps with their
+// errors; it is called off the event loop.
+type TransferPlan struct {
+	Steps []TransferStep
+	Run   func() []TransferStep
+}
+
+// SecretCopier checks copying (or with move, moving) each srcs[i] to dsts[i]
+// against -on-conflict without writing anything, like cp and mv do before
+// their first write.
+type SecretCopier func(srcs, dsts []string, move bool) (*TransferPlan, error)
+
+// toggleSelected adds or removes the item under the cursor from the
+// multi-selection and advances the cursor, like fzf's Tab in -m mode.
+func (st *UIState) toggleSelected() {
+	if st.Cursor < 0 || st.Cursor >= len(st.Filtered) {
+		return
+	}
+	if st.Selected == nil {
+		st.Selected = make(map[string]bool)
+	}
+	p := st.Filtered[st.Cursor].Path
+	if st.Selected[p] {
+		delete(st.Selected, p)
+	} else {
+		st.Selected[p] = true
+	}
+	if st.Cursor < len(st.Filtered)-1 {
+		st.Cursor++
+	}
+}
+
+// selectedPaths returns the multi-selection in sorted order, or the item under
+// the cursor when nothing is selected.
+func (st *UIState) selectedPaths() []string {
+	out := make([]string, 0, len(st.Selected))
+	for p := range st.Selected {
+		out = append(out, p)
+	}
+	if len(out) == 0 && st.Cursor >= 0 && st.Cursor < len(st.Filtered) {
+		out = append(out, st.Filtered[st.Cursor].Path)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// commonDir returns the longest directory prefix (ending in "/") shared by paths.
+func commonDir(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+	prefix := paths[0][:strings.LastIndex(paths[0], "/")+1]
+	for _, p := range paths[1:] {
+		for !strings.HasPrefix(p, prefix) {
+			prefix = prefix[:strings.LastIndex(strings.TrimSuffix(prefix, "/"), "/")+1]
+		}
+	}
+	return prefix
+}
+
+// openTransfer asks whether to copy or move the selected secrets, then prompts
+// for a destination prefix replacing their common directory.
+func (st *UIState) openTransfer(s tcell.Screen, copier SecretCopier) {
+	paths := st.selectedPaths()
+	if copier == nil && st.ReadOnly {
+		st.showToast(readOnlyToast, true)
+		return
+	}
+	if copier == nil || len(paths) == 0 {
+		return
+	}
+	if st.transferring {
+		st.showToast("a copy or move is still running", true)
+		return
+	}
+	st.openPanel(&Panel{
+		Title: fmt.Sprintf("%d secret(s) under %s (Enter: choose, Esc: cancel)", len(paths), commonDir(paths)),
+		Lines: []string{"Copy to another prefix", "Move to another prefix"},
+		Submit: func(p *Panel) {
+			move := p.Cursor == 1
+			label := "copy to"
+			if move {
+				label = "move to"
+			}
+			from := commonDir(paths)
+			st.openPrompt(label, from, st.rootSuggestions(), func(to string) {
+				if to == "" || to == from {
+					return
+				}
+				if !strings.HasSuffix(to, "/") {
+					to += "/"
+				}
+				st.planTransfer(s, copier, paths, from, to, move)
+			})
+		},
+	})
+}
+
+// planTransfer checks the destinations in the background, then runs a copy
+// straight away and asks to type "yes" before a move, listing every step.
+func (st *UIState) planTransfer(s tcell.Screen, copier SecretCopier, paths []string, from, to string, move bool) {
+	dsts := make([]string, len(paths))
+	for i, src := range paths {
+		dsts[i] = to + strings.TrimPrefix(src, from)
+	}
+	st.transferring = true
+	st.showToast(fmt.Sprintf("checking %d destination(s)…", len(paths)), false)
+	st.background(s, func() func() {
+		plan, err := copier(paths, dsts, move)
+		return func() {
+			if err != nil {
+				st.transferring = false
+				slog.Warn("transfer check failed", "to", to, "err", err)
+				st.showToast(err.Error(), true)
+				return
+			}
+			if !move {
+				st.runTransfer(s, plan, move)
+				return
+			}
+			lines := make([]string, len(plan.Steps))
+			for i, step := range plan.Steps {
+				lines[i] = fmt.Sprintf("%s → %s", step.Src, step.Dst)
+				if step.Action != "copy" {
+					lines[i] += " (" + step.Action + " existing)"
+				}
+			}
+			st.openPanel(&Panel{Title: fmt.Sprintf("move %d secret(s); each source is deleted once copied", len(plan.Steps)), Lines: lines})
+			st.openPrompt(fmt.Sprintf("move these %d secret(s)? Type \"yes\" to continue", len(plan.Steps)), "", nil, func(in string) {
+				st.Panel = nil
+				if in != "yes" {
+					st.transferring = false
+					st.showToast("not moved", false)
+					return
+				}
+				st.runTransfer(s, plan, move)
+			})
+		}
+	})
+}
+
+// runTransfer writes the plan in the background, then shows per-item
+// results. Successful targets join the result list; moved sources leave it.
+func (st *UIState) runTransfer(s tcell.Screen, plan *TransferPlan, move bool) {
+	st.transferring = true
+	st.showToast(fmt.Sprintf("writing %d secret(s)…", len(plan.Steps)), false)
+	st.background(s, func() func() {
+		steps := plan.Run()
+		return func() {
+			st.transferring = false
+			st.showTransferResults(steps, move)
+		}
+	})
+}
+
+// showTransferResults applies a finished copy or move to the result list and
+// lists what happened to each secret, failures highlighted.
+func (st *UIState) showTransferResults(steps []TransferStep, move bool) {
+	verb := "copied"
+	if move {
+		verb = "moved"
+	}
+	lines := make([]string, 0, len(steps))
+	highlight := make(map[int]bool)
+	var added []search.FoundItem
+	removed := make(map[string]bool)
+	failed, skipped := 0, 0
+	for i, step := range steps {
+		switch {
+		case step.Err != nil:
+			slog.Warn("transfer failed", "from", step.Src, "to", step.Dst, "err", step.Err)
+			failed++
+			highlight[i] = true
+			lines = append(lines, fmt.Sprintf("✗ %s → %s: %v", step.Src, step.Dst, step.Err))
+			continue
+		case step.Action == "skip":
+			skipped++
+			lines = append(lines, fmt.Sprintf("- %s → %s: exists, skipped", step.Src, step.Dst))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("✓ %s → %s", step.Src, step.Dst))
+		if step.Action == "copy" {
+			added = append(added, search.FoundItem{Path: step.Dst})
+		}
+		if move {
+			removed[step.Src] = true
+		}
+	}
+	if len(removed) > 0 {
+		kept := st.Items[:0]
+		for _, it := range st.Items {
+			if !removed[it.Path] {
+				kept = append(kept, it)
+			}
+		}
+		st.Items = kept
+		st.invalidateIndex()
+		st.ApplyFilter()
+	}
+	st.AddItems(added)
+	st.Selected = nil
+	st.Toast = nil
+	st.openPanel(&Panel{
+		Title:     fmt.Sprintf("%s %d of %d, %d skipped (failures highlighted; Esc: close)", verb, len(steps)-failed-skipped, len(steps), skipped),
+		Lines:     lines,
+		Highlight: highlight,
+	})
+}
+
+// readOnlyToast explains why a write action does nothing under -read-only.
+const readOnlyToast = "read-only mode: writes are disabled (read-only: false in the config enables them)"
@@ -0,0 +1,160 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"fvf/search"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestCommonDir(t *testing.T) {
+	cases := []struct {
+		in   []string
+		want string
+	}{
+		{[]string{"kv/dev/app/db"}, "kv/dev/app/"},
+		{[]string{"kv/dev/app/db", "kv/dev/app/api"}, "kv/dev/app/"},
+		{[]string{"kv/dev/app/db", "kv/dev/web/api"}, "kv/dev/"},
+		{[]string{"kv/a", "other/b"}, ""},
+	}
+	for _, c := range cases {
+		if got := commonDir(c.in); got != c.want {
+			t.Fatalf("commonDir(%v)=%q want %q", c.in, got, c.want)
+		}
+	}
+}
+
+// waitBackground applies background work once it has finished.
+func waitBackground(t *testing.T, st *UIState) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		st.results.mu.Lock()
+		n := len(st.results.pending)
+		st.results.mu.Unlock()
+		if n > 0 {
+			st.applyBackground()
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("background work did not finish")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTransfer_MoveSelectedReportsPerItem(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	st := &UIState{PreviewCache: map[string]string{}, PreviewErr: map[string]error{}}
+	st.Items = []search.FoundItem{{Path: "kv/dev/app/api"}, {Path: "kv/dev/app/db"}, {Path: "kv/dev/app/web"}}
+	st.ApplyFilter()
+	var planned, ran [][2]string
+	st.copier = func(srcs, dsts []string, move bool) (*TransferPlan, error) {
+		if !move {
+			t.Fatal("expected a move")
+		}
+		plan := &TransferPlan{}
+		for i := range srcs {
+			planned = append(planned, [2]string{srcs[i], dsts[i]})
+			plan.Steps = append(plan.Steps, TransferStep{Src: srcs[i], Dst: dsts[i], Action: "copy"})
+		}
+		plan.Run = func() []TransferStep {
+			out := append([]TransferStep(nil), plan.Steps...)
+			for i := range out {
+				ran = append(ran, [2]string{out[i].Src, out[i].Dst})
+				if strings.HasSuffix(out[i].Src, "/web") {
+					out[i].Err = errors.New("permission denied")
+				}
+			}
+			return out
+		}
+		return plan, nil
+	}
+	key := func(k tcell.Key, r rune) {
+		HandleKey(s, tcell.NewEventKey(k, r, tcell.ModNone), &st.Items, &st.Filtered, &st.Query, &st.Cursor, &st.Offset, st.PreviewCache, nil, st, st.ApplyFilter, nil)
+	}
+
+	key(tcell.KeyCtrlSpace, 0) // api, cursor -> db
+	key(tcell.KeyDown, 0)      // web
+	key(tcell.KeyCtrlSpace, 0)
+	if len(st.Selected) != 2 {
+		t.Fatalf("expected 2 selected, got %v", st.Selected)
+	}
+
+	key(tcell.KeyCtrlK, 0)
+	if st.Panel == nil {
+		t.Fatal("expected copy/move chooser")
+	}
+	key(tcell.KeyDown, 0)
+	key(tcell.KeyEnter, 0) // move
+	if st.Prompt == nil || st.Prompt.Input != "kv/dev/app/" {
+		t.Fatalf("expected destination prompt prefilled with common dir, got %+v", st.Prompt)
+	}
+	st.Prompt.Input = "kv/prod/app"
+	key(tcell.KeyEnter, 0)
+
+	waitBackground(t, st)
+	if len(planned) != 2 || planned[0] != [2]string{"kv/dev/app/api", "kv/prod/app/api"} || planned[1][1] != "kv/prod/app/web" {
+		t.Fatalf("unexpected plan: %+v", planned)
+	}
+	if st.Prompt == nil || st.Panel == nil || len(st.Panel.Lines) != 2 {
+		t.Fatalf("expected the move listed with a typed confirmation, got prompt %+v panel %+v", st.Prompt, st.Panel)
+	}
+	if len(ran) != 0 {
+		t.Fatal("a move must not run before it is confirmed")
+	}
+	st.Prompt.Input = "yes"
+	key(tcell.KeyEnter, 0)
+	waitBackground(t, st)
+
+	if len(ran) != 2 {
+		t.Fatalf("unexpected writes: %+v", ran)
+	}
+	if st.Panel == nil || !strings.Contains(st.Panel.Title, "moved 1 of 2") || !st.Panel.Highlight[1] {
+		t.Fatalf("expected result panel with the failure highlighted, got %+v", st.Panel)
+	}
+	paths := make([]string, 0, len(st.Items))
+	for _, it := range st.Items {
+		paths = append(paths, it.Path)
+	}
+	if strings.Join(paths, ",") != "kv/dev/app/db,kv/dev/app/web,kv/prod/app/api" || st.Selected != nil {
+		t.Fatalf("unexpected items after move: %v selected=%v", paths, st.Selected)
+	}
+}
+
+func TestTransfer_MoveNotConfirmedAndCheckFailure(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	st := &UIState{PreviewCache: map[string]string{}, PreviewErr: map[string]error{}}
+	st.Items = []search.FoundItem{{Path: "kv/dev/db"}}
+	st.ApplyFilter()
+	ran := false
+	copier := func(srcs, dsts []string, move bool) (*TransferPlan, error) {
+		if !move {
+			return nil, errors.New("1 destination(s) already exist, e.g. kv/prod/db")
+		}
+		return &TransferPlan{Steps: []TransferStep{{Src: srcs[0], Dst: dsts[0], Action: "copy"}}, Run: func() []TransferStep {
+			ran = true
+			return nil
+		}}, nil
+	}
+
+	st.planTransfer(s, copier, []string{"kv/dev/db"}, "kv/dev/", "kv/prod/", false)
+	waitBackground(t, st)
+	if st.Toast == nil || !st.Toast.Err || !strings.Contains(st.Toast.Text, "already exist") || st.transferring {
+		t.Fatalf("expected the conflict as an error toast, got %+v", st.Toast)
+	}
+
+	st.planTransfer(s, copier, []string{"kv/dev/db"}, "kv/dev/", "kv/prod/", true)
+	waitBackground(t, st)
+	handlePromptKey(tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone), st)
+	handlePromptKey(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), st)
+	if ran || st.transferring || st.Panel != nil {
+		t.Fatal("a move confirmed with anything but yes must not run")
+	}
+}
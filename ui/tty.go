@@ -0,0 +1,22 @@
+package ui
+
+import (
+	"io"
+	"os"
+)
+
+// commandOutput is where programs run from the UI (execute bindings, '|'
+// pipes) write. That is stdout, unless stdout is a pipe or file collecting
+// the selection, as in SECRET=$(fvf ...): then it is the terminal the UI runs
+// on, so their output neither ends up in the selection nor gets lost.
+// The returned func releases the terminal.
+func commandOutput() (io.Writer, func()) {
+	if fi, err := os.Stdout.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+		return os.Stdout, func() {}
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return os.Stderr, func() {}
+	}
+	return tty, func() { tty.Close() }
+}
@@ -347,16 +347,129 @@ func putLineWithHighlights(s tcell.Screen, x, y int, text, query string, baseSty
 	}
 }
 
+// StreamStarter cancels the caller's running walk and starts a new one at roots,
+// returning the channel the new walk streams items on. Empty roots mean all KV mounts.
+type StreamStarter func(roots []string) <-chan search.FoundItem
+
+// StreamOptions carries optional integrations for RunStream. Zero values disable
+// the corresponding features.
+type StreamOptions struct {
+	// Roots are the start paths of the initial walk, shown in the header.
+	Roots []string
+	// Restart enables changing the search root from inside the UI (Ctrl-G).
+	Restart StreamStarter
+	// Mounts enables the mount switcher (Ctrl-O); requires Restart.
+	Mounts MountLister
+	// PickMounts opens the mount switcher on startup. The caller should pass a nil
+	// items channel and let the selection start the first walk.
+	PickMounts bool
+	// MountInfo enables the mount details panel (Alt-m).
+	MountInfo MountInspector
+	// KVVersion enables the KV version badge in the preview header.
+	KVVersion KVVersionFunc
+	// Warning is shown as an error toast when the UI starts, e.g. when a
+	// version override disagrees with the mounts.
+	Warning string
+	// Namespace is the initial namespace; Namespaces and SetNamespace enable the
+	// namespace switcher (Ctrl-N); requires Restart.
+	Namespace    string
+	Namespaces   NamespaceLister
+	SetNamespace NamespaceSetter
+	// PrintPath makes Enter print the selected path and Alt-Enter the value
+	// (the default is the other way around).
+	PrintPath bool
+	// GroupByMount starts with the list grouped under collapsible mount
+	// headers (Alt-g toggles).
+	GroupByMount bool
+	// RelativePaths starts with list paths shown relative to the roots
+	// (Alt-p toggles).
+	RelativePaths bool
+	// LineNumbers starts with numbered list rows (Alt-n toggles).
+	LineNumbers bool
+	// ConfirmPrint makes Enter ask before printing a secret's value, with
+	// copying it to the clipboard instead as the alternative.
+	ConfirmPrint bool
+	// Metadata enables the KV v2 metadata lines in the preview header.
+	Metadata MetadataFetcher
+	// Capabilities enables the per-path capability badges in the preview header.
+	Capabilities CapabilityFetcher
+	// RenewToken enables token renewal (Ctrl-T).
+	RenewToken TokenRenewer
+	// PolicyPane is the initial policies share of the preview in percent
+	// (0 = default half, negative = hidden); Ctrl-L cycles it.
+	PolicyPane int
+	// LockAfter hides the list and preview behind a lock screen after this much
+	// time without input (0 disables).
+	LockAfter time.Duration
+	// Policy enables the policy drill-down (Ctrl-P).
+	Policy PolicyReader
+	// Audit enables the audit panel (Ctrl-A): recent operations on the secret.
+	Audit AuditFetcher
+	// Copy enables copying/moving the selected secrets to another prefix (Ctrl-K).
+	Copy SecretCopier
+	// Aliases are offered as @name when completing the root prompt (Ctrl-G);
+	// ExpandRoot resolves the roots entered there.
+	Aliases    []string
+	ExpandRoot func(root string) (string, error)
+	// ReadOnly marks the write actions (Copy, Rollback, WriteMetadata) as
+	// disabled by read-only mode rather than unavailable.
+	ReadOnly bool
+	// FetchContext, when set, is used for the background preview reads instead
+	// of the fetcher, so reads the cursor moved away from are cancelled.
+	FetchContext ContextFetcher
+	// FetchBatch makes Enter with a multi-selection print all selected
+	// secrets as one document.
+	FetchBatch BatchFetcher
+	// Report enables writing a report on the results to a file (Alt-e).
+	// ReportReadsValues tells that it reads every secret's value, so a report
+	// needs that many reads of the MaxReadsPerMinute budget.
+	Report            ReportWriter
+	ReportReadsValues bool
+	// Share enables wrapped share tokens for the current secret (Ctrl-W).
+	Share SecretSharer
+	// Rollback enables rolling back from the version browser (Ctrl-V), which
+	// needs Metadata.
+	Rollback SecretRollbacker
+	// WriteMetadata enables the custom_metadata editor (Ctrl-D), which needs
+	// Metadata.
+	WriteMetadata CustomMetadataWriter
+	// Bindings run external commands on user-defined keys; they take
+	// precedence over the built-in keys.
+	Bindings []Binding
+	// StatusSegments and StatusLayout replace the fixed status bar with the
+	// configured segments; when StatusSegments is nil the StatusProvider is used.
+	StatusSegments StatusSegments
+	StatusLayout   StatusLayout
+	// PreviewCacheEntries and PreviewCacheBytes bound the per-tab preview cache
+	// (0 = unbounded).
+	PreviewCacheEntries int
+	PreviewCacheBytes   int
+	// MaxValueBytes and MaxReadsPerMinute guard preview reads (0 = no limit):
+	// larger values (checked once read) and reads past the budget are held
+	// back until Alt-v. With a budget, neighbours are not prefetched, and
+	// batch reads and reports must fit in what is left of it.
+	MaxValueBytes     int
+	MaxReadsPerMinute int
+	// WalkErrors receives the errors of the walks started by the caller; when
+	// set, the UI shows an error count and lists them on Ctrl-E.
+	WalkErrors *WalkErrorLog
+	// Session restores a saved session (tabs, query, selection); the caller
+	// starts the walk at its ActiveRoots. SaveSession receives the session
+	// when the UI exits.
+	Session     *Session
+	SaveSession func(Session)
+}
+
 // RunStream is a small wrapper that delegates to the internal implementation.
 // Kept minimal to improve readability and testability.
-func RunStream(itemsCh <-chan search.FoundItem, printValues bool, jsonPreview bool, fetcher ValueFetcher, policyFetcher PolicyFetcher, status StatusProvider, quit <-chan struct{}, activity chan<- struct{}) error {
-    return runStreamImpl(itemsCh, printValues, jsonPreview, fetcher, policyFetcher, status, quit, activity)
+func RunStream(itemsCh <-chan search.FoundItem, printValues bool, jsonPreview bool, fetcher ValueFetcher, policyFetcher PolicyFetcher, status StatusProvider, quit <-chan struct{}, activity chan<- struct{}, opts StreamOptions) error {
+    return runStreamImpl(itemsCh, printValues, jsonPreview, fetcher, policyFetcher, status, quit, activity, opts)
 }
 
 // It mirrors the old Run() behavior, including lazy preview fetching when printValues is true.
 // quit: when a value arrives, the UI exits gracefully.
 // activity: UI sends an event on any user interaction (keys/mouse) to help the caller detect idleness.
-func runStreamImpl(itemsCh <-chan search.FoundItem, printValues bool, jsonPreview bool, fetcher ValueFetcher, policyFetcher PolicyFetcher, status StatusProvider, quit <-chan struct{}, activity chan<- struct{}) error {
+func runStreamImpl(itemsCh <-chan search.FoundItem, printValues bool, jsonPreview bool, fetcher ValueFetcher, policyFetcher PolicyFetcher, status StatusProvider, quit <-chan struct{}, activity chan<- struct{}, opts StreamOptions) error {
     s, err := tcell.NewScreen()
     if err != nil {
         return err
@@ -390,6 +503,8 @@ func runStreamImpl(itemsCh <-chan search.FoundItem, printValues bool, jsonPrevie
         MouseEnabled:  false,
         PrintValues:   printValues,
         JSONPreview:   jsonPreview,
+        Roots:         opts.Roots,
+        Namespace:     opts.Namespace,
     }
 
     // Per-secret copy buttons (drawn in redraw) and flash state keyed by secret key
@@ -420,6 +535,8 @@ func runStreamImpl(itemsCh <-chan search.FoundItem, printValues bool, jsonPrevie
         }()
     }
 
+    // liveRefresh mirrors uiState.LiveRefresh for the 1s ticker goroutine
+    var liveRefresh atomic.Bool
     redraw := func() {
         copyBtnX, copyBtnY, copyBtnW, toggleBtnX, toggleBtnY, toggleBtnW, revealBtnX, revealBtnY, revealBtnW = RenderAll(
             s,
@@ -429,23 +546,91 @@ func runStreamImpl(itemsCh <-chan search.FoundItem, printValues bool, jsonPrevie
             status,
             uiState,
         )
+        liveRefresh.Store(uiState.LiveRefresh)
     }
 
-    applyFilter := func() { uiState.ApplyFilter() }
+    // Large result sets are re-filtered once typing pauses
+    applyFilter := func() {
+        if len(uiState.Items) < filterDebounceMin {
+            uiState.ApplyFilter()
+            return
+        }
+        uiState.scheduleFilter(func() { s.PostEvent(tcell.NewEventInterrupt(nil)) })
+    }
 
-    // receive items and trigger redraws
-    go func() {
-        for it := range itemsCh {
-            uiState.Items = append(uiState.Items, it)
-            q := strings.ToLower(strings.TrimSpace(uiState.Query))
-            if q == "" || strings.Contains(strings.ToLower(it.Path), q) {
-                uiState.Filtered = append(uiState.Filtered, it)
-                sort.Slice(uiState.Filtered, func(i, j int) bool { return uiState.Filtered[i].Path < uiState.Filtered[j].Path })
+    // receive items in the background; the event loop merges them on each interrupt
+    feed := newItemFeed(func() { s.PostEvent(tcell.NewEventInterrupt(nil)) })
+    feed.follow(itemsCh)
+    uiState.statusSegments = opts.StatusSegments
+    uiState.statusLayout = opts.StatusLayout
+    uiState.metadata = opts.Metadata
+    uiState.mountInfo = opts.MountInfo
+    uiState.kvVersion = opts.KVVersion
+    uiState.capabilities = opts.Capabilities
+    uiState.renew = opts.RenewToken
+    uiState.PrintPath = opts.PrintPath
+    uiState.Grouped = opts.GroupByMount
+    uiState.RelativePaths = opts.RelativePaths
+    uiState.LineNumbers = opts.LineNumbers
+    uiState.ConfirmPrint = opts.ConfirmPrint
+    uiState.ReadOnly = opts.ReadOnly
+    uiState.copier = opts.Copy
+    uiState.fetchCtx = opts.FetchContext
+    uiState.fetchBatch = opts.FetchBatch
+    uiState.report = opts.Report
+    uiState.reportReads = opts.ReportReadsValues
+    uiState.aliases = opts.Aliases
+    uiState.expandRoot = opts.ExpandRoot
+    uiState.policyReader = opts.Policy
+    uiState.audit = opts.Audit
+    uiState.bindings = opts.Bindings
+    uiState.share = opts.Share
+    uiState.rollback = opts.Rollback
+    uiState.writeMeta = opts.WriteMetadata
+    uiState.PolicyPane = opts.PolicyPane
+    uiState.LockAfter = opts.LockAfter
+    uiState.PreviewCacheEntries = opts.PreviewCacheEntries
+    uiState.PreviewCacheBytes = opts.PreviewCacheBytes
+    uiState.guard = newReadGuard(opts.MaxValueBytes, opts.MaxReadsPerMinute)
+    if opts.Session != nil {
+        uiState.restoreSession(*opts.Session)
+    }
+    if opts.SaveSession != nil {
+        defer func() { opts.SaveSession(uiState.session()) }()
+    }
+    uiState.touch()
+    if opts.Warning != "" {
+        uiState.showToast(opts.Warning, true)
+    }
+    if opts.WalkErrors != nil {
+        opts.WalkErrors.setWake(func() { s.PostEvent(tcell.NewEventInterrupt(nil)) })
+    }
+    if opts.Restart != nil {
+        uiState.restartWalk = func(roots []string) {
+            feed.reset()
+            if opts.WalkErrors != nil {
+                opts.WalkErrors.reset()
             }
-            s.PostEvent(tcell.NewEventInterrupt(nil))
+            uiState.WalkErrors = nil
+            if uiState.prefetch != nil {
+                uiState.prefetch.reset()
+            }
+            uiState.Items = uiState.Items[:0]
+            uiState.invalidateIndex()
+            uiState.Filtered = uiState.Filtered[:0]
+            uiState.Cursor, uiState.Offset = 0, 0
+            uiState.Roots = roots
+            feed.follow(opts.Restart(roots))
         }
-        s.PostEvent(tcell.NewEventInterrupt(nil))
-    }()
+        uiState.mounts = opts.Mounts
+        uiState.namespaces = opts.Namespaces
+        uiState.setNS = opts.SetNamespace
+        if opts.PickMounts && opts.Mounts != nil {
+            // Cancelling the startup picker falls back to the initial roots
+            initial := opts.Roots
+            uiState.openMountPanel(opts.Mounts, func() { uiState.restartWalk(initial) })
+        }
+    }
 
     uiState.ApplyFilter()
     redraw()
@@ -467,10 +652,32 @@ func runStreamImpl(itemsCh <-chan search.FoundItem, printValues bool, jsonPrevie
         }
     }()
 
+    // Tick every second while a TOTP countdown is on screen
+    go func() {
+        ticker := time.NewTicker(time.Second)
+        defer ticker.Stop()
+        for range ticker.C {
+            if shouldQuit.Load() {
+                return
+            }
+            if liveRefresh.Load() {
+                s.PostEvent(tcell.NewEventInterrupt(nil))
+            }
+        }
+    }()
+
     for {
         ev := s.PollEvent()
         switch ev := ev.(type) {
         case *tcell.EventInterrupt:
+            uiState.applyBackground()
+            if batch := feed.drain(); len(batch) > 0 {
+                uiState.AddItems(batch)
+            }
+            if opts.WalkErrors != nil {
+                uiState.WalkErrors = append(uiState.WalkErrors, opts.WalkErrors.drain()...)
+            }
+            uiState.flushFilter(time.Now())
             redraw()
         case *tcell.EventKey:
             shouldRedraw, shouldQuit := HandleKey(s, ev, &uiState.Items, &uiState.Filtered, &uiState.Query, &uiState.Cursor, &uiState.Offset, uiState.PreviewCache, fetcher, uiState, applyFilter, activity)
@@ -534,27 +741,65 @@ func maskJSONStrings(v interface{}, mask bool) interface{} {
 }
 
 func drawPreview(s tcell.Screen, x, y, w, h int, filtered []search.FoundItem, cursor int, printValues bool, jsonPreview bool, fetched string, policies []string, wrap bool, reveal bool) {
+	drawPreviewWith(s, x, y, w, h, filtered, cursor, printValues, jsonPreview, fetched, policies, wrap, reveal, previewView{})
+}
+
+// splitPreview divides the preview body between the secrets and policies
+// sections. pane is the policies share in percent: 0 means the default half,
+// negative hides the policies section.
+func splitPreview(avail, pane int) (secrets, policies int) {
+	if pane < 0 {
+		return avail, 0
+	}
+	if pane == 0 {
+		pane = 50
+	}
+	secrets = avail * (100 - pane) / 100
+	return secrets, avail - secrets
+}
+
+// previewHeaderHeight is the preview header height for a pane of height h: the
+// path line plus as many metadata lines as fit while leaving room for the body.
+func previewHeaderHeight(h int, meta []string) int {
+	n := 1 + len(meta)
+	if limit := h - 3; n > limit {
+		n = limit
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// drawPreviewWith is drawPreview with per-key reveal and focus options.
+func drawPreviewWith(s tcell.Screen, x, y, w, h int, filtered []search.FoundItem, cursor int, printValues bool, jsonPreview bool, fetched string, policies []string, wrap bool, reveal bool, view previewView) {
 	if cursor < 0 || cursor >= len(filtered) || w <= 0 || h <= 0 {
 		return
 	}
 
 	it := filtered[cursor]
 	allLines := make([]string, 0, h)
-	allLines = append(allLines, it.Path)
+	header := it.Path
+	if view.Badges != "" {
+		header += "  " + view.Badges
+	}
+	allLines = append(allLines, header)
 
 	// Calculate heights for each section (half the available height for each)
-	headerHeight := 1 // For the path line
+	headerHeight := previewHeaderHeight(h, view.Meta) // path line plus metadata lines
 	separatorHeight := 1
 	availableHeight := h - headerHeight - separatorHeight
 
 	// Split the available height between secrets and policies
-	secretsHeight := availableHeight / 2
-	policiesHeight := availableHeight - secretsHeight
+	secretsHeight, policiesHeight := splitPreview(availableHeight, view.PolicyPane)
 
-	// Draw the header (path)
+	// Draw the header (path, then metadata)
 	if h > 0 {
 		putLine(s, x, y, allLines[0])
 	}
+	for i := 1; i < headerHeight; i++ {
+		putLine(s, x, y+i, view.Meta[i-1])
+	}
 
 	// Draw separator after header
 	if h > 1 {
@@ -570,19 +815,19 @@ func drawPreview(s tcell.Screen, x, y, w, h int, filtered []search.FoundItem, cu
     testMode := fetched == "" && len(filtered) > 0 && filtered[cursor].Value != nil
 
     if printValues || testMode {
-        if testMode || fetched != "" {
+        if view.Loading {
+            secretsLines = append(secretsLines, "(loading…)")
+        } else if testMode || fetched != "" {
             if testMode {
                 // In test mode, use the value directly from the test data
                 if val, ok := filtered[cursor].Value.(map[string]interface{}); ok {
-                    kv := toKVFromMap(val)
-                    kv = maskKV(kv, !reveal)
-                    secretsLines = append(secretsLines, renderKVTable(kv)...)
+                    secretsLines = append(secretsLines, previewTableLines(toKVFromMap(val), reveal, view, time.Now())...)
                 }
             } else if jsonPreview && isLikelyJSON(fetched) {
                 // Mask JSON strings when not revealed
                 var obj interface{}
                 if err := json.Unmarshal([]byte(fetched), &obj); err == nil {
-                    obj = maskJSONStrings(obj, !reveal)
+                    obj = maskJSONExcept(obj, !reveal, view.Revealed)
                     if b, err := json.MarshalIndent(obj, "", "  "); err == nil {
                         secretsLines = append(secretsLines, strings.Split(string(b), "\n")...)
                     } else {
@@ -595,9 +840,7 @@ func drawPreview(s tcell.Screen, x, y, w, h int, filtered []search.FoundItem, cu
                 // In table mode, render JSON object as a padded key-value table for alignment
                 var obj map[string]interface{}
                 if err := json.Unmarshal([]byte(fetched), &obj); err == nil {
-                    kv := toKVFromMap(obj)
-                    kv = maskKV(kv, !reveal)
-                    secretsLines = append(secretsLines, renderKVTable(kv)...)
+                    secretsLines = append(secretsLines, previewTableLines(toKVFromMap(obj), reveal, view, time.Now())...)
                 } else {
                     // Fallback to readable JSON lines
                     if !reveal {
@@ -618,17 +861,14 @@ func drawPreview(s tcell.Screen, x, y, w, h int, filtered []search.FoundItem, cu
                 if len(kv) > 0 {
                     if jsonPreview {
                         // Render KV as pretty JSON when jsonPreview is ON
-                        if !reveal {
-                            kv = maskKV(kv, true)
-                        }
+                        kv = maskKVExcept(kv, !reveal, view.Revealed)
                         if b, err := json.MarshalIndent(kv, "", "  "); err == nil {
                             secretsLines = append(secretsLines, strings.Split(string(b), "\n")...)
                         } else {
                             secretsLines = append(secretsLines, renderKVTable(kv)...)
                         }
                     } else {
-                        kv = maskKV(kv, !reveal)
-                        secretsLines = append(secretsLines, renderKVTable(kv)...)
+                        secretsLines = append(secretsLines, previewTableLines(kv, reveal, view, time.Now())...)
                     }
                 } else {
                     if !reveal {
@@ -646,7 +886,7 @@ func drawPreview(s tcell.Screen, x, y, w, h int, filtered []search.FoundItem, cu
     }
 
     // Draw secrets section
-    drawSection := func(s tcell.Screen, x, y, w, maxH int, lines []string, wrap bool) {
+    drawSection := func(s tcell.Screen, x, y, w, maxH int, lines []string, wrap bool, focus string) {
         if maxH <= 0 || len(lines) == 0 {
             return
         }
@@ -674,12 +914,26 @@ func drawPreview(s tcell.Screen, x, y, w, h int, filtered []search.FoundItem, cu
             if !wrap && runewidth.StringWidth(line) > w {
                 line = runewidth.Truncate(line, w, "…")
             }
+            if strings.Contains(line, certExpiredMark) {
+                st := tcell.StyleDefault.Foreground(tcell.ColorRed)
+                putLineWithHighlights(s, x, y+i, line, "", st, st)
+                continue
+            }
+            if focus != "" && keyOfLine(line, jsonPreview) == focus {
+                st := tcell.StyleDefault.Reverse(true)
+                putLineWithHighlights(s, x, y+i, line, "", st, st)
+                continue
+            }
             putLine(s, x, y+i, line)
         }
     }
 
     // Draw secrets section
-    drawSection(s, x, secretsY, w, secretsHeight, secretsLines, wrap)
+    drawSection(s, x, secretsY, w, secretsHeight, secretsLines, wrap, view.FocusKey)
+
+    if policiesHeight <= 0 {
+        return
+    }
 
     // Draw separator between secrets and policies
     if h > secretsY+secretsHeight-y {
@@ -706,5 +960,5 @@ func drawPreview(s tcell.Screen, x, y, w, h int, filtered []search.FoundItem, cu
     }
 
     // Draw policies section
-    drawSection(s, x, policiesY, w, policiesHeight, policiesLines, false)
+    drawSection(s, x, policiesY, w, policiesHeight, policiesLines, false, "")
 }
@@ -1,7 +1,11 @@
 package ui
 
 import (
+	"fmt"
+	"strconv"
+
 	"fvf/search"
+
 	"github.com/gdamore/tcell/v2"
 	"github.com/mattn/go-runewidth"
 )
@@ -36,10 +40,23 @@ func drawVerticalSeparator(s tcell.Screen, x, h int) {
 }
 
 // drawLeftList renders the list of results with highlighting and selection.
-func drawLeftList(s tcell.Screen, contentTop, leftW, w int, filtered []search.FoundItem, q string, cursor, offset, maxRows int) {
+// While a multi-selection exists, every row gets a marker column ("● " when selected).
+// Paths are shown relative to roots when set.
+func drawLeftList(s tcell.Screen, contentTop, leftW, w int, filtered []search.FoundItem, q string, cursor, offset, maxRows int, selected map[string]bool, roots []string, numbers bool) {
+	numW := len(strconv.Itoa(len(filtered)))
 	for i := 0; i < maxRows && i+offset < len(filtered); i++ {
 		it := filtered[i+offset]
-		line := it.Path
+		line := RelativePath(it.Path, roots)
+		if len(selected) > 0 {
+			if selected[it.Path] {
+				line = "● " + line
+			} else {
+				line = "  " + line
+			}
+		}
+		if numbers {
+			line = fmt.Sprintf("%*d ", numW, i+offset+1) + line
+		}
 		avail := leftW
 		if avail <= 0 {
 			avail = w
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"fvf/search"
 	"github.com/gdamore/tcell/v2"
+	"log/slog"
 )
 
 // fetchPreviewAndPolicies retrieves the preview value (with cache) and policies for the current selection.
@@ -27,6 +28,7 @@ func fetchPreviewAndPolicies(
 				val = v
 				previewCache[p] = v
 			} else {
+				slog.Warn("preview fetch failed", "path", p, "err", err)
 				msg := fmt.Sprintf("(error fetching values) %v", err)
 				previewCache[p] = msg
 				previewErr[p] = err
@@ -2,6 +2,8 @@ package ui
 
 import (
 	"testing"
+
+	"fvf/search"
 	"github.com/gdamore/tcell/v2"
 	"github.com/mattn/go-runewidth"
 )
@@ -64,3 +66,42 @@ func TestDrawStatusBar_TruncatesWhenTight(t *testing.T) {
 		t.Fatalf("expected right-aligned text to reach end, got space at end: %q", ln)
 	}
 }
+
+func TestParseStatusLayout(t *testing.T) {
+	l, err := ParseStatusLayout("ttl, idle|addr|counts,version")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(l[0]) != 2 || l[0][1] != "idle" || len(l[1]) != 1 || len(l[2]) != 2 || l[2][0] != "counts" {
+		t.Fatalf("unexpected layout: %#v", l)
+	}
+	if l, err := ParseStatusLayout("namespace"); err != nil || len(l[0]) != 1 || len(l[1]) != 0 {
+		t.Fatalf("single part should fill left only: %#v %v", l, err)
+	}
+	if _, err := ParseStatusLayout("ttl|bogus"); err == nil {
+		t.Fatal("expected error for unknown segment")
+	}
+	if _, err := ParseStatusLayout("a|b|c|d"); err == nil {
+		t.Fatal("expected error for more than 3 parts")
+	}
+}
+
+func TestStatusBar_ComposesConfiguredSegments(t *testing.T) {
+	st := &UIState{Query: "db", Namespace: "team-a/"}
+	st.Items = make([]search.FoundItem, 3)
+	st.Filtered = st.Items[:1]
+	st.statusLayout, _ = ParseStatusLayout("counts,filter|namespace|ttl,version")
+	st.statusSegments = func() map[string]string {
+		return map[string]string{"ttl": "TTL: 1h", "version": "fvf dev", "idle": "Idle: 0s"}
+	}
+	l, m, r := st.statusBar(nil)()
+	if l != "1/3 | filter: db" || m != "ns: team-a/" || r != "TTL: 1h | fvf dev" {
+		t.Fatalf("unexpected status: %q %q %q", l, m, r)
+	}
+
+	fallback := func() (string, string, string) { return "L", "M", "R" }
+	st.statusSegments = nil
+	if l, _, _ := st.statusBar(fallback)(); l != "L" {
+		t.Fatalf("expected fallback provider without segments, got %q", l)
+	}
+}
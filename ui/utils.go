@@ -119,6 +119,27 @@ func toKVFromLines(s string) map[string]string {
 	return kv
 }
 
+// inPreviewFormat converts a fetched value to the preview's current format:
+// pretty JSON in JSON mode, key: value lines in table mode.
+func inPreviewFormat(out string, jsonPreview bool) string {
+	if jsonPreview {
+		if isLikelyJSON(out) {
+			return out
+		}
+		if kv := toKVFromLines(out); len(kv) > 0 {
+			if b, err := json.MarshalIndent(kv, "", "  "); err == nil {
+				return string(b)
+			}
+		}
+		return out
+	}
+	// Ensure table output in table mode
+	if isLikelyJSON(out) {
+		return joinLines(toLinesFromJSONText(out))
+	}
+	return out
+}
+
 func toKVFromMap(m map[string]interface{}) map[string]string {
 	kv := make(map[string]string)
 	for k, v := range m {
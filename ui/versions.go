@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// SecretRollbacker writes version of the secret at path as its new current
+// version and returns the version written.
+type SecretRollbacker func(path string, version int) (int, error)
+
+// openVersions lists the KV v2 versions of the current secret (Ctrl-V),
+// newest first. Enter on an older version asks before rolling back to it.
+func (st *UIState) openVersions() {
+	if st.metadata == nil || st.Cursor < 0 || st.Cursor >= len(st.Filtered) {
+		return
+	}
+	secret := st.Filtered[st.Cursor].Path
+	md, err := st.metadata(secret)
+	if err != nil {
+		st.showToast("versions: "+err.Error(), true)
+		return
+	}
+	if md == nil || len(md.Versions) == 0 {
+		st.showToast(secret+" has no versions (KV v1?)", true)
+		return
+	}
+	lines := make([]string, len(md.Versions))
+	highlight := make(map[int]bool)
+	for i, v := range md.Versions {
+		var state []string
+		switch {
+		case v.Version == md.CurrentVersion:
+			state = append(state, "current")
+			highlight[i] = true
+		case v.Destroyed:
+			state = append(state, "destroyed")
+		case !v.DeletionTime.IsZero():
+			state = append(state, "deleted "+v.DeletionTime.Local().Format("2006-01-02 15:04"))
+		}
+		line := fmt.Sprintf("v%-4d %s", v.Version, v.CreatedTime.Local().Format("2006-01-02 15:04:05"))
+		if len(state) > 0 {
+			line += "  " + strings.Join(state, ", ")
+		}
+		lines[i] = line
+	}
+	title := fmt.Sprintf("Versions of %s (Esc: close)", secret)
+	if st.ReadOnly {
+		title = fmt.Sprintf("Versions of %s (read-only, Esc: close)", secret)
+	}
+	if st.rollback != nil {
+		title = fmt.Sprintf("Versions of %s (Enter: roll back to, Esc: close)", secret)
+	}
+	st.openPanel(&Panel{
+		Title:     title,
+		Lines:     lines,
+		Highlight: highlight,
+		Submit: func(p *Panel) {
+			if st.rollback == nil || p.Cursor < 0 || p.Cursor >= len(md.Versions) {
+				return
+			}
+			v := md.Versions[p.Cursor]
+			switch {
+			case v.Version == md.CurrentVersion:
+				st.showToast(fmt.Sprintf("v%d is already current", v.Version), false)
+				return
+			case !v.Readable():
+				st.showToast(fmt.Sprintf("v%d is deleted or destroyed and cannot be restored", v.Version), true)
+				return
+			}
+			st.openPrompt(fmt.Sprintf("roll back %s to v%d? (y/n)", secret, v.Version), "", nil, func(in string) {
+				if in != "y" && in != "yes" {
+					return
+				}
+				st.rollbackTo(secret, v.Version)
+			})
+		},
+	})
+}
+
+// rollbackTo rolls the secret back and drops its cached value and metadata so
+// the preview shows the new version.
+func (st *UIState) rollbackTo(secret string, version int) {
+	written, err := st.rollback(secret, version)
+	if err != nil {
+		slog.Warn("rollback failed", "path", secret, "version", version, "err", err)
+		st.showToast("rollback failed: "+err.Error(), true)
+		return
+	}
+	delete(st.PreviewCache, secret)
+	delete(st.PreviewErr, secret)
+	delete(st.MetaCache, secret)
+	st.metaLookups.forget(secret)
+	if st.previewLRU != nil {
+		st.previewLRU.remove(secret)
+	}
+	st.showToast(fmt.Sprintf("%s: v%d restored as v%d", secret, version, written), false)
+}
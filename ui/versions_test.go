@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"fvf/search"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestOpenVersions_Rollback(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	st := &UIState{PreviewCache: map[string]string{"kv/app/db": "pass: new"}, PreviewErr: map[string]error{}}
+	st.Items = []search.FoundItem{{Path: "kv/app/db"}}
+	st.ApplyFilter()
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	st.metadata = func(string) (*search.SecretMetadata, error) {
+		return &search.SecretMetadata{CurrentVersion: 3, Versions: []search.VersionInfo{
+			{Version: 3, CreatedTime: created},
+			{Version: 2, CreatedTime: created},
+			{Version: 1, CreatedTime: created, Destroyed: true},
+		}}, nil
+	}
+	var rolled []int
+	st.rollback = func(p string, v int) (int, error) {
+		rolled = append(rolled, v)
+		return 4, nil
+	}
+	key := func(k tcell.Key, r rune) {
+		HandleKey(s, tcell.NewEventKey(k, r, tcell.ModNone), &st.Items, &st.Filtered, &st.Query, &st.Cursor, &st.Offset, st.PreviewCache, nil, st, st.ApplyFilter, nil)
+	}
+
+	key(tcell.KeyCtrlV, 0)
+	if st.Panel == nil || len(st.Panel.Lines) != 3 || !strings.Contains(st.Panel.Lines[0], "current") || !strings.Contains(st.Panel.Lines[2], "destroyed") {
+		t.Fatalf("version panel %+v", st.Panel)
+	}
+	// A destroyed version cannot be restored
+	key(tcell.KeyEnd, 0)
+	key(tcell.KeyEnter, 0)
+	if st.Prompt != nil || len(rolled) != 0 {
+		t.Fatal("destroyed version offered for rollback")
+	}
+
+	key(tcell.KeyCtrlV, 0)
+	key(tcell.KeyDown, 0)
+	key(tcell.KeyEnter, 0)
+	if st.Prompt == nil {
+		t.Fatal("rollback should ask for confirmation")
+	}
+	key(tcell.KeyRune, 'y')
+	key(tcell.KeyEnter, 0)
+	if len(rolled) != 1 || rolled[0] != 2 {
+		t.Fatalf("rolled back to %v", rolled)
+	}
+	if _, ok := st.PreviewCache["kv/app/db"]; ok {
+		t.Fatal("the cached value should be dropped after a rollback")
+	}
+}
@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"fmt"
+	"sync"
+
+	"fvf/search"
+)
+
+// WalkError records a subtree the walk skipped because listing or reading it failed.
+type WalkError struct {
+	Path string
+	Err  error
+}
+
+// WalkErrorLog collects walk errors from walker goroutines for the UI event loop.
+// Like itemFeed, errors reported by a walk superseded by reset are dropped.
+type WalkErrorLog struct {
+	mu      sync.Mutex
+	gen     int
+	pending []WalkError
+	all     []WalkError
+	wake    func()
+}
+
+// NewWalkErrorLog returns an empty log.
+func NewWalkErrorLog() *WalkErrorLog {
+	return &WalkErrorLog{wake: func() {}}
+}
+
+// Reporter returns an error handler bound to the current walk. It records each
+// error and lets the walk continue with the next subtree.
+func (l *WalkErrorLog) Reporter() search.ErrorHandler {
+	l.mu.Lock()
+	gen := l.gen
+	l.mu.Unlock()
+	return func(p string, err error) error {
+		l.mu.Lock()
+		if l.gen == gen {
+			e := WalkError{Path: p, Err: err}
+			l.pending = append(l.pending, e)
+			l.all = append(l.all, e)
+		}
+		wake := l.wake
+		l.mu.Unlock()
+		wake()
+		return nil
+	}
+}
+
+// Errors returns every error recorded for the current walk.
+func (l *WalkErrorLog) Errors() []WalkError {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]WalkError(nil), l.all...)
+}
+
+func (l *WalkErrorLog) setWake(wake func()) {
+	l.mu.Lock()
+	l.wake = wake
+	l.mu.Unlock()
+}
+
+// reset forgets recorded errors and invalidates the reporters of running walks.
+func (l *WalkErrorLog) reset() {
+	l.mu.Lock()
+	l.gen++
+	l.pending, l.all = nil, nil
+	l.mu.Unlock()
+}
+
+// drain returns the errors reported since the last call.
+func (l *WalkErrorLog) drain() []WalkError {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := l.pending
+	l.pending = nil
+	return out
+}
+
+// walkErrorBadge is the indicator shown next to the result counts, empty without errors.
+func (st *UIState) walkErrorBadge() string {
+	switch n := len(st.WalkErrors); n {
+	case 0:
+		return ""
+	case 1:
+		return "⚠ 1 error"
+	default:
+		return fmt.Sprintf("⚠ %d errors", n)
+	}
+}
+
+// openWalkErrors lists the skipped subtrees in a scrollable panel (Ctrl-E).
+func (st *UIState) openWalkErrors() {
+	if len(st.WalkErrors) == 0 {
+		st.showToast("no walk errors", false)
+		return
+	}
+	lines := make([]string, len(st.WalkErrors))
+	for i, e := range st.WalkErrors {
+		lines[i] = fmt.Sprintf("%s: %v", e.Path, e.Err)
+	}
+	st.openPanel(&Panel{
+		Title: fmt.Sprintf("Walk errors: %d subtree(s) skipped (Esc: close)", len(lines)),
+		Lines: lines,
+	})
+}
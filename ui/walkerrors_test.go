@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestWalkErrorLog_DropsErrorsFromSupersededWalk(t *testing.T) {
+	log := NewWalkErrorLog()
+	woke := 0
+	log.setWake(func() { woke++ })
+
+	old := log.Reporter()
+	if err := old("kv/a", errors.New("permission denied")); err != nil {
+		t.Fatalf("reporter should let the walk continue, got %v", err)
+	}
+	if got := log.drain(); len(got) != 1 || got[0].Path != "kv/a" {
+		t.Fatalf("drain=%v", got)
+	}
+	if len(log.drain()) != 0 {
+		t.Fatal("drain should empty the pending errors")
+	}
+
+	log.reset()
+	cur := log.Reporter()
+	_ = old("kv/stale", errors.New("late"))
+	_ = cur("kv/b", errors.New("timeout"))
+	got := log.Errors()
+	if len(got) != 1 || got[0].Path != "kv/b" {
+		t.Fatalf("Errors()=%v, want only kv/b", got)
+	}
+	if woke != 3 {
+		t.Fatalf("wake called %d times, want 3", woke)
+	}
+}
+
+func TestWalkErrors_BadgeAndPanel(t *testing.T) {
+	s := newSimScreen(t)
+	defer s.Fini()
+	st := &UIState{PreviewCache: map[string]string{}, PreviewErr: map[string]error{}}
+	if st.walkErrorBadge() != "" {
+		t.Fatal("no badge expected without errors")
+	}
+	st.WalkErrors = []WalkError{
+		{Path: "kv/team-a", Err: errors.New("permission denied")},
+		{Path: "kv/team-b", Err: errors.New("context deadline exceeded")},
+	}
+	if got := st.walkErrorBadge(); got != "⚠ 2 errors" {
+		t.Fatalf("badge=%q", got)
+	}
+	if got := st.uiStatusSegments()["errors"]; got != "⚠ 2 errors" {
+		t.Fatalf("errors segment=%q", got)
+	}
+
+	HandleKey(s, tcell.NewEventKey(tcell.KeyCtrlE, 0, tcell.ModNone), &st.Items, &st.Filtered, &st.Query, &st.Cursor, &st.Offset, st.PreviewCache, nil, st, st.ApplyFilter, nil)
+	if st.Panel == nil {
+		t.Fatal("Ctrl-E should open the error panel")
+	}
+	if len(st.Panel.Lines) != 2 || !strings.HasPrefix(st.Panel.Lines[0], "kv/team-a: permission denied") {
+		t.Fatalf("panel lines=%v", st.Panel.Lines)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// The helpers below reproduce the table layout of `vault kv get` (the vault
+// CLI's padEqualSigns, getHeaderForMap and columnized Key/Value tables) so
+// scripts parsing that output keep working with -output vault.
+
+// vaultPadEqualSigns centres header between runs of '=' for a section
+// totalLen columns wide.
+func vaultPadEqualSigns(header string, totalLen int) string {
+	n := totalLen - (len(header) + 2)
+	if n <= 0 {
+		n = 4
+	}
+	if n%2 != 0 {
+		n++
+	}
+	return strings.Repeat("=", n/2) + " " + header + " " + strings.Repeat("=", n/2)
+}
+
+// vaultMapHeader sizes the section header to the table below it: the longest
+// key, the four-space column gap and len("Value").
+func vaultMapHeader(header string, data map[string]interface{}) string {
+	maxKey := 0
+	for k := range data {
+		maxKey = max(maxKey, len(k))
+	}
+	return vaultPadEqualSigns(header, maxKey+4+5)
+}
+
+// vaultTable renders data as the sorted Key/Value table; empty values print
+// as n/a. It returns "" for an empty map.
+func vaultTable(data map[string]interface{}) string {
+	if len(data) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	rows := [][2]string{{"Key", "Value"}, {"---", "-----"}}
+	width := len("Key")
+	for _, k := range keys {
+		v := strings.TrimSpace(fmt.Sprintf("%v", data[k]))
+		if v == "" {
+			v = "n/a"
+		}
+		rows = append(rows, [2]string{k, v})
+		width = max(width, len(k))
+	}
+	var b strings.Builder
+	for _, r := range rows {
+		fmt.Fprintf(&b, "%-*s    %s\n", width, r[0], r[1])
+	}
+	return b.String()
+}
+
+// formatVaultKVGet renders the response of reading apiPath like `vault kv
+// get`: on KV v2 the secret path and the version metadata, then the data.
+func formatVaultKVGet(apiPath string, kv2 bool, sec *vault.Secret) string {
+	var b strings.Builder
+	data := sec.Data
+	if kv2 {
+		b.WriteString(vaultPadEqualSigns("Secret Path", len(apiPath)) + "\n" + apiPath + "\n\n")
+		if md, ok := sec.Data["metadata"].(map[string]interface{}); ok {
+			b.WriteString(vaultMapHeader("Metadata", md) + "\n")
+			b.WriteString(vaultTable(md) + "\n")
+		}
+		data, _ = sec.Data["data"].(map[string]interface{})
+	}
+	if data != nil {
+		b.WriteString(vaultMapHeader("Data", data) + "\n")
+		b.WriteString(vaultTable(data))
+	}
+	return b.String()
+}